@@ -3,17 +3,30 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
-	"path/filepath"
-	"strings"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"desent-api/configs"
+	"desent-api/internal/dbinstrument"
 	"desent-api/internal/handlers"
+	"desent-api/internal/health"
+	"desent-api/internal/lifecycle"
+	"desent-api/internal/mail"
+	"desent-api/internal/metrics"
 	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/oidc"
+	"desent-api/internal/ratelimit"
 	"desent-api/internal/repositories"
+	"desent-api/internal/storage"
+	"desent-api/internal/tracing"
 	"desent-api/internal/usecases"
 	"desent-api/internal/utils"
 
@@ -23,47 +36,311 @@ import (
 )
 
 func main() {
+	startedAt := time.Now()
 	cfg := configs.Load()
 
 	loggers, err := utils.NewLoggers(cfg.Logging)
 	if err != nil {
 		panic(fmt.Sprintf("init loggers: %v", err))
 	}
-	defer loggers.Close()
+	// Each shutdown step gets its own budget, so a slow drain does not
+	// leave the workers, flushes and database close with an expired one.
+	lc := lifecycle.New(loggers.Error).WithStepTimeout(cfg.Server.ShutdownTimeout)
+	lc.OnStop("loggers", func(context.Context) error { return loggers.Close() })
+	lc.Go("log retention", func(ctx context.Context) {
+		loggers.RunRetention(ctx, cfg.Logging.RetentionInterval)
+	})
+	metrics.Default.NewCounterFunc("http_log_dropped_total", "Access log entries dropped because the write queue was full.",
+		func() float64 { return float64(loggers.DroppedHTTPEntries()) })
+	workerLogger := loggers.Component(utils.LogComponentWorker)
+	authLogger := loggers.Component(utils.LogComponentAuth)
+	lc.Go("log level reload", func(ctx context.Context) {
+		reloadLogLevelsOnHangup(ctx, loggers)
+	})
+	if err := setupTracing(cfg.Tracing, lc, workerLogger); err != nil {
+		panic(fmt.Sprintf("init tracing: %v", err))
+	}
 
-	db, err := openDatabase(cfg.Database)
+	db, err := utils.OpenDatabase(cfg.Database,
+		dbinstrument.Tracing(cfg.Database.Driver),
+		dbinstrument.Metrics(),
+		dbinstrument.NewSlowQueryLog(loggers.Component(utils.LogComponentDB), cfg.Database.SlowQueryThreshold, cfg.Database.ExplainSlowQueries),
+	)
 	if err != nil {
 		panic(fmt.Sprintf("open database: %v", err))
 	}
-	defer db.Close()
+	lc.OnStop("database", func(context.Context) error { return db.Close() })
+	metrics.RegisterDBStats(metrics.Default, db)
+
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		panic(fmt.Sprintf("init schema: %v", err))
+	}
 
-	if err := repositories.InitBooksSchema(context.Background(), db); err != nil {
-		panic(fmt.Sprintf("init books schema: %v", err))
+	coverStore, err := storage.NewLocalBlobStore(cfg.Storage.CoversDir)
+	if err != nil {
+		panic(fmt.Sprintf("init cover storage: %v", err))
 	}
 
 	bookRepository := repositories.NewSQLiteBookRepository(db)
+	bookCoverRepository := repositories.NewSQLiteBookCoverRepository(db)
+	bookProvenanceRepository := repositories.NewSQLiteBookProvenanceRepository(db)
+	bookEnricher := usecases.NewBookEnricher(repositories.NewSQLiteMetadataRepository(db), bookProvenanceRepository)
+	createBookUsecase := usecases.NewCreateBookUsecase(bookRepository)
+	if cfg.Metadata.EnrichOnCreate {
+		createBookUsecase.WithEnricher(bookEnricher)
+	}
 	bookHandler := handlers.NewBookHandler(
-		usecases.NewCreateBookUsecase(bookRepository),
+		createBookUsecase,
 		usecases.NewListBooksUsecase(bookRepository),
 		usecases.NewGetBookUsecase(bookRepository),
 		usecases.NewUpdateBookUsecase(bookRepository),
-		usecases.NewDeleteBookUsecase(bookRepository),
+		usecases.NewDeleteBookUsecase(bookRepository).WithCovers(bookCoverRepository, coverStore),
+	)
+	bookDuplicateHandler := handlers.NewBookDuplicateHandler(
+		usecases.NewFindDuplicateBooksUsecase(bookRepository),
+		usecases.NewMergeBooksUsecase(bookRepository).WithCovers(bookCoverRepository, coverStore),
+	)
+	bookImportHandler := handlers.NewBookImportHandler(usecases.NewImportBooksUsecase(createBookUsecase))
+	bookEnrichmentHandler := handlers.NewBookEnrichmentHandler(
+		usecases.NewEnrichBookUsecase(bookRepository, bookEnricher),
+		usecases.NewGetBookProvenanceUsecase(bookRepository, bookProvenanceRepository),
+	)
+	bookCoverHandler := handlers.NewBookCoverHandler(
+		usecases.NewUploadBookCoverUsecase(bookRepository, bookCoverRepository, coverStore),
+		usecases.NewGetBookCoverUsecase(bookCoverRepository, coverStore),
+		usecases.NewDeleteBookCoverUsecase(bookCoverRepository, coverStore),
+		cfg.Storage.CoverMaxUploadBytes,
+	)
+	workRepository := repositories.NewSQLiteWorkRepository(db)
+	seriesRepository := repositories.NewSQLiteSeriesRepository(db)
+	publisherRepository := repositories.NewSQLitePublisherRepository(db)
+	catalogHandler := handlers.NewCatalogHandler(
+		usecases.NewCreateWorkUsecase(workRepository),
+		usecases.NewListWorksUsecase(workRepository),
+		usecases.NewGetWorkUsecase(workRepository),
+		usecases.NewCreateSeriesUsecase(seriesRepository),
+		usecases.NewListSeriesUsecase(seriesRepository),
+		usecases.NewGetSeriesUsecase(seriesRepository),
+		usecases.NewCreatePublisherUsecase(publisherRepository),
+		usecases.NewListPublishersUsecase(publisherRepository),
+		usecases.NewGetPublisherUsecase(publisherRepository),
+	)
+	readingListRepository := repositories.NewSQLiteReadingListRepository(db)
+	readingListHandler := handlers.NewReadingListHandler(
+		usecases.NewCreateReadingListUsecase(readingListRepository),
+		usecases.NewGetReadingListUsecase(readingListRepository),
+		usecases.NewListUserReadingListsUsecase(readingListRepository),
+		usecases.NewUpdateReadingListUsecase(readingListRepository),
+		usecases.NewDeleteReadingListUsecase(readingListRepository),
+		usecases.NewAddReadingListEntryUsecase(readingListRepository),
+		usecases.NewRemoveReadingListEntryUsecase(readingListRepository),
+		usecases.NewReorderReadingListUsecase(readingListRepository),
+	)
+	loginThrottle := usecases.NewLoginThrottle(
+		repositories.NewSQLiteLoginAttemptRepository(db),
+		usecases.LoginThrottlePolicy{
+			UsernameThreshold: cfg.Auth.LockoutUsernameThreshold,
+			IPThreshold:       cfg.Auth.LockoutIPThreshold,
+			BaseLockout:       cfg.Auth.LockoutBase,
+			MaxLockout:        cfg.Auth.LockoutMax,
+			FailureWindow:     cfg.Auth.LoginFailureWindow,
+		},
+		authLogger,
+		time.Now,
 	)
-	authHandler := handlers.NewAuthHandler(cfg.Auth.JWTSecret, time.Duration(cfg.Auth.JWTTTLSeconds)*time.Second)
+	// Attempts expire one failure window after their last failure or
+	// lockout, so sweeping once per window keeps the table small.
+	lc.Go("login attempt cleanup", func(ctx context.Context) {
+		loginThrottle.RunCleanup(ctx, cfg.Auth.LoginFailureWindow, func(err error) {
+			workerLogger.Error("cleanup login attempts", "error", err.Error())
+		})
+	})
+	// Without its own key, TOTP secrets are encrypted under a key derived
+	// from JWT_SECRET rather than the signing key itself.
+	mfaKey := cfg.Auth.MFAEncryptionKey
+	if mfaKey == "" {
+		mfaKey, err = utils.DeriveKey(cfg.Auth.JWTSecret, utils.KeyLabelMFAEncryption)
+		if err != nil {
+			panic(fmt.Sprintf("derive mfa encryption key: %v", err))
+		}
+	}
+	mfaBox, err := utils.NewSecretBox(mfaKey)
+	if err != nil {
+		panic(fmt.Sprintf("init mfa encryption: %v", err))
+	}
+	mfa := usecases.NewMFA(
+		repositories.NewSQLiteMFARepository(db),
+		mfaBox,
+		usecases.MFAPolicy{Issuer: cfg.Auth.MFAIssuer, ChallengeTTL: cfg.Auth.MFAChallengeTTL},
+		authLogger,
+		time.Now,
+	).WithLoginThrottle(loginThrottle)
+	userRepository := repositories.NewSQLiteUserRepository(db)
+	credentials := usecases.NewCredentials(userRepository)
+	sessions := usecases.NewSessions(repositories.NewSQLiteSessionRepository(db), authLogger, time.Now)
+	authHandler := handlers.NewAuthHandler(cfg.Auth.JWTSecret, time.Duration(cfg.Auth.JWTTTLSeconds)*time.Second).
+		WithLoginThrottle(loginThrottle).
+		WithMFA(mfa).
+		WithCredentials(credentials).
+		WithSessions(sessions)
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		panic(fmt.Sprintf("init mailer: %v", err))
+	}
+	accountHandler := handlers.NewAccountHandler(usecases.NewAccountRecovery(
+		userRepository,
+		repositories.NewSQLiteAccountTokenRepository(db),
+		credentials,
+		mailer,
+		usecases.AccountRecoveryPolicy{
+			BaseURL:         cfg.Mail.BaseURL,
+			ResetTTL:        cfg.Auth.PasswordResetTTL,
+			VerificationTTL: cfg.Auth.EmailVerificationTTL,
+		},
+		authLogger,
+		time.Now,
+	).WithLoginThrottle(loginThrottle).WithMFA(mfa).WithSessions(sessions))
+	mfaHandler := handlers.NewMFAHandler(mfa)
+	oauthServer := usecases.NewOAuthServer(
+		repositories.NewSQLiteOAuthClientRepository(db),
+		cfg.Auth.JWTSecret,
+		time.Duration(cfg.Auth.JWTTTLSeconds)*time.Second,
+		time.Now,
+	).WithLoginThrottle(loginThrottle).WithMFA(mfa).WithCredentials(credentials).WithSessions(sessions)
+	oauthHandler := handlers.NewOAuthHandler(oauthServer)
+	oauthClientHandler := handlers.NewOAuthClientHandler(oauthServer)
+	lockoutHandler := handlers.NewLockoutHandler(loginThrottle)
+	sessionHandler := handlers.NewSessionHandler(sessions)
+	logLevelHandler := handlers.NewLogLevelHandler(loggers.Levels, loggers.Error)
+	healthChecker := health.NewChecker(cfg.Health.CheckTimeout, cfg.Health.CacheTTL, time.Now).WithLogger(workerLogger)
+	healthChecker.Register("database", health.Database(db))
+	healthChecker.Register("schema", func(ctx context.Context) error { return repositories.CheckSchema(ctx, db) })
+	healthChecker.RegisterNonCritical("logs_disk", health.DiskSpace(cfg.Logging.LogsDir, cfg.Health.MinFreeDiskBytes))
+	healthHandler := handlers.NewHealthHandler(lc, healthChecker)
+
+	tokenVerifiers, err := newTokenVerifiers(cfg.Auth)
+	if err != nil {
+		panic(fmt.Sprintf("init oidc issuers: %v", err))
+	}
+	requireAuth := middlewares.Traced("auth", chi.Chain(
+		middlewares.RequireBearerAuth(cfg.Auth.JWTSecret, tokenVerifiers...),
+		middlewares.RequireActiveSession(sessions),
+	).Handler)
+	optionalAuth := middlewares.Traced("auth", chi.Chain(
+		middlewares.OptionalBearerAuth(cfg.Auth.JWTSecret, tokenVerifiers...),
+		middlewares.RequireActiveSession(sessions),
+	).Handler)
 
 	r := chi.NewRouter()
 	r.Use(chiMiddleware.RequestID)
-	r.Use(chiMiddleware.RealIP)
+	clientIPResolver, err := middlewares.NewClientIPResolver(cfg.Server.TrustedProxies, cfg.Server.TrustedProxyHeader)
+	if err != nil {
+		panic(fmt.Sprintf("parse TRUSTED_PROXIES: %v", err))
+	}
+	r.Use(middlewares.ResolveClientIP(clientIPResolver))
+	r.Use(middlewares.Tracing())
+	if cfg.Logging.HTTPLogEnabled {
+		r.Use(middlewares.HTTPLogger(loggers.HTTP, middlewares.HTTPLogOptions{
+			SuccessSampleRatio: cfg.Logging.HTTPLogSuccessSampleRatio,
+		}))
+	}
+	r.Use(middlewares.HTTPMetrics())
 	r.Use(chiMiddleware.Recoverer)
+	if cfg.Rate.Enabled {
+		policies, err := ratelimit.LoadPolicyTable(
+			cfg.Rate.PoliciesFile,
+			ratelimit.Policy{Name: "default", Burst: cfg.Rate.Burst, Rate: cfg.Rate.RequestsPerMinute, Period: time.Minute},
+		)
+		if err != nil {
+			panic(fmt.Sprintf("load rate limit policies: %v", err))
+		}
+		lc.Go("rate limit policy reload", func(ctx context.Context) {
+			policies.Watch(ctx, cfg.Rate.PoliciesReloadInterval, func(err error) {
+				workerLogger.Error("reload rate limit policies", "error", err.Error())
+			})
+		})
+
+		limiter, err := newRateLimiter(cfg.Rate, db)
+		if err != nil {
+			panic(fmt.Sprintf("init rate limiter: %v", err))
+		}
+
+		r.Use(middlewares.Traced("rate_limit", middlewares.RateLimit(
+			limiter,
+			policies,
+			middlewares.PrincipalRateLimitSubject(cfg.Auth.JWTSecret, sessions, tokenVerifiers...),
+		)))
+	}
 
 	r.Get("/ping", handlers.Ping)
+	r.Get("/healthz", healthHandler.Live)
+	r.Get("/readyz", healthHandler.Ready)
+	r.Method(http.MethodGet, "/metrics", metrics.Default.Handler())
 	r.Post("/echo", handlers.Echo)
 	r.Post("/auth/token", authHandler.CreateToken)
+	r.Post("/auth/token/mfa", authHandler.CompleteMFA)
+	r.Post("/auth/password/forgot", accountHandler.ForgotPassword)
+	r.Post("/auth/password/reset", accountHandler.ResetPassword)
+	r.Post("/auth/email/verify", accountHandler.VerifyEmail)
+	r.Post("/oauth/token", oauthHandler.Token)
+	r.Post("/oauth/introspect", oauthHandler.Introspect)
 	r.Post("/books", bookHandler.CreateBook)
-	r.With(middlewares.RequireBearerAuth(cfg.Auth.JWTSecret)).Get("/books", bookHandler.ListBooks)
+	r.With(requireAuth, middlewares.RequireScope(models.ScopeBooksRead)).Get("/books", bookHandler.ListBooks)
 	r.Get("/books/{id}", bookHandler.GetBookByID)
 	r.Put("/books/{id}", bookHandler.UpdateBook)
 	r.Delete("/books/{id}", bookHandler.DeleteBook)
+	r.Post("/works", catalogHandler.CreateWork)
+	r.Get("/works", catalogHandler.ListWorks)
+	r.Get("/works/{id}", catalogHandler.GetWork)
+	r.Post("/series", catalogHandler.CreateSeries)
+	r.Get("/series", catalogHandler.ListSeries)
+	r.Get("/series/{id}", catalogHandler.GetSeries)
+	r.Post("/publishers", catalogHandler.CreatePublisher)
+	r.Get("/publishers", catalogHandler.ListPublishers)
+	r.Get("/publishers/{id}", catalogHandler.GetPublisher)
+
+	booksRead := middlewares.RequireScope(models.ScopeBooksRead)
+	booksWrite := middlewares.RequireScope(models.ScopeBooksWrite)
+	listsWrite := middlewares.RequireScope(models.ScopeListsWrite)
+	r.With(requireAuth, booksWrite).Post("/books/import", bookImportHandler.ImportBooks)
+	r.With(requireAuth, booksRead).Get("/books/duplicates", bookDuplicateHandler.ListDuplicates)
+	r.With(requireAuth, booksWrite).Post("/books/{id}/merge", bookDuplicateHandler.MergeBook)
+	r.With(requireAuth, booksWrite).Post("/books/{id}/enrich", bookEnrichmentHandler.EnrichBook)
+	r.Get("/books/{id}/provenance", bookEnrichmentHandler.GetProvenance)
+	r.With(requireAuth, booksWrite).Put("/books/{id}/cover", bookCoverHandler.UploadCover)
+	r.Get("/books/{id}/cover", bookCoverHandler.GetCover)
+	r.With(requireAuth, booksWrite).Delete("/books/{id}/cover", bookCoverHandler.DeleteCover)
+	r.With(requireAuth, listsWrite).Post("/lists", readingListHandler.CreateReadingList)
+	r.With(optionalAuth).Get("/lists/{id}", readingListHandler.GetReadingList)
+	r.With(requireAuth, listsWrite).Put("/lists/{id}", readingListHandler.UpdateReadingList)
+	r.With(requireAuth, listsWrite).Delete("/lists/{id}", readingListHandler.DeleteReadingList)
+	r.With(requireAuth, listsWrite).Post("/lists/{id}/books", readingListHandler.AddReadingListEntry)
+	r.With(requireAuth, listsWrite).Put("/lists/{id}/books", readingListHandler.ReorderReadingList)
+	r.With(requireAuth, listsWrite).Delete("/lists/{id}/books/{bookID}", readingListHandler.RemoveReadingListEntry)
+	r.With(optionalAuth).Get("/users/{id}/lists", readingListHandler.ListUserReadingLists)
+	// OAuth client tokens are limited to the book and list scopes above.
+	accountAuth := []func(http.Handler) http.Handler{requireAuth, middlewares.RequireFirstPartyToken()}
+	r.With(accountAuth...).Get("/me", accountHandler.GetAccount)
+	r.With(accountAuth...).Put("/me/email", accountHandler.UpdateEmail)
+	r.With(accountAuth...).Get("/me/sessions", sessionHandler.ListSessions)
+	r.With(accountAuth...).Delete("/me/sessions/{id}", sessionHandler.RevokeSession)
+	r.With(accountAuth...).Get("/auth/mfa", mfaHandler.GetStatus)
+	r.With(accountAuth...).Post("/auth/mfa/enroll", mfaHandler.Enroll)
+	r.With(accountAuth...).Post("/auth/mfa/confirm", mfaHandler.Confirm)
+	r.With(accountAuth...).Post("/auth/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+	r.With(accountAuth...).Delete("/auth/mfa", mfaHandler.Disable)
+
+	adminAuth := []func(http.Handler) http.Handler{requireAuth, middlewares.RequireFirstPartyToken(), middlewares.RequireAdmin(cfg.Auth.AdminUsers)}
+	if cfg.Auth.MFARequiredForAdmins {
+		adminAuth = append(adminAuth, middlewares.RequireMFA())
+	}
+	r.With(adminAuth...).Get("/admin/lockouts", lockoutHandler.ListLockouts)
+	r.With(adminAuth...).Delete("/admin/lockouts/{scope}/{subject}", lockoutHandler.Unlock)
+	r.With(adminAuth...).Post("/admin/oauth/clients", oauthClientHandler.CreateClient)
+	r.With(adminAuth...).Get("/admin/oauth/clients", oauthClientHandler.ListClients)
+	r.With(adminAuth...).Delete("/admin/oauth/clients/{clientID}", oauthClientHandler.DeleteClient)
+	r.With(adminAuth...).Get("/admin/log-level", logLevelHandler.GetLevels)
+	r.With(adminAuth...).Put("/admin/log-level", logLevelHandler.SetLevel)
 
 	srv := &http.Server{
 		Addr:              cfg.Server.Address,
@@ -74,48 +351,220 @@ func main() {
 		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 
+	if cfg.Admin.Address != "" {
+		adminRouter := newAdminRouter(cfg, loggers, chi.Chain(adminAuth...).Handler, startedAt)
+		if err := startAdminServer(cfg, adminRouter, lc, loggers); err != nil {
+			_ = lc.Shutdown(context.Background())
+			panic(fmt.Sprintf("start admin server: %v", err))
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", cfg.Server.Address)
+	if err != nil {
+		_ = lc.Shutdown(context.Background())
+		panic(fmt.Sprintf("listen on %s: %v", cfg.Server.Address, err))
+	}
+
+	lc.OnStopTimeout("http server", cfg.Server.ShutdownDelay+cfg.Server.ShutdownTimeout, drainServer(srv, cfg.Server.ShutdownDelay, cfg.Server.ShutdownTimeout))
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.Serve(listener)
+	}()
+	lc.SetReady(true)
 	loggers.HTTP.Info("server listening", "address", cfg.Server.Address)
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+
+	select {
+	case <-ctx.Done():
+		// A second signal kills the process instead of waiting for the drain.
+		stop()
+		loggers.HTTP.Info("shutting down", "delay", cfg.Server.ShutdownDelay.String(), "timeout", cfg.Server.ShutdownTimeout.String())
+	case err := <-serverErr:
 		loggers.Error.Error("server failed", "error", err.Error())
+		_ = lc.Shutdown(context.Background())
 		panic(fmt.Sprintf("server failed: %v", err))
 	}
+
+	if err := lc.Shutdown(context.Background()); err != nil {
+		os.Exit(1)
+	}
 }
 
-func openDatabase(cfg configs.DatabaseConfig) (*sql.DB, error) {
-	if cfg.Driver == "sqlite" {
-		if err := ensureSQLiteDir(cfg.DSN); err != nil {
-			return nil, err
+// drainServer waits delay so load balancers notice /readyz failing, then
+// lets in-flight requests finish for up to timeout before closing the
+// remaining connections.
+func drainServer(srv *http.Server, delay, timeout time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
 		}
+
+		drainCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		if err := srv.Shutdown(drainCtx); err != nil {
+			_ = srv.Close()
+			return err
+		}
+
+		return nil
 	}
+}
 
-	db, err := sql.Open(cfg.Driver, cfg.DSN)
-	if err != nil {
-		return nil, err
+// newAdminRouter serves pprof and process diagnostics. Requests need the
+// ADMIN_TOKEN or an access token that passes adminAuth.
+func newAdminRouter(cfg configs.Config, loggers *utils.Loggers, adminAuth func(http.Handler) http.Handler, startedAt time.Time) http.Handler {
+	diagnosticsHandler := handlers.NewDiagnosticsHandler(cfg.Masked(), startedAt)
+
+	r := chi.NewRouter()
+	r.Use(chiMiddleware.RequestID)
+	// Every diagnostics request is logged, whatever the success sampling.
+	r.Use(middlewares.HTTPLogger(loggers.HTTP, middlewares.HTTPLogOptions{SuccessSampleRatio: 1}))
+	r.Use(chiMiddleware.Recoverer)
+	r.Use(middlewares.AllowAdminToken(cfg.Admin.Token, adminAuth))
+
+	r.Mount("/debug", chiMiddleware.Profiler())
+	r.Get("/admin/runtime", diagnosticsHandler.GetRuntimeStats)
+	r.Get("/admin/build", diagnosticsHandler.GetBuildInfo)
+	r.Get("/admin/config", diagnosticsHandler.GetConfig)
+	r.Get("/admin/goroutines", diagnosticsHandler.GetGoroutines)
+
+	return r
+}
+
+// startAdminServer listens on ADMIN_ADDR. It has no write timeout so CPU
+// profiles and execution traces can run for as long as requested, and it
+// stops after the public server has drained.
+func startAdminServer(cfg configs.Config, handler http.Handler, lc *lifecycle.Manager, loggers *utils.Loggers) error {
+	if cfg.Admin.Address == cfg.Server.Address {
+		return fmt.Errorf("ADMIN_ADDR must differ from HTTP_ADDR, both are %q", cfg.Admin.Address)
 	}
 
-	if err := db.Ping(); err != nil {
-		_ = db.Close()
-		return nil, err
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	listener, err := net.Listen("tcp", cfg.Admin.Address)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", cfg.Admin.Address, err)
 	}
 
-	return db, nil
+	lc.OnStop("admin server", func(ctx context.Context) error {
+		if err := srv.Shutdown(ctx); err != nil {
+			_ = srv.Close()
+			return err
+		}
+		return nil
+	})
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			loggers.Error.Error("admin server failed", "error", err.Error())
+		}
+	}()
+	loggers.HTTP.Info("admin server listening", "address", cfg.Admin.Address)
+
+	return nil
 }
 
-func ensureSQLiteDir(dsn string) error {
-	if !strings.HasPrefix(dsn, "file:") {
-		return nil
+// reloadLogLevelsOnHangup re-reads LOG_LEVELS_FILE on every SIGHUP,
+// discarding levels changed through /admin/log-level.
+func reloadLogLevelsOnHangup(ctx context.Context, loggers *utils.Loggers) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			if err := loggers.ReloadLevels(); err != nil {
+				loggers.Error.Error("reload log levels", "error", err.Error())
+				continue
+			}
+			loggers.Error.Info("log levels reloaded", "levels", loggers.Levels.Snapshot())
+		}
 	}
+}
 
-	filePart := strings.TrimPrefix(dsn, "file:")
-	filePath := strings.SplitN(filePart, "?", 2)[0]
-	if filePath == "" || filePath == ":memory:" {
+// setupTracing installs the global tracing provider. Its worker is stopped,
+// and the remaining spans flushed, after the HTTP server has drained.
+func setupTracing(cfg configs.TracingConfig, lc *lifecycle.Manager, logger *slog.Logger) error {
+	var exporter tracing.Exporter
+	switch cfg.Exporter {
+	case "none":
 		return nil
+	case "otlp":
+		exporter = tracing.NewOTLPExporter(cfg.OTLPEndpoint, cfg.ServiceName, nil)
+	case "file":
+		fileExporter, err := tracing.NewFileExporter(cfg.File, cfg.ServiceName)
+		if err != nil {
+			return err
+		}
+		lc.OnStop("trace file", func(context.Context) error { return fileExporter.Close() })
+		exporter = fileExporter
+	default:
+		return fmt.Errorf("unknown TRACING_EXPORTER %q", cfg.Exporter)
 	}
 
-	dir := filepath.Dir(filePath)
-	if dir == "." || dir == "" {
-		return nil
+	provider := tracing.NewProvider(exporter, cfg.SampleRatio, func(err error) {
+		logger.Error("export spans", "error", err.Error())
+	}, time.Now)
+	tracing.SetProvider(provider)
+	lc.Go("tracing", provider.Run)
+
+	return nil
+}
+
+func newRateLimiter(cfg configs.RateLimitConfig, db *sql.DB) (ratelimit.Limiter, error) {
+	switch cfg.Store {
+	case "memory":
+		return ratelimit.NewMemoryLimiter(time.Now), nil
+	case "sqlite":
+		return ratelimit.NewSQLiteLimiter(db, time.Now), nil
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_STORE %q", cfg.Store)
+	}
+}
+
+func newTokenVerifiers(cfg configs.AuthConfig) ([]middlewares.TokenVerifier, error) {
+	if cfg.OIDCIssuersFile == "" {
+		return nil, nil
 	}
 
-	return os.MkdirAll(dir, 0o755)
+	issuers, err := oidc.LoadIssuers(cfg.OIDCIssuersFile)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := oidc.NewVerifier(issuers, nil, time.Now)
+	if err != nil {
+		return nil, err
+	}
+
+	return []middlewares.TokenVerifier{verifier}, nil
+}
+
+func newMailer(cfg configs.MailConfig) (mail.Mailer, error) {
+	switch cfg.Driver {
+	case "log":
+		// Mails carry reset tokens; the log driver is for local development.
+		return mail.NewLogMailer(slog.Default()), nil
+	case "file":
+		return mail.NewFileMailer(cfg.Dir, cfg.From)
+	case "smtp":
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		})
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", cfg.Driver)
+	}
 }
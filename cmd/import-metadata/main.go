@@ -0,0 +1,74 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"desent-api/configs"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/usecases"
+	"desent-api/internal/utils"
+
+	_ "modernc.org/sqlite"
+)
+
+func main() {
+	file := flag.String("file", "", "path to the metadata dump (.gz files are decompressed)")
+	format := flag.String("format", models.MetadataFormatOpenLibrary, "dump format: openlibrary, marc or marcxml")
+	flag.Parse()
+
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "usage: import-metadata -file <dump> [-format openlibrary|marc|marcxml]")
+		os.Exit(2)
+	}
+
+	if err := run(*file, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "import metadata: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(path, format string) error {
+	cfg := configs.Load()
+	ctx := context.Background()
+
+	db, err := utils.OpenDatabase(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer db.Close()
+
+	if err := repositories.InitSchema(ctx, db); err != nil {
+		return fmt.Errorf("init schema: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	stats, err := usecases.NewImportMetadataUsecase(repositories.NewSQLiteMetadataRepository(db)).Execute(ctx, format, r)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d editions, %d works, %d authors (%d lines skipped)\n", stats.Editions, stats.Works, stats.Authors, stats.Skipped)
+	return nil
+}
@@ -3,6 +3,7 @@ package configs
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,66 +13,239 @@ type Config struct {
 	Database DatabaseConfig
 	Auth     AuthConfig
 	Rate     RateLimitConfig
+	Storage  StorageConfig
+	Metadata MetadataConfig
+	Mail     MailConfig
+	Health   HealthConfig
+	Tracing  TracingConfig
+	Admin    AdminConfig
 }
 
 type ServerConfig struct {
-	Address           string
-	ReadTimeout       time.Duration
-	ReadHeaderTimeout time.Duration
-	WriteTimeout      time.Duration
-	IdleTimeout       time.Duration
+	Address        string
+	TrustedProxies []string
+	// TrustedProxyHeader is the forwarding header the trusted proxies
+	// write: "x-forwarded-for" or "forwarded".
+	TrustedProxyHeader string
+	ReadTimeout        time.Duration
+	ReadHeaderTimeout  time.Duration
+	WriteTimeout       time.Duration
+	IdleTimeout        time.Duration
+	// ShutdownDelay is how long /readyz fails before the server stops
+	// accepting connections; ShutdownTimeout bounds the drain after that.
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
 }
 
+// LoggingConfig also controls the access log: every response outside 2xx
+// is logged, and HTTPLogSuccessSampleRatio of the 2xx ones. Entries wait in
+// a queue of HTTPLogQueueSize and are dropped when it is full.
 type LoggingConfig struct {
-	LogsDir            string
-	RetentionDays      int
-	ConsoleEnabled     bool
-	FileEnabled        bool
-	HTTPLogFilePrefix  string
-	ErrorLogFilePrefix string
+	LogsDir                   string
+	RetentionDays             int
+	ConsoleEnabled            bool
+	FileEnabled               bool
+	HTTPLogFilePrefix         string
+	ErrorLogFilePrefix        string
+	HTTPLogEnabled            bool
+	HTTPLogSuccessSampleRatio float64
+	HTTPLogQueueSize          int
+	// Log files switch at midnight and, when RotateMaxBytes is set, once
+	// they reach that size. Rotated files are gzipped if CompressRotated is
+	// set, and retention runs every RetentionInterval.
+	RotateMaxBytes    int64
+	CompressRotated   bool
+	RetentionInterval time.Duration
+	// Levels sets component log levels as "http=info,db=debug". LevelsFile
+	// holds the same pairs, one per line, and is re-read on SIGHUP.
+	Levels     string
+	LevelsFile string
 }
 
 type DatabaseConfig struct {
-	Driver string
-	DSN    string
+	Driver             string
+	DSN                string
+	SlowQueryThreshold time.Duration
+	ExplainSlowQueries bool
 }
 
 type AuthConfig struct {
-	JWTSecret     string
-	JWTTTLSeconds int
+	JWTSecret                string `secret:"true"`
+	JWTTTLSeconds            int
+	AdminUsers               []string
+	LockoutUsernameThreshold int
+	LockoutIPThreshold       int
+	LockoutBase              time.Duration
+	LockoutMax               time.Duration
+	LoginFailureWindow       time.Duration
+	MFAIssuer                string
+	MFAChallengeTTL          time.Duration
+	MFARequiredForAdmins     bool
+	MFAEncryptionKey         string `secret:"true"`
+	OIDCIssuersFile          string
+	PasswordResetTTL         time.Duration
+	EmailVerificationTTL     time.Duration
 }
 
+type StorageConfig struct {
+	CoversDir           string
+	CoverMaxUploadBytes int64
+}
+
+// MailConfig selects how account mails are delivered: "log" writes them to
+// the application log, "file" writes .eml files into Dir and "smtp" sends
+// them through SMTPHost. BaseURL is the frontend that links point to.
+type MailConfig struct {
+	Driver       string
+	From         string
+	Dir          string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string `secret:"true"`
+	BaseURL      string
+}
+
+// HealthConfig tunes /readyz: every check gets CheckTimeout, results are
+// reused for CacheTTL, and the logs directory must keep MinFreeDiskBytes.
+type HealthConfig struct {
+	CheckTimeout     time.Duration
+	CacheTTL         time.Duration
+	MinFreeDiskBytes uint64
+}
+
+// TracingConfig selects where spans go: "none" disables tracing, "otlp"
+// posts them to OTLPEndpoint and "file" appends them to File as OTLP JSON.
+// SampleRatio applies to traces that start here; requests carrying a
+// traceparent follow the caller's decision.
+type TracingConfig struct {
+	Exporter     string
+	OTLPEndpoint string
+	ServiceName  string
+	File         string
+	SampleRatio  float64
+}
+
+// AdminConfig runs the diagnostics listener on Address, away from the
+// public one; an empty Address disables it. Token is a dedicated bearer
+// token accepted there besides an administrator's access token.
+type AdminConfig struct {
+	Address string
+	Token   string `secret:"true"`
+}
+
+type MetadataConfig struct {
+	EnrichOnCreate bool
+}
+
+// RateLimitConfig describes the default token bucket, which holds Burst
+// requests and refills at RequestsPerMinute. PoliciesFile can override it
+// per route and tier and is re-read every PoliciesReloadInterval. Store is
+// "memory" for a per-process limiter or "sqlite" to share buckets through
+// the application database.
 type RateLimitConfig struct {
-	RequestsPerMinute int
+	Enabled                bool
+	Store                  string
+	RequestsPerMinute      int
+	Burst                  int
+	PoliciesFile           string
+	PoliciesReloadInterval time.Duration
 }
 
 func Load() Config {
+	jwtSecret := Getenv("JWT_SECRET", "dev-secret-change-me")
+
 	return Config{
 		Server: ServerConfig{
-			Address:           Getenv("HTTP_ADDR", ":8080"),
-			ReadTimeout:       time.Duration(GetenvInt("HTTP_READ_TIMEOUT_SECONDS", 10)) * time.Second,
-			ReadHeaderTimeout: time.Duration(GetenvInt("HTTP_READ_HEADER_TIMEOUT_SECONDS", 5)) * time.Second,
-			WriteTimeout:      time.Duration(GetenvInt("HTTP_WRITE_TIMEOUT_SECONDS", 15)) * time.Second,
-			IdleTimeout:       time.Duration(GetenvInt("HTTP_IDLE_TIMEOUT_SECONDS", 60)) * time.Second,
+			Address:            Getenv("HTTP_ADDR", ":8080"),
+			TrustedProxies:     GetenvList("TRUSTED_PROXIES", nil),
+			TrustedProxyHeader: Getenv("TRUSTED_PROXY_HEADER", "x-forwarded-for"),
+			ReadTimeout:        time.Duration(GetenvInt("HTTP_READ_TIMEOUT_SECONDS", 10)) * time.Second,
+			ReadHeaderTimeout:  time.Duration(GetenvInt("HTTP_READ_HEADER_TIMEOUT_SECONDS", 5)) * time.Second,
+			WriteTimeout:       time.Duration(GetenvInt("HTTP_WRITE_TIMEOUT_SECONDS", 15)) * time.Second,
+			IdleTimeout:        time.Duration(GetenvInt("HTTP_IDLE_TIMEOUT_SECONDS", 60)) * time.Second,
+			ShutdownDelay:      time.Duration(GetenvInt("SHUTDOWN_DELAY_SECONDS", 5)) * time.Second,
+			ShutdownTimeout:    time.Duration(GetenvInt("SHUTDOWN_TIMEOUT_SECONDS", 30)) * time.Second,
 		},
 		Logging: LoggingConfig{
-			LogsDir:            Getenv("LOGS_DIR", "logs"),
-			RetentionDays:      GetenvInt("LOG_RETENTION_DAYS", 7),
-			ConsoleEnabled:     GetenvBool("HTTP_LOG_CONSOLE_ENABLED", true),
-			FileEnabled:        GetenvBool("HTTP_LOG_FILE_ENABLED", true),
-			HTTPLogFilePrefix:  "http",
-			ErrorLogFilePrefix: "error",
+			LogsDir:                   Getenv("LOGS_DIR", "logs"),
+			RetentionDays:             GetenvInt("LOG_RETENTION_DAYS", 7),
+			ConsoleEnabled:            GetenvBool("HTTP_LOG_CONSOLE_ENABLED", true),
+			FileEnabled:               GetenvBool("HTTP_LOG_FILE_ENABLED", true),
+			HTTPLogFilePrefix:         "http",
+			ErrorLogFilePrefix:        "error",
+			HTTPLogEnabled:            GetenvBool("HTTP_ACCESS_LOG_ENABLED", true),
+			HTTPLogSuccessSampleRatio: float64(GetenvInt("HTTP_ACCESS_LOG_SUCCESS_SAMPLE_PERCENT", 100)) / 100,
+			HTTPLogQueueSize:          GetenvInt("HTTP_ACCESS_LOG_QUEUE_SIZE", 4096),
+			RotateMaxBytes:            int64(GetenvInt("LOG_ROTATE_MAX_MB", 0)) << 20,
+			CompressRotated:           GetenvBool("LOG_COMPRESS_ROTATED", true),
+			RetentionInterval:         time.Duration(GetenvInt("LOG_RETENTION_INTERVAL_MINUTES", 60)) * time.Minute,
+			Levels:                    Getenv("LOG_LEVELS", ""),
+			LevelsFile:                Getenv("LOG_LEVELS_FILE", ""),
 		},
 		Database: DatabaseConfig{
-			Driver: Getenv("DB_DRIVER", "sqlite"),
-			DSN:    Getenv("DB_DSN", "file:/tmp/books.db"),
+			Driver:             Getenv("DB_DRIVER", "sqlite"),
+			DSN:                Getenv("DB_DSN", "file:/tmp/books.db"),
+			SlowQueryThreshold: time.Duration(GetenvInt("DB_SLOW_QUERY_MS", 200)) * time.Millisecond,
+			ExplainSlowQueries: GetenvBool("DB_EXPLAIN_SLOW_QUERIES", false),
 		},
 		Auth: AuthConfig{
-			JWTSecret:     Getenv("JWT_SECRET", "dev-secret-change-me"),
-			JWTTTLSeconds: GetenvInt("JWT_TTL_SECONDS", 3600),
+			JWTSecret:                jwtSecret,
+			JWTTTLSeconds:            GetenvInt("JWT_TTL_SECONDS", 3600),
+			AdminUsers:               GetenvList("ADMIN_USERS", []string{"admin"}),
+			LockoutUsernameThreshold: GetenvInt("AUTH_LOCKOUT_USERNAME_THRESHOLD", 5),
+			LockoutIPThreshold:       GetenvInt("AUTH_LOCKOUT_IP_THRESHOLD", 20),
+			LockoutBase:              time.Duration(GetenvInt("AUTH_LOCKOUT_BASE_SECONDS", 60)) * time.Second,
+			LockoutMax:               time.Duration(GetenvInt("AUTH_LOCKOUT_MAX_SECONDS", 3600)) * time.Second,
+			LoginFailureWindow:       time.Duration(GetenvInt("AUTH_FAILURE_WINDOW_SECONDS", 900)) * time.Second,
+			MFAIssuer:                Getenv("MFA_ISSUER", "desent-api"),
+			MFAChallengeTTL:          time.Duration(GetenvInt("MFA_CHALLENGE_TTL_SECONDS", 300)) * time.Second,
+			MFARequiredForAdmins:     GetenvBool("MFA_REQUIRED_FOR_ADMINS", true),
+			MFAEncryptionKey:         Getenv("MFA_ENCRYPTION_KEY", ""),
+			OIDCIssuersFile:          Getenv("OIDC_ISSUERS_FILE", ""),
+			PasswordResetTTL:         time.Duration(GetenvInt("PASSWORD_RESET_TTL_SECONDS", 3600)) * time.Second,
+			EmailVerificationTTL:     time.Duration(GetenvInt("EMAIL_VERIFICATION_TTL_SECONDS", 86400)) * time.Second,
 		},
 		Rate: RateLimitConfig{
-			RequestsPerMinute: GetenvInt("RATE_LIMIT_PER_MINUTE", 200),
+			Enabled:                GetenvBool("RATE_LIMIT_ENABLED", true),
+			Store:                  Getenv("RATE_LIMIT_STORE", "memory"),
+			RequestsPerMinute:      GetenvInt("RATE_LIMIT_PER_MINUTE", 200),
+			Burst:                  GetenvInt("RATE_LIMIT_BURST", 50),
+			PoliciesFile:           Getenv("RATE_LIMIT_POLICIES_FILE", ""),
+			PoliciesReloadInterval: time.Duration(GetenvInt("RATE_LIMIT_POLICIES_RELOAD_SECONDS", 10)) * time.Second,
+		},
+		Storage: StorageConfig{
+			CoversDir:           Getenv("COVERS_DIR", "data/covers"),
+			CoverMaxUploadBytes: int64(GetenvInt("COVER_MAX_UPLOAD_BYTES", 5<<20)),
+		},
+		Metadata: MetadataConfig{
+			EnrichOnCreate: GetenvBool("ENRICH_ON_CREATE", true),
+		},
+		Mail: MailConfig{
+			Driver:       Getenv("MAIL_DRIVER", "log"),
+			From:         Getenv("MAIL_FROM", "desent-api <no-reply@localhost>"),
+			Dir:          Getenv("MAIL_DIR", "data/mail"),
+			SMTPHost:     Getenv("SMTP_HOST", ""),
+			SMTPPort:     GetenvInt("SMTP_PORT", 587),
+			SMTPUsername: Getenv("SMTP_USERNAME", ""),
+			SMTPPassword: Getenv("SMTP_PASSWORD", ""),
+			BaseURL:      Getenv("APP_BASE_URL", ""),
+		},
+		Health: HealthConfig{
+			CheckTimeout:     time.Duration(GetenvInt("HEALTH_CHECK_TIMEOUT_MS", 2000)) * time.Millisecond,
+			CacheTTL:         time.Duration(GetenvInt("HEALTH_CACHE_MS", 2000)) * time.Millisecond,
+			MinFreeDiskBytes: uint64(GetenvInt("HEALTH_MIN_FREE_DISK_MB", 100)) << 20,
+		},
+		Tracing: TracingConfig{
+			Exporter:     Getenv("TRACING_EXPORTER", "none"),
+			OTLPEndpoint: Getenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+			ServiceName:  Getenv("OTEL_SERVICE_NAME", "desent-api"),
+			File:         Getenv("TRACING_FILE", "logs/traces.jsonl"),
+			SampleRatio:  float64(GetenvInt("TRACING_SAMPLE_PERCENT", 100)) / 100,
+		},
+		Admin: AdminConfig{
+			Address: Getenv("ADMIN_ADDR", "127.0.0.1:6060"),
+			Token:   Getenv("ADMIN_TOKEN", ""),
 		},
 	}
 }
@@ -111,3 +285,20 @@ func GetenvBool(key string, defaultValue bool) bool {
 
 	return parsed
 }
+
+// GetenvList splits a comma-separated variable, dropping empty entries.
+func GetenvList(key string, defaultValue []string) []string {
+	value := Getenv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+
+	return values
+}
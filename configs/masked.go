@@ -0,0 +1,44 @@
+package configs
+
+import (
+	"reflect"
+	"time"
+)
+
+// maskedValue replaces fields tagged `secret:"true"` that are set.
+const maskedValue = "[REDACTED]"
+
+// Masked returns the configuration as nested maps keyed by field name, with
+// secrets replaced and durations written like "1m30s". Unset secrets stay
+// empty so it is visible that they are missing.
+func (c Config) Masked() map[string]any {
+	return maskStruct(reflect.ValueOf(c))
+}
+
+func maskStruct(v reflect.Value) map[string]any {
+	fields := make(map[string]any, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		value := v.Field(i)
+		switch {
+		case field.Tag.Get("secret") == "true":
+			if value.IsZero() {
+				fields[field.Name] = ""
+			} else {
+				fields[field.Name] = maskedValue
+			}
+		case value.Type() == reflect.TypeOf(time.Duration(0)):
+			fields[field.Name] = time.Duration(value.Int()).String()
+		case value.Kind() == reflect.Struct:
+			fields[field.Name] = maskStruct(value)
+		default:
+			fields[field.Name] = value.Interface()
+		}
+	}
+
+	return fields
+}
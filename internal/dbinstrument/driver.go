@@ -0,0 +1,459 @@
+package dbinstrument
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Statement describes a statement that is about to run.
+type Statement struct {
+	Query string
+	// Operation is the leading SQL keyword, such as SELECT or INSERT.
+	Operation string
+	Args      []driver.NamedValue
+	// ReturnsRows is set for queries, whose Outcome counts rows read
+	// rather than rows affected.
+	ReturnsRows bool
+}
+
+// Outcome describes a finished statement. For queries it is reported once
+// the rows are closed, so Duration includes reading them.
+type Outcome struct {
+	Duration time.Duration
+	// Rows is the number of rows read or affected, or -1 if unknown.
+	Rows int64
+	Err  error
+	// Explain runs EXPLAIN QUERY PLAN for the statement on the connection
+	// it ran on and returns the detail of every plan step. It must only be
+	// called before the finish function returns.
+	Explain func(ctx context.Context) ([]string, error)
+}
+
+// Observer is told about every statement run through a DB opened by Open.
+// Start may return a derived context and a nil finish function.
+type Observer interface {
+	Start(ctx context.Context, stmt Statement) (context.Context, func(Outcome))
+}
+
+var (
+	errExplainUnsupported   = errors.New("driver cannot run EXPLAIN QUERY PLAN")
+	errNotExplainable       = errors.New("only a single SELECT, WITH, INSERT, UPDATE or DELETE statement is explained")
+	errIsolationUnsupported = errors.New("sql: driver does not support non-default isolation level")
+	errReadOnlyUnsupported  = errors.New("sql: driver does not support read-only transactions")
+)
+
+// Open opens driverName like sql.Open, reporting every statement to
+// observers in order.
+func Open(driverName, dsn string, observers ...Observer) (*sql.DB, error) {
+	probe, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := probe.Driver()
+	_ = probe.Close()
+
+	return sql.OpenDB(&connector{driver: drv, dsn: dsn, observers: observers}), nil
+}
+
+type connector struct {
+	driver    driver.Driver
+	dsn       string
+	observers []Observer
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	var conn driver.Conn
+	var err error
+	if dc, ok := c.driver.(driver.DriverContext); ok {
+		inner, openErr := dc.OpenConnector(c.dsn)
+		if openErr != nil {
+			return nil, openErr
+		}
+		conn, err = inner.Connect(ctx)
+	} else {
+		conn, err = c.driver.Open(c.dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedConn{Conn: conn, observers: c.observers}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// instrumentedConn forwards the optional driver interfaces database/sql
+// uses and falls back the way database/sql would when the wrapped conn
+// lacks one.
+type instrumentedConn struct {
+	driver.Conn
+	observers []Observer
+}
+
+// statementRun is one statement between start and finish.
+type statementRun struct {
+	conn      *instrumentedConn
+	stmt      Statement
+	startedAt time.Time
+	finishers []func(Outcome)
+}
+
+func (c *instrumentedConn) start(ctx context.Context, query string, args []driver.NamedValue, returnsRows bool) (context.Context, *statementRun) {
+	run := &statementRun{
+		conn:      c,
+		stmt:      Statement{Query: query, Operation: operation(query), Args: args, ReturnsRows: returnsRows},
+		startedAt: time.Now(),
+	}
+	for _, observer := range c.observers {
+		var finish func(Outcome)
+		ctx, finish = observer.Start(ctx, run.stmt)
+		if finish != nil {
+			run.finishers = append(run.finishers, finish)
+		}
+	}
+
+	return ctx, run
+}
+
+// finish reports the outcome to observers, last started first. A
+// statement the driver skipped is not reported; database/sql retries it
+// through a prepared statement, which is.
+func (r *statementRun) finish(rows int64, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+
+	outcome := Outcome{
+		Duration: time.Since(r.startedAt),
+		Rows:     rows,
+		Err:      err,
+		Explain: func(ctx context.Context) ([]string, error) {
+			return r.conn.explain(ctx, r.stmt.Query, r.stmt.Args)
+		},
+	}
+	for i := len(r.finishers) - 1; i >= 0; i-- {
+		r.finishers[i](outcome)
+	}
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, run := c.start(ctx, query, args, true)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		run.finish(-1, err)
+		return nil, err
+	}
+
+	return &instrumentedRows{Rows: rows, run: run}, nil
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, run := c.start(ctx, query, args, false)
+	result, err := execer.ExecContext(ctx, query, args)
+	run.finish(rowsAffected(result, err), err)
+
+	return result, err
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+// BeginTx rejects options a conn without ConnBeginTx cannot honour, as
+// database/sql does, rather than silently starting a default transaction.
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errIsolationUnsupported
+	}
+	if opts.ReadOnly {
+		return nil, errReadOnlyUnsupported
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return c.Conn.Begin()
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	return driver.ErrSkip
+}
+
+// explain runs on the wrapped conn directly, so the plan query is neither
+// observed itself nor waits for another pool connection. The driver runs a
+// multi-statement string as a script and would only explain the first
+// statement while running the rest again, so those are not explained.
+func (c *instrumentedConn) explain(ctx context.Context, query string, args []driver.NamedValue) ([]string, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, errExplainUnsupported
+	}
+	if !explainable(query) {
+		return nil, errNotExplainable
+	}
+
+	rows, err := queryer.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make([]driver.Value, len(rows.Columns()))
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	var plan []string
+	for {
+		if err := rows.Next(values); err != nil {
+			if err == io.EOF {
+				return plan, nil
+			}
+			return plan, err
+		}
+
+		// The last column is the human-readable detail of the step.
+		switch detail := values[len(values)-1].(type) {
+		case []byte:
+			plan = append(plan, string(detail))
+		default:
+			plan = append(plan, fmt.Sprint(detail))
+		}
+	}
+}
+
+type instrumentedStmt struct {
+	driver.Stmt
+	conn  *instrumentedConn
+	query string
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, run := s.conn.start(ctx, s.query, args, false)
+
+	var result driver.Result
+	var err error
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedValuesToValues(args))
+	}
+	run.finish(rowsAffected(result, err), err)
+
+	return result, err
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, run := s.conn.start(ctx, s.query, args, true)
+
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedValuesToValues(args))
+	}
+	if err != nil {
+		run.finish(-1, err)
+		return nil, err
+	}
+
+	return &instrumentedRows{Rows: rows, run: run}, nil
+}
+
+func (s *instrumentedStmt) CheckNamedValue(value *driver.NamedValue) error {
+	return s.conn.CheckNamedValue(value)
+}
+
+// instrumentedRows reports the query once the rows are closed, so the
+// outcome covers the time spent reading them.
+type instrumentedRows struct {
+	driver.Rows
+	run   *statementRun
+	count int64
+	err   error
+}
+
+func (r *instrumentedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.count++
+	case err != io.EOF:
+		r.err = err
+	}
+
+	return err
+}
+
+func (r *instrumentedRows) Close() error {
+	err := r.Rows.Close()
+	r.run.finish(r.count, r.err)
+
+	return err
+}
+
+func rowsAffected(result driver.Result, err error) int64 {
+	if err != nil || result == nil {
+		return -1
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return -1
+	}
+
+	return affected
+}
+
+func namedValuesToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+
+	return values
+}
+
+func operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return strings.ToUpper(fields[0])
+}
+
+// explainable reports whether query is a single SELECT, WITH, INSERT,
+// UPDATE or DELETE statement, ignoring comments and one trailing semicolon.
+func explainable(query string) bool {
+	rest := skipSQLSpace(query)
+	keyword := rest
+	if i := strings.IndexFunc(rest, func(r rune) bool { return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') }); i >= 0 {
+		keyword = rest[:i]
+	}
+	switch strings.ToUpper(keyword) {
+	case "SELECT", "WITH", "INSERT", "UPDATE", "DELETE":
+	default:
+		return false
+	}
+
+	end, ok := statementEnd(rest)
+	return ok && skipSQLSpace(rest[end:]) == ""
+}
+
+// statementEnd returns the index just past the first semicolon outside
+// literals, quoted names and comments, or len(query) if there is none.
+// It fails on an unterminated literal or comment.
+func statementEnd(query string) (int, bool) {
+	for i := 0; i < len(query); i++ {
+		closing := ""
+		switch c := query[i]; {
+		case c == ';':
+			return i + 1, true
+		case c == '\'' || c == '"' || c == '`':
+			closing = string(c)
+		case c == '[':
+			closing = "]"
+		case strings.HasPrefix(query[i:], "--"):
+			closing = "\n"
+		case strings.HasPrefix(query[i:], "/*"):
+			closing = "*/"
+			i++
+		default:
+			continue
+		}
+
+		end := strings.Index(query[i+1:], closing)
+		if end < 0 {
+			return len(query), closing == "\n"
+		}
+		i += end + len(closing)
+	}
+
+	return len(query), true
+}
+
+// skipSQLSpace drops leading white space and comments.
+func skipSQLSpace(query string) string {
+	for {
+		query = strings.TrimLeft(query, " \t\r\n")
+		switch {
+		case strings.HasPrefix(query, "--"):
+			_, rest, ok := strings.Cut(query, "\n")
+			if !ok {
+				return ""
+			}
+			query = rest
+		case strings.HasPrefix(query, "/*"):
+			_, rest, ok := strings.Cut(query[2:], "*/")
+			if !ok {
+				return ""
+			}
+			query = rest
+		default:
+			return query
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package dbinstrument
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+type report struct {
+	stmt    Statement
+	outcome Outcome
+	plan    []string
+	planErr error
+}
+
+// recorder keeps every reported statement and, with explain set, the plan
+// fetched from inside the finish function.
+type recorder struct {
+	explain bool
+
+	mu      sync.Mutex
+	reports []report
+}
+
+func (r *recorder) Start(ctx context.Context, stmt Statement) (context.Context, func(Outcome)) {
+	return ctx, func(outcome Outcome) {
+		rep := report{stmt: stmt, outcome: outcome}
+		if r.explain {
+			rep.plan, rep.planErr = outcome.Explain(ctx)
+		}
+
+		r.mu.Lock()
+		r.reports = append(r.reports, rep)
+		r.mu.Unlock()
+	}
+}
+
+func (r *recorder) take() []report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reports := r.reports
+	r.reports = nil
+	return reports
+}
+
+// legacyDriver hides every optional interface of the sqlite conn, so
+// database/sql has to fall back to Prepare and Begin.
+type legacyDriver struct {
+	inner driver.Driver
+}
+
+func (d legacyDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.inner.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return legacyConn{inner: conn}, nil
+}
+
+type legacyConn struct {
+	inner driver.Conn
+}
+
+func (c legacyConn) Prepare(query string) (driver.Stmt, error) { return c.inner.Prepare(query) }
+func (c legacyConn) Close() error                              { return c.inner.Close() }
+func (c legacyConn) Begin() (driver.Tx, error)                 { return c.inner.Begin() }
+
+var registerLegacy sync.Once
+
+func openTestDB(t *testing.T, driverName string, observers ...Observer) *sql.DB {
+	t.Helper()
+
+	if driverName == "sqlite-legacy" {
+		registerLegacy.Do(func() {
+			probe, err := sql.Open("sqlite", ":memory:")
+			if err != nil {
+				t.Fatalf("open sqlite: %v", err)
+			}
+			sql.Register("sqlite-legacy", legacyDriver{inner: probe.Driver()})
+			_ = probe.Close()
+		})
+	}
+
+	db, err := Open(driverName, "file:"+t.TempDir()+"/test.db", observers...)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if _, err := db.Exec("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT NOT NULL, cover BLOB)"); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	for _, title := range []string{"Dune", "Emma", "Ulysses"} {
+		if _, err := db.Exec("INSERT INTO books (title) VALUES (?)", title); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	return db
+}
+
+func TestOpen_ReportsExecAndCountsRowsOnClose(t *testing.T) {
+	rec := &recorder{}
+	db := openTestDB(t, "sqlite", rec)
+	rec.take()
+
+	if _, err := db.Exec("UPDATE books SET title = upper(title) WHERE id > ?", 1); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	reports := rec.take()
+	if len(reports) != 1 || reports[0].stmt.Operation != "UPDATE" || reports[0].stmt.ReturnsRows || reports[0].outcome.Rows != 2 {
+		t.Fatalf("expected one UPDATE affecting two rows, got %+v", reports)
+	}
+
+	rows, err := db.Query("SELECT title FROM books ORDER BY id")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if got := rec.take(); len(got) != 0 {
+		t.Fatalf("expected the query to be reported on close, got %+v", got)
+	}
+	rows.Next()
+	rows.Next()
+	if err := rows.Close(); err != nil {
+		t.Fatalf("close rows: %v", err)
+	}
+	reports = rec.take()
+	if len(reports) != 1 || !reports[0].stmt.ReturnsRows || reports[0].outcome.Rows != 2 || reports[0].outcome.Err != nil {
+		t.Fatalf("expected one query with two rows read, got %+v", reports)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM books").Scan(&count); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if reports := rec.take(); len(reports) != 1 || reports[0].outcome.Rows != 1 {
+		t.Fatalf("expected QueryRow to be reported once, got %+v", reports)
+	}
+
+	if _, err := db.Exec("INSERT INTO missing (id) VALUES (1)"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if reports := rec.take(); len(reports) != 1 || reports[0].outcome.Err == nil || reports[0].outcome.Rows != -1 {
+		t.Fatalf("expected the failure to be reported, got %+v", reports)
+	}
+}
+
+func TestOpen_SkippedStatementsAreReportedOnce(t *testing.T) {
+	rec := &recorder{}
+	db := openTestDB(t, "sqlite-legacy", rec)
+	rec.take()
+
+	if _, err := db.Exec("DELETE FROM books WHERE id = ?", 1); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	reports := rec.take()
+	if len(reports) != 1 || reports[0].stmt.Operation != "DELETE" || reports[0].outcome.Err != nil || reports[0].outcome.Rows != 1 {
+		t.Fatalf("expected exactly one DELETE report, got %+v", reports)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM books").Scan(&count); err != nil || count != 2 {
+		t.Fatalf("count: %d, %v", count, err)
+	}
+	if reports := rec.take(); len(reports) != 1 || reports[0].stmt.Operation != "SELECT" {
+		t.Fatalf("expected exactly one SELECT report, got %+v", reports)
+	}
+}
+
+func TestOpen_PreparedStatementsAndTransactions(t *testing.T) {
+	rec := &recorder{}
+	db := openTestDB(t, "sqlite", rec)
+	rec.take()
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec("INSERT INTO books (title) VALUES (?)", "Middlemarch"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	stmt, err := tx.Prepare("SELECT title FROM books WHERE id = ?")
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	for _, id := range []int{1, 4} {
+		var title string
+		if err := stmt.QueryRow(id).Scan(&title); err != nil {
+			t.Fatalf("query %d: %v", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	reports := rec.take()
+	if len(reports) != 3 {
+		t.Fatalf("expected three reports, got %+v", reports)
+	}
+	if reports[0].stmt.Operation != "INSERT" || reports[0].outcome.Rows != 1 {
+		t.Fatalf("unexpected insert report %+v", reports[0])
+	}
+	for _, rep := range reports[1:] {
+		if rep.stmt.Query != "SELECT title FROM books WHERE id = ?" || rep.outcome.Rows != 1 || len(rep.stmt.Args) != 1 {
+			t.Fatalf("unexpected prepared query report %+v", rep)
+		}
+	}
+
+	insert, err := db.Prepare("INSERT INTO books (title) VALUES (?)")
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	defer insert.Close()
+	for _, title := range []string{"Beloved", "Possession"} {
+		if _, err := insert.Exec(title); err != nil {
+			t.Fatalf("exec: %v", err)
+		}
+	}
+	if reports := rec.take(); len(reports) != 2 || reports[0].stmt.Operation != "INSERT" || reports[1].outcome.Rows != 1 {
+		t.Fatalf("expected one report per execution, got %+v", reports)
+	}
+}
+
+func TestOpen_BeginTxRejectsOptionsTheDriverCannotHonour(t *testing.T) {
+	db := openTestDB(t, "sqlite-legacy")
+
+	for _, opts := range []*sql.TxOptions{
+		{Isolation: sql.LevelSerializable},
+		{ReadOnly: true},
+	} {
+		if tx, err := db.BeginTx(context.Background(), opts); err == nil {
+			_ = tx.Rollback()
+			t.Fatalf("expected %+v to be rejected", opts)
+		}
+	}
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{})
+	if err != nil {
+		t.Fatalf("expected default options to work: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+}
+
+func TestOpen_ExplainRunsOnTheStatementsConnection(t *testing.T) {
+	rec := &recorder{explain: true}
+	db := openTestDB(t, "sqlite", rec)
+	ctx := context.Background()
+
+	// A temporary table only exists on the connection that created it, so
+	// the plan can only be produced there.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("conn: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "CREATE TEMP TABLE drafts (id INTEGER PRIMARY KEY, title TEXT)"); err != nil {
+		t.Fatalf("create temp table: %v", err)
+	}
+	rec.take()
+
+	rows, err := conn.QueryContext(ctx, "SELECT title FROM drafts WHERE id = ?", 1)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	_ = rows.Close()
+
+	reports := rec.take()
+	if len(reports) != 1 || reports[0].planErr != nil || len(reports[0].plan) == 0 || !strings.Contains(reports[0].plan[0], "drafts") {
+		t.Fatalf("expected a plan for the temporary table, got %+v", reports)
+	}
+	if len(rec.take()) != 0 {
+		t.Fatal("expected the EXPLAIN itself not to be reported")
+	}
+}
+
+func TestOpen_ExplainIsUnsupportedWithoutQueryer(t *testing.T) {
+	rec := &recorder{explain: true}
+	db := openTestDB(t, "sqlite-legacy", rec)
+	rec.take()
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM books").Scan(&count); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if reports := rec.take(); len(reports) != 1 || !errors.Is(reports[0].planErr, errExplainUnsupported) {
+		t.Fatalf("expected the plan to be unsupported, got %+v", reports)
+	}
+}
+
+func TestSlowQueryLog_ThresholdAndRedaction(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		threshold time.Duration
+		logged    bool
+	}{
+		{"disabled", 0, false},
+		{"fast", time.Hour, false},
+		{"slow", time.Nanosecond, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&out, nil))
+			db := openTestDB(t, "sqlite", NewSlowQueryLog(logger, tc.threshold, true))
+			out.Reset()
+
+			if _, err := db.Exec("UPDATE books SET title = ?, cover = ? WHERE id = ?", "Secret Title", []byte("secret cover"), 1); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+
+			if logged := out.Len() > 0; logged != tc.logged {
+				t.Fatalf("expected logged=%v, got %q", tc.logged, out.String())
+			}
+			if !tc.logged {
+				return
+			}
+			entry := out.String()
+			if strings.Contains(entry, "Secret Title") || strings.Contains(entry, "secret cover") {
+				t.Fatalf("expected argument values to be redacted: %s", entry)
+			}
+			for _, want := range []string{`"msg":"slow query"`, `"level":"WARN"`, `"operation":"UPDATE"`, `"args":["string(12)","bytes(12)","int64"]`, `"rows":1`, `"plan":[`} {
+				if !strings.Contains(entry, want) {
+					t.Fatalf("expected %s in %s", want, entry)
+				}
+			}
+		})
+	}
+}
+
+func TestOpen_ExplainSkipsMultiStatementScripts(t *testing.T) {
+	rec := &recorder{explain: true}
+	db := openTestDB(t, "sqlite", rec)
+	rec.take()
+
+	if _, err := db.Exec("INSERT INTO books (title) VALUES ('Middlemarch'); INSERT INTO books (title) VALUES ('Beloved')"); err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	if reports := rec.take(); len(reports) != 1 || !errors.Is(reports[0].planErr, errNotExplainable) {
+		t.Fatalf("expected the script not to be explained, got %+v", reports)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM books").Scan(&count); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 5 {
+		t.Fatalf("expected each statement to run once, got %d books", count)
+	}
+}
+
+func TestExplainable(t *testing.T) {
+	for query, want := range map[string]bool{
+		"SELECT * FROM books":                                      true,
+		"  select title from books where id = ?;":                  true,
+		"/* lookup */ -- by id\nSELECT 1;  -- done":                true,
+		"WITH t AS (SELECT 1) SELECT * FROM t":                     true,
+		"INSERT INTO books (title) VALUES ('a;b')":                 true,
+		`UPDATE "odd;name" SET title = 'it''s; fine'`:              true,
+		"DELETE FROM [x;y] WHERE title = `z;`":                     true,
+		"SELECT 1; SELECT 2":                                       false,
+		"INSERT INTO books (title) VALUES ('a'); DROP TABLE books": false,
+		"SELECT 1;;":                  false,
+		"CREATE TABLE t (id INTEGER)": false,
+		"PRAGMA table_info(books)":    false,
+		"SELECT 'unterminated":        false,
+		"SELECT 1 /* unterminated":    false,
+		"":                            false,
+	} {
+		if got := explainable(query); got != want {
+			t.Errorf("explainable(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	got := redactArgs([]driver.NamedValue{
+		{Value: nil},
+		{Value: "abc"},
+		{Value: []byte{1, 2}},
+		{Value: int64(7)},
+		{Value: time.Time{}},
+	})
+	want := []string{"NULL", "string(3)", "bytes(2)", "int64", "time.Time"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,184 @@
+package dbinstrument
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"desent-api/internal/metrics"
+	"desent-api/internal/tracing"
+)
+
+var (
+	dbQueryDuration = metrics.Default.NewHistogramVec(
+		"db_query_duration_seconds",
+		"SQL statement latency by operation, including reading the rows.",
+		metrics.DefaultBuckets,
+		"operation",
+	)
+	dbQueries = metrics.Default.NewCounterVec(
+		"db_queries_total",
+		"SQL statements by operation and status.",
+		"operation", "status",
+	)
+	dbSlowQueries = metrics.Default.NewCounterVec(
+		"db_slow_queries_total",
+		"SQL statements slower than DB_SLOW_QUERY_MS by operation.",
+		"operation",
+	)
+)
+
+// explainTimeout bounds the EXPLAIN QUERY PLAN run for a slow statement.
+const explainTimeout = time.Second
+
+type observerFunc func(ctx context.Context, stmt Statement) (context.Context, func(Outcome))
+
+func (f observerFunc) Start(ctx context.Context, stmt Statement) (context.Context, func(Outcome)) {
+	return f(ctx, stmt)
+}
+
+// Tracing records a client span for every statement, with the SQL text and
+// the number of rows returned or affected. system is the db.system
+// attribute, such as "sqlite".
+func Tracing(system string) Observer {
+	return observerFunc(func(ctx context.Context, stmt Statement) (context.Context, func(Outcome)) {
+		ctx, span := tracing.Start(ctx, spanName(stmt.Operation), tracing.WithKind(tracing.SpanKindClient), tracing.WithAttributes(
+			tracing.String("db.system", system),
+			tracing.String("db.statement", stmt.Query),
+			tracing.String("db.operation", stmt.Operation),
+		))
+		if span == nil {
+			return ctx, nil
+		}
+
+		return ctx, func(outcome Outcome) {
+			if outcome.Err != nil {
+				span.RecordError(outcome.Err)
+			}
+			switch {
+			case stmt.ReturnsRows:
+				span.SetAttributes(tracing.Int64("db.rows_returned", outcome.Rows))
+			case outcome.Rows >= 0:
+				span.SetAttributes(tracing.Int64("db.rows_affected", outcome.Rows))
+			}
+			span.End()
+		}
+	})
+}
+
+// Metrics feeds statement latency and outcome into metrics.Default.
+func Metrics() Observer {
+	return observerFunc(func(ctx context.Context, stmt Statement) (context.Context, func(Outcome)) {
+		op := metricOperation(stmt.Operation)
+		return ctx, func(outcome Outcome) {
+			status := "ok"
+			if outcome.Err != nil {
+				status = "error"
+			}
+			dbQueries.Inc(op, status)
+			dbQueryDuration.Observe(outcome.Duration.Seconds(), op)
+		}
+	})
+}
+
+// SlowQueryLog logs statements that take at least a threshold at Warn.
+type SlowQueryLog struct {
+	logger    *slog.Logger
+	threshold time.Duration
+	explain   bool
+}
+
+// NewSlowQueryLog logs statements slower than threshold to logger. A
+// threshold of zero or less disables it. With explain set, the entry also
+// carries the EXPLAIN QUERY PLAN output. Argument values are never logged,
+// only their types and lengths.
+func NewSlowQueryLog(logger *slog.Logger, threshold time.Duration, explain bool) *SlowQueryLog {
+	return &SlowQueryLog{logger: logger, threshold: threshold, explain: explain}
+}
+
+func (l *SlowQueryLog) Start(ctx context.Context, stmt Statement) (context.Context, func(Outcome)) {
+	if l.threshold <= 0 {
+		return ctx, nil
+	}
+
+	return ctx, func(outcome Outcome) {
+		if outcome.Duration < l.threshold {
+			return
+		}
+		dbSlowQueries.Inc(metricOperation(stmt.Operation))
+
+		if !l.logger.Enabled(ctx, slog.LevelWarn) {
+			return
+		}
+
+		attrs := []slog.Attr{
+			slog.String("statement", strings.Join(strings.Fields(stmt.Query), " ")),
+			slog.String("operation", stmt.Operation),
+			slog.Int64("duration_ms", outcome.Duration.Milliseconds()),
+			slog.Int64("rows", outcome.Rows),
+			slog.Any("args", redactArgs(stmt.Args)),
+		}
+		if outcome.Err != nil {
+			attrs = append(attrs, slog.String("error", outcome.Err.Error()))
+		}
+		if sc := tracing.SpanFromContext(ctx).SpanContext(); sc.IsValid() {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID.String()))
+		}
+		if l.explain && outcome.Err == nil && outcome.Explain != nil {
+			// The request may already be over once its rows are closed, so
+			// the plan gets its own deadline.
+			explainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), explainTimeout)
+			plan, err := outcome.Explain(explainCtx)
+			cancel()
+			if err != nil {
+				attrs = append(attrs, slog.String("plan_error", err.Error()))
+			} else {
+				attrs = append(attrs, slog.Any("plan", plan))
+			}
+		}
+
+		l.logger.LogAttrs(ctx, slog.LevelWarn, "slow query", attrs...)
+	}
+}
+
+// redactArgs describes each argument by type, and length for strings and
+// bytes, so slow query entries never carry user data.
+func redactArgs(args []driver.NamedValue) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		switch value := arg.Value.(type) {
+		case nil:
+			redacted[i] = "NULL"
+		case string:
+			redacted[i] = fmt.Sprintf("string(%d)", len(value))
+		case []byte:
+			redacted[i] = fmt.Sprintf("bytes(%d)", len(value))
+		default:
+			redacted[i] = fmt.Sprintf("%T", value)
+		}
+	}
+
+	return redacted
+}
+
+// metricOperation keeps the operation label to a known set, so unusual
+// statements do not each get their own series.
+func metricOperation(op string) string {
+	switch op {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "BEGIN", "COMMIT", "ROLLBACK", "CREATE", "DROP", "ALTER", "PRAGMA":
+		return op
+	default:
+		return "OTHER"
+	}
+}
+
+func spanName(op string) string {
+	if op != "" {
+		return "db " + op
+	}
+
+	return "db"
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"desent-api/internal/metrics"
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+)
+
+type AccountHandler struct {
+	recovery *usecases.AccountRecovery
+}
+
+func NewAccountHandler(recovery *usecases.AccountRecovery) *AccountHandler {
+	return &AccountHandler{recovery: recovery}
+}
+
+func (h *AccountHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+
+	user, err := h.recovery.Account(r.Context(), principal.Subject)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToAccountResponse(user))
+}
+
+func (h *AccountHandler) UpdateEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateEmailRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+	if err := h.recovery.ChangeEmail(r.Context(), principal.Subject, middlewares.ClientIP(r), req); err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AccountHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyEmailRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	if err := h.recovery.VerifyEmail(r.Context(), req.Token); err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPassword always answers 202 for a well-formed address so callers
+// cannot tell which addresses have accounts.
+func (h *AccountHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ForgotPasswordRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	if err := h.recovery.ForgotPassword(r.Context(), req.Email); err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AccountHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	if err := h.recovery.ResetPassword(r.Context(), req.Token, req.NewPassword); err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeAccountError(w http.ResponseWriter, err error) {
+	var lockedErr *usecases.LoginLockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		writeAuthError(w, err)
+	case errors.Is(err, usecases.ErrInvalidCredentials):
+		metrics.AuthFailures.Inc(metrics.AuthFailureInvalidCredentials)
+		writeError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "current password is incorrect")
+	case errors.Is(err, usecases.ErrInvalidMFACode):
+		metrics.AuthFailures.Inc(metrics.AuthFailureInvalidMFACode)
+		writeError(w, http.StatusUnauthorized, "INVALID_MFA_CODE", "invalid verification code")
+	case errors.Is(err, usecases.ErrNoLocalAccount):
+		writeError(w, http.StatusForbidden, "LOCAL_ACCOUNT_REQUIRED", "only accounts managed by this API have an email address")
+	case errors.Is(err, usecases.ErrValidation):
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+	case errors.Is(err, usecases.ErrInvalidAccountToken):
+		writeError(w, http.StatusBadRequest, "INVALID_TOKEN", "token is invalid or expired")
+	case errors.Is(err, usecases.ErrEmailTaken):
+		writeError(w, http.StatusConflict, "EMAIL_TAKEN", "email belongs to another user")
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+	}
+}
@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime/quotedprintable"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"desent-api/internal/mail"
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/usecases"
+	"desent-api/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type accountTestEnv struct {
+	router  http.Handler
+	mailDir string
+	now     *time.Time
+	users   *repositories.SQLiteUserRepository
+	handler *AccountHandler
+	events  *bytes.Buffer
+}
+
+func setupAccountRouter(t *testing.T) accountTestEnv {
+	t.Helper()
+
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	mailDir := t.TempDir()
+	mailer, err := mail.NewFileMailer(mailDir, "desent-api <no-reply@example.test>")
+	if err != nil {
+		t.Fatalf("new file mailer: %v", err)
+	}
+
+	box, err := utils.NewSecretBox("test-key")
+	if err != nil {
+		t.Fatalf("new secret box: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	nowFunc := func() time.Time { return now }
+	buf := &bytes.Buffer{}
+	events := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	users := repositories.NewSQLiteUserRepository(db)
+	credentials := usecases.NewCredentials(users)
+	sessions := usecases.NewSessions(repositories.NewSQLiteSessionRepository(db), events, nowFunc)
+	mfa := usecases.NewMFA(
+		repositories.NewSQLiteMFARepository(db),
+		box,
+		usecases.MFAPolicy{Issuer: "desent-api", ChallengeTTL: 5 * time.Minute, MaxChallengeAttempts: 3},
+		events,
+		nowFunc,
+	)
+	recovery := usecases.NewAccountRecovery(
+		users,
+		repositories.NewSQLiteAccountTokenRepository(db),
+		credentials,
+		mailer,
+		usecases.AccountRecoveryPolicy{ResetTTL: time.Hour, VerificationTTL: 24 * time.Hour},
+		events,
+		nowFunc,
+	).WithMFA(mfa).WithSessions(sessions)
+
+	authHandler := NewAuthHandler("test-secret", time.Hour).WithCredentials(credentials).WithMFA(mfa).WithSessions(sessions)
+	accountHandler := NewAccountHandler(recovery)
+	mfaHandler := NewMFAHandler(mfa)
+
+	r := chi.NewRouter()
+	r.Post("/auth/token", authHandler.CreateToken)
+	r.Post("/auth/password/forgot", accountHandler.ForgotPassword)
+	r.Post("/auth/password/reset", accountHandler.ResetPassword)
+	r.Post("/auth/email/verify", accountHandler.VerifyEmail)
+	auth := r.With(middlewares.RequireBearerAuth("test-secret"), middlewares.RequireActiveSession(sessions))
+	auth.Get("/me", accountHandler.GetAccount)
+	auth.Put("/me/email", accountHandler.UpdateEmail)
+	auth.Post("/auth/mfa/enroll", mfaHandler.Enroll)
+	auth.Post("/auth/mfa/confirm", mfaHandler.Confirm)
+
+	return accountTestEnv{router: r, mailDir: mailDir, now: &now, users: users, handler: accountHandler, events: buf}
+}
+
+// login returns a session token for username.
+func (env accountTestEnv) login(t *testing.T, username, password string) string {
+	t.Helper()
+
+	res := doJSON(t, env.router, http.MethodPost, "/auth/token", "", `{"username":"`+username+`","password":"`+password+`"}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected login to succeed, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	var body models.TokenResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal token: %v", err)
+	}
+	return body.Token
+}
+
+// createUser stores a local account with password.
+func (env accountTestEnv) createUser(t *testing.T, username, password string) {
+	t.Helper()
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	if err := env.users.SetPassword(context.Background(), username, hash, *env.now); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+}
+
+var mailedTokenPattern = regexp.MustCompile(`(?m)^([A-Za-z0-9_-]{43})\r?$`)
+
+// mails returns the decoded bodies of every message sent so far.
+func (env accountTestEnv) mails(t *testing.T) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(env.mailDir)
+	if err != nil {
+		t.Fatalf("read mail dir: %v", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	bodies := make([]string, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(env.mailDir, name))
+		if err != nil {
+			t.Fatalf("read mail: %v", err)
+		}
+
+		headers, body, _ := strings.Cut(string(data), "\r\n\r\n")
+		decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+		if err != nil {
+			t.Fatalf("decode mail body: %v", err)
+		}
+		bodies = append(bodies, headers+"\n\n"+string(decoded))
+	}
+
+	return bodies
+}
+
+func (env accountTestEnv) lastToken(t *testing.T) string {
+	t.Helper()
+
+	mails := env.mails(t)
+	if len(mails) == 0 {
+		t.Fatal("expected a mail to be sent")
+	}
+
+	match := mailedTokenPattern.FindStringSubmatch(mails[len(mails)-1])
+	if match == nil {
+		t.Fatalf("no token in mail %s", mails[len(mails)-1])
+	}
+	return match[1]
+}
+
+func TestAccount_EmailVerificationAndPasswordReset(t *testing.T) {
+	env := setupAccountRouter(t)
+	token := env.login(t, "admin", "password")
+
+	if res := doJSON(t, env.router, http.MethodPut, "/me/email", token, `{"email":"admin@example.test","current_password":"password"}`); res.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusAccepted, res.Code, res.Body.String())
+	}
+	mails := env.mails(t)
+	if len(mails) != 1 || !strings.Contains(mails[0], "To: admin@example.test") || !strings.Contains(mails[0], "Subject: Verify your email address") {
+		t.Fatalf("expected a verification mail, got %v", mails)
+	}
+	verification := env.lastToken(t)
+
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/password/forgot", "", `{"email":"admin@example.test"}`); res.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, res.Code)
+	}
+	if len(env.mails(t)) != 1 {
+		t.Fatal("expected no reset mail for an unverified address")
+	}
+
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/email/verify", "", `{"token":"`+verification+`"}`); res.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusNoContent, res.Code, res.Body.String())
+	}
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/email/verify", "", `{"token":"`+verification+`"}`); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected a used verification token to be rejected, got %d", res.Code)
+	}
+	if res := doJSON(t, env.router, http.MethodGet, "/me", token, ""); !strings.Contains(res.Body.String(), `"email_verified":true`) {
+		t.Fatalf("expected a verified email, got %s", res.Body.String())
+	}
+
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/password/forgot", "", `{"email":"nobody@example.test"}`); res.Code != http.StatusAccepted || len(env.mails(t)) != 1 {
+		t.Fatalf("expected unknown addresses to be accepted silently, got %d", res.Code)
+	}
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/password/forgot", "", `{"email":"ADMIN@example.test"}`); res.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, res.Code)
+	}
+	if mails := env.mails(t); len(mails) != 2 || !strings.Contains(mails[1], "Subject: Reset your password") {
+		t.Fatalf("expected a reset mail, got %v", mails)
+	}
+	reset := env.lastToken(t)
+
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/password/reset", "", `{"token":"`+reset+`","new_password":"short"}`); res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "VALIDATION_ERROR") {
+		t.Fatalf("expected a short password to be rejected, got %d (%s)", res.Code, res.Body.String())
+	}
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/password/reset", "", `{"token":"`+reset+`","new_password":"correct horse battery"}`); res.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusNoContent, res.Code, res.Body.String())
+	}
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/password/reset", "", `{"token":"`+reset+`","new_password":"another password"}`); res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "INVALID_TOKEN") {
+		t.Fatalf("expected a used reset token to be rejected, got %d (%s)", res.Code, res.Body.String())
+	}
+	if res := doJSON(t, env.router, http.MethodGet, "/me", token, ""); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected sessions from before the reset to be revoked, got %d", res.Code)
+	}
+
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/token", "", `{"username":"admin","password":"password"}`); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the built-in password to stop working, got %d", res.Code)
+	}
+	if res := doJSON(t, env.router, http.MethodGet, "/me", env.login(t, "admin", "correct horse battery"), ""); res.Code != http.StatusOK {
+		t.Fatalf("expected the new password to work, got %d", res.Code)
+	}
+
+	for _, event := range []string{"email_changed", "password_reset_unverified_email", "email_verified", "password_reset_unknown_email", "password_reset_requested", "password_reset"} {
+		if !strings.Contains(env.events.String(), `"level":"INFO","msg":"security event","event":"auth.`+event+`"`) {
+			t.Fatalf("expected security event auth.%s at Info, got %s", event, env.events.String())
+		}
+	}
+}
+
+func TestAccount_ResetTokenExpires(t *testing.T) {
+	env := setupAccountRouter(t)
+	token := env.login(t, "admin", "password")
+
+	doJSON(t, env.router, http.MethodPut, "/me/email", token, `{"email":"admin@example.test","current_password":"password"}`)
+	doJSON(t, env.router, http.MethodPost, "/auth/email/verify", "", `{"token":"`+env.lastToken(t)+`"}`)
+	doJSON(t, env.router, http.MethodPost, "/auth/password/forgot", "", `{"email":"admin@example.test"}`)
+	reset := env.lastToken(t)
+
+	*env.now = env.now.Add(61 * time.Minute)
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/password/reset", "", `{"token":"`+reset+`","new_password":"correct horse battery"}`); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected an expired token to be rejected, got %d", res.Code)
+	}
+}
+
+func TestAccount_EmailBelongsToOneUser(t *testing.T) {
+	env := setupAccountRouter(t)
+	env.createUser(t, "reader", "reader password")
+	reader := env.login(t, "reader", "reader password")
+
+	doJSON(t, env.router, http.MethodPut, "/me/email", env.login(t, "admin", "password"), `{"email":"shared@example.test","current_password":"password"}`)
+	if res := doJSON(t, env.router, http.MethodPut, "/me/email", reader, `{"email":"Shared@example.test","current_password":"reader password"}`); res.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, res.Code)
+	}
+	if res := doJSON(t, env.router, http.MethodPut, "/me/email", reader, `{"email":"not an address","current_password":"reader password"}`); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, res.Code)
+	}
+}
+
+func TestAccount_EmailChangeRequiresReauthenticationAndNotifiesOldAddress(t *testing.T) {
+	env := setupAccountRouter(t)
+	token := env.login(t, "admin", "password")
+
+	for _, body := range []string{
+		`{"email":"admin@example.test"}`,
+		`{"email":"admin@example.test","current_password":"wrong"}`,
+	} {
+		if res := doJSON(t, env.router, http.MethodPut, "/me/email", token, body); res.Code != http.StatusUnauthorized || !strings.Contains(res.Body.String(), "INVALID_CREDENTIALS") {
+			t.Fatalf("expected %s to be rejected, got %d (%s)", body, res.Code, res.Body.String())
+		}
+	}
+	doJSON(t, env.router, http.MethodPut, "/me/email", token, `{"email":"admin@example.test","current_password":"password"}`)
+	doJSON(t, env.router, http.MethodPost, "/auth/email/verify", "", `{"token":"`+env.lastToken(t)+`"}`)
+
+	res := doJSON(t, env.router, http.MethodPost, "/auth/mfa/enroll", token, "")
+	var setup models.MFASetupResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &setup); err != nil {
+		t.Fatalf("unmarshal setup: %v", err)
+	}
+	code := func() string {
+		code, err := utils.TOTPCode(setup.Secret, *env.now)
+		if err != nil {
+			t.Fatalf("totp code: %v", err)
+		}
+		return code
+	}
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/mfa/confirm", token, `{"code":"`+code()+`"}`); res.Code != http.StatusOK {
+		t.Fatalf("expected MFA to be enabled, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	*env.now = env.now.Add(30 * time.Second)
+	if res := doJSON(t, env.router, http.MethodPut, "/me/email", token, `{"email":"attacker@example.test","current_password":"password"}`); res.Code != http.StatusUnauthorized || !strings.Contains(res.Body.String(), "INVALID_MFA_CODE") {
+		t.Fatalf("expected a missing second factor to be rejected, got %d (%s)", res.Code, res.Body.String())
+	}
+	if res := doJSON(t, env.router, http.MethodPut, "/me/email", token, `{"email":"new@example.test","current_password":"password","code":"`+code()+`"}`); res.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusAccepted, res.Code, res.Body.String())
+	}
+
+	var notice string
+	for _, mail := range env.mails(t) {
+		if strings.Contains(mail, "Subject: Your email address was changed") {
+			notice = mail
+		}
+	}
+	if !strings.Contains(notice, "To: admin@example.test") || !strings.Contains(notice, "new@example.test") {
+		t.Fatalf("expected the old address to be told about the change, got %q", notice)
+	}
+}
+
+func TestAccount_EmailChangeNeedsLocalAccount(t *testing.T) {
+	env := setupAccountRouter(t)
+
+	r := chi.NewRouter()
+	r.With(middlewares.RequireBearerAuth("test-secret")).Put("/me/email", env.handler.UpdateEmail)
+	for _, subject := range []string{"corp:alice", "client:indexer"} {
+		res := doJSON(t, r, http.MethodPut, "/me/email", userToken(t, subject), `{"email":"alice@example.test","current_password":"password"}`)
+		if res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), "LOCAL_ACCOUNT_REQUIRED") {
+			t.Fatalf("expected %s to be rejected, got %d (%s)", subject, res.Code, res.Body.String())
+		}
+		if _, err := env.users.FindByUsername(context.Background(), subject); err == nil {
+			t.Fatalf("expected no user row for %s", subject)
+		}
+	}
+}
@@ -1,22 +1,59 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
+	"desent-api/internal/metrics"
+	"desent-api/internal/middlewares"
 	"desent-api/internal/models"
+	"desent-api/internal/usecases"
 	"desent-api/internal/utils"
 )
 
 type AuthHandler struct {
 	jwtSecret string
 	jwtTTL    time.Duration
+	throttle  *usecases.LoginThrottle
+	mfa       *usecases.MFA
+	creds     *usecases.Credentials
+	sessions  *usecases.Sessions
 }
 
 func NewAuthHandler(jwtSecret string, jwtTTL time.Duration) *AuthHandler {
 	return &AuthHandler{jwtSecret: jwtSecret, jwtTTL: jwtTTL}
 }
 
+// WithLoginThrottle turns on failed-login tracking and lockouts.
+func (h *AuthHandler) WithLoginThrottle(throttle *usecases.LoginThrottle) *AuthHandler {
+	h.throttle = throttle
+	return h
+}
+
+// WithCredentials checks passwords against stored hashes instead of only
+// the built-in account.
+func (h *AuthHandler) WithCredentials(creds *usecases.Credentials) *AuthHandler {
+	h.creds = creds
+	return h
+}
+
+// WithSessions records a session for every token issued, so it shows up in
+// /me/sessions and can be revoked.
+func (h *AuthHandler) WithSessions(sessions *usecases.Sessions) *AuthHandler {
+	h.sessions = sessions
+	return h
+}
+
+// WithMFA makes /auth/token return a challenge for users with MFA enabled
+// instead of a token.
+func (h *AuthHandler) WithMFA(mfa *usecases.MFA) *AuthHandler {
+	h.mfa = mfa
+	return h
+}
+
 func (h *AuthHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
 	var req models.TokenRequest
 	if err := decodeJSON(r.Body, &req); err != nil {
@@ -24,12 +61,111 @@ func (h *AuthHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Username != "admin" || req.Password != "password" {
+	ip := middlewares.ClientIP(r)
+	if h.throttle != nil {
+		if err := h.throttle.Check(r.Context(), req.Username, ip); err != nil {
+			writeAuthError(w, err)
+			return
+		}
+	}
+
+	valid := usecases.CheckPassword(req.Username, req.Password)
+	if h.creds != nil {
+		var err error
+		if valid, err = h.creds.Check(r.Context(), req.Username, req.Password); err != nil {
+			writeAuthError(w, err)
+			return
+		}
+	}
+
+	if !valid {
+		if h.throttle != nil {
+			if err := h.throttle.RecordFailure(r.Context(), req.Username, ip); err != nil {
+				writeAuthError(w, err)
+				return
+			}
+		}
+
+		metrics.AuthFailures.Inc(metrics.AuthFailureInvalidCredentials)
 		writeError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "invalid credentials")
 		return
 	}
 
-	token, err := utils.GenerateToken(req.Username, h.jwtSecret, h.jwtTTL)
+	if h.mfa != nil {
+		challenge, required, err := h.mfa.StartChallenge(r.Context(), req.Username)
+		if err != nil {
+			writeAuthError(w, err)
+			return
+		}
+		if required {
+			writeJSON(w, http.StatusOK, models.TokenResponse{
+				MFARequired:    true,
+				ChallengeToken: challenge,
+				ExpiresIn:      int(h.mfa.ChallengeTTL().Seconds()),
+			})
+			return
+		}
+	}
+
+	if h.throttle != nil {
+		if err := h.throttle.RecordSuccess(r.Context(), req.Username); err != nil {
+			writeAuthError(w, err)
+			return
+		}
+	}
+
+	h.writeToken(w, r, req.Username, utils.AuthMethodPassword)
+}
+
+// CompleteMFA exchanges a challenge from CreateToken and a TOTP or
+// recovery code for a token.
+func (h *AuthHandler) CompleteMFA(w http.ResponseWriter, r *http.Request) {
+	if h.mfa == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "multi-factor authentication is not enabled")
+		return
+	}
+
+	var req models.MFAChallengeRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	username, method, err := h.mfa.CompleteChallenge(r.Context(), req.ChallengeToken, req.Code, req.RecoveryCode, middlewares.ClientIP(r))
+	if err != nil {
+		if errors.Is(err, usecases.ErrLoginLocked) {
+			writeAuthError(w, err)
+			return
+		}
+		if errors.Is(err, usecases.ErrInvalidMFACode) || errors.Is(err, usecases.ErrInvalidMFAChallenge) {
+			metrics.AuthFailures.Inc(metrics.AuthFailureInvalidMFACode)
+		}
+
+		writeMFAError(w, err)
+		return
+	}
+
+	h.writeToken(w, r, username, utils.AuthMethodPassword, method)
+}
+
+func (h *AuthHandler) writeToken(w http.ResponseWriter, r *http.Request, username string, authMethods ...string) {
+	var sessionID string
+	if h.sessions != nil {
+		session, err := h.sessions.Start(r.Context(), models.Session{
+			Subject:     username,
+			AuthMethods: authMethods,
+			IP:          middlewares.ClientIP(r),
+			UserAgent:   r.UserAgent(),
+		}, h.jwtTTL)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+			return
+		}
+
+		sessionID = session.ID
+	}
+
+	token, err := utils.GenerateSessionToken(username, sessionID, h.jwtSecret, h.jwtTTL, authMethods...)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
 		return
@@ -37,3 +173,15 @@ func (h *AuthHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, models.TokenResponse{Token: token})
 }
+
+func writeAuthError(w http.ResponseWriter, err error) {
+	var lockedErr *usecases.LoginLockedError
+	if errors.As(err, &lockedErr) {
+		metrics.AuthFailures.Inc(metrics.AuthFailureLocked)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, "LOGIN_LOCKED", "too many failed logins, try again later")
+		return
+	}
+
+	writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+}
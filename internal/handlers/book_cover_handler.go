@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type BookCoverHandler struct {
+	uploadUsecase  *usecases.UploadBookCoverUsecase
+	getUsecase     *usecases.GetBookCoverUsecase
+	deleteUsecase  *usecases.DeleteBookCoverUsecase
+	maxUploadBytes int64
+}
+
+func NewBookCoverHandler(
+	uploadUsecase *usecases.UploadBookCoverUsecase,
+	getUsecase *usecases.GetBookCoverUsecase,
+	deleteUsecase *usecases.DeleteBookCoverUsecase,
+	maxUploadBytes int64,
+) *BookCoverHandler {
+	return &BookCoverHandler{
+		uploadUsecase:  uploadUsecase,
+		getUsecase:     getUsecase,
+		deleteUsecase:  deleteUsecase,
+		maxUploadBytes: maxUploadBytes,
+	}
+}
+
+func (h *BookCoverHandler) UploadCover(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadBytes)
+
+	data, err := readCoverUpload(r, h.maxUploadBytes)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, "COVER_TOO_LARGE", "cover exceeds the maximum upload size")
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, "INVALID_COVER_UPLOAD", "cover must be sent as the request body or a multipart field named cover")
+		return
+	}
+
+	cover, err := h.uploadUsecase.Execute(r.Context(), chi.URLParam(r, "id"), data)
+	if err != nil {
+		status, code, message := mapBookCoverError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToBookCoverResponse(cover, r.URL.Path))
+}
+
+func (h *BookCoverHandler) GetCover(w http.ResponseWriter, r *http.Request) {
+	image, err := h.getUsecase.Execute(r.Context(), chi.URLParam(r, "id"), r.URL.Query().Get("size"))
+	if err != nil {
+		status, code, message := mapBookCoverError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	// Only the versioned URL always names the same image; the plain one
+	// must be revalidated so a new upload shows up at once.
+	cacheControl := "no-cache"
+	if r.URL.Query().Get("v") == models.CoverVersion(image.Cover.Checksum) {
+		cacheControl = "public, max-age=31536000, immutable"
+	}
+
+	w.Header().Set("Content-Type", image.ContentType)
+	w.Header().Set("ETag", image.ETag)
+	w.Header().Set("Cache-Control", cacheControl)
+	http.ServeContent(w, r, "", image.Cover.UpdatedAt, bytes.NewReader(image.Data))
+}
+
+func (h *BookCoverHandler) DeleteCover(w http.ResponseWriter, r *http.Request) {
+	if err := h.deleteUsecase.Execute(r.Context(), chi.URLParam(r, "id")); err != nil {
+		status, code, message := mapBookCoverError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func readCoverUpload(r *http.Request, maxUploadBytes int64) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return io.ReadAll(r.Body)
+	}
+
+	if err := r.ParseMultipartForm(maxUploadBytes); err != nil {
+		return nil, err
+	}
+
+	file, _, err := r.FormFile("cover")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+func mapBookCoverError(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, usecases.ErrBookCoverNotFound):
+		return http.StatusNotFound, "COVER_NOT_FOUND", "book cover not found"
+	case errors.Is(err, usecases.ErrUnsupportedCoverType):
+		return http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", err.Error()
+	default:
+		return mapBookError(err)
+	}
+}
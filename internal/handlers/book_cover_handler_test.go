@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/storage"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+	_ "modernc.org/sqlite"
+)
+
+// setupBookCoversRouter returns the router, the directory the cover blobs
+// are stored in and the database.
+func setupBookCoversRouter(t *testing.T, maxUploadBytes int64) (http.Handler, string, *sql.DB) {
+	t.Helper()
+
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	blobDir := t.TempDir()
+	store, err := storage.NewLocalBlobStore(blobDir)
+	if err != nil {
+		t.Fatalf("init blob store: %v", err)
+	}
+
+	bookRepo := repositories.NewSQLiteBookRepository(db)
+	coverRepo := repositories.NewSQLiteBookCoverRepository(db)
+	bookHandler := NewBookHandler(
+		usecases.NewCreateBookUsecase(bookRepo),
+		usecases.NewListBooksUsecase(bookRepo),
+		usecases.NewGetBookUsecase(bookRepo),
+		usecases.NewUpdateBookUsecase(bookRepo),
+		usecases.NewDeleteBookUsecase(bookRepo).WithCovers(coverRepo, store),
+	)
+
+	h := NewBookCoverHandler(
+		usecases.NewUploadBookCoverUsecase(bookRepo, coverRepo, store),
+		usecases.NewGetBookCoverUsecase(coverRepo, store),
+		usecases.NewDeleteBookCoverUsecase(coverRepo, store),
+		maxUploadBytes,
+	)
+
+	r := chi.NewRouter()
+	r.Post("/books", bookHandler.CreateBook)
+	r.Delete("/books/{id}", bookHandler.DeleteBook)
+	r.Put("/books/{id}/cover", h.UploadCover)
+	r.Get("/books/{id}/cover", h.GetCover)
+	r.Delete("/books/{id}/cover", h.DeleteCover)
+
+	if res := doJSON(t, r, http.MethodPost, "/books", "", `{"title":"Dune","author":"Frank Herbert","year":1965}`); res.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, res.Code)
+	}
+
+	return r, blobDir, db
+}
+
+// pngHeader returns just the signature and header chunk of a PNG, which is
+// all image.DecodeConfig reads.
+func pngHeader(width, height uint32) []byte {
+	chunk := []byte("IHDR")
+	chunk = binary.BigEndian.AppendUint32(chunk, width)
+	chunk = binary.BigEndian.AppendUint32(chunk, height)
+	chunk = append(chunk, 8, 6, 0, 0, 0)
+
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(chunk)-4))
+	data = append(data, chunk...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(chunk))
+}
+
+func countBlobs(t *testing.T, dir string) int {
+	t.Helper()
+
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			count++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walk blobs: %v", err)
+	}
+
+	return count
+}
+
+func uploadCover(t *testing.T, r http.Handler, target string, data []byte) models.BookCoverResponse {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPut, target, bytes.NewReader(data))
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	var cover models.BookCoverResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &cover); err != nil {
+		t.Fatalf("unmarshal cover: %v", err)
+	}
+
+	return cover
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestBookCovers_UploadServeAndCache(t *testing.T) {
+	r, _, _ := setupBookCoversRouter(t, 1<<20)
+	original := testPNG(t, 600, 300)
+
+	uploadReq := httptest.NewRequest(http.MethodPut, "/books/1/cover", bytes.NewReader(original))
+	uploadReq.Header.Set("Content-Type", "image/png")
+	uploadRes := httptest.NewRecorder()
+	r.ServeHTTP(uploadRes, uploadReq)
+	if uploadRes.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, uploadRes.Code, uploadRes.Body.String())
+	}
+
+	originalRes := doJSON(t, r, http.MethodGet, "/books/1/cover", "", "")
+	if originalRes.Code != http.StatusOK || originalRes.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("unexpected original response: %d %s", originalRes.Code, originalRes.Header().Get("Content-Type"))
+	}
+	if !bytes.Equal(originalRes.Body.Bytes(), original) {
+		t.Fatalf("expected original bytes to round-trip")
+	}
+
+	smallRes := doJSON(t, r, http.MethodGet, "/books/1/cover?size=small", "", "")
+	if smallRes.Code != http.StatusOK || smallRes.Header().Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("unexpected thumbnail response: %d %s", smallRes.Code, smallRes.Header().Get("Content-Type"))
+	}
+
+	thumb, err := jpeg.DecodeConfig(bytes.NewReader(smallRes.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if thumb.Width != 96 || thumb.Height != 48 {
+		t.Fatalf("expected 96x48 thumbnail, got %dx%d", thumb.Width, thumb.Height)
+	}
+
+	etag := smallRes.Header().Get("ETag")
+	if etag == "" || etag == originalRes.Header().Get("ETag") {
+		t.Fatalf("expected distinct per-size ETag, got %q", etag)
+	}
+
+	cachedReq := httptest.NewRequest(http.MethodGet, "/books/1/cover?size=small", nil)
+	cachedReq.Header.Set("If-None-Match", etag)
+	cachedRes := httptest.NewRecorder()
+	r.ServeHTTP(cachedRes, cachedReq)
+	if cachedRes.Code != http.StatusNotModified {
+		t.Fatalf("expected status %d, got %d", http.StatusNotModified, cachedRes.Code)
+	}
+
+	invalidSize := doJSON(t, r, http.MethodGet, "/books/1/cover?size=huge", "", "")
+	if invalidSize.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, invalidSize.Code)
+	}
+
+	deleteRes := doJSON(t, r, http.MethodDelete, "/books/1/cover", "", "")
+	if deleteRes.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, deleteRes.Code)
+	}
+
+	if res := doJSON(t, r, http.MethodGet, "/books/1/cover", "", ""); res.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, res.Code)
+	}
+}
+
+func TestBookCovers_VersionedURLsAreCachedForGood(t *testing.T) {
+	r, _, _ := setupBookCoversRouter(t, 1<<20)
+
+	first := uploadCover(t, r, "/books/1/cover", testPNG(t, 60, 30))
+	if !strings.Contains(first.URLs["small"], "size=small&v=") {
+		t.Fatalf("expected versioned cover URLs, got %v", first.URLs)
+	}
+
+	res := doJSON(t, r, http.MethodGet, first.URLs["small"], "", "")
+	if res.Code != http.StatusOK || !strings.Contains(res.Header().Get("Cache-Control"), "immutable") {
+		t.Fatalf("expected the versioned URL to be cached for good, got %d %q", res.Code, res.Header().Get("Cache-Control"))
+	}
+	res = doJSON(t, r, http.MethodGet, "/books/1/cover?size=small", "", "")
+	if res.Header().Get("Cache-Control") != "no-cache" || res.Header().Get("ETag") == "" {
+		t.Fatalf("expected the plain URL to be revalidated, got %q", res.Header().Get("Cache-Control"))
+	}
+
+	second := uploadCover(t, r, "/books/1/cover", testPNG(t, 30, 60))
+	if second.URLs["small"] == first.URLs["small"] {
+		t.Fatalf("expected a new upload to change the URLs, got %v", second.URLs)
+	}
+	res = doJSON(t, r, http.MethodGet, first.URLs["small"], "", "")
+	if res.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("expected an outdated version not to be cached for good, got %q", res.Header().Get("Cache-Control"))
+	}
+}
+
+func TestBookCovers_DeletingBookRemovesBlobs(t *testing.T) {
+	r, blobDir, _ := setupBookCoversRouter(t, 1<<20)
+	if res := doJSON(t, r, http.MethodPost, "/books", "", `{"title":"Emma","author":"Jane Austen","year":1815}`); res.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, res.Code)
+	}
+
+	shared := testPNG(t, 50, 50)
+	uploadCover(t, r, "/books/1/cover", shared)
+	uploadCover(t, r, "/books/2/cover", shared)
+	if got := countBlobs(t, blobDir); got != 1+len(models.CoverSizes) {
+		t.Fatalf("expected one set of shared blobs, got %d", got)
+	}
+
+	if res := doJSON(t, r, http.MethodDelete, "/books/1", "", ""); res.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, res.Code)
+	}
+	if got := countBlobs(t, blobDir); got != 1+len(models.CoverSizes) {
+		t.Fatalf("expected blobs still used by another book to be kept, got %d", got)
+	}
+	if res := doJSON(t, r, http.MethodGet, "/books/2/cover", "", ""); res.Code != http.StatusOK {
+		t.Fatalf("expected the other cover to remain, got %d", res.Code)
+	}
+
+	if res := doJSON(t, r, http.MethodDelete, "/books/2", "", ""); res.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, res.Code)
+	}
+	if got := countBlobs(t, blobDir); got != 0 {
+		t.Fatalf("expected every blob to be deleted with the last book, got %d", got)
+	}
+}
+
+func TestBookCovers_FailedSaveRemovesNewBlobs(t *testing.T) {
+	r, blobDir, db := setupBookCoversRouter(t, 1<<20)
+	if res := doJSON(t, r, http.MethodPost, "/books", "", `{"title":"Emma","author":"Jane Austen","year":1815}`); res.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, res.Code)
+	}
+
+	shared := testPNG(t, 50, 50)
+	uploadCover(t, r, "/books/1/cover", shared)
+	if _, err := db.Exec(`CREATE TRIGGER fail_cover_saves BEFORE INSERT ON book_covers BEGIN SELECT RAISE(ABORT, 'disk full'); END`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+
+	for _, upload := range []struct {
+		target string
+		data   []byte
+	}{
+		{"/books/1/cover", testPNG(t, 60, 40)},
+		{"/books/2/cover", shared},
+	} {
+		req := httptest.NewRequest(http.MethodPut, upload.target, bytes.NewReader(upload.data))
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+		if res.Code != http.StatusInternalServerError {
+			t.Fatalf("%s: expected status %d, got %d", upload.target, http.StatusInternalServerError, res.Code)
+		}
+		if got := countBlobs(t, blobDir); got != 1+len(models.CoverSizes) {
+			t.Fatalf("%s: expected only the saved cover's blobs to remain, got %d", upload.target, got)
+		}
+	}
+
+	if res := doJSON(t, r, http.MethodGet, "/books/1/cover?size=small", "", ""); res.Code != http.StatusOK {
+		t.Fatalf("expected the saved cover to still be served, got %d", res.Code)
+	}
+}
+
+func TestBookCovers_MultipartUpload(t *testing.T) {
+	r, _, _ := setupBookCoversRouter(t, 1<<20)
+
+	body := bytes.Buffer{}
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("cover", "cover.png")
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	_, _ = part.Write(testPNG(t, 40, 80))
+	_ = writer.Close()
+
+	req := httptest.NewRequest(http.MethodPut, "/books/1/cover", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	largeRes := doJSON(t, r, http.MethodGet, "/books/1/cover?size=large", "", "")
+	thumb, err := jpeg.DecodeConfig(bytes.NewReader(largeRes.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if thumb.Width != 40 || thumb.Height != 80 {
+		t.Fatalf("expected small images not to be upscaled, got %dx%d", thumb.Width, thumb.Height)
+	}
+}
+
+func TestBookCovers_UploadErrors(t *testing.T) {
+	r, _, _ := setupBookCoversRouter(t, 4096)
+
+	tests := []struct {
+		name   string
+		target string
+		body   []byte
+		status int
+	}{
+		{name: "unsupported type", target: "/books/1/cover", body: []byte("%PDF-1.4 not an image"), status: http.StatusUnsupportedMediaType},
+		{name: "too large", target: "/books/1/cover", body: bytes.Repeat([]byte{0xff}, 8192), status: http.StatusRequestEntityTooLarge},
+		{name: "empty body", target: "/books/1/cover", body: nil, status: http.StatusBadRequest},
+		{name: "unknown book", target: "/books/9/cover", body: testPNG(t, 10, 10), status: http.StatusNotFound},
+		{name: "too wide", target: "/books/1/cover", body: pngHeader(9000, 10), status: http.StatusBadRequest},
+		{name: "too many pixels", target: "/books/1/cover", body: pngHeader(5000, 5000), status: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, tc.target, bytes.NewReader(tc.body))
+			res := httptest.NewRecorder()
+			r.ServeHTTP(res, req)
+			if res.Code != tc.status {
+				t.Fatalf("expected status %d, got %d: %s", tc.status, res.Code, res.Body.String())
+			}
+		})
+	}
+}
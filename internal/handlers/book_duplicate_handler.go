@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type BookDuplicateHandler struct {
+	findUsecase  *usecases.FindDuplicateBooksUsecase
+	mergeUsecase *usecases.MergeBooksUsecase
+}
+
+func NewBookDuplicateHandler(
+	findUsecase *usecases.FindDuplicateBooksUsecase,
+	mergeUsecase *usecases.MergeBooksUsecase,
+) *BookDuplicateHandler {
+	return &BookDuplicateHandler{
+		findUsecase:  findUsecase,
+		mergeUsecase: mergeUsecase,
+	}
+}
+
+func (h *BookDuplicateHandler) ListDuplicates(w http.ResponseWriter, r *http.Request) {
+	query := models.DuplicateQuery{}
+	if raw := strings.TrimSpace(r.URL.Query().Get("threshold")); raw != "" {
+		threshold, err := strconv.ParseFloat(raw, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			writeError(w, http.StatusBadRequest, "INVALID_QUERY", "threshold must be a number between 0 and 1")
+			return
+		}
+		query.Threshold = threshold
+	}
+
+	groups, err := h.findUsecase.Execute(r.Context(), query)
+	if err != nil {
+		status, code, message := mapBookError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	response := make([]models.DuplicateBookGroupResponse, 0, len(groups))
+	for _, group := range groups {
+		response = append(response, models.ToDuplicateBookGroupResponse(group))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *BookDuplicateHandler) MergeBook(w http.ResponseWriter, r *http.Request) {
+	var req models.MergeBooksRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	book, err := h.mergeUsecase.Execute(r.Context(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		status, code, message := mapBookError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToBookResponse(book))
+}
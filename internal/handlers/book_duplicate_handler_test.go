@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/storage"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+	_ "modernc.org/sqlite"
+)
+
+func setupBookDuplicatesRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	dbPath := t.TempDir() + "/books.db"
+	db, err := sql.Open("sqlite", "file:"+dbPath)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	bookRepo := repositories.NewSQLiteBookRepository(db)
+	bookHandler := NewBookHandler(
+		usecases.NewCreateBookUsecase(bookRepo),
+		usecases.NewListBooksUsecase(bookRepo),
+		usecases.NewGetBookUsecase(bookRepo),
+		usecases.NewUpdateBookUsecase(bookRepo),
+		usecases.NewDeleteBookUsecase(bookRepo),
+	)
+	h := NewBookDuplicateHandler(
+		usecases.NewFindDuplicateBooksUsecase(bookRepo),
+		usecases.NewMergeBooksUsecase(bookRepo),
+	)
+
+	listRepo := repositories.NewSQLiteReadingListRepository(db)
+	listHandler := NewReadingListHandler(
+		usecases.NewCreateReadingListUsecase(listRepo),
+		usecases.NewGetReadingListUsecase(listRepo),
+		usecases.NewListUserReadingListsUsecase(listRepo),
+		usecases.NewUpdateReadingListUsecase(listRepo),
+		usecases.NewDeleteReadingListUsecase(listRepo),
+		usecases.NewAddReadingListEntryUsecase(listRepo),
+		usecases.NewRemoveReadingListEntryUsecase(listRepo),
+		usecases.NewReorderReadingListUsecase(listRepo),
+	)
+
+	requireAuth := middlewares.RequireBearerAuth("test-secret")
+
+	r := chi.NewRouter()
+	r.Post("/books", bookHandler.CreateBook)
+	r.Get("/books/{id}", bookHandler.GetBookByID)
+	r.With(requireAuth).Get("/books/duplicates", h.ListDuplicates)
+	r.With(requireAuth).Post("/books/{id}/merge", h.MergeBook)
+	r.With(requireAuth).Post("/lists", listHandler.CreateReadingList)
+	r.With(requireAuth).Get("/lists/{id}", listHandler.GetReadingList)
+	r.With(requireAuth).Post("/lists/{id}/books", listHandler.AddReadingListEntry)
+	return r
+}
+
+func TestBooks_CreateRejectsInvalidISBN(t *testing.T) {
+	r := setupBookDuplicatesRouter(t)
+
+	res := doJSON(t, r, http.MethodPost, "/books", "", `{"title":"Dune","author":"Frank Herbert","year":1965,"isbn":"0-441-17271-8"}`)
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, res.Code)
+	}
+
+	res = doJSON(t, r, http.MethodPost, "/books", "", `{"title":"Dune","author":"Frank Herbert","year":1965,"isbn":"0-441-17271-7"}`)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, res.Code, res.Body.String())
+	}
+
+	if got := strings.TrimSpace(res.Body.String()); got != `{"id":1,"title":"Dune","author":"Frank Herbert","year":1965,"isbn":"9780441172719"}` {
+		t.Fatalf("unexpected create response: %s", got)
+	}
+}
+
+func TestBooks_ListDuplicates(t *testing.T) {
+	r := setupBookDuplicatesRouter(t)
+	token := userToken(t, "admin")
+
+	for _, payload := range []string{
+		`{"title":"Dune","author":"Frank Herbert","year":1965,"isbn":"0441172717"}`,
+		`{"title":"Foundation","author":"Isaac Asimov","year":1951}`,
+		`{"title":"Dune ","author":"frank herbert","year":1965}`,
+		`{"title":"Foundaton","author":"Asimov, Isaac","year":1951}`,
+		`{"title":"Dune","author":"Frank Herbert","year":1990,"isbn":"9780593099322"}`,
+		`{"title":"Hyperion","author":"Dan Simmons","year":1989}`,
+		`{"title":"Dune (reissue)","author":"Frank Herbert","year":2005,"isbn":"978-0-441-17271-9"}`,
+	} {
+		res := doJSON(t, r, http.MethodPost, "/books", "", payload)
+		if res.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, res.Code, res.Body.String())
+		}
+	}
+
+	unauthorized := doJSON(t, r, http.MethodGet, "/books/duplicates", "", "")
+	if unauthorized.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, unauthorized.Code)
+	}
+
+	res := doJSON(t, r, http.MethodGet, "/books/duplicates", token, "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.Code)
+	}
+
+	var groups []models.DuplicateBookGroupResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &groups); err != nil {
+		t.Fatalf("unmarshal duplicates: %v", err)
+	}
+
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 duplicate groups, got %d: %s", len(groups), res.Body.String())
+	}
+
+	got := make([][]int64, 0, len(groups))
+	for _, group := range groups {
+		ids := make([]int64, 0, len(group.Books))
+		for _, book := range group.Books {
+			ids = append(ids, book.ID)
+		}
+		got = append(got, ids)
+	}
+
+	// Book 5 shares title and author with book 1 but is a different edition.
+	if len(got[0]) != 3 || got[0][0] != 1 || got[0][1] != 3 || got[0][2] != 7 {
+		t.Fatalf("unexpected first group: %v", got[0])
+	}
+	if len(got[1]) != 2 || got[1][0] != 2 || got[1][1] != 4 || groups[1].Reason != models.DuplicateReasonSimilar {
+		t.Fatalf("unexpected second group: %v (%s)", got[1], groups[1].Reason)
+	}
+
+	invalid := doJSON(t, r, http.MethodGet, "/books/duplicates?threshold=2", token, "")
+	if invalid.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, invalid.Code)
+	}
+}
+
+func TestBooks_DuplicatesOnlyCompareBlockedCandidates(t *testing.T) {
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	// Thousands of unrelated books would take billions of steps to compare
+	// pairwise; the typo'd copies must still be found.
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	for i := 0; i < 4000; i++ {
+		title := strings.Repeat(fmt.Sprintf("%04d ", i), 4)
+		author := fmt.Sprintf("Writer%d Surname%d", i, i%50)
+		if _, err := tx.Exec("INSERT INTO books (title, author, year) VALUES (?, ?, 2000)", title, author); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	for _, book := range [][2]string{
+		{"The Left Hand of Darkness", "Ursula K. Le Guin"},
+		{"Left Hand of Darkness", "Le Guin, Ursula K."},
+		{"Neuromancer", "William Gibson"},
+		{"Nuromancer", "William Gibson"},
+	} {
+		if _, err := tx.Exec("INSERT INTO books (title, author, year) VALUES (?, ?, 1970)", book[0], book[1]); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	started := time.Now()
+	groups, err := usecases.NewFindDuplicateBooksUsecase(repositories.NewSQLiteBookRepository(db)).Execute(context.Background(), models.DuplicateQuery{})
+	if err != nil {
+		t.Fatalf("find duplicates: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Fatalf("expected blocking to keep the report fast, took %s", elapsed)
+	}
+
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	for i, want := range [][2]int64{{4001, 4002}, {4003, 4004}} {
+		if books := groups[i].Books; len(books) != 2 || books[0].ID != want[0] || books[1].ID != want[1] {
+			t.Fatalf("expected group %d to hold books %v, got %+v", i, want, books)
+		}
+	}
+}
+
+func TestBooks_MergeRepointsListEntries(t *testing.T) {
+	r := setupBookDuplicatesRouter(t)
+	token := userToken(t, "alice")
+
+	for _, payload := range []string{
+		`{"title":"Dune","author":"Frank Herbert","year":1965}`,
+		`{"title":"dune","author":"Frank Herbert","year":1965,"isbn":"0441172717"}`,
+		`{"title":"Hyperion","author":"Dan Simmons","year":1989}`,
+	} {
+		res := doJSON(t, r, http.MethodPost, "/books", "", payload)
+		if res.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d", http.StatusCreated, res.Code)
+		}
+	}
+
+	doJSON(t, r, http.MethodPost, "/lists", token, `{"name":"Both"}`)
+	doJSON(t, r, http.MethodPost, "/lists", token, `{"name":"Source only"}`)
+	for _, step := range []struct {
+		target string
+		body   string
+	}{
+		{"/lists/1/books", `{"book_id":1}`},
+		{"/lists/1/books", `{"book_id":2}`},
+		{"/lists/2/books", `{"book_id":3}`},
+		{"/lists/2/books", `{"book_id":2}`},
+	} {
+		res := doJSON(t, r, http.MethodPost, step.target, token, step.body)
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, res.Code, res.Body.String())
+		}
+	}
+
+	selfMerge := doJSON(t, r, http.MethodPost, "/books/1/merge", token, `{"source_id":1}`)
+	if selfMerge.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, selfMerge.Code)
+	}
+
+	missing := doJSON(t, r, http.MethodPost, "/books/1/merge", token, `{"source_id":99}`)
+	if missing.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, missing.Code)
+	}
+
+	res := doJSON(t, r, http.MethodPost, "/books/1/merge", token, `{"source_id":2}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	if got := strings.TrimSpace(res.Body.String()); got != `{"id":1,"title":"Dune","author":"Frank Herbert","year":1965,"isbn":"9780441172719"}` {
+		t.Fatalf("unexpected merge response: %s", got)
+	}
+
+	if source := doJSON(t, r, http.MethodGet, "/books/2", "", ""); source.Code != http.StatusNotFound {
+		t.Fatalf("expected merged source to be deleted, got %d", source.Code)
+	}
+
+	both := decodeReadingList(t, doJSON(t, r, http.MethodGet, "/lists/1", token, ""))
+	if ids := entryBookIDs(both); len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("expected list 1 to hold the target once, got %v", ids)
+	}
+
+	sourceOnly := decodeReadingList(t, doJSON(t, r, http.MethodGet, "/lists/2", token, ""))
+	if ids := entryBookIDs(sourceOnly); len(ids) != 2 || ids[0] != 3 || ids[1] != 1 {
+		t.Fatalf("expected list 2 to point at the target, got %v", ids)
+	}
+}
+
+func TestBooks_MergeMovesProvenanceAndDropsSourceCover(t *testing.T) {
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	blobDir := t.TempDir()
+	store, err := storage.NewLocalBlobStore(blobDir)
+	if err != nil {
+		t.Fatalf("init blob store: %v", err)
+	}
+
+	bookRepo := repositories.NewSQLiteBookRepository(db)
+	coverRepo := repositories.NewSQLiteBookCoverRepository(db)
+	provenanceRepo := repositories.NewSQLiteBookProvenanceRepository(db)
+	bookHandler := NewBookHandler(
+		usecases.NewCreateBookUsecase(bookRepo),
+		usecases.NewListBooksUsecase(bookRepo),
+		usecases.NewGetBookUsecase(bookRepo),
+		usecases.NewUpdateBookUsecase(bookRepo),
+		usecases.NewDeleteBookUsecase(bookRepo),
+	)
+	h := NewBookDuplicateHandler(
+		usecases.NewFindDuplicateBooksUsecase(bookRepo),
+		usecases.NewMergeBooksUsecase(bookRepo).WithCovers(coverRepo, store),
+	)
+	coverHandler := NewBookCoverHandler(
+		usecases.NewUploadBookCoverUsecase(bookRepo, coverRepo, store),
+		usecases.NewGetBookCoverUsecase(coverRepo, store),
+		usecases.NewDeleteBookCoverUsecase(coverRepo, store),
+		1<<20,
+	)
+
+	r := chi.NewRouter()
+	r.Post("/books", bookHandler.CreateBook)
+	r.Get("/books/{id}", bookHandler.GetBookByID)
+	r.Put("/books/{id}/cover", coverHandler.UploadCover)
+	r.Post("/books/{id}/merge", h.MergeBook)
+
+	for _, payload := range []string{
+		`{"title":"Dune","author":"Frank Herbert","year":1965}`,
+		`{"title":"Dune","author":"Frank Herbert","year":1965,"subjects":["Science fiction"]}`,
+	} {
+		if res := doJSON(t, r, http.MethodPost, "/books", "", payload); res.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, res.Code, res.Body.String())
+		}
+	}
+	if err := provenanceRepo.Save(context.Background(), []models.BookFieldProvenance{
+		{BookID: 2, Field: models.BookFieldSubjects, Source: "marc", SourceRef: "rec-7", RecordedAt: time.Now()},
+		{BookID: 2, Field: models.BookFieldTitle, Source: "marc", SourceRef: "rec-7", RecordedAt: time.Now()},
+	}); err != nil {
+		t.Fatalf("save provenance: %v", err)
+	}
+	uploadCover(t, r, "/books/1/cover", testPNG(t, 40, 40))
+	uploadCover(t, r, "/books/2/cover", testPNG(t, 50, 50))
+
+	if res := doJSON(t, r, http.MethodPost, "/books/1/merge", "", `{"source_id":2}`); res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	provenance, err := provenanceRepo.FindByBookID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("find provenance: %v", err)
+	}
+	if len(provenance) != 1 || provenance[0].Field != models.BookFieldSubjects || provenance[0].SourceRef != "rec-7" {
+		t.Fatalf("expected only the copied subjects to keep their provenance, got %+v", provenance)
+	}
+	if got := countBlobs(t, blobDir); got != 1+len(models.CoverSizes) {
+		t.Fatalf("expected only the target's cover blobs to remain, got %d", got)
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type BookEnrichmentHandler struct {
+	enrichUsecase     *usecases.EnrichBookUsecase
+	provenanceUsecase *usecases.GetBookProvenanceUsecase
+}
+
+func NewBookEnrichmentHandler(
+	enrichUsecase *usecases.EnrichBookUsecase,
+	provenanceUsecase *usecases.GetBookProvenanceUsecase,
+) *BookEnrichmentHandler {
+	return &BookEnrichmentHandler{
+		enrichUsecase:     enrichUsecase,
+		provenanceUsecase: provenanceUsecase,
+	}
+}
+
+func (h *BookEnrichmentHandler) EnrichBook(w http.ResponseWriter, r *http.Request) {
+	enrichment, err := h.enrichUsecase.Execute(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		status, code, message := mapBookEnrichmentError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToEnrichBookResponse(enrichment))
+}
+
+func (h *BookEnrichmentHandler) GetProvenance(w http.ResponseWriter, r *http.Request) {
+	provenance, err := h.provenanceUsecase.Execute(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		status, code, message := mapBookEnrichmentError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToBookFieldProvenanceResponses(provenance))
+}
+
+func mapBookEnrichmentError(err error) (int, string, string) {
+	if errors.Is(err, usecases.ErrMetadataNotFound) {
+		return http.StatusNotFound, "METADATA_NOT_FOUND", "no metadata found for the book's isbn"
+	}
+
+	return mapBookError(err)
+}
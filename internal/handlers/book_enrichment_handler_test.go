@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+	_ "modernc.org/sqlite"
+)
+
+const openLibrarySample = `/type/author	/authors/OL79034A	3	2010-04-13T05:43:13.573622	{"type": {"key": "/type/author"}, "key": "/authors/OL79034A", "name": "Frank Herbert"}
+/type/work	/works/OL893415W	5	2012-01-01T00:00:00.000000	{"type": {"key": "/type/work"}, "key": "/works/OL893415W", "title": "Dune", "subjects": ["Science fiction", "Arrakis"], "authors": [{"author": {"key": "/authors/OL79034A"}, "type": {"key": "/type/author_role"}}]}
+/type/edition	/books/OL1532643M	8	2011-01-01T00:00:00.000000	{"type": {"key": "/type/edition"}, "key": "/books/OL1532643M", "title": "Dune", "publish_date": "August 2005", "isbn_10": ["0441013597"], "works": [{"key": "/works/OL893415W"}]}
+/type/edition	/books/OL2M	1	2011-01-01T00:00:00.000000	{"type": {"key": "/type/edition"}, "key": "/books/OL2M", "title": "Dune Messiah", "publish_date": "1969", "isbn_13": ["9780306406157"], "subjects": ["Science fiction"], "authors": [{"key": "/authors/OL79034A"}]}
+not a dump line
+`
+
+func setupBookEnrichmentRouter(t *testing.T) (http.Handler, *usecases.ImportMetadataUsecase) {
+	t.Helper()
+
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	bookRepo := repositories.NewSQLiteBookRepository(db)
+	metadataRepo := repositories.NewSQLiteMetadataRepository(db)
+	provenanceRepo := repositories.NewSQLiteBookProvenanceRepository(db)
+	enricher := usecases.NewBookEnricher(metadataRepo, provenanceRepo)
+
+	bookHandler := NewBookHandler(
+		usecases.NewCreateBookUsecase(bookRepo).WithEnricher(enricher),
+		usecases.NewListBooksUsecase(bookRepo),
+		usecases.NewGetBookUsecase(bookRepo),
+		usecases.NewUpdateBookUsecase(bookRepo),
+		usecases.NewDeleteBookUsecase(bookRepo),
+	)
+	h := NewBookEnrichmentHandler(
+		usecases.NewEnrichBookUsecase(bookRepo, enricher),
+		usecases.NewGetBookProvenanceUsecase(bookRepo, provenanceRepo),
+	)
+
+	r := chi.NewRouter()
+	r.Post("/books", bookHandler.CreateBook)
+	r.Put("/books/{id}", bookHandler.UpdateBook)
+	r.With(middlewares.RequireBearerAuth("test-secret")).Post("/books/{id}/enrich", h.EnrichBook)
+	r.Get("/books/{id}/provenance", h.GetProvenance)
+
+	return r, usecases.NewImportMetadataUsecase(metadataRepo)
+}
+
+func provenanceFields(t *testing.T, body []byte) []string {
+	t.Helper()
+
+	var provenance []models.BookFieldProvenanceResponse
+	if err := json.Unmarshal(body, &provenance); err != nil {
+		t.Fatalf("unmarshal provenance: %v (%s)", err, body)
+	}
+
+	fields := make([]string, 0, len(provenance))
+	for _, entry := range provenance {
+		fields = append(fields, entry.Field)
+	}
+
+	return fields
+}
+
+func TestBookEnrichment_ImportAndEnrich(t *testing.T) {
+	r, importer := setupBookEnrichmentRouter(t)
+	token := userToken(t, "staff")
+
+	if res := doJSON(t, r, http.MethodPost, "/books", "", `{"title":"Dune","author":"Frank Herbert","year":1965,"isbn":"0441013597"}`); res.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, res.Code)
+	}
+
+	stats, err := importer.Execute(context.Background(), models.MetadataFormatOpenLibrary, strings.NewReader(openLibrarySample))
+	if err != nil {
+		t.Fatalf("import metadata: %v", err)
+	}
+	if stats.Editions != 2 || stats.Works != 1 || stats.Authors != 1 || stats.Skipped != 1 {
+		t.Fatalf("unexpected import stats %+v", stats)
+	}
+
+	res := doJSON(t, r, http.MethodPost, "/books/1/enrich", token, "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	var enriched models.EnrichBookResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &enriched); err != nil {
+		t.Fatalf("unmarshal enrichment: %v", err)
+	}
+	if !reflect.DeepEqual(enriched.EnrichedFields, []string{models.BookFieldSubjects}) {
+		t.Fatalf("expected only subjects to be enriched, got %v", enriched.EnrichedFields)
+	}
+	if enriched.Book.Title != "Dune" || enriched.Book.Year != 1965 {
+		t.Fatalf("expected existing fields to be kept, got %+v", enriched.Book)
+	}
+	if !reflect.DeepEqual(enriched.Book.Subjects, []string{"Science fiction", "Arrakis"}) {
+		t.Fatalf("expected work subjects, got %v", enriched.Book.Subjects)
+	}
+	if len(enriched.Provenance) != 1 || enriched.Provenance[0].SourceRef != "/books/OL1532643M" || enriched.Provenance[0].Source != models.MetadataSourceOpenLibrary {
+		t.Fatalf("unexpected provenance %+v", enriched.Provenance)
+	}
+
+	res = doJSON(t, r, http.MethodPost, "/books/1/enrich", token, "")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"enriched_fields":[]`) {
+		t.Fatalf("expected nothing left to enrich, got %d %s", res.Code, res.Body.String())
+	}
+}
+
+func TestBookEnrichment_AutoEnrichOnCreate(t *testing.T) {
+	r, importer := setupBookEnrichmentRouter(t)
+
+	if _, err := importer.Execute(context.Background(), models.MetadataFormatOpenLibrary, strings.NewReader(openLibrarySample)); err != nil {
+		t.Fatalf("import metadata: %v", err)
+	}
+
+	res := doJSON(t, r, http.MethodPost, "/books", "", `{"isbn":"978-0-306-40615-7"}`)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusCreated, res.Code, res.Body.String())
+	}
+
+	var book models.BookResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &book); err != nil {
+		t.Fatalf("unmarshal book: %v", err)
+	}
+	if book.Title != "Dune Messiah" || book.Author != "Frank Herbert" || book.Year != 1969 || !reflect.DeepEqual(book.Subjects, []string{"Science fiction"}) {
+		t.Fatalf("unexpected enriched book %+v", book)
+	}
+
+	res = doJSON(t, r, http.MethodGet, "/books/1/provenance", "", "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.Code)
+	}
+	if got := provenanceFields(t, res.Body.Bytes()); !reflect.DeepEqual(got, []string{"author", "subjects", "title", "year"}) {
+		t.Fatalf("unexpected provenance fields %v", got)
+	}
+
+	body := `{"title":"Dune Messiah (Deluxe)","author":"Frank Herbert","year":1969,"isbn":"9780306406157","subjects":["Science fiction"]}`
+	if res := doJSON(t, r, http.MethodPut, "/books/1", "", body); res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	res = doJSON(t, r, http.MethodGet, "/books/1/provenance", "", "")
+	if got := provenanceFields(t, res.Body.Bytes()); !reflect.DeepEqual(got, []string{"author", "subjects", "year"}) {
+		t.Fatalf("expected edited title to lose its provenance, got %v", got)
+	}
+
+	res = doJSON(t, r, http.MethodPost, "/books", "", `{"isbn":"9780140449136"}`)
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown isbn without details to fail validation, got %d", res.Code)
+	}
+}
+
+func TestBookEnrichment_Errors(t *testing.T) {
+	r, _ := setupBookEnrichmentRouter(t)
+	token := userToken(t, "staff")
+
+	doJSON(t, r, http.MethodPost, "/books", "", `{"title":"No ISBN","author":"Someone","year":2000}`)
+	doJSON(t, r, http.MethodPost, "/books", "", `{"title":"Unknown","author":"Someone","year":2000,"isbn":"9780140449136"}`)
+
+	tests := []struct {
+		name   string
+		target string
+		token  string
+		status int
+		code   string
+	}{
+		{"unauthorized", "/books/1/enrich", "", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"missing isbn", "/books/1/enrich", token, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"no metadata", "/books/2/enrich", token, http.StatusNotFound, "METADATA_NOT_FOUND"},
+		{"unknown book", "/books/99/enrich", token, http.StatusNotFound, "BOOK_NOT_FOUND"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := doJSON(t, r, http.MethodPost, tc.target, tc.token, "")
+			if res.Code != tc.status {
+				t.Fatalf("expected status %d, got %d", tc.status, res.Code)
+			}
+			if !strings.Contains(res.Body.String(), tc.code) {
+				t.Fatalf("expected error code %s, got %s", tc.code, res.Body.String())
+			}
+		})
+	}
+
+	if res := doJSON(t, r, http.MethodGet, "/books/99/provenance", "", ""); res.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, res.Code)
+	}
+}
+
+func TestBookEnrichment_MARCXMLMetadata(t *testing.T) {
+	r, importer := setupBookEnrichmentRouter(t)
+
+	stats, err := importer.Execute(context.Background(), models.MetadataFormatMARCXML, strings.NewReader(marcXMLSample))
+	if err != nil {
+		t.Fatalf("import metadata: %v", err)
+	}
+	if stats.Editions != 1 || stats.Authors != 1 || stats.Skipped != 1 {
+		t.Fatalf("unexpected import stats %+v", stats)
+	}
+
+	res := doJSON(t, r, http.MethodPost, "/books", "", `{"isbn":"0441013597"}`)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusCreated, res.Code, res.Body.String())
+	}
+	if !strings.Contains(res.Body.String(), `"title":"Dune: a novel","author":"Frank Herbert","year":2005`) {
+		t.Fatalf("unexpected enriched book %s", res.Body.String())
+	}
+
+	res = doJSON(t, r, http.MethodGet, "/books/1/provenance", "", "")
+	if !strings.Contains(res.Body.String(), `"source":"marc","source_ref":"ocm00001"`) {
+		t.Fatalf("expected MARC provenance, got %s", res.Body.String())
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"desent-api/internal/metadata"
+	"desent-api/internal/models"
+)
+
+const (
+	mediaTypeJSON    = "application/json"
+	mediaTypeMARC    = "application/marc"
+	mediaTypeMARCXML = "application/marcxml+xml"
+	mediaTypeMODS    = "application/mods+xml"
+)
+
+// bookMediaTypes lists the representations of a book in server preference
+// order; ties in the client's Accept header resolve to the earlier entry.
+var bookMediaTypes = []string{mediaTypeJSON, mediaTypeMARCXML, mediaTypeMODS, mediaTypeMARC}
+
+// negotiateMediaType picks the offer with the highest quality in the Accept
+// header, or "" when the client accepts none of them.
+func negotiateMediaType(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	best, bestQuality := "", 0.0
+	for _, offer := range offers {
+		if quality := acceptQuality(accept, offer); quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+
+	return best
+}
+
+// acceptQuality returns the q-value of the most specific range matching offer.
+func acceptQuality(accept, offer string) float64 {
+	offerType, _, _ := strings.Cut(offer, "/")
+
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		rangeSpecificity := -1
+		switch {
+		case mediaRange == offer:
+			rangeSpecificity = 2
+		case mediaRange == offerType+"/*":
+			rangeSpecificity = 1
+		case mediaRange == "*/*":
+			rangeSpecificity = 0
+		}
+		if rangeSpecificity <= specificity {
+			continue
+		}
+
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			parsed, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		quality, specificity = q, rangeSpecificity
+	}
+
+	return quality
+}
+
+func writeBook(w http.ResponseWriter, mediaType string, book models.Book) {
+	buf := bytes.Buffer{}
+	var err error
+	switch mediaType {
+	case mediaTypeMARCXML:
+		err = metadata.WriteMARCXML(&buf, metadata.MARCFromBook(book))
+	case mediaTypeMODS:
+		err = metadata.WriteMODS(&buf, book)
+	case mediaTypeMARC:
+		err = metadata.WriteMARC(&buf, metadata.MARCFromBook(book))
+	default:
+		writeJSON(w, http.StatusOK, models.ToBookResponse(book))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// recordFormat maps an upload Content-Type to a MARC import format.
+func recordFormat(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+
+	switch mediaType {
+	case mediaTypeMARC, "application/octet-stream":
+		return models.MetadataFormatMARC, true
+	case mediaTypeMARCXML, "application/xml", "text/xml":
+		return models.MetadataFormatMARCXML, true
+	default:
+		return "", false
+	}
+}
@@ -88,6 +88,27 @@ func parseBookListQuery(r *http.Request) (models.BookListQuery, error) {
 		Author: strings.TrimSpace(values.Get("author")),
 	}
 
+	filters := []struct {
+		name   string
+		target *int64
+	}{
+		{"work_id", &query.WorkID},
+		{"series_id", &query.SeriesID},
+		{"publisher_id", &query.PublisherID},
+	}
+	for _, filter := range filters {
+		raw := strings.TrimSpace(values.Get(filter.name))
+		if raw == "" {
+			continue
+		}
+
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return models.BookListQuery{}, errors.New(filter.name + " must be a positive integer")
+		}
+		*filter.target = id
+	}
+
 	pageRaw := strings.TrimSpace(values.Get("page"))
 	limitRaw := strings.TrimSpace(values.Get("limit"))
 
@@ -124,6 +145,13 @@ func parseBookListQuery(r *http.Request) (models.BookListQuery, error) {
 func (h *BookHandler) GetBookByID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
+	w.Header().Set("Vary", "Accept")
+	mediaType := negotiateMediaType(r.Header.Get("Accept"), bookMediaTypes)
+	if mediaType == "" {
+		writeError(w, http.StatusNotAcceptable, "NOT_ACCEPTABLE", "supported types are "+strings.Join(bookMediaTypes, ", "))
+		return
+	}
+
 	book, err := h.getUsecase.Execute(r.Context(), id)
 	if err != nil {
 		status, code, message := mapBookError(err)
@@ -131,7 +159,7 @@ func (h *BookHandler) GetBookByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, models.ToBookResponse(book))
+	writeBook(w, mediaType, book)
 }
 
 func (h *BookHandler) UpdateBook(w http.ResponseWriter, r *http.Request) {
@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"desent-api/internal/dbinstrument"
+	"desent-api/internal/metrics"
 	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
 	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
 	"desent-api/internal/usecases"
 
 	"github.com/go-chi/chi/v5"
@@ -389,3 +396,133 @@ func TestBooks_DeleteBookErrors(t *testing.T) {
 		t.Fatalf("expected status %d, got %d", http.StatusNotFound, notFoundRes.Code)
 	}
 }
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (r *spanRecorder) Export(_ context.Context, spans []tracing.SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func TestBooks_ListIsTracedDownToSQL(t *testing.T) {
+	recorder := &spanRecorder{}
+	provider := tracing.NewProvider(recorder, 1, nil, nil)
+	tracing.SetProvider(provider)
+	t.Cleanup(func() { tracing.SetProvider(nil) })
+
+	db, err := dbinstrument.Open("sqlite", "file:"+t.TempDir()+"/books.db", dbinstrument.Tracing("sqlite"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := repositories.InitBooksSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	repo := repositories.NewSQLiteBookRepository(db)
+	for _, title := range []string{"Dune", "Emma"} {
+		if _, err := repo.Create(context.Background(), models.Book{Title: title, Author: "Someone", Year: 1965}); err != nil {
+			t.Fatalf("create book: %v", err)
+		}
+	}
+	h := NewBookHandler(nil, usecases.NewListBooksUsecase(repo), nil, nil, nil)
+	r := chi.NewRouter()
+	r.Use(middlewares.Tracing())
+	r.Get("/books", h.ListBooks)
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/books", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, res.Code, res.Body.String())
+	}
+	provider.Flush(context.Background())
+
+	byName := make(map[string]tracing.SpanData)
+	for _, span := range recorder.spans {
+		byName[span.Name] = span
+	}
+	server, usecase, query := byName["GET /books"], byName["ListBooksUsecase.Execute"], byName["db SELECT"]
+	if usecase.Parent.SpanID != server.SpanContext.SpanID || query.Parent.SpanID != usecase.SpanContext.SpanID {
+		t.Fatalf("expected server > usecase > query spans, got %+v", recorder.spans)
+	}
+
+	attrs := make(map[string]any)
+	for _, attr := range query.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	if attrs["db.system"] != "sqlite" || attrs["db.rows_returned"] != int64(2) ||
+		!strings.Contains(attrs["db.statement"].(string), "FROM books") {
+		t.Fatalf("unexpected query span attributes %v", attrs)
+	}
+}
+
+func TestBooks_SlowQueriesAreLoggedWithRedactedArgsAndPlan(t *testing.T) {
+	var out bytes.Buffer
+	slowLog := dbinstrument.NewSlowQueryLog(slog.New(slog.NewJSONHandler(&out, nil)), time.Nanosecond, true)
+	db, err := dbinstrument.Open("sqlite", "file:"+t.TempDir()+"/books.db", dbinstrument.Metrics(), slowLog)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := repositories.InitBooksSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	repo := repositories.NewSQLiteBookRepository(db)
+	book, err := repo.Create(context.Background(), models.Book{Title: "Secret Title", Author: "Someone", Year: 1965})
+	if err != nil {
+		t.Fatalf("create book: %v", err)
+	}
+	out.Reset()
+	if _, err := repo.FindByID(context.Background(), book.ID); err != nil {
+		t.Fatalf("find book: %v", err)
+	}
+
+	var entry struct {
+		Msg       string   `json:"msg"`
+		Level     string   `json:"level"`
+		Operation string   `json:"operation"`
+		Rows      int64    `json:"rows"`
+		Args      []string `json:"args"`
+		Plan      []string `json:"plan"`
+	}
+	if err := json.Unmarshal(bytes.SplitN(out.Bytes(), []byte("\n"), 2)[0], &entry); err != nil {
+		t.Fatalf("decode slow query entry %q: %v", out.String(), err)
+	}
+	if entry.Msg != "slow query" || entry.Level != "WARN" || entry.Operation != "SELECT" || entry.Rows != 1 {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if len(entry.Args) != 1 || entry.Args[0] != "int64" {
+		t.Fatalf("expected arguments described by type only, got %v", entry.Args)
+	}
+	if len(entry.Plan) == 0 || !strings.HasPrefix(entry.Plan[0], "SEARCH") {
+		t.Fatalf("expected a query plan, got %v", entry.Plan)
+	}
+
+	out.Reset()
+	if _, err := repo.Create(context.Background(), models.Book{Title: "Secret Title", Author: "Someone", Year: 1965}); err != nil {
+		t.Fatalf("create book: %v", err)
+	}
+	if strings.Contains(out.String(), "Secret Title") || !strings.Contains(out.String(), `"string(12)"`) {
+		t.Fatalf("expected string arguments to be redacted, got %s", out.String())
+	}
+
+	var exposition bytes.Buffer
+	if _, err := metrics.Default.WriteTo(&exposition); err != nil {
+		t.Fatalf("write metrics: %v", err)
+	}
+	for _, series := range []string{
+		`db_queries_total{operation="SELECT",status="ok"}`,
+		`db_query_duration_seconds_count{operation="INSERT"}`,
+		`db_slow_queries_total{operation="SELECT"}`,
+	} {
+		if !strings.Contains(exposition.String(), series) {
+			t.Errorf("expected %s in metrics", series)
+		}
+	}
+}
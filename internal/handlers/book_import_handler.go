@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+)
+
+const maxBookImportBytes = 10 << 20
+
+type BookImportHandler struct {
+	importUsecase *usecases.ImportBooksUsecase
+}
+
+func NewBookImportHandler(importUsecase *usecases.ImportBooksUsecase) *BookImportHandler {
+	return &BookImportHandler{importUsecase: importUsecase}
+}
+
+func (h *BookImportHandler) ImportBooks(w http.ResponseWriter, r *http.Request) {
+	format, ok := recordFormat(r.Header.Get("Content-Type"))
+	if !ok {
+		writeError(w, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", "records must be sent as "+mediaTypeMARC+" or "+mediaTypeMARCXML)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBookImportBytes)
+
+	result, err := h.importUsecase.Execute(r.Context(), format, r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			writeError(w, http.StatusRequestEntityTooLarge, "IMPORT_TOO_LARGE", "import exceeds the maximum upload size")
+		case errors.Is(err, usecases.ErrInvalidRecords):
+			writeError(w, http.StatusBadRequest, "INVALID_RECORDS", err.Error())
+		default:
+			status, code, message := mapBookError(err)
+			writeError(w, status, code, message)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToBookImportResponse(result))
+}
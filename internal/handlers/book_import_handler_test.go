@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"desent-api/internal/metadata"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+	_ "modernc.org/sqlite"
+)
+
+const marcXMLSample = `<?xml version="1.0" encoding="UTF-8"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim">
+  <record>
+    <leader>00000cam a2200000 i 4500</leader>
+    <controlfield tag="001">ocm00001</controlfield>
+    <datafield tag="020" ind1=" " ind2=" "><subfield code="a">0441013597 (pbk.)</subfield></datafield>
+    <datafield tag="100" ind1="1" ind2=" "><subfield code="a">Herbert, Frank,</subfield><subfield code="e">author.</subfield></datafield>
+    <datafield tag="245" ind1="1" ind2="0"><subfield code="a">Dune :</subfield><subfield code="b">a novel /</subfield><subfield code="c">Frank Herbert.</subfield></datafield>
+    <datafield tag="264" ind1=" " ind2="1"><subfield code="a">New York :</subfield><subfield code="b">Ace,</subfield><subfield code="c">[2005]</subfield></datafield>
+    <datafield tag="650" ind1=" " ind2="0"><subfield code="a">Science fiction.</subfield></datafield>
+  </record>
+  <record>
+    <leader>00000cam a2200000 i 4500</leader>
+    <datafield tag="100" ind1="1" ind2=" "><subfield code="a">Nobody, Anne.</subfield></datafield>
+    <datafield tag="260" ind1=" " ind2=" "><subfield code="c">c1999.</subfield></datafield>
+  </record>
+</collection>`
+
+func setupBookImportRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	bookRepo := repositories.NewSQLiteBookRepository(db)
+	createUsecase := usecases.NewCreateBookUsecase(bookRepo)
+	bookHandler := NewBookHandler(
+		createUsecase,
+		usecases.NewListBooksUsecase(bookRepo),
+		usecases.NewGetBookUsecase(bookRepo),
+		usecases.NewUpdateBookUsecase(bookRepo),
+		usecases.NewDeleteBookUsecase(bookRepo),
+	)
+	h := NewBookImportHandler(usecases.NewImportBooksUsecase(createUsecase))
+
+	r := chi.NewRouter()
+	r.Post("/books", bookHandler.CreateBook)
+	r.Get("/books/{id}", bookHandler.GetBookByID)
+	r.Post("/books/import", h.ImportBooks)
+
+	return r
+}
+
+func doRecords(t *testing.T, r http.Handler, contentType string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/books/import", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	return res
+}
+
+func getBookAs(t *testing.T, r http.Handler, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/books/1", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	return res
+}
+
+func TestBookImport_MARCXML(t *testing.T) {
+	r := setupBookImportRouter(t)
+
+	res := doRecords(t, r, "application/marcxml+xml", []byte(marcXMLSample))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	var result models.BookImportResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &result); err != nil {
+		t.Fatalf("unmarshal import: %v", err)
+	}
+	if result.Imported != 1 || len(result.Errors) != 1 || result.Errors[0].Record != 2 {
+		t.Fatalf("unexpected import result %+v", result)
+	}
+
+	book := result.Books[0]
+	if book.Title != "Dune: a novel" || book.Author != "Frank Herbert" || book.Year != 2005 || book.ISBN != "9780441013593" {
+		t.Fatalf("unexpected imported book %+v", book)
+	}
+	if !reflect.DeepEqual(book.Subjects, []string{"Science fiction"}) {
+		t.Fatalf("unexpected subjects %v", book.Subjects)
+	}
+}
+
+func TestBookImport_BinaryMARCRoundTrip(t *testing.T) {
+	r := setupBookImportRouter(t)
+
+	source := models.Book{ID: 7, Title: "Tolkien Reader", Author: "J. R. R. Tolkien", Year: 1966, ISBN: "9780345345066", Subjects: []string{"Fantasy"}}
+	buf := bytes.Buffer{}
+	if err := metadata.WriteMARC(&buf, metadata.MARCFromBook(source)); err != nil {
+		t.Fatalf("write marc: %v", err)
+	}
+
+	res := doRecords(t, r, "application/marc", buf.Bytes())
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"imported":1`) {
+		t.Fatalf("expected one imported book, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	res = getBookAs(t, r, "application/marc")
+	if res.Code != http.StatusOK || res.Header().Get("Content-Type") != "application/marc" {
+		t.Fatalf("expected binary MARC, got %d %q", res.Code, res.Header().Get("Content-Type"))
+	}
+
+	var records []metadata.MARCRecord
+	skipped, err := metadata.ParseMARC(res.Body, func(record metadata.MARCRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil || skipped != 0 || len(records) != 1 {
+		t.Fatalf("parse exported marc: %v (skipped %d, records %d)", err, skipped, len(records))
+	}
+
+	got := metadata.BookRequestFromMARC(records[0])
+	want := models.CreateBookRequest{Title: source.Title, Author: source.Author, Year: source.Year, ISBN: source.ISBN, Subjects: source.Subjects}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected round trip %+v, got %+v", want, got)
+	}
+}
+
+func TestBooks_GetByIDContentNegotiation(t *testing.T) {
+	r := setupBookImportRouter(t)
+
+	body := `{"title":"Dune","author":"Frank Herbert","year":1965,"isbn":"0441013597","subjects":["Science fiction"]}`
+	if res := doJSON(t, r, http.MethodPost, "/books", "", body); res.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, res.Code)
+	}
+
+	res := getBookAs(t, r, "application/marcxml+xml")
+	if res.Code != http.StatusOK || res.Header().Get("Content-Type") != "application/marcxml+xml" {
+		t.Fatalf("expected MARCXML, got %d %q", res.Code, res.Header().Get("Content-Type"))
+	}
+	if res.Header().Get("Vary") != "Accept" {
+		t.Fatalf("expected Vary: Accept, got %q", res.Header().Get("Vary"))
+	}
+
+	var records []metadata.MARCRecord
+	if _, err := metadata.ParseMARCXML(res.Body, func(record metadata.MARCRecord) error {
+		records = append(records, record)
+		return nil
+	}); err != nil || len(records) != 1 {
+		t.Fatalf("parse exported marcxml: %v (%d records)", err, len(records))
+	}
+	if got := metadata.BookRequestFromMARC(records[0]); got.Title != "Dune" || got.Author != "Frank Herbert" || got.Year != 1965 || got.ISBN != "9780441013593" {
+		t.Fatalf("unexpected MARCXML mapping %+v", got)
+	}
+
+	res = getBookAs(t, r, "application/*;q=0.5, application/mods+xml")
+	if res.Code != http.StatusOK || res.Header().Get("Content-Type") != "application/mods+xml" {
+		t.Fatalf("expected MODS, got %d %q", res.Code, res.Header().Get("Content-Type"))
+	}
+	for _, fragment := range []string{`xmlns="http://www.loc.gov/mods/v3"`, "<title>Dune</title>", "<namePart>Frank Herbert</namePart>", `<identifier type="isbn">9780441013593</identifier>`, "<topic>Science fiction</topic>"} {
+		if !strings.Contains(res.Body.String(), fragment) {
+			t.Fatalf("expected MODS to contain %s, got %s", fragment, res.Body.String())
+		}
+	}
+
+	for _, accept := range []string{"", "*/*", "text/html,application/xhtml+xml,*/*;q=0.8"} {
+		if res := getBookAs(t, r, accept); res.Code != http.StatusOK || res.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("expected JSON for Accept %q, got %d %q", accept, res.Code, res.Header().Get("Content-Type"))
+		}
+	}
+
+	if res := getBookAs(t, r, "text/html"); res.Code != http.StatusNotAcceptable {
+		t.Fatalf("expected status %d, got %d", http.StatusNotAcceptable, res.Code)
+	}
+}
+
+func TestBookImport_Errors(t *testing.T) {
+	r := setupBookImportRouter(t)
+
+	if res := doRecords(t, r, "text/csv", []byte("title,author")); res.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected status %d, got %d", http.StatusUnsupportedMediaType, res.Code)
+	}
+
+	res := doRecords(t, r, "application/marcxml+xml", []byte(`<collection><record><leader>`))
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "INVALID_RECORDS") {
+		t.Fatalf("expected INVALID_RECORDS, got %d %s", res.Code, res.Body.String())
+	}
+
+	res = doRecords(t, r, "application/marc", []byte("not a marc record\x1d"))
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"skipped":1`) {
+		t.Fatalf("expected damaged record to be skipped, got %d %s", res.Code, res.Body.String())
+	}
+}
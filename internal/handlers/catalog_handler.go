@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type CatalogHandler struct {
+	createWorkUsecase      *usecases.CreateWorkUsecase
+	listWorksUsecase       *usecases.ListWorksUsecase
+	getWorkUsecase         *usecases.GetWorkUsecase
+	createSeriesUsecase    *usecases.CreateSeriesUsecase
+	listSeriesUsecase      *usecases.ListSeriesUsecase
+	getSeriesUsecase       *usecases.GetSeriesUsecase
+	createPublisherUsecase *usecases.CreatePublisherUsecase
+	listPublishersUsecase  *usecases.ListPublishersUsecase
+	getPublisherUsecase    *usecases.GetPublisherUsecase
+}
+
+func NewCatalogHandler(
+	createWorkUsecase *usecases.CreateWorkUsecase,
+	listWorksUsecase *usecases.ListWorksUsecase,
+	getWorkUsecase *usecases.GetWorkUsecase,
+	createSeriesUsecase *usecases.CreateSeriesUsecase,
+	listSeriesUsecase *usecases.ListSeriesUsecase,
+	getSeriesUsecase *usecases.GetSeriesUsecase,
+	createPublisherUsecase *usecases.CreatePublisherUsecase,
+	listPublishersUsecase *usecases.ListPublishersUsecase,
+	getPublisherUsecase *usecases.GetPublisherUsecase,
+) *CatalogHandler {
+	return &CatalogHandler{
+		createWorkUsecase:      createWorkUsecase,
+		listWorksUsecase:       listWorksUsecase,
+		getWorkUsecase:         getWorkUsecase,
+		createSeriesUsecase:    createSeriesUsecase,
+		listSeriesUsecase:      listSeriesUsecase,
+		getSeriesUsecase:       getSeriesUsecase,
+		createPublisherUsecase: createPublisherUsecase,
+		listPublishersUsecase:  listPublishersUsecase,
+		getPublisherUsecase:    getPublisherUsecase,
+	}
+}
+
+func (h *CatalogHandler) CreateWork(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWorkRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	work, err := h.createWorkUsecase.Execute(r.Context(), req)
+	if err != nil {
+		status, code, message := mapCatalogError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.ToWorkResponse(work))
+}
+
+func (h *CatalogHandler) ListWorks(w http.ResponseWriter, r *http.Request) {
+	works, err := h.listWorksUsecase.Execute(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		return
+	}
+
+	response := make([]models.WorkResponse, 0, len(works))
+	for _, work := range works {
+		response = append(response, models.ToWorkResponse(work))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *CatalogHandler) GetWork(w http.ResponseWriter, r *http.Request) {
+	work, err := h.getWorkUsecase.Execute(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		status, code, message := mapCatalogError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToWorkResponse(work))
+}
+
+func (h *CatalogHandler) CreateSeries(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateSeriesRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	series, err := h.createSeriesUsecase.Execute(r.Context(), req)
+	if err != nil {
+		status, code, message := mapCatalogError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.ToSeriesResponse(series))
+}
+
+func (h *CatalogHandler) ListSeries(w http.ResponseWriter, r *http.Request) {
+	seriesList, err := h.listSeriesUsecase.Execute(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		return
+	}
+
+	response := make([]models.SeriesResponse, 0, len(seriesList))
+	for _, series := range seriesList {
+		response = append(response, models.ToSeriesResponse(series))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *CatalogHandler) GetSeries(w http.ResponseWriter, r *http.Request) {
+	series, err := h.getSeriesUsecase.Execute(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		status, code, message := mapCatalogError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToSeriesResponse(series))
+}
+
+func (h *CatalogHandler) CreatePublisher(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePublisherRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	publisher, err := h.createPublisherUsecase.Execute(r.Context(), req)
+	if err != nil {
+		status, code, message := mapCatalogError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.ToPublisherResponse(publisher))
+}
+
+func (h *CatalogHandler) ListPublishers(w http.ResponseWriter, r *http.Request) {
+	publishers, err := h.listPublishersUsecase.Execute(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		return
+	}
+
+	response := make([]models.PublisherResponse, 0, len(publishers))
+	for _, publisher := range publishers {
+		response = append(response, models.ToPublisherResponse(publisher))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *CatalogHandler) GetPublisher(w http.ResponseWriter, r *http.Request) {
+	publisher, err := h.getPublisherUsecase.Execute(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		status, code, message := mapCatalogError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToPublisherResponse(publisher))
+}
+
+func mapCatalogError(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, usecases.ErrWorkNotFound):
+		return http.StatusNotFound, "WORK_NOT_FOUND", "work not found"
+	case errors.Is(err, usecases.ErrSeriesNotFound):
+		return http.StatusNotFound, "SERIES_NOT_FOUND", "series not found"
+	case errors.Is(err, usecases.ErrPublisherNotFound):
+		return http.StatusNotFound, "PUBLISHER_NOT_FOUND", "publisher not found"
+	default:
+		return mapBookError(err)
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+	_ "modernc.org/sqlite"
+)
+
+func setupCatalogRouter(t *testing.T, db *sql.DB) http.Handler {
+	t.Helper()
+
+	if err := repositories.InitBooksSchema(context.Background(), db); err != nil {
+		t.Fatalf("init books schema: %v", err)
+	}
+
+	bookRepo := repositories.NewSQLiteBookRepository(db)
+	bookHandler := NewBookHandler(
+		usecases.NewCreateBookUsecase(bookRepo),
+		usecases.NewListBooksUsecase(bookRepo),
+		usecases.NewGetBookUsecase(bookRepo),
+		usecases.NewUpdateBookUsecase(bookRepo),
+		usecases.NewDeleteBookUsecase(bookRepo),
+	)
+
+	workRepo := repositories.NewSQLiteWorkRepository(db)
+	seriesRepo := repositories.NewSQLiteSeriesRepository(db)
+	publisherRepo := repositories.NewSQLitePublisherRepository(db)
+	h := NewCatalogHandler(
+		usecases.NewCreateWorkUsecase(workRepo),
+		usecases.NewListWorksUsecase(workRepo),
+		usecases.NewGetWorkUsecase(workRepo),
+		usecases.NewCreateSeriesUsecase(seriesRepo),
+		usecases.NewListSeriesUsecase(seriesRepo),
+		usecases.NewGetSeriesUsecase(seriesRepo),
+		usecases.NewCreatePublisherUsecase(publisherRepo),
+		usecases.NewListPublishersUsecase(publisherRepo),
+		usecases.NewGetPublisherUsecase(publisherRepo),
+	)
+
+	r := chi.NewRouter()
+	r.Post("/books", bookHandler.CreateBook)
+	r.With(middlewares.RequireBearerAuth("test-secret")).Get("/books", bookHandler.ListBooks)
+	r.Get("/books/{id}", bookHandler.GetBookByID)
+	r.Put("/books/{id}", bookHandler.UpdateBook)
+	r.Post("/works", h.CreateWork)
+	r.Get("/works/{id}", h.GetWork)
+	r.Post("/series", h.CreateSeries)
+	r.Get("/series/{id}", h.GetSeries)
+	r.Post("/publishers", h.CreatePublisher)
+	r.Get("/publishers/{id}", h.GetPublisher)
+	return r
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+t.TempDir()+"/books.db")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+func TestCatalog_BooksEmbedWorkSeriesAndPublisher(t *testing.T) {
+	r := setupCatalogRouter(t, openTestDB(t))
+
+	for _, step := range []struct {
+		target string
+		body   string
+	}{
+		{"/works", `{"title":"Dune","author":"Frank Herbert","original_year":1965}`},
+		{"/series", `{"name":"Dune Chronicles"}`},
+		{"/publishers", `{"name":"Ace Books","location":"New York"}`},
+	} {
+		res := doJSON(t, r, http.MethodPost, step.target, "", step.body)
+		if res.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, res.Code, res.Body.String())
+		}
+	}
+
+	for _, payload := range []string{
+		`{"title":"Children of Dune","author":"Frank Herbert","year":1976,"series_id":1,"series_position":3}`,
+		`{"title":"Dune","author":"Frank Herbert","year":1965,"work_id":1,"series_id":1,"series_position":1,"publisher_id":1}`,
+		`{"title":"Dune Messiah","author":"Frank Herbert","year":1969,"series_id":1,"series_position":2}`,
+		`{"title":"Dune","author":"Frank Herbert","year":2005,"work_id":1}`,
+	} {
+		res := doJSON(t, r, http.MethodPost, "/books", "", payload)
+		if res.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, res.Code, res.Body.String())
+		}
+	}
+
+	getRes := doJSON(t, r, http.MethodGet, "/books/2", "", "")
+	expected := `{"id":2,"title":"Dune","author":"Frank Herbert","year":1965,"work":{"id":1,"title":"Dune"},"series":{"id":1,"name":"Dune Chronicles","position":1},"publisher":{"id":1,"name":"Ace Books"}}`
+	if got := strings.TrimSpace(getRes.Body.String()); got != expected {
+		t.Fatalf("unexpected book response: %s", got)
+	}
+
+	token := userToken(t, "admin")
+	seriesRes := doJSON(t, r, http.MethodGet, "/books?series_id=1", token, "")
+	if seriesRes.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, seriesRes.Code)
+	}
+	var volumes []models.BookResponse
+	if err := json.Unmarshal(seriesRes.Body.Bytes(), &volumes); err != nil {
+		t.Fatalf("unmarshal series books: %v", err)
+	}
+	if len(volumes) != 3 || volumes[0].ID != 2 || volumes[1].ID != 3 || volumes[2].ID != 1 {
+		t.Fatalf("expected series volumes ordered by position, got %s", seriesRes.Body.String())
+	}
+
+	workRes := doJSON(t, r, http.MethodGet, "/books?work_id=1", token, "")
+	if got := strings.Count(workRes.Body.String(), `"work":{"id":1`); got != 2 {
+		t.Fatalf("expected 2 editions of work 1, got %d: %s", got, workRes.Body.String())
+	}
+
+	invalidFilter := doJSON(t, r, http.MethodGet, "/books?publisher_id=abc", token, "")
+	if invalidFilter.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, invalidFilter.Code)
+	}
+}
+
+func TestCatalog_RejectsUnknownReferences(t *testing.T) {
+	r := setupCatalogRouter(t, openTestDB(t))
+
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{
+			name:    "unknown work",
+			body:    `{"title":"Dune","author":"Frank Herbert","year":1965,"work_id":9}`,
+			message: "validation error: work_id does not exist",
+		},
+		{
+			name:    "unknown series",
+			body:    `{"title":"Dune","author":"Frank Herbert","year":1965,"series_id":9}`,
+			message: "validation error: series_id does not exist",
+		},
+		{
+			name:    "position without series",
+			body:    `{"title":"Dune","author":"Frank Herbert","year":1965,"series_position":2}`,
+			message: "validation error: series_position requires series_id",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := doJSON(t, r, http.MethodPost, "/books", "", tc.body)
+			if res.Code != http.StatusBadRequest {
+				t.Fatalf("expected status %d, got %d", http.StatusBadRequest, res.Code)
+			}
+
+			expected := `{"error_code":"VALIDATION_ERROR","message":"` + tc.message + `"}`
+			if got := strings.TrimSpace(res.Body.String()); got != expected {
+				t.Fatalf("unexpected validation response: %s", got)
+			}
+		})
+	}
+
+	if res := doJSON(t, r, http.MethodGet, "/series/1", "", ""); res.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, res.Code)
+	}
+}
+
+func TestCatalog_MigratesExistingBooksTable(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := db.Exec(`CREATE TABLE books (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, author TEXT NOT NULL, year INTEGER NOT NULL);
+INSERT INTO books (title, author, year) VALUES ('Dune', 'Frank Herbert', 1965);`); err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+
+	r := setupCatalogRouter(t, db)
+
+	if res := doJSON(t, r, http.MethodPost, "/publishers", "", `{"name":"Ace Books"}`); res.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, res.Code)
+	}
+
+	res := doJSON(t, r, http.MethodPut, "/books/1", "", `{"title":"Dune","author":"Frank Herbert","year":1965,"publisher_id":1}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	if got := strings.TrimSpace(res.Body.String()); got != `{"id":1,"title":"Dune","author":"Frank Herbert","year":1965,"publisher":{"id":1,"name":"Ace Books"}}` {
+		t.Fatalf("unexpected migrated book: %s", got)
+	}
+}
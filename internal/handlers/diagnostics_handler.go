@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"time"
+
+	"desent-api/internal/models"
+)
+
+// DiagnosticsHandler serves process internals for the admin listener. It
+// must never be mounted on the public router.
+type DiagnosticsHandler struct {
+	config    map[string]any
+	startedAt time.Time
+}
+
+// NewDiagnosticsHandler serves config as the current configuration, which
+// must already have its secrets masked.
+func NewDiagnosticsHandler(config map[string]any, startedAt time.Time) *DiagnosticsHandler {
+	return &DiagnosticsHandler{config: config, startedAt: startedAt}
+}
+
+func (h *DiagnosticsHandler) GetRuntimeStats(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	gc := models.GCStats{
+		NumGC:        mem.NumGC,
+		PauseTotalMs: float64(mem.PauseTotalNs) / float64(time.Millisecond),
+		NextGC:       mem.NextGC,
+	}
+	if mem.LastGC != 0 {
+		lastGC := time.Unix(0, int64(mem.LastGC)).UTC()
+		gc.LastGC = &lastGC
+	}
+
+	writeJSON(w, http.StatusOK, models.RuntimeStatsResponse{
+		GoVersion:     runtime.Version(),
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		NumCPU:        runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		Goroutines:    runtime.NumGoroutine(),
+		StartedAt:     h.startedAt.UTC(),
+		UptimeSeconds: time.Since(h.startedAt).Seconds(),
+		Memory: models.MemoryStats{
+			HeapAlloc:   mem.HeapAlloc,
+			HeapInuse:   mem.HeapInuse,
+			HeapIdle:    mem.HeapIdle,
+			HeapObjects: mem.HeapObjects,
+			StackInuse:  mem.StackInuse,
+			Sys:         mem.Sys,
+			TotalAlloc:  mem.TotalAlloc,
+			Mallocs:     mem.Mallocs,
+			Frees:       mem.Frees,
+		},
+		GC: gc,
+	})
+}
+
+func (h *DiagnosticsHandler) GetBuildInfo(w http.ResponseWriter, r *http.Request) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		writeError(w, http.StatusNotFound, "BUILD_INFO_UNAVAILABLE", "binary was built without build information")
+		return
+	}
+
+	res := models.BuildInfoResponse{
+		GoVersion:    info.GoVersion,
+		Path:         info.Path,
+		Version:      info.Main.Version,
+		Dependencies: make([]string, 0, len(info.Deps)),
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			res.VCSRevision = setting.Value
+		case "vcs.time":
+			res.VCSTime = setting.Value
+		case "vcs.modified":
+			res.VCSModified = setting.Value == "true"
+		}
+	}
+	for _, dep := range info.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		res.Dependencies = append(res.Dependencies, dep.Path+"@"+dep.Version)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *DiagnosticsHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.config)
+}
+
+// GetGoroutines writes the stack of every goroutine as plain text, in the
+// format of an unrecovered panic.
+func (h *DiagnosticsHandler) GetGoroutines(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = pprof.Lookup("goroutine").WriteTo(w, 2)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"desent-api/configs"
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func setupDiagnosticsRouter(t *testing.T, cfg configs.Config) http.Handler {
+	t.Helper()
+
+	h := NewDiagnosticsHandler(cfg.Masked(), time.Now().Add(-time.Minute))
+	adminAuth := chi.Chain(middlewares.RequireBearerAuth("test-secret"), middlewares.RequireAdmin([]string{"admin"})).Handler
+
+	r := chi.NewRouter()
+	r.Use(middlewares.AllowAdminToken(cfg.Admin.Token, adminAuth))
+	r.Mount("/debug", chiMiddleware.Profiler())
+	r.Get("/admin/runtime", h.GetRuntimeStats)
+	r.Get("/admin/build", h.GetBuildInfo)
+	r.Get("/admin/config", h.GetConfig)
+	r.Get("/admin/goroutines", h.GetGoroutines)
+	return r
+}
+
+func TestDiagnostics_RequireAdminOrDedicatedToken(t *testing.T) {
+	cfg := configs.Config{Admin: configs.AdminConfig{Address: "127.0.0.1:6060", Token: "diagnostics-token"}}
+	r := setupDiagnosticsRouter(t, cfg)
+
+	for _, tc := range []struct {
+		token string
+		want  int
+	}{
+		{"", http.StatusUnauthorized},
+		{"wrong-token", http.StatusUnauthorized},
+		{userToken(t, "reader"), http.StatusForbidden},
+		{userToken(t, "admin"), http.StatusOK},
+		{"diagnostics-token", http.StatusOK},
+	} {
+		if res := doJSON(t, r, http.MethodGet, "/admin/runtime", tc.token, ""); res.Code != tc.want {
+			t.Fatalf("expected status %d for token %q, got %d: %s", tc.want, tc.token, res.Code, res.Body.String())
+		}
+	}
+
+	res := doJSON(t, r, http.MethodGet, "/admin/runtime", "diagnostics-token", "")
+	var stats models.RuntimeStatsResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("unmarshal runtime stats: %v", err)
+	}
+	if stats.Goroutines == 0 || stats.NumCPU == 0 || stats.Memory.Sys == 0 || stats.UptimeSeconds < 60 {
+		t.Fatalf("unexpected runtime stats %+v", stats)
+	}
+
+	res = doJSON(t, r, http.MethodGet, "/admin/goroutines", "diagnostics-token", "")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "goroutine ") {
+		t.Fatalf("expected a goroutine dump, got %d: %s", res.Code, res.Body.String())
+	}
+
+	res = doJSON(t, r, http.MethodGet, "/debug/pprof/heap?debug=1", "diagnostics-token", "")
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), "heap profile") {
+		t.Fatalf("expected a heap profile, got %d: %s", res.Code, res.Body.String())
+	}
+	if res := doJSON(t, r, http.MethodGet, "/debug/pprof/heap?debug=1", "", ""); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected pprof to require authentication, got %d", res.Code)
+	}
+}
+
+func TestDiagnostics_ConfigMasksSecrets(t *testing.T) {
+	cfg := configs.Config{
+		Server:   configs.ServerConfig{Address: ":8080", ReadTimeout: 10 * time.Second},
+		Database: configs.DatabaseConfig{SlowQueryThreshold: 200 * time.Millisecond},
+		Auth:     configs.AuthConfig{JWTSecret: "jwt-signing-secret"},
+		Mail:     configs.MailConfig{SMTPUsername: "mailer", SMTPPassword: "smtp-password"},
+		Admin:    configs.AdminConfig{Token: "diagnostics-token"},
+	}
+	r := setupDiagnosticsRouter(t, cfg)
+
+	res := doJSON(t, r, http.MethodGet, "/admin/config", userToken(t, "admin"), "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, res.Code, res.Body.String())
+	}
+	for _, secret := range []string{"jwt-signing-secret", "smtp-password", "diagnostics-token"} {
+		if strings.Contains(res.Body.String(), secret) {
+			t.Fatalf("expected %q to be masked: %s", secret, res.Body.String())
+		}
+	}
+
+	var body map[string]map[string]any
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal config: %v", err)
+	}
+	if body["Auth"]["JWTSecret"] != "[REDACTED]" || body["Auth"]["MFAEncryptionKey"] != "" {
+		t.Fatalf("expected set secrets masked and unset ones empty, got %v", body["Auth"])
+	}
+	if body["Mail"]["SMTPUsername"] != "mailer" || body["Server"]["Address"] != ":8080" {
+		t.Fatalf("expected other settings to be shown, got %v", body)
+	}
+	if body["Server"]["ReadTimeout"] != "10s" || body["Database"]["SlowQueryThreshold"] != "200ms" {
+		t.Fatalf("expected durations to be readable, got %v and %v", body["Server"], body["Database"])
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"desent-api/internal/health"
+	"desent-api/internal/lifecycle"
+)
+
+type livenessResponse struct {
+	Status string `json:"status"`
+}
+
+// readinessResponse is public, so it leaves out why a check failed; the
+// checker logs that instead.
+type readinessResponse struct {
+	Status    string                `json:"status"`
+	CheckedAt *time.Time            `json:"checked_at,omitempty"`
+	Checks    []healthCheckResponse `json:"checks,omitempty"`
+}
+
+type healthCheckResponse struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+type HealthHandler struct {
+	lifecycle *lifecycle.Manager
+	checker   *health.Checker
+}
+
+func NewHealthHandler(lifecycle *lifecycle.Manager, checker *health.Checker) *HealthHandler {
+	return &HealthHandler{lifecycle: lifecycle, checker: checker}
+}
+
+// Live only reports that the process serves requests; restarting it would
+// not fix a missing dependency.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, livenessResponse{Status: "ok"})
+}
+
+// Ready runs the dependency checks. It fails without running them as soon
+// as shutdown starts so load balancers stop sending traffic while in-flight
+// requests drain. Failing non-critical checks leave it ready but degraded.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	if !h.lifecycle.Ready() {
+		writeJSON(w, http.StatusServiceUnavailable, readinessResponse{Status: "draining"})
+		return
+	}
+
+	report := h.checker.Run(r.Context())
+	response := readinessResponse{
+		Status:    "ready",
+		CheckedAt: &report.CheckedAt,
+		Checks:    make([]healthCheckResponse, 0, len(report.Checks)),
+	}
+	for _, check := range report.Checks {
+		response.Checks = append(response.Checks, healthCheckResponse{
+			Name:      check.Name,
+			Status:    check.Status,
+			LatencyMS: float64(check.Latency.Microseconds()) / 1000,
+		})
+	}
+
+	status := http.StatusOK
+	switch {
+	case !report.Healthy:
+		response.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+	case report.Status == health.StatusDegraded:
+		response.Status = health.StatusDegraded
+	}
+
+	writeJSON(w, status, response)
+}
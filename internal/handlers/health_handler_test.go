@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"desent-api/internal/health"
+	"desent-api/internal/lifecycle"
+	"desent-api/internal/repositories"
+)
+
+func getHealth(handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, target, nil))
+	return res
+}
+
+func TestReadiness_FailsOnceShutdownStarts(t *testing.T) {
+	lc := lifecycle.New(nil)
+	handler := NewHealthHandler(lc, health.NewChecker(time.Second, 0, nil))
+
+	var stopped []string
+	lc.OnStop("first", func(context.Context) error {
+		res := getHealth(handler.Ready, "/readyz")
+		if res.Code != http.StatusServiceUnavailable || !strings.Contains(res.Body.String(), `"status":"draining"`) {
+			t.Errorf("expected readiness to fail during shutdown, got %d", res.Code)
+		}
+
+		stopped = append(stopped, "first")
+		return nil
+	})
+	lc.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		stopped = append(stopped, "worker")
+	})
+
+	if res := getHealth(handler.Ready, "/readyz"); res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected not ready before start, got %d", res.Code)
+	}
+
+	lc.SetReady(true)
+	if res := getHealth(handler.Ready, "/readyz"); res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"status":"ready"`) {
+		t.Fatalf("expected ready, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	if err := lc.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if strings.Join(stopped, ",") != "worker,first" {
+		t.Fatalf("expected hooks to stop newest first, got %v", stopped)
+	}
+	if res := getHealth(handler.Live, "/healthz"); res.Code != http.StatusOK {
+		t.Fatalf("expected liveness to be unaffected by shutdown, got %d", res.Code)
+	}
+}
+
+func TestReadiness_ReportsChecks(t *testing.T) {
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	var logs bytes.Buffer
+	checker := health.NewChecker(50*time.Millisecond, 5*time.Second, func() time.Time { return now }).
+		WithLogger(slog.New(slog.NewJSONHandler(&logs, nil)))
+	checker.Register("database", health.Database(db))
+	checker.Register("schema", func(ctx context.Context) error { return repositories.CheckSchema(ctx, db) })
+	checker.RegisterNonCritical("logs_disk", health.DiskSpace(t.TempDir(), 1))
+	slowRuns := 0
+	checker.Register("slow", func(ctx context.Context) error {
+		slowRuns++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	lc := lifecycle.New(nil)
+	lc.SetReady(true)
+	handler := NewHealthHandler(lc, checker)
+
+	res := getHealth(handler.Ready, "/readyz")
+	if res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable, res.Code)
+	}
+
+	var report readinessResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal report: %v", err)
+	}
+	if report.Status != "unavailable" || len(report.Checks) != 4 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	for _, check := range report.Checks[:3] {
+		if check.Status != health.StatusOK {
+			t.Fatalf("expected %s to pass, got %+v", check.Name, check)
+		}
+	}
+	if slow := report.Checks[3]; slow.Status != health.StatusFail || slow.LatencyMS < 50 {
+		t.Fatalf("expected the slow check to time out after 50ms, got %+v", slow)
+	}
+	if report.CheckedAt == nil || !report.CheckedAt.Equal(now) {
+		t.Fatalf("expected checked_at %s, got %v", now, report.CheckedAt)
+	}
+	if strings.Contains(res.Body.String(), "deadline") || strings.Contains(res.Body.String(), `"error"`) {
+		t.Fatalf("expected no error details in the public report, got %s", res.Body.String())
+	}
+	if !strings.Contains(logs.String(), `"check":"slow"`) || !strings.Contains(logs.String(), "deadline exceeded") {
+		t.Fatalf("expected the failure details to be logged, got %s", logs.String())
+	}
+
+	checkedAt := func(res *httptest.ResponseRecorder) time.Time {
+		var report readinessResponse
+		if err := json.Unmarshal(res.Body.Bytes(), &report); err != nil || report.CheckedAt == nil {
+			t.Fatalf("unmarshal report: %v (%s)", err, res.Body.String())
+		}
+		return *report.CheckedAt
+	}
+
+	started := now
+	now = now.Add(time.Second)
+	if cached := checkedAt(getHealth(handler.Ready, "/readyz")); slowRuns != 1 || !cached.Equal(started) {
+		t.Fatalf("expected the cached report from %s to be reused, got %d runs at %s", started, slowRuns, cached)
+	}
+
+	now = now.Add(5 * time.Second)
+	if fresh := checkedAt(getHealth(handler.Ready, "/readyz")); slowRuns != 2 || !fresh.Equal(now) {
+		t.Fatalf("expected checks to rerun after the cache expired, got %d runs at %s", slowRuns, fresh)
+	}
+}
+
+func TestReadiness_DetectsMissingSchemaAndDisk(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := repositories.CheckSchema(context.Background(), db); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("expected an unmigrated database to fail, got %v", err)
+	}
+	if err := health.DiskSpace(t.TempDir(), 1<<62)(context.Background()); err == nil {
+		t.Fatal("expected the disk check to fail")
+	}
+	if err := health.DiskSpace("/does/not/exist", 1)(context.Background()); err == nil {
+		t.Fatal("expected a missing directory to fail")
+	}
+}
+
+func TestReadiness_DegradedWhenOnlyNonCriticalChecksFail(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	checker := health.NewChecker(time.Second, 0, func() time.Time { return now }).WithLogger(slog.New(slog.DiscardHandler))
+	checker.Register("database", func(context.Context) error { return nil })
+	checker.RegisterNonCritical("logs_disk", func(context.Context) error { return errors.New("3 MiB free in /var/log/api") })
+
+	lc := lifecycle.New(nil)
+	lc.SetReady(true)
+	res := getHealth(NewHealthHandler(lc, checker).Ready, "/readyz")
+
+	var report readinessResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal report: %v", err)
+	}
+	if res.Code != http.StatusOK || report.Status != health.StatusDegraded || len(report.Checks) != 2 ||
+		report.Checks[0].Status != health.StatusOK || report.Checks[1].Status != health.StatusFail {
+		t.Fatalf("expected a degraded but ready report, got %d %s", res.Code, res.Body.String())
+	}
+	if strings.Contains(res.Body.String(), "MiB") || !strings.Contains(res.Body.String(), `"latency_ms":`) || !strings.Contains(res.Body.String(), `"checked_at":"2026-03-01T09:00:00Z"`) {
+		t.Fatalf("expected latency and checked_at but no error text, got %s", res.Body.String())
+	}
+}
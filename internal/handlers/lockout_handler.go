@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type LockoutHandler struct {
+	throttle *usecases.LoginThrottle
+}
+
+func NewLockoutHandler(throttle *usecases.LoginThrottle) *LockoutHandler {
+	return &LockoutHandler{throttle: throttle}
+}
+
+func (h *LockoutHandler) ListLockouts(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := h.throttle.ListLockouts(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToLockoutResponses(lockouts))
+}
+
+func (h *LockoutHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+
+	err := h.throttle.Unlock(r.Context(), chi.URLParam(r, "scope"), chi.URLParam(r, "subject"), principal.Subject)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecases.ErrValidation):
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		case errors.Is(err, usecases.ErrLockoutNotFound):
+			writeError(w, http.StatusNotFound, "LOCKOUT_NOT_FOUND", "no failed logins recorded for subject")
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+	_ "modernc.org/sqlite"
+)
+
+type lockoutTestEnv struct {
+	router   http.Handler
+	now      *time.Time
+	events   *bytes.Buffer
+	db       *sql.DB
+	throttle *usecases.LoginThrottle
+}
+
+func setupLockoutRouter(t *testing.T) lockoutTestEnv {
+	t.Helper()
+
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	events := &bytes.Buffer{}
+	throttle := usecases.NewLoginThrottle(
+		repositories.NewSQLiteLoginAttemptRepository(db),
+		usecases.LoginThrottlePolicy{
+			UsernameThreshold: 3,
+			IPThreshold:       5,
+			BaseLockout:       time.Minute,
+			MaxLockout:        4 * time.Minute,
+			FailureWindow:     15 * time.Minute,
+		},
+		slog.New(slog.NewJSONHandler(events, &slog.HandlerOptions{Level: slog.LevelInfo})),
+		func() time.Time { return now },
+	)
+
+	authHandler := NewAuthHandler("test-secret", time.Hour).WithLoginThrottle(throttle)
+	lockoutHandler := NewLockoutHandler(throttle)
+
+	r := chi.NewRouter()
+	r.Post("/auth/token", authHandler.CreateToken)
+	admin := r.With(middlewares.RequireBearerAuth("test-secret"), middlewares.RequireAdmin([]string{"admin"}))
+	admin.Get("/admin/lockouts", lockoutHandler.ListLockouts)
+	admin.Delete("/admin/lockouts/{scope}/{subject}", lockoutHandler.Unlock)
+
+	return lockoutTestEnv{router: r, now: &now, events: events, db: db, throttle: throttle}
+}
+
+func (env lockoutTestEnv) login(t *testing.T, username, password, remoteAddr string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body := fmt.Sprintf(`{"username":%q,"password":%q}`, username, password)
+	req := httptest.NewRequest(http.MethodPost, "/auth/token", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = remoteAddr
+	res := httptest.NewRecorder()
+	env.router.ServeHTTP(res, req)
+	return res
+}
+
+func TestAuth_LockoutWithExponentialBackoff(t *testing.T) {
+	env := setupLockoutRouter(t)
+
+	for i := 0; i < 3; i++ {
+		if res := env.login(t, "admin", "wrong", "198.51.100.1:1000"); res.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected status %d, got %d", i+1, http.StatusUnauthorized, res.Code)
+		}
+	}
+
+	res := env.login(t, "admin", "password", "198.51.100.2:1000")
+	if res.Code != http.StatusTooManyRequests || res.Header().Get("Retry-After") != "60" {
+		t.Fatalf("expected locked username even with the right password, got %d (Retry-After %q)", res.Code, res.Header().Get("Retry-After"))
+	}
+
+	*env.now = env.now.Add(61 * time.Second)
+	if res := env.login(t, "admin", "wrong", "198.51.100.2:1000"); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status %d after the lockout, got %d", http.StatusUnauthorized, res.Code)
+	}
+	if res := env.login(t, "admin", "password", "198.51.100.2:1000"); res.Header().Get("Retry-After") != "120" {
+		t.Fatalf("expected the next lockout to double, got %d (Retry-After %q)", res.Code, res.Header().Get("Retry-After"))
+	}
+
+	*env.now = env.now.Add(121 * time.Second)
+	if res := env.login(t, "admin", "password", "198.51.100.2:1000"); res.Code != http.StatusOK {
+		t.Fatalf("expected login after the lockout expired, got %d", res.Code)
+	}
+	if res := env.login(t, "admin", "wrong", "198.51.100.2:1000"); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a successful login to reset the username counter, got %d", res.Code)
+	}
+
+	for _, event := range []string{`"level":"INFO","msg":"security event","event":"auth.login_failed"`, `"level":"WARN","msg":"security event","event":"auth.lockout"`, `"level":"WARN","msg":"security event","event":"auth.login_blocked"`, `"scope":"username","subject":"admin"`} {
+		if !strings.Contains(env.events.String(), event) {
+			t.Fatalf("expected security event %s, got %s", event, env.events.String())
+		}
+	}
+	if strings.Contains(env.events.String(), `"level":"ERROR"`) {
+		t.Fatalf("expected routine lockout events below Error, got %s", env.events.String())
+	}
+}
+
+func TestAuth_ExpiredLoginAttemptsAreDeleted(t *testing.T) {
+	env := setupLockoutRouter(t)
+	countAttempts := func() int {
+		var count int
+		if err := env.db.QueryRow(`SELECT count(*) FROM login_attempts`).Scan(&count); err != nil {
+			t.Fatalf("count login attempts: %v", err)
+		}
+		return count
+	}
+
+	for i := 0; i < 3; i++ {
+		env.login(t, "admin", "wrong", "198.51.100.1:1000")
+	}
+	*env.now = env.now.Add(10 * time.Minute)
+	env.login(t, "reader", "wrong", "198.51.100.2:1000")
+	if got := countAttempts(); got != 4 {
+		t.Fatalf("expected username and IP rows for both clients, got %d", got)
+	}
+
+	// The admin lockout ends a minute after the first failures, so their
+	// window is over after another 15 minutes; the reader's is not.
+	*env.now = env.now.Add(7 * time.Minute)
+	if err := env.throttle.DeleteExpired(context.Background()); err != nil {
+		t.Fatalf("delete expired: %v", err)
+	}
+	if got := countAttempts(); got != 2 {
+		t.Fatalf("expected only the recent attempts to remain, got %d", got)
+	}
+
+	*env.now = env.now.Add(15 * time.Minute)
+	if err := env.throttle.DeleteExpired(context.Background()); err != nil {
+		t.Fatalf("delete expired: %v", err)
+	}
+	if got := countAttempts(); got != 0 {
+		t.Fatalf("expected every attempt to be deleted, got %d", got)
+	}
+}
+
+func TestAuth_LockoutPerIP(t *testing.T) {
+	env := setupLockoutRouter(t)
+
+	for i := 0; i < 5; i++ {
+		env.login(t, fmt.Sprintf("user-%d", i), "guess", "198.51.100.7:1000")
+	}
+
+	if res := env.login(t, "admin", "password", "198.51.100.7:1000"); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the IP to be locked, got %d", res.Code)
+	}
+	if res := env.login(t, "admin", "password", "198.51.100.8:1000"); res.Code != http.StatusOK {
+		t.Fatalf("expected other IPs to be unaffected, got %d", res.Code)
+	}
+}
+
+func TestAuth_AdminUnlock(t *testing.T) {
+	env := setupLockoutRouter(t)
+
+	for i := 0; i < 3; i++ {
+		env.login(t, "admin", "wrong", "198.51.100.1:1000")
+	}
+
+	if res := doJSON(t, env.router, http.MethodGet, "/admin/lockouts", userToken(t, "reader"), ""); res.Code != http.StatusForbidden {
+		t.Fatalf("expected non-admins to be rejected, got %d", res.Code)
+	}
+
+	adminToken := userToken(t, "admin")
+	res := doJSON(t, env.router, http.MethodGet, "/admin/lockouts", adminToken, "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.Code)
+	}
+
+	var lockouts []models.LockoutResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &lockouts); err != nil {
+		t.Fatalf("unmarshal lockouts: %v", err)
+	}
+	if len(lockouts) != 1 || lockouts[0].Scope != "username" || lockouts[0].Subject != "admin" || lockouts[0].Failures != 3 {
+		t.Fatalf("unexpected lockouts %+v", lockouts)
+	}
+
+	if res := doJSON(t, env.router, http.MethodDelete, "/admin/lockouts/username/admin", adminToken, ""); res.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, res.Code)
+	}
+	if res := env.login(t, "admin", "password", "198.51.100.1:1000"); res.Code != http.StatusOK {
+		t.Fatalf("expected login after unlock, got %d", res.Code)
+	}
+	if !strings.Contains(env.events.String(), `"event":"auth.unlock","scope":"username","subject":"admin","actor":"admin"`) {
+		t.Fatalf("expected unlock security event, got %s", env.events.String())
+	}
+
+	if res := doJSON(t, env.router, http.MethodDelete, "/admin/lockouts/username/admin", adminToken, ""); res.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, res.Code)
+	}
+	if res := doJSON(t, env.router, http.MethodDelete, "/admin/lockouts/email/admin", adminToken, ""); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, res.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/utils"
+)
+
+type LogLevelHandler struct {
+	levels *utils.LogLevels
+	events *slog.Logger
+}
+
+// NewLogLevelHandler records level changes as security events on events.
+func NewLogLevelHandler(levels *utils.LogLevels, events *slog.Logger) *LogLevelHandler {
+	if events == nil {
+		events = slog.Default()
+	}
+
+	return &LogLevelHandler{levels: levels, events: events}
+}
+
+func (h *LogLevelHandler) GetLevels(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, models.LogLevelsResponse{Levels: h.levels.Snapshot()})
+}
+
+// SetLevel changes one component's level until the next change, SIGHUP or
+// restart.
+func (h *LogLevelHandler) SetLevel(w http.ResponseWriter, r *http.Request) {
+	var req models.LogLevelRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	if err := h.levels.Set(req.Component, req.Level); err != nil {
+		switch {
+		case errors.Is(err, utils.ErrUnknownLogComponent):
+			writeError(w, http.StatusBadRequest, "UNKNOWN_LOG_COMPONENT", err.Error())
+		case errors.Is(err, utils.ErrInvalidLogLevel):
+			writeError(w, http.StatusBadRequest, "INVALID_LOG_LEVEL", err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		}
+		return
+	}
+
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+	h.logChange(r.Context(), principal.Subject, req.Component)
+
+	writeJSON(w, http.StatusOK, models.LogLevelsResponse{Levels: h.levels.Snapshot()})
+}
+
+func (h *LogLevelHandler) logChange(ctx context.Context, actor, component string) {
+	h.events.LogAttrs(ctx, slog.LevelError, "security event",
+		slog.String("event", "admin.log_level_changed"),
+		slog.String("actor", actor),
+		slog.String("component", component),
+		slog.String("level", h.levels.Var(component).Level().String()),
+	)
+}
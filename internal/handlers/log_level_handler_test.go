@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestLogLevel_AdminChangesComponentLevelAtRuntime(t *testing.T) {
+	levels := utils.NewLogLevels(nil)
+	var dbOutput, events bytes.Buffer
+	dbLogger := slog.New(slog.NewJSONHandler(&dbOutput, &slog.HandlerOptions{Level: levels.Var(utils.LogComponentDB)}))
+	h := NewLogLevelHandler(levels, slog.New(slog.NewJSONHandler(&events, nil)))
+
+	r := chi.NewRouter()
+	adminOnly := r.With(middlewares.RequireBearerAuth("test-secret"), middlewares.RequireAdmin([]string{"admin"}))
+	adminOnly.Get("/admin/log-level", h.GetLevels)
+	adminOnly.Put("/admin/log-level", h.SetLevel)
+
+	if res := doJSON(t, r, http.MethodPut, "/admin/log-level", userToken(t, "reader"), `{"component":"db","level":"debug"}`); res.Code != http.StatusForbidden {
+		t.Fatalf("expected non-admins to be rejected, got %d", res.Code)
+	}
+
+	dbLogger.Debug("query")
+	if dbOutput.Len() != 0 {
+		t.Fatalf("expected debug entries to be dropped at the default level, got %s", dbOutput.String())
+	}
+
+	admin := userToken(t, "admin")
+	res := doJSON(t, r, http.MethodPut, "/admin/log-level", admin, `{"component":"db","level":"debug"}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, res.Code, res.Body.String())
+	}
+	var body models.LogLevelsResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal levels: %v", err)
+	}
+	if body.Levels["db"] != "DEBUG" || body.Levels["http"] != "INFO" || len(body.Levels) != 5 {
+		t.Fatalf("unexpected levels %v", body.Levels)
+	}
+
+	dbLogger.Debug("query")
+	if !strings.Contains(dbOutput.String(), `"msg":"query"`) {
+		t.Fatal("expected the existing logger to pick up the new level")
+	}
+	if !strings.Contains(events.String(), `"event":"admin.log_level_changed","actor":"admin","component":"db","level":"DEBUG"`) {
+		t.Fatalf("expected a security event, got %s", events.String())
+	}
+
+	for _, tc := range []struct {
+		body string
+		code string
+	}{
+		{`{"component":"cache","level":"debug"}`, "UNKNOWN_LOG_COMPONENT"},
+		{`{"component":"db","level":"loud"}`, "INVALID_LOG_LEVEL"},
+		{`{"component":"db","level":"debug","extra":1}`, "INVALID_JSON_BODY"},
+	} {
+		res := doJSON(t, r, http.MethodPut, "/admin/log-level", admin, tc.body)
+		if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), tc.code) {
+			t.Fatalf("expected %s for %s, got %d: %s", tc.code, tc.body, res.Code, res.Body.String())
+		}
+	}
+
+	res = doJSON(t, r, http.MethodGet, "/admin/log-level", admin, "")
+	if !strings.Contains(res.Body.String(), `"db":"DEBUG"`) {
+		t.Fatalf("expected rejected changes to leave levels alone, got %s", res.Body.String())
+	}
+
+	levels.Reset()
+	if levels.Var(utils.LogComponentDB).Level() != slog.LevelWarn {
+		t.Fatal("expected reset to restore the starting level")
+	}
+}
+
+func TestLogLevels_ReplaceResetsComponentsItLeavesOut(t *testing.T) {
+	levels := utils.NewLogLevels(nil)
+	if err := levels.Set(utils.LogComponentDB, "debug"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	if err := levels.Replace(map[string]slog.Level{utils.LogComponentHTTP: slog.LevelWarn}); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if levels.Var(utils.LogComponentHTTP).Level() != slog.LevelWarn || levels.Var(utils.LogComponentDB).Level() != slog.LevelWarn {
+		t.Fatalf("expected http from the new levels and db back at its default, got %v", levels.Snapshot())
+	}
+
+	err := levels.Replace(map[string]slog.Level{utils.LogComponentDB: slog.LevelDebug, "cache": slog.LevelDebug})
+	if !errors.Is(err, utils.ErrUnknownLogComponent) {
+		t.Fatalf("expected an unknown component to be rejected, got %v", err)
+	}
+	if levels.Var(utils.LogComponentHTTP).Level() != slog.LevelWarn || levels.Var(utils.LogComponentDB).Level() != slog.LevelWarn {
+		t.Fatalf("expected a rejected replace to change nothing, got %v", levels.Snapshot())
+	}
+}
+
+func TestParseLogLevels(t *testing.T) {
+	levels, err := utils.ParseLogLevels("http=warn, DB=debug\n# comment\n\nauth=info")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if levels["http"] != slog.LevelWarn || levels["db"] != slog.LevelDebug || levels["auth"] != slog.LevelInfo {
+		t.Fatalf("unexpected levels %v", levels)
+	}
+
+	for _, spec := range []string{"http", "cache=info", "db=loud"} {
+		if _, err := utils.ParseLogLevels(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+)
+
+type MFAHandler struct {
+	mfa *usecases.MFA
+}
+
+func NewMFAHandler(mfa *usecases.MFA) *MFAHandler {
+	return &MFAHandler{mfa: mfa}
+}
+
+func (h *MFAHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+
+	status, err := h.mfa.Status(r.Context(), principal.Subject)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.MFAStatusResponse{
+		Enabled:                status.Enabled,
+		Pending:                status.Pending,
+		RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+	})
+}
+
+func (h *MFAHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+
+	setup, err := h.mfa.Enroll(r.Context(), principal.Subject)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.MFASetupResponse{Secret: setup.Secret, OTPAuthURI: setup.OTPAuthURI})
+}
+
+func (h *MFAHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	var req models.MFACodeRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+
+	codes, err := h.mfa.Confirm(r.Context(), principal.Subject, req.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req models.MFACodeRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+
+	codes, err := h.mfa.RegenerateRecoveryCodes(r.Context(), principal.Subject, req.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *MFAHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	var req models.MFACodeRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+
+	if err := h.mfa.Disable(r.Context(), principal.Subject, req.Code); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeMFAError(w http.ResponseWriter, err error) {
+	status, code, message := mapMFAError(err)
+	writeError(w, status, code, message)
+}
+
+func mapMFAError(err error) (int, string, string) {
+	var lockedErr *usecases.LoginLockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		return http.StatusTooManyRequests, "LOGIN_LOCKED", "too many failed logins, try again later"
+	case errors.Is(err, usecases.ErrValidation):
+		return http.StatusBadRequest, "VALIDATION_ERROR", err.Error()
+	case errors.Is(err, usecases.ErrMFAAlreadyEnabled):
+		return http.StatusConflict, "MFA_ALREADY_ENABLED", "multi-factor authentication is already enabled"
+	case errors.Is(err, usecases.ErrMFANotEnrolled):
+		return http.StatusNotFound, "MFA_NOT_ENROLLED", "multi-factor authentication is not enrolled"
+	case errors.Is(err, usecases.ErrInvalidMFACode):
+		return http.StatusUnauthorized, "INVALID_MFA_CODE", "invalid verification code"
+	case errors.Is(err, usecases.ErrInvalidMFAChallenge):
+		return http.StatusUnauthorized, "INVALID_MFA_CHALLENGE", "mfa challenge is invalid or expired"
+	default:
+		return http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error"
+	}
+}
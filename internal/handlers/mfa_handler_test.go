@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/usecases"
+	"desent-api/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type mfaTestEnv struct {
+	router http.Handler
+	now    *time.Time
+	events *bytes.Buffer
+}
+
+func setupMFARouter(t *testing.T) mfaTestEnv {
+	t.Helper()
+
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	box, err := utils.NewSecretBox("test-key")
+	if err != nil {
+		t.Fatalf("new secret box: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	nowFunc := func() time.Time { return now }
+	buf := &bytes.Buffer{}
+	events := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	throttle := usecases.NewLoginThrottle(
+		repositories.NewSQLiteLoginAttemptRepository(db),
+		usecases.LoginThrottlePolicy{UsernameThreshold: 20, IPThreshold: 50, BaseLockout: time.Minute, MaxLockout: time.Hour, FailureWindow: time.Hour},
+		events,
+		nowFunc,
+	)
+	mfa := usecases.NewMFA(
+		repositories.NewSQLiteMFARepository(db),
+		box,
+		usecases.MFAPolicy{Issuer: "desent-api", ChallengeTTL: 5 * time.Minute, MaxChallengeAttempts: 3},
+		events,
+		nowFunc,
+	).WithLoginThrottle(throttle)
+
+	authHandler := NewAuthHandler("test-secret", time.Hour).WithLoginThrottle(throttle).WithMFA(mfa)
+	mfaHandler := NewMFAHandler(mfa)
+	lockoutHandler := NewLockoutHandler(throttle)
+
+	r := chi.NewRouter()
+	r.Post("/auth/token", authHandler.CreateToken)
+	r.Post("/auth/token/mfa", authHandler.CompleteMFA)
+	requireAuth := middlewares.RequireBearerAuth("test-secret")
+	r.With(requireAuth).Get("/auth/mfa", mfaHandler.GetStatus)
+	r.With(requireAuth).Post("/auth/mfa/enroll", mfaHandler.Enroll)
+	r.With(requireAuth).Post("/auth/mfa/confirm", mfaHandler.Confirm)
+	r.With(requireAuth).Delete("/auth/mfa", mfaHandler.Disable)
+	r.With(requireAuth, middlewares.RequireAdmin([]string{"admin"}), middlewares.RequireMFA()).
+		Get("/admin/lockouts", lockoutHandler.ListLockouts)
+
+	return mfaTestEnv{router: r, now: &now, events: buf}
+}
+
+// enroll enables MFA for admin and returns the TOTP secret and recovery codes.
+func (env mfaTestEnv) enroll(t *testing.T) (string, []string) {
+	t.Helper()
+
+	token := userToken(t, "admin")
+	res := doJSON(t, env.router, http.MethodPost, "/auth/mfa/enroll", token, "")
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusCreated, res.Code, res.Body.String())
+	}
+
+	var setup models.MFASetupResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &setup); err != nil {
+		t.Fatalf("unmarshal setup: %v", err)
+	}
+	if !strings.HasPrefix(setup.OTPAuthURI, "otpauth://totp/desent-api:admin?") || !strings.Contains(setup.OTPAuthURI, "secret="+setup.Secret) {
+		t.Fatalf("unexpected otpauth uri %q", setup.OTPAuthURI)
+	}
+
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/mfa/confirm", token, `{"code":"000000"}`); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a wrong confirmation code to be rejected, got %d", res.Code)
+	}
+
+	res = doJSON(t, env.router, http.MethodPost, "/auth/mfa/confirm", token, fmt.Sprintf(`{"code":%q}`, env.code(t, setup.Secret)))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	var recovery models.MFARecoveryCodesResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &recovery); err != nil {
+		t.Fatalf("unmarshal recovery codes: %v", err)
+	}
+	if len(recovery.RecoveryCodes) != 10 {
+		t.Fatalf("expected 10 recovery codes, got %v", recovery.RecoveryCodes)
+	}
+
+	return setup.Secret, recovery.RecoveryCodes
+}
+
+func (env mfaTestEnv) code(t *testing.T, secret string) string {
+	t.Helper()
+
+	code, err := utils.TOTPCode(secret, *env.now)
+	if err != nil {
+		t.Fatalf("totp code: %v", err)
+	}
+	return code
+}
+
+func (env mfaTestEnv) challenge(t *testing.T) string {
+	t.Helper()
+
+	res := doJSON(t, env.router, http.MethodPost, "/auth/token", "", `{"username":"admin","password":"password"}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.Code)
+	}
+
+	var token models.TokenResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &token); err != nil {
+		t.Fatalf("unmarshal token response: %v", err)
+	}
+	if !token.MFARequired || token.ChallengeToken == "" || token.Token != "" || token.ExpiresIn != 300 {
+		t.Fatalf("expected an mfa challenge instead of a token, got %s", res.Body.String())
+	}
+
+	return token.ChallengeToken
+}
+
+func TestMFA_LoginRequiresSecondFactor(t *testing.T) {
+	env := setupMFARouter(t)
+
+	if res := doJSON(t, env.router, http.MethodGet, "/admin/lockouts", userToken(t, "admin"), ""); res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), "MFA_REQUIRED") {
+		t.Fatalf("expected a password-only token to be refused on admin routes, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	secret, _ := env.enroll(t)
+	challenge := env.challenge(t)
+
+	body := fmt.Sprintf(`{"challenge_token":%q,"code":%q}`, challenge, env.code(t, secret))
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/token/mfa", "", body); res.Code != http.StatusUnauthorized || !strings.Contains(res.Body.String(), "INVALID_MFA_CODE") {
+		t.Fatalf("expected the code used for confirmation to be rejected as a replay, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	*env.now = env.now.Add(utils.TOTPPeriod)
+	body = fmt.Sprintf(`{"challenge_token":%q,"code":%q}`, challenge, env.code(t, secret))
+	res := doJSON(t, env.router, http.MethodPost, "/auth/token/mfa", "", body)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	var token models.TokenResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &token); err != nil {
+		t.Fatalf("unmarshal token response: %v", err)
+	}
+	claims, err := utils.ParseToken(token.Token, "test-secret")
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	if strings.Join(claims.AuthMethods, ",") != "pwd,otp" {
+		t.Fatalf("expected amr [pwd otp], got %v", claims.AuthMethods)
+	}
+
+	if res := doJSON(t, env.router, http.MethodGet, "/admin/lockouts", token.Token, ""); res.Code != http.StatusOK {
+		t.Fatalf("expected an mfa token to reach admin routes, got %d", res.Code)
+	}
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/token/mfa", "", body); res.Code != http.StatusUnauthorized || !strings.Contains(res.Body.String(), "INVALID_MFA_CHALLENGE") {
+		t.Fatalf("expected a completed challenge to be single use, got %d (%s)", res.Code, res.Body.String())
+	}
+}
+
+func TestMFA_RecoveryCodesAreSingleUse(t *testing.T) {
+	env := setupMFARouter(t)
+	_, recoveryCodes := env.enroll(t)
+
+	body := fmt.Sprintf(`{"challenge_token":%q,"recovery_code":%q}`, env.challenge(t), strings.ToUpper(recoveryCodes[0]))
+	res := doJSON(t, env.router, http.MethodPost, "/auth/token/mfa", "", body)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	body = fmt.Sprintf(`{"challenge_token":%q,"recovery_code":%q}`, env.challenge(t), recoveryCodes[0])
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/token/mfa", "", body); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a used recovery code to be rejected, got %d", res.Code)
+	}
+
+	res = doJSON(t, env.router, http.MethodGet, "/auth/mfa", userToken(t, "admin"), "")
+	if !strings.Contains(res.Body.String(), `"enabled":true`) || !strings.Contains(res.Body.String(), `"recovery_codes_remaining":9`) {
+		t.Fatalf("unexpected mfa status %s", res.Body.String())
+	}
+
+	for _, event := range []string{`"level":"INFO","msg":"security event","event":"auth.mfa_enabled"`, `"level":"WARN","msg":"security event","event":"auth.recovery_code_used"`, `"level":"WARN","msg":"security event","event":"auth.mfa_failed"`} {
+		if !strings.Contains(env.events.String(), event) {
+			t.Fatalf("expected security event %s, got %s", event, env.events.String())
+		}
+	}
+}
+
+func TestMFA_ChallengeExpiresAfterFailedAttempts(t *testing.T) {
+	env := setupMFARouter(t)
+	secret, _ := env.enroll(t)
+	challenge := env.challenge(t)
+
+	for i := 0; i < 3; i++ {
+		body := fmt.Sprintf(`{"challenge_token":%q,"code":"000000"}`, challenge)
+		if res := doJSON(t, env.router, http.MethodPost, "/auth/token/mfa", "", body); res.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected status %d, got %d", i+1, http.StatusUnauthorized, res.Code)
+		}
+	}
+
+	*env.now = env.now.Add(utils.TOTPPeriod)
+	body := fmt.Sprintf(`{"challenge_token":%q,"code":%q}`, challenge, env.code(t, secret))
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/token/mfa", "", body); !strings.Contains(res.Body.String(), "INVALID_MFA_CHALLENGE") {
+		t.Fatalf("expected the challenge to be discarded, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	challenge = env.challenge(t)
+	*env.now = env.now.Add(6 * time.Minute)
+	body = fmt.Sprintf(`{"challenge_token":%q,"code":%q}`, challenge, env.code(t, secret))
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/token/mfa", "", body); !strings.Contains(res.Body.String(), "INVALID_MFA_CHALLENGE") {
+		t.Fatalf("expected an expired challenge to be rejected, got %d (%s)", res.Code, res.Body.String())
+	}
+}
+
+func TestMFA_Disable(t *testing.T) {
+	env := setupMFARouter(t)
+	secret, _ := env.enroll(t)
+	token := userToken(t, "admin")
+
+	if res := doJSON(t, env.router, http.MethodPost, "/auth/mfa/enroll", token, ""); res.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, res.Code)
+	}
+	if res := doJSON(t, env.router, http.MethodDelete, "/auth/mfa", token, `{"code":"000000"}`); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a wrong code to be rejected, got %d", res.Code)
+	}
+
+	*env.now = env.now.Add(utils.TOTPPeriod)
+	if res := doJSON(t, env.router, http.MethodDelete, "/auth/mfa", token, fmt.Sprintf(`{"code":%q}`, env.code(t, secret))); res.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusNoContent, res.Code, res.Body.String())
+	}
+
+	res := doJSON(t, env.router, http.MethodPost, "/auth/token", "", `{"username":"admin","password":"password"}`)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"token":"`) {
+		t.Fatalf("expected a token without mfa, got %d (%s)", res.Code, res.Body.String())
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type OAuthClientHandler struct {
+	server *usecases.OAuthServer
+}
+
+func NewOAuthClientHandler(server *usecases.OAuthServer) *OAuthClientHandler {
+	return &OAuthClientHandler{server: server}
+}
+
+func (h *OAuthClientHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateOAuthClientRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	client, secret, err := h.server.RegisterClient(r.Context(), req)
+	if err != nil {
+		status, code, message := mapOAuthClientError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.ToOAuthClientResponse(client, secret))
+}
+
+func (h *OAuthClientHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.server.ListClients(r.Context())
+	if err != nil {
+		status, code, message := mapOAuthClientError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToOAuthClientResponses(clients))
+}
+
+func (h *OAuthClientHandler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	if err := h.server.DeleteClient(r.Context(), chi.URLParam(r, "clientID")); err != nil {
+		status, code, message := mapOAuthClientError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func mapOAuthClientError(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, usecases.ErrValidation):
+		return http.StatusBadRequest, "VALIDATION_ERROR", err.Error()
+	case errors.Is(err, usecases.ErrOAuthClientNotFound):
+		return http.StatusNotFound, "OAUTH_CLIENT_NOT_FOUND", "oauth client not found"
+	default:
+		return http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error"
+	}
+}
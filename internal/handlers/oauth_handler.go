@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"desent-api/internal/metrics"
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+)
+
+const maxOAuthFormBytes = 64 << 10
+
+type OAuthHandler struct {
+	server *usecases.OAuthServer
+}
+
+func NewOAuthHandler(server *usecases.OAuthServer) *OAuthHandler {
+	return &OAuthHandler{server: server}
+}
+
+// Token implements the RFC 6749 token endpoint for the client_credentials
+// and password grants.
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if !parseOAuthForm(w, r) {
+		return
+	}
+
+	clientID, clientSecret := oauthClientCredentials(r)
+	token, err := h.server.Token(r.Context(), models.OAuthTokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scope:        r.PostForm.Get("scope"),
+		Username:     r.PostForm.Get("username"),
+		Password:     r.PostForm.Get("password"),
+		ClientIP:     middlewares.ClientIP(r),
+		UserAgent:    r.UserAgent(),
+	})
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	writeOAuthJSON(w, http.StatusOK, models.OAuthTokenResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(token.ExpiresIn.Seconds()),
+		Scope:       token.Scope,
+	})
+}
+
+// Introspect implements RFC 7662 for clients registered with this API.
+func (h *OAuthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	if !parseOAuthForm(w, r) {
+		return
+	}
+
+	clientID, clientSecret := oauthClientCredentials(r)
+	introspection, err := h.server.Introspect(r.Context(), clientID, clientSecret, r.PostForm.Get("token"))
+	if err != nil {
+		writeOAuthError(w, err)
+		return
+	}
+
+	writeOAuthJSON(w, http.StatusOK, models.ToOAuthIntrospectionResponse(introspection))
+}
+
+func parseOAuthForm(w http.ResponseWriter, r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		writeOAuthJSON(w, http.StatusBadRequest, models.OAuthErrorResponse{
+			Error:            usecases.OAuthInvalidRequest,
+			ErrorDescription: "request body must be application/x-www-form-urlencoded",
+		})
+		return false
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxOAuthFormBytes)
+	if err := r.ParseForm(); err != nil {
+		writeOAuthJSON(w, http.StatusBadRequest, models.OAuthErrorResponse{
+			Error:            usecases.OAuthInvalidRequest,
+			ErrorDescription: "invalid form body",
+		})
+		return false
+	}
+
+	return true
+}
+
+// oauthClientCredentials reads client_secret_basic credentials, which are
+// form-encoded inside the Basic header (RFC 6749 section 2.3.1), falling
+// back to client_id and client_secret in the body.
+func oauthClientCredentials(r *http.Request) (string, string) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		decodedID, idErr := url.QueryUnescape(id)
+		decodedSecret, secretErr := url.QueryUnescape(secret)
+		if idErr == nil && secretErr == nil {
+			return decodedID, decodedSecret
+		}
+
+		return id, secret
+	}
+
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+}
+
+func writeOAuthError(w http.ResponseWriter, err error) {
+	var lockedErr *usecases.LoginLockedError
+	if errors.As(err, &lockedErr) {
+		metrics.AuthFailures.Inc(metrics.AuthFailureLocked)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		writeOAuthJSON(w, http.StatusTooManyRequests, models.OAuthErrorResponse{
+			Error:            usecases.OAuthInvalidGrant,
+			ErrorDescription: "too many failed logins, try again later",
+		})
+		return
+	}
+
+	var oauthErr *usecases.OAuthError
+	if !errors.As(err, &oauthErr) {
+		writeOAuthJSON(w, http.StatusInternalServerError, models.OAuthErrorResponse{Error: "server_error"})
+		return
+	}
+
+	status := http.StatusBadRequest
+	switch oauthErr.Code {
+	case usecases.OAuthInvalidGrant:
+		metrics.AuthFailures.Inc(metrics.AuthFailureInvalidCredentials)
+	case usecases.OAuthInvalidClient:
+		metrics.AuthFailures.Inc(metrics.AuthFailureInvalidClient)
+		status = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	writeOAuthJSON(w, status, models.OAuthErrorResponse{Error: oauthErr.Code, ErrorDescription: oauthErr.Description})
+}
+
+// writeOAuthJSON adds the no-store headers RFC 6749 requires on responses
+// that carry tokens.
+func writeOAuthJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupOAuthRouter(t *testing.T) *chi.Mux {
+	t.Helper()
+
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	server := usecases.NewOAuthServer(repositories.NewSQLiteOAuthClientRepository(db), "test-secret", time.Hour, nil)
+	oauthHandler := NewOAuthHandler(server)
+	clientHandler := NewOAuthClientHandler(server)
+
+	r := chi.NewRouter()
+	r.Post("/oauth/token", oauthHandler.Token)
+	r.Post("/oauth/introspect", oauthHandler.Introspect)
+	admin := r.With(middlewares.RequireBearerAuth("test-secret"), middlewares.RequireFirstPartyToken(), middlewares.RequireAdmin([]string{"admin"}))
+	admin.Post("/admin/oauth/clients", clientHandler.CreateClient)
+	admin.Get("/admin/oauth/clients", clientHandler.ListClients)
+	admin.Delete("/admin/oauth/clients/{clientID}", clientHandler.DeleteClient)
+	r.With(middlewares.RequireBearerAuth("test-secret"), middlewares.RequireScope(models.ScopeBooksWrite)).
+		Post("/books/import", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	return r
+}
+
+func registerOAuthClient(t *testing.T, r http.Handler, body string) models.OAuthClientResponse {
+	t.Helper()
+
+	res := doJSON(t, r, http.MethodPost, "/admin/oauth/clients", userToken(t, "admin"), body)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusCreated, res.Code, res.Body.String())
+	}
+
+	var client models.OAuthClientResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &client); err != nil {
+		t.Fatalf("unmarshal client: %v", err)
+	}
+	if client.ClientID == "" || client.ClientSecret == "" {
+		t.Fatalf("expected client credentials, got %+v", client)
+	}
+
+	return client
+}
+
+func postForm(t *testing.T, r http.Handler, target string, form url.Values, clientID, clientSecret string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	return res
+}
+
+func decodeOAuthToken(t *testing.T, res *httptest.ResponseRecorder) models.OAuthTokenResponse {
+	t.Helper()
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusOK, res.Code, res.Body.String())
+	}
+	if res.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("expected Cache-Control no-store, got %q", res.Header().Get("Cache-Control"))
+	}
+
+	var token models.OAuthTokenResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &token); err != nil {
+		t.Fatalf("unmarshal token: %v", err)
+	}
+	return token
+}
+
+func TestOAuth_ClientCredentialsGrant(t *testing.T) {
+	r := setupOAuthRouter(t)
+	client := registerOAuthClient(t, r, `{"name":"indexer","scopes":["books:read","books:write"]}`)
+
+	res := postForm(t, r, "/oauth/token", url.Values{"grant_type": {"client_credentials"}}, client.ClientID, client.ClientSecret)
+	token := decodeOAuthToken(t, res)
+	if token.TokenType != "Bearer" || token.ExpiresIn != 3600 || token.Scope != "books:read books:write" {
+		t.Fatalf("unexpected token response %+v", token)
+	}
+
+	if res := doJSON(t, r, http.MethodPost, "/books/import", token.AccessToken, ""); res.Code != http.StatusNoContent {
+		t.Fatalf("expected a books:write token to pass, got %d", res.Code)
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}, "scope": {"books:read"}, "client_id": {client.ClientID}, "client_secret": {client.ClientSecret}}
+	readOnly := decodeOAuthToken(t, postForm(t, r, "/oauth/token", form, "", ""))
+	if readOnly.Scope != "books:read" {
+		t.Fatalf("expected the requested scope, got %q", readOnly.Scope)
+	}
+	if res := doJSON(t, r, http.MethodPost, "/books/import", readOnly.AccessToken, ""); res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), "INSUFFICIENT_SCOPE") {
+		t.Fatalf("expected a books:read token to be refused, got %d (%s)", res.Code, res.Body.String())
+	}
+	if res := doJSON(t, r, http.MethodPost, "/books/import", userToken(t, "reader"), ""); res.Code != http.StatusNoContent {
+		t.Fatalf("expected first-party tokens to be unscoped, got %d", res.Code)
+	}
+}
+
+func TestOAuth_TokenErrors(t *testing.T) {
+	r := setupOAuthRouter(t)
+	client := registerOAuthClient(t, r, `{"name":"indexer","scopes":["books:read"]}`)
+
+	tests := []struct {
+		name   string
+		form   url.Values
+		id     string
+		secret string
+		status int
+		error  string
+	}{
+		{name: "wrong secret", form: url.Values{"grant_type": {"client_credentials"}}, id: client.ClientID, secret: "nope", status: http.StatusUnauthorized, error: "invalid_client"},
+		{name: "no client", form: url.Values{"grant_type": {"client_credentials"}}, status: http.StatusUnauthorized, error: "invalid_client"},
+		{name: "unknown grant", form: url.Values{"grant_type": {"authorization_code"}}, id: client.ClientID, secret: client.ClientSecret, status: http.StatusBadRequest, error: "unsupported_grant_type"},
+		{name: "grant not allowed", form: url.Values{"grant_type": {"password"}, "username": {"admin"}, "password": {"password"}}, id: client.ClientID, secret: client.ClientSecret, status: http.StatusBadRequest, error: "unauthorized_client"},
+		{name: "scope not allowed", form: url.Values{"grant_type": {"client_credentials"}, "scope": {"books:write"}}, id: client.ClientID, secret: client.ClientSecret, status: http.StatusBadRequest, error: "invalid_scope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := postForm(t, r, "/oauth/token", tt.form, tt.id, tt.secret)
+			if res.Code != tt.status || !strings.Contains(res.Body.String(), `"error":"`+tt.error+`"`) {
+				t.Fatalf("expected %d %s, got %d (%s)", tt.status, tt.error, res.Code, res.Body.String())
+			}
+		})
+	}
+
+	res := doJSON(t, r, http.MethodPost, "/oauth/token", "", `{"grant_type":"client_credentials"}`)
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "invalid_request") {
+		t.Fatalf("expected JSON bodies to be rejected, got %d (%s)", res.Code, res.Body.String())
+	}
+}
+
+func TestOAuth_PasswordGrantAndIntrospection(t *testing.T) {
+	r := setupOAuthRouter(t)
+	client := registerOAuthClient(t, r, `{"name":"portal","scopes":["lists:write"],"grant_types":["password","client_credentials"]}`)
+
+	form := url.Values{"grant_type": {"password"}, "username": {"admin"}, "password": {"wrong"}}
+	if res := postForm(t, r, "/oauth/token", form, client.ClientID, client.ClientSecret); res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "invalid_grant") {
+		t.Fatalf("expected invalid_grant, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	form.Set("password", "password")
+	token := decodeOAuthToken(t, postForm(t, r, "/oauth/token", form, client.ClientID, client.ClientSecret))
+
+	res := postForm(t, r, "/oauth/introspect", url.Values{"token": {token.AccessToken}}, client.ClientID, client.ClientSecret)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusOK, res.Code, res.Body.String())
+	}
+
+	var introspection models.OAuthIntrospectionResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &introspection); err != nil {
+		t.Fatalf("unmarshal introspection: %v", err)
+	}
+	if !introspection.Active || introspection.Username != "admin" || introspection.ClientID != client.ClientID || introspection.Scope != "lists:write" || introspection.ExpiresAt == 0 {
+		t.Fatalf("unexpected introspection %+v", introspection)
+	}
+
+	res = postForm(t, r, "/oauth/introspect", url.Values{"token": {"not-a-token"}}, client.ClientID, client.ClientSecret)
+	if res.Code != http.StatusOK || strings.TrimSpace(res.Body.String()) != `{"active":false}` {
+		t.Fatalf("expected an inactive token, got %d (%s)", res.Code, res.Body.String())
+	}
+
+	if res := postForm(t, r, "/oauth/introspect", url.Values{"token": {token.AccessToken}}, "", ""); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected introspection to require client authentication, got %d", res.Code)
+	}
+
+	if res := doJSON(t, r, http.MethodDelete, "/admin/oauth/clients/"+client.ClientID, userToken(t, "admin"), ""); res.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, res.Code)
+	}
+	if res := postForm(t, r, "/oauth/token", url.Values{"grant_type": {"client_credentials"}}, client.ClientID, client.ClientSecret); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a deleted client to be rejected, got %d", res.Code)
+	}
+}
+
+func TestOAuth_ClientTokensCannotReachAccountMFAOrAdminRoutes(t *testing.T) {
+	r := setupOAuthRouter(t)
+	accountOnly := r.With(middlewares.RequireBearerAuth("test-secret"), middlewares.RequireFirstPartyToken())
+	adminOnly := accountOnly.With(middlewares.RequireAdmin([]string{"admin"}))
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	routes := []struct{ method, path string }{
+		{http.MethodGet, "/me"},
+		{http.MethodPut, "/me/email"},
+		{http.MethodGet, "/me/sessions"},
+		{http.MethodDelete, "/me/sessions/1"},
+		{http.MethodGet, "/auth/mfa"},
+		{http.MethodPost, "/auth/mfa/enroll"},
+		{http.MethodPost, "/auth/mfa/confirm"},
+		{http.MethodPost, "/auth/mfa/recovery-codes"},
+		{http.MethodDelete, "/auth/mfa"},
+	}
+	for _, route := range routes {
+		accountOnly.MethodFunc(route.method, route.path, ok)
+	}
+	adminOnly.Get("/admin/lockouts", ok)
+	adminOnly.Put("/admin/log-level", ok)
+	routes = append(routes, struct{ method, path string }{http.MethodGet, "/admin/lockouts"}, struct{ method, path string }{http.MethodPut, "/admin/log-level"})
+
+	client := registerOAuthClient(t, r, `{"name":"reader","scopes":["books:read"],"grant_types":["password","client_credentials"]}`)
+	password := decodeOAuthToken(t, postForm(t, r, "/oauth/token", url.Values{"grant_type": {"password"}, "username": {"admin"}, "password": {"password"}}, client.ClientID, client.ClientSecret))
+	clientCredentials := decodeOAuthToken(t, postForm(t, r, "/oauth/token", url.Values{"grant_type": {"client_credentials"}}, client.ClientID, client.ClientSecret))
+
+	for _, route := range routes {
+		for _, token := range []string{password.AccessToken, clientCredentials.AccessToken} {
+			res := doJSON(t, r, route.method, route.path, token, "")
+			if res.Code != http.StatusForbidden || !strings.Contains(res.Body.String(), "FIRST_PARTY_TOKEN_REQUIRED") {
+				t.Fatalf("expected %s %s to reject OAuth client tokens, got %d (%s)", route.method, route.path, res.Code, res.Body.String())
+			}
+		}
+		if res := doJSON(t, r, route.method, route.path, userToken(t, "admin"), ""); res.Code != http.StatusNoContent {
+			t.Fatalf("expected %s %s to accept first-party tokens, got %d", route.method, route.path, res.Code)
+		}
+	}
+}
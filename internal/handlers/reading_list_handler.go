@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ReadingListHandler struct {
+	createUsecase      *usecases.CreateReadingListUsecase
+	getUsecase         *usecases.GetReadingListUsecase
+	listByUserUsecase  *usecases.ListUserReadingListsUsecase
+	updateUsecase      *usecases.UpdateReadingListUsecase
+	deleteUsecase      *usecases.DeleteReadingListUsecase
+	addEntryUsecase    *usecases.AddReadingListEntryUsecase
+	removeEntryUsecase *usecases.RemoveReadingListEntryUsecase
+	reorderUsecase     *usecases.ReorderReadingListUsecase
+}
+
+func NewReadingListHandler(
+	createUsecase *usecases.CreateReadingListUsecase,
+	getUsecase *usecases.GetReadingListUsecase,
+	listByUserUsecase *usecases.ListUserReadingListsUsecase,
+	updateUsecase *usecases.UpdateReadingListUsecase,
+	deleteUsecase *usecases.DeleteReadingListUsecase,
+	addEntryUsecase *usecases.AddReadingListEntryUsecase,
+	removeEntryUsecase *usecases.RemoveReadingListEntryUsecase,
+	reorderUsecase *usecases.ReorderReadingListUsecase,
+) *ReadingListHandler {
+	return &ReadingListHandler{
+		createUsecase:      createUsecase,
+		getUsecase:         getUsecase,
+		listByUserUsecase:  listByUserUsecase,
+		updateUsecase:      updateUsecase,
+		deleteUsecase:      deleteUsecase,
+		addEntryUsecase:    addEntryUsecase,
+		removeEntryUsecase: removeEntryUsecase,
+		reorderUsecase:     reorderUsecase,
+	}
+}
+
+func (h *ReadingListHandler) CreateReadingList(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "unauthorized")
+		return
+	}
+
+	var req models.CreateReadingListRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	list, err := h.createUsecase.Execute(r.Context(), principal.Subject, req)
+	if err != nil {
+		status, code, message := mapReadingListError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.ToReadingListResponse(list))
+}
+
+func (h *ReadingListHandler) GetReadingList(w http.ResponseWriter, r *http.Request) {
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+
+	list, err := h.getUsecase.Execute(r.Context(), principal.Subject, chi.URLParam(r, "id"))
+	if err != nil {
+		status, code, message := mapReadingListError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToReadingListResponse(list))
+}
+
+func (h *ReadingListHandler) ListUserReadingLists(w http.ResponseWriter, r *http.Request) {
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+
+	lists, err := h.listByUserUsecase.Execute(r.Context(), principal.Subject, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		return
+	}
+
+	response := make([]models.ReadingListSummaryResponse, 0, len(lists))
+	for _, list := range lists {
+		response = append(response, models.ToReadingListSummaryResponse(list))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *ReadingListHandler) UpdateReadingList(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "unauthorized")
+		return
+	}
+
+	var req models.CreateReadingListRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	list, err := h.updateUsecase.Execute(r.Context(), principal.Subject, chi.URLParam(r, "id"), req)
+	if err != nil {
+		status, code, message := mapReadingListError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToReadingListResponse(list))
+}
+
+func (h *ReadingListHandler) DeleteReadingList(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "unauthorized")
+		return
+	}
+
+	if err := h.deleteUsecase.Execute(r.Context(), principal.Subject, chi.URLParam(r, "id")); err != nil {
+		status, code, message := mapReadingListError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ReadingListHandler) AddReadingListEntry(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "unauthorized")
+		return
+	}
+
+	var req models.AddReadingListEntryRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	list, err := h.addEntryUsecase.Execute(r.Context(), principal.Subject, chi.URLParam(r, "id"), req)
+	if err != nil {
+		status, code, message := mapReadingListError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToReadingListResponse(list))
+}
+
+func (h *ReadingListHandler) RemoveReadingListEntry(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "unauthorized")
+		return
+	}
+
+	if err := h.removeEntryUsecase.Execute(r.Context(), principal.Subject, chi.URLParam(r, "id"), chi.URLParam(r, "bookID")); err != nil {
+		status, code, message := mapReadingListError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ReadingListHandler) ReorderReadingList(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middlewares.PrincipalFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "unauthorized")
+		return
+	}
+
+	var req models.ReorderReadingListRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_JSON_BODY", "invalid JSON body")
+		return
+	}
+
+	list, err := h.reorderUsecase.Execute(r.Context(), principal.Subject, chi.URLParam(r, "id"), req)
+	if err != nil {
+		status, code, message := mapReadingListError(err)
+		writeError(w, status, code, message)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToReadingListResponse(list))
+}
+
+func mapReadingListError(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, usecases.ErrReadingListNotFound):
+		return http.StatusNotFound, "READING_LIST_NOT_FOUND", "reading list not found"
+	case errors.Is(err, usecases.ErrReadingListForbidden):
+		return http.StatusForbidden, "FORBIDDEN", "reading list belongs to another user"
+	case errors.Is(err, usecases.ErrReadingListEntryNotFound):
+		return http.StatusNotFound, "READING_LIST_ENTRY_NOT_FOUND", "book is not in reading list"
+	case errors.Is(err, usecases.ErrReadingListEntryExists):
+		return http.StatusConflict, "READING_LIST_ENTRY_EXISTS", "book is already in reading list"
+	default:
+		return mapBookError(err)
+	}
+}
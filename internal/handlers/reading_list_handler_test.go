@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/usecases"
+	"desent-api/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	_ "modernc.org/sqlite"
+)
+
+func setupReadingListsRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	dbPath := t.TempDir() + "/books.db"
+	db, err := sql.Open("sqlite", "file:"+dbPath)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	if err := repositories.InitBooksSchema(context.Background(), db); err != nil {
+		t.Fatalf("init books schema: %v", err)
+	}
+
+	if err := repositories.InitReadingListsSchema(context.Background(), db); err != nil {
+		t.Fatalf("init reading lists schema: %v", err)
+	}
+
+	bookRepo := repositories.NewSQLiteBookRepository(db)
+	bookHandler := NewBookHandler(
+		usecases.NewCreateBookUsecase(bookRepo),
+		usecases.NewListBooksUsecase(bookRepo),
+		usecases.NewGetBookUsecase(bookRepo),
+		usecases.NewUpdateBookUsecase(bookRepo),
+		usecases.NewDeleteBookUsecase(bookRepo),
+	)
+
+	listRepo := repositories.NewSQLiteReadingListRepository(db)
+	h := NewReadingListHandler(
+		usecases.NewCreateReadingListUsecase(listRepo),
+		usecases.NewGetReadingListUsecase(listRepo),
+		usecases.NewListUserReadingListsUsecase(listRepo),
+		usecases.NewUpdateReadingListUsecase(listRepo),
+		usecases.NewDeleteReadingListUsecase(listRepo),
+		usecases.NewAddReadingListEntryUsecase(listRepo),
+		usecases.NewRemoveReadingListEntryUsecase(listRepo),
+		usecases.NewReorderReadingListUsecase(listRepo),
+	)
+
+	requireAuth := middlewares.RequireBearerAuth("test-secret")
+	optionalAuth := middlewares.OptionalBearerAuth("test-secret")
+
+	r := chi.NewRouter()
+	r.Post("/books", bookHandler.CreateBook)
+	r.Delete("/books/{id}", bookHandler.DeleteBook)
+	r.With(requireAuth).Post("/lists", h.CreateReadingList)
+	r.With(optionalAuth).Get("/lists/{id}", h.GetReadingList)
+	r.With(requireAuth).Put("/lists/{id}", h.UpdateReadingList)
+	r.With(requireAuth).Delete("/lists/{id}", h.DeleteReadingList)
+	r.With(requireAuth).Post("/lists/{id}/books", h.AddReadingListEntry)
+	r.With(requireAuth).Put("/lists/{id}/books", h.ReorderReadingList)
+	r.With(requireAuth).Delete("/lists/{id}/books/{bookID}", h.RemoveReadingListEntry)
+	r.With(optionalAuth).Get("/users/{id}/lists", h.ListUserReadingLists)
+
+	for _, payload := range []string{
+		`{"title":"Dune","author":"Frank Herbert","year":1965}`,
+		`{"title":"Foundation","author":"Isaac Asimov","year":1951}`,
+		`{"title":"Hyperion","author":"Dan Simmons","year":1989}`,
+	} {
+		res := doJSON(t, r, http.MethodPost, "/books", "", payload)
+		if res.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d", http.StatusCreated, res.Code)
+		}
+	}
+
+	return r
+}
+
+func userToken(t *testing.T, username string) string {
+	t.Helper()
+
+	token, err := utils.GenerateToken(username, "test-secret", time.Hour)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	return token
+}
+
+func doJSON(t *testing.T, r http.Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, target, nil)
+	} else {
+		req = httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	return res
+}
+
+func decodeReadingList(t *testing.T, res *httptest.ResponseRecorder) models.ReadingListResponse {
+	t.Helper()
+
+	var list models.ReadingListResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &list); err != nil {
+		t.Fatalf("unmarshal reading list: %v (%s)", err, res.Body.String())
+	}
+
+	return list
+}
+
+func entryBookIDs(list models.ReadingListResponse) []int64 {
+	ids := make([]int64, 0, len(list.Entries))
+	for _, entry := range list.Entries {
+		ids = append(ids, entry.Book.ID)
+	}
+
+	return ids
+}
+
+func TestReadingLists_CreateAddReorderRemove(t *testing.T) {
+	r := setupReadingListsRouter(t)
+	alice := userToken(t, "alice")
+
+	createRes := doJSON(t, r, http.MethodPost, "/lists", alice, `{"name":"Want to read"}`)
+	if createRes.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, createRes.Code)
+	}
+
+	created := decodeReadingList(t, createRes)
+	if created.OwnerID != "alice" || created.Visibility != models.ReadingListVisibilityPrivate || created.BookCount != 0 {
+		t.Fatalf("unexpected created list: %+v", created)
+	}
+
+	for _, body := range []string{`{"book_id":1}`, `{"book_id":2}`, `{"book_id":3,"position":1}`} {
+		res := doJSON(t, r, http.MethodPost, "/lists/1/books", alice, body)
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, res.Code, res.Body.String())
+		}
+	}
+
+	getRes := doJSON(t, r, http.MethodGet, "/lists/1", alice, "")
+	if got := entryBookIDs(decodeReadingList(t, getRes)); len(got) != 3 || got[0] != 3 || got[1] != 1 || got[2] != 2 {
+		t.Fatalf("unexpected entry order: %v", got)
+	}
+
+	duplicateRes := doJSON(t, r, http.MethodPost, "/lists/1/books", alice, `{"book_id":1}`)
+	if duplicateRes.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, duplicateRes.Code)
+	}
+
+	missingBookRes := doJSON(t, r, http.MethodPost, "/lists/1/books", alice, `{"book_id":99}`)
+	if missingBookRes.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, missingBookRes.Code)
+	}
+
+	reorderRes := doJSON(t, r, http.MethodPut, "/lists/1/books", alice, `{"book_ids":[2,3,1]}`)
+	if reorderRes.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, reorderRes.Code, reorderRes.Body.String())
+	}
+	if got := entryBookIDs(decodeReadingList(t, reorderRes)); got[0] != 2 || got[1] != 3 || got[2] != 1 {
+		t.Fatalf("unexpected reordered entries: %v", got)
+	}
+
+	invalidReorderRes := doJSON(t, r, http.MethodPut, "/lists/1/books", alice, `{"book_ids":[2,2,1]}`)
+	if invalidReorderRes.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, invalidReorderRes.Code)
+	}
+
+	removeRes := doJSON(t, r, http.MethodDelete, "/lists/1/books/3", alice, "")
+	if removeRes.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, removeRes.Code)
+	}
+
+	deleteBookRes := doJSON(t, r, http.MethodDelete, "/books/2", "", "")
+	if deleteBookRes.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, deleteBookRes.Code)
+	}
+
+	final := decodeReadingList(t, doJSON(t, r, http.MethodGet, "/lists/1", alice, ""))
+	if got := entryBookIDs(final); len(got) != 1 || got[0] != 1 || final.BookCount != 1 {
+		t.Fatalf("unexpected final list: %+v", final)
+	}
+}
+
+func TestReadingLists_VisibilityAndOwnership(t *testing.T) {
+	r := setupReadingListsRouter(t)
+	alice := userToken(t, "alice")
+	bob := userToken(t, "bob")
+
+	for _, body := range []string{
+		`{"name":"Private","visibility":"private"}`,
+		`{"name":"Unlisted","visibility":"unlisted"}`,
+		`{"name":"Public","visibility":"public"}`,
+	} {
+		res := doJSON(t, r, http.MethodPost, "/lists", alice, body)
+		if res.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d", http.StatusCreated, res.Code)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		body   string
+		status int
+	}{
+		{name: "anonymous cannot create", method: http.MethodPost, target: "/lists", body: `{"name":"x"}`, status: http.StatusUnauthorized},
+		{name: "invalid visibility", method: http.MethodPost, target: "/lists", token: alice, body: `{"name":"x","visibility":"friends"}`, status: http.StatusBadRequest},
+		{name: "owner reads private", method: http.MethodGet, target: "/lists/1", token: alice, status: http.StatusOK},
+		{name: "other user cannot read private", method: http.MethodGet, target: "/lists/1", token: bob, status: http.StatusNotFound},
+		{name: "anonymous reads unlisted", method: http.MethodGet, target: "/lists/2", status: http.StatusOK},
+		{name: "anonymous reads public", method: http.MethodGet, target: "/lists/3", status: http.StatusOK},
+		{name: "other user cannot modify public", method: http.MethodPut, target: "/lists/3", token: bob, body: `{"name":"Mine"}`, status: http.StatusForbidden},
+		{name: "other user cannot add to public", method: http.MethodPost, target: "/lists/3/books", token: bob, body: `{"book_id":1}`, status: http.StatusForbidden},
+		{name: "other user cannot delete private", method: http.MethodDelete, target: "/lists/1", token: bob, status: http.StatusNotFound},
+		{name: "owner updates visibility", method: http.MethodPut, target: "/lists/1", token: alice, body: `{"name":"Shared","visibility":"public"}`, status: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := doJSON(t, r, tc.method, tc.target, tc.token, tc.body)
+			if res.Code != tc.status {
+				t.Fatalf("expected status %d, got %d: %s", tc.status, res.Code, res.Body.String())
+			}
+		})
+	}
+
+	var ownerView []models.ReadingListSummaryResponse
+	if err := json.Unmarshal(doJSON(t, r, http.MethodGet, "/users/alice/lists", alice, "").Body.Bytes(), &ownerView); err != nil {
+		t.Fatalf("unmarshal owner lists: %v", err)
+	}
+	if len(ownerView) != 3 {
+		t.Fatalf("expected owner to see 3 lists, got %d", len(ownerView))
+	}
+
+	var otherView []models.ReadingListSummaryResponse
+	if err := json.Unmarshal(doJSON(t, r, http.MethodGet, "/users/alice/lists", bob, "").Body.Bytes(), &otherView); err != nil {
+		t.Fatalf("unmarshal other lists: %v", err)
+	}
+	if len(otherView) != 2 || otherView[0].Name != "Shared" || otherView[1].Name != "Public" {
+		t.Fatalf("expected other user to see public lists only, got %+v", otherView)
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SessionHandler struct {
+	sessions *usecases.Sessions
+}
+
+func NewSessionHandler(sessions *usecases.Sessions) *SessionHandler {
+	return &SessionHandler{sessions: sessions}
+}
+
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+
+	sessions, err := h.sessions.List(r.Context(), principal.Subject)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.ToSessionResponses(sessions, principal.SessionID))
+}
+
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	principal, _ := middlewares.PrincipalFromContext(r.Context())
+
+	if err := h.sessions.Revoke(r.Context(), principal.Subject, chi.URLParam(r, "id")); err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrSessionNotFound):
+		writeError(w, http.StatusNotFound, "SESSION_NOT_FOUND", "session not found")
+	default:
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"desent-api/internal/middlewares"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/usecases"
+	"desent-api/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type sessionTestEnv struct {
+	router   http.Handler
+	sessions *usecases.Sessions
+	now      *time.Time
+	events   *bytes.Buffer
+}
+
+func setupSessionRouter(t *testing.T) sessionTestEnv {
+	t.Helper()
+
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	events := &bytes.Buffer{}
+	sessions := usecases.NewSessions(
+		repositories.NewSQLiteSessionRepository(db),
+		slog.New(slog.NewJSONHandler(events, &slog.HandlerOptions{Level: slog.LevelInfo})),
+		func() time.Time { return now },
+	)
+
+	authHandler := NewAuthHandler("test-secret", time.Hour).WithSessions(sessions)
+	sessionHandler := NewSessionHandler(sessions)
+
+	r := chi.NewRouter()
+	r.Post("/auth/token", authHandler.CreateToken)
+	auth := r.With(middlewares.RequireBearerAuth("test-secret"), middlewares.RequireActiveSession(sessions))
+	auth.Get("/me/sessions", sessionHandler.ListSessions)
+	auth.Delete("/me/sessions/{id}", sessionHandler.RevokeSession)
+
+	return sessionTestEnv{router: r, sessions: sessions, now: &now, events: events}
+}
+
+func (env sessionTestEnv) login(t *testing.T, username, remoteAddr, userAgent string) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/token", strings.NewReader(`{"username":"`+username+`","password":"password"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.RemoteAddr = remoteAddr
+	res := httptest.NewRecorder()
+	env.router.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("login: expected status %d, got %d", http.StatusOK, res.Code)
+	}
+
+	var token models.TokenResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &token); err != nil {
+		t.Fatalf("unmarshal token: %v", err)
+	}
+	return token.Token
+}
+
+// sessionToken signs a token with a fresh session for subject, for users
+// that cannot log in through /auth/token.
+func (env sessionTestEnv) sessionToken(t *testing.T, subject string) string {
+	t.Helper()
+
+	session, err := env.sessions.Start(context.Background(), models.Session{Subject: subject}, time.Hour)
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	token, err := utils.GenerateSessionToken(subject, session.ID, "test-secret", time.Hour)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	return token
+}
+
+func (env sessionTestEnv) listSessions(t *testing.T, token string) []models.SessionResponse {
+	t.Helper()
+
+	res := doJSON(t, env.router, http.MethodGet, "/me/sessions", token, "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("list sessions: expected status %d, got %d", http.StatusOK, res.Code)
+	}
+
+	var sessions []models.SessionResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &sessions); err != nil {
+		t.Fatalf("unmarshal sessions: %v", err)
+	}
+	return sessions
+}
+
+func TestSessions_ListAndRevoke(t *testing.T) {
+	env := setupSessionRouter(t)
+
+	laptop := env.login(t, "admin", "198.51.100.1:1000", "laptop-browser")
+	*env.now = env.now.Add(time.Minute)
+	phone := env.login(t, "admin", "198.51.100.2:1000", "phone-app")
+	other := env.sessionToken(t, "reader")
+
+	sessions := env.listSessions(t, laptop)
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %+v", sessions)
+	}
+	if sessions[0].UserAgent != "phone-app" || sessions[0].IP != "198.51.100.2" || sessions[0].Current {
+		t.Fatalf("expected the phone session first and not current, got %+v", sessions[0])
+	}
+	if sessions[1].UserAgent != "laptop-browser" || !sessions[1].Current || len(sessions[1].AuthMethods) != 1 {
+		t.Fatalf("expected the laptop session to be current, got %+v", sessions[1])
+	}
+	phoneID := sessions[0].ID
+
+	if res := doJSON(t, env.router, http.MethodDelete, "/me/sessions/"+phoneID, other, ""); res.Code != http.StatusNotFound {
+		t.Fatalf("expected other users' sessions to be hidden, got %d", res.Code)
+	}
+	if res := doJSON(t, env.router, http.MethodDelete, "/me/sessions/"+phoneID, laptop, ""); res.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, res.Code)
+	}
+	if res := doJSON(t, env.router, http.MethodGet, "/me/sessions", phone, ""); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the revoked token to be rejected, got %d", res.Code)
+	}
+	if res := doJSON(t, env.router, http.MethodDelete, "/me/sessions/"+phoneID, laptop, ""); res.Code != http.StatusNotFound {
+		t.Fatalf("expected a revoked session to be gone, got %d", res.Code)
+	}
+	if sessions := env.listSessions(t, laptop); len(sessions) != 1 || !sessions[0].Current {
+		t.Fatalf("expected only the current session, got %+v", sessions)
+	}
+	if !strings.Contains(env.events.String(), `"level":"INFO","msg":"security event","event":"auth.session_revoked","subject":"admin","session_id":"`+phoneID+`"`) {
+		t.Fatalf("expected revocation security event at Info, got %s", env.events.String())
+	}
+}
+
+func TestSessions_RejectTokensWithoutSession(t *testing.T) {
+	env := setupSessionRouter(t)
+	withoutSession := userToken(t, "admin")
+
+	if res := doJSON(t, env.router, http.MethodGet, "/me/sessions", withoutSession, ""); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a token without jti to be rejected, got %d", res.Code)
+	}
+
+	db := openTestDB(t)
+	if err := repositories.InitSchema(context.Background(), db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	server := usecases.NewOAuthServer(repositories.NewSQLiteOAuthClientRepository(db), "test-secret", time.Hour, nil).WithSessions(env.sessions)
+	client, secret, err := server.RegisterClient(context.Background(), models.CreateOAuthClientRequest{
+		Name:       "gateway",
+		Scopes:     []string{models.ScopeBooksWrite},
+		GrantTypes: []string{"client_credentials"},
+	})
+	if err != nil {
+		t.Fatalf("register client: %v", err)
+	}
+
+	for token, want := range map[string]bool{withoutSession: false, env.sessionToken(t, "admin"): true} {
+		introspection, err := server.Introspect(context.Background(), client.ClientID, secret, token)
+		if err != nil || introspection.Active != want {
+			t.Fatalf("expected active=%v, got %+v, %v", want, introspection, err)
+		}
+	}
+}
+
+func TestSessions_TracksLastSeen(t *testing.T) {
+	env := setupSessionRouter(t)
+
+	token := env.login(t, "admin", "198.51.100.1:1000", "laptop-browser")
+	issuedAt := *env.now
+
+	*env.now = env.now.Add(5 * time.Minute)
+	sessions := env.listSessions(t, token)
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session, got %+v", sessions)
+	}
+	if !sessions[0].IssuedAt.Equal(issuedAt) || !sessions[0].LastSeenAt.Equal(*env.now) {
+		t.Fatalf("expected issued %v and last seen %v, got %+v", issuedAt, *env.now, sessions[0])
+	}
+	if sessions[0].IP != "198.51.100.1" || sessions[0].LastIP != "192.0.2.1" {
+		t.Fatalf("expected login and last-used addresses, got %+v", sessions[0])
+	}
+}
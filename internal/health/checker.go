@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// CheckFunc reports a problem with a dependency. It must return once ctx
+// is done.
+type CheckFunc func(ctx context.Context) error
+
+type CheckResult struct {
+	Name    string
+	Status  string
+	Latency time.Duration
+	Error   string
+}
+
+// Report is StatusFail and not Healthy when a critical check fails, and
+// StatusDegraded but still Healthy when only non-critical checks fail.
+type Report struct {
+	Healthy   bool
+	Status    string
+	CheckedAt time.Time
+	Checks    []CheckResult
+}
+
+type check struct {
+	name     string
+	run      CheckFunc
+	critical bool
+}
+
+// Checker runs registered checks concurrently, each bounded by timeout.
+// Reports are reused for cacheTTL, and callers arriving while checks run
+// wait for that run instead of starting their own, so a burst of probes
+// costs one round of checks.
+type Checker struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	nowFunc  func() time.Time
+	logger   *slog.Logger
+
+	mu     sync.Mutex
+	checks []check
+	last   Report
+}
+
+func NewChecker(timeout, cacheTTL time.Duration, nowFunc func() time.Time) *Checker {
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	return &Checker{timeout: timeout, cacheTTL: cacheTTL, nowFunc: nowFunc, logger: slog.Default()}
+}
+
+// WithLogger sets where failed checks are logged, with their error, once
+// per run. Reports themselves may be shown to anyone.
+func (c *Checker) WithLogger(logger *slog.Logger) *Checker {
+	c.logger = logger
+	return c
+}
+
+// Register adds a check whose failure makes the report unhealthy.
+func (c *Checker) Register(name string, run CheckFunc) {
+	c.register(check{name: name, run: run, critical: true})
+}
+
+// RegisterNonCritical adds a check whose failure only degrades the report.
+func (c *Checker) RegisterNonCritical(name string, run CheckFunc) {
+	c.register(check{name: name, run: run})
+}
+
+func (c *Checker) register(chk check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, chk)
+	c.last = Report{}
+}
+
+// Run returns the latest report, running the checks if it is older than
+// the cache TTL.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.last.CheckedAt.IsZero() && c.nowFunc().Sub(c.last.CheckedAt) < c.cacheTTL {
+		return c.last
+	}
+
+	// The report is shared, so one caller going away must not fail it.
+	ctx = context.WithoutCancel(ctx)
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.runCheck(ctx, chk)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Healthy: true, Status: StatusOK, CheckedAt: c.nowFunc(), Checks: results}
+	for i, result := range results {
+		if result.Status == StatusOK {
+			continue
+		}
+
+		c.logger.LogAttrs(ctx, slog.LevelError, "health check failed",
+			slog.String("check", result.Name),
+			slog.Bool("critical", c.checks[i].critical),
+			slog.Float64("latency_ms", float64(result.Latency.Microseconds())/1000),
+			slog.String("error", result.Error),
+		)
+		if c.checks[i].critical {
+			report.Healthy = false
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	c.last = report
+	return report
+}
+
+func (c *Checker) runCheck(ctx context.Context, chk check) CheckResult {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	startedAt := time.Now()
+	err := chk.run(ctx)
+	result := CheckResult{Name: chk.name, Status: StatusOK, Latency: time.Since(startedAt)}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
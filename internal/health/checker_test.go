@@ -0,0 +1,170 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestChecker(timeout, cacheTTL time.Duration, now *time.Time) (*Checker, *bytes.Buffer) {
+	var logs bytes.Buffer
+	checker := NewChecker(timeout, cacheTTL, func() time.Time { return *now }).
+		WithLogger(slog.New(slog.NewJSONHandler(&logs, nil)))
+	return checker, &logs
+}
+
+func TestChecker_AllChecksPass(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	checker, logs := newTestChecker(time.Second, 0, &now)
+	checker.Register("database", func(context.Context) error { return nil })
+	checker.RegisterNonCritical("logs_disk", func(context.Context) error { return nil })
+
+	report := checker.Run(context.Background())
+	if !report.Healthy || report.Status != StatusOK || !report.CheckedAt.Equal(now) || len(report.Checks) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if report.Checks[0].Name != "database" || report.Checks[1].Name != "logs_disk" {
+		t.Fatalf("expected checks in registration order, got %+v", report.Checks)
+	}
+	if logs.Len() != 0 {
+		t.Fatalf("expected nothing to be logged, got %s", logs.String())
+	}
+}
+
+func TestChecker_FailedAndDegradedStates(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	var databaseErr, diskErr error
+	checker, logs := newTestChecker(time.Second, 0, &now)
+	checker.Register("database", func(context.Context) error { return databaseErr })
+	checker.RegisterNonCritical("logs_disk", func(context.Context) error { return diskErr })
+
+	for _, tc := range []struct {
+		name        string
+		databaseErr error
+		diskErr     error
+		healthy     bool
+		status      string
+	}{
+		{"non-critical failure", nil, errors.New("3 MiB free"), true, StatusDegraded},
+		{"critical failure", errors.New("database is locked"), nil, false, StatusFail},
+		{"both fail", errors.New("database is locked"), errors.New("3 MiB free"), false, StatusFail},
+	} {
+		databaseErr, diskErr = tc.databaseErr, tc.diskErr
+		logs.Reset()
+
+		report := checker.Run(context.Background())
+		if report.Healthy != tc.healthy || report.Status != tc.status {
+			t.Fatalf("%s: expected healthy=%v status=%s, got %+v", tc.name, tc.healthy, tc.status, report)
+		}
+		for i, err := range []error{tc.databaseErr, tc.diskErr} {
+			result := report.Checks[i]
+			if err == nil && (result.Status != StatusOK || result.Error != "") {
+				t.Fatalf("%s: expected %s to pass, got %+v", tc.name, result.Name, result)
+			}
+			if err != nil && (result.Status != StatusFail || result.Error != err.Error()) {
+				t.Fatalf("%s: expected %s to fail with %q, got %+v", tc.name, result.Name, err, result)
+			}
+			if err != nil && !strings.Contains(logs.String(), `"check":"`+result.Name+`"`) {
+				t.Fatalf("%s: expected the %s failure to be logged, got %s", tc.name, result.Name, logs.String())
+			}
+		}
+	}
+}
+
+func TestChecker_TimesOutSlowChecks(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	checker, logs := newTestChecker(20*time.Millisecond, 0, &now)
+	checker.Register("fast", func(context.Context) error { return nil })
+	checker.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	started := time.Now()
+	report := checker.Run(context.Background())
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("expected the run to stop at the timeout, took %s", elapsed)
+	}
+	if report.Healthy || report.Checks[0].Status != StatusOK {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	slow := report.Checks[1]
+	if slow.Status != StatusFail || !strings.Contains(slow.Error, "deadline exceeded") || slow.Latency < 20*time.Millisecond {
+		t.Fatalf("expected the slow check to time out, got %+v", slow)
+	}
+	if !strings.Contains(logs.String(), "deadline exceeded") {
+		t.Fatalf("expected the timeout to be logged, got %s", logs.String())
+	}
+}
+
+func TestChecker_CachesReports(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	checker, _ := newTestChecker(time.Second, 5*time.Second, &now)
+	var runs atomic.Int32
+	checker.Register("database", func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	first := checker.Run(context.Background())
+	now = now.Add(4 * time.Second)
+	if cached := checker.Run(context.Background()); !cached.CheckedAt.Equal(first.CheckedAt) || runs.Load() != 1 {
+		t.Fatalf("expected the cached report within the TTL, got %d runs", runs.Load())
+	}
+
+	now = now.Add(2 * time.Second)
+	if fresh := checker.Run(context.Background()); !fresh.CheckedAt.Equal(now) || runs.Load() != 2 {
+		t.Fatalf("expected a new run after the TTL, got %d runs", runs.Load())
+	}
+
+	checker.Register("schema", func(context.Context) error { return nil })
+	if report := checker.Run(context.Background()); len(report.Checks) != 2 || runs.Load() != 3 {
+		t.Fatalf("expected registering a check to drop the cache, got %d runs", runs.Load())
+	}
+}
+
+func TestChecker_ConcurrentCallersShareOneRun(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	checker, _ := newTestChecker(time.Second, time.Minute, &now)
+	var runs atomic.Int32
+	release := make(chan struct{})
+	checker.Register("database", func(context.Context) error {
+		runs.Add(1)
+		<-release
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checker.Run(context.Background())
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := runs.Load(); got != 1 {
+		t.Fatalf("expected one run for concurrent callers, got %d", got)
+	}
+}
+
+func TestChecker_CancelledCallerDoesNotFailTheReport(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	checker, _ := newTestChecker(time.Second, time.Minute, &now)
+	checker.Register("database", func(ctx context.Context) error { return ctx.Err() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := checker.Run(ctx); !report.Healthy {
+		t.Fatalf("expected the shared report to ignore the caller's cancellation, got %+v", report)
+	}
+}
@@ -0,0 +1,31 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Database pings db.
+func Database(db *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// DiskSpace fails when the filesystem holding dir has less than minFree
+// bytes available to the process.
+func DiskSpace(dir string, minFree uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		free, err := freeBytes(dir)
+		if err != nil {
+			return err
+		}
+
+		if free < minFree {
+			return fmt.Errorf("%d MiB free in %s, need %d MiB", free>>20, dir, minFree>>20)
+		}
+
+		return nil
+	}
+}
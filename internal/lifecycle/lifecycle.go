@@ -0,0 +1,135 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Manager collects stop hooks and runs them in reverse registration order,
+// like deferred calls, so resources opened first are closed last.
+type Manager struct {
+	logger      *slog.Logger
+	ready       atomic.Bool
+	stepTimeout time.Duration
+
+	mu       sync.Mutex
+	hooks    []hook
+	shutdown sync.Once
+	err      error
+}
+
+type hook struct {
+	name    string
+	timeout time.Duration
+	stop    func(ctx context.Context) error
+}
+
+func New(logger *slog.Logger) *Manager {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Manager{logger: logger}
+}
+
+// WithStepTimeout gives every stop hook its own budget of timeout, so a
+// slow hook does not leave the ones after it with an expired context.
+func (m *Manager) WithStepTimeout(timeout time.Duration) *Manager {
+	m.stepTimeout = timeout
+	return m
+}
+
+// OnStop registers stop to run during Shutdown.
+func (m *Manager) OnStop(name string, stop func(ctx context.Context) error) {
+	m.OnStopTimeout(name, 0, stop)
+}
+
+// OnStopTimeout is OnStop with a budget of its own instead of the step
+// timeout.
+func (m *Manager) OnStopTimeout(name string, timeout time.Duration, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, hook{name: name, timeout: timeout, stop: stop})
+}
+
+// Go runs a background worker until Shutdown reaches it. The worker must
+// return once ctx is cancelled; Shutdown waits for it until the hook's
+// context is done.
+func (m *Manager) Go(name string, run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(ctx)
+	}()
+
+	m.OnStop(name, func(stopCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return fmt.Errorf("worker did not stop: %w", stopCtx.Err())
+		}
+	})
+}
+
+// SetReady marks the process as able to take traffic.
+func (m *Manager) SetReady(ready bool) {
+	m.ready.Store(ready)
+}
+
+// Ready reports whether the process takes traffic. It turns false as soon
+// as Shutdown starts.
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// Shutdown marks the process as not ready and runs every stop hook, newest
+// first. A hook with a timeout gets a fresh context with that deadline;
+// the others share ctx. Hooks run even after ctx is done so resources are
+// still closed; ctx only bounds how long they wait. Later calls return the
+// first result.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.shutdown.Do(func() {
+		m.ready.Store(false)
+
+		m.mu.Lock()
+		hooks := m.hooks
+		m.hooks = nil
+		m.mu.Unlock()
+
+		var errs []error
+		for i := len(hooks) - 1; i >= 0; i-- {
+			if err := m.runHook(ctx, hooks[i]); err != nil {
+				errs = append(errs, fmt.Errorf("stop %s: %w", hooks[i].name, err))
+				m.logger.Error("shutdown step failed", "step", hooks[i].name, "error", err.Error())
+			}
+		}
+
+		m.err = errors.Join(errs...)
+	})
+
+	return m.err
+}
+
+func (m *Manager) runHook(ctx context.Context, h hook) error {
+	timeout := h.timeout
+	if timeout <= 0 {
+		timeout = m.stepTimeout
+	}
+	if timeout <= 0 {
+		return h.stop(ctx)
+	}
+
+	hookCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	return h.stop(hookCtx)
+}
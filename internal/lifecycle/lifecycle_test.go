@@ -0,0 +1,153 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdown_RunsHooksNewestFirst(t *testing.T) {
+	m := New(slog.New(slog.DiscardHandler))
+	m.SetReady(true)
+
+	var order []string
+	for _, name := range []string{"database", "cache", "server"} {
+		m.OnStop(name, func(context.Context) error {
+			if m.Ready() {
+				t.Errorf("expected the process to be unready while %s stops", name)
+			}
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if got := strings.Join(order, ","); got != "server,cache,database" {
+		t.Fatalf("expected hooks in reverse order, got %s", got)
+	}
+
+	if err := m.Shutdown(context.Background()); err != nil || len(order) != 3 {
+		t.Fatalf("expected a second shutdown to do nothing, got %v and %v", err, order)
+	}
+}
+
+func TestShutdown_AggregatesErrorsAndKeepsGoing(t *testing.T) {
+	var logs bytes.Buffer
+	m := New(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	errCache := errors.New("cache flush failed")
+	errQueue := errors.New("queue still busy")
+	var ran []string
+	m.OnStop("database", func(context.Context) error { ran = append(ran, "database"); return nil })
+	m.OnStop("cache", func(context.Context) error { ran = append(ran, "cache"); return errCache })
+	m.OnStop("queue", func(context.Context) error { ran = append(ran, "queue"); return errQueue })
+
+	err := m.Shutdown(context.Background())
+	if !errors.Is(err, errCache) || !errors.Is(err, errQueue) {
+		t.Fatalf("expected both errors, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "stop cache: cache flush failed") || !strings.Contains(err.Error(), "stop queue: queue still busy") {
+		t.Fatalf("expected errors to name their hook, got %v", err)
+	}
+	if got := strings.Join(ran, ","); got != "queue,cache,database" {
+		t.Fatalf("expected every hook to run after failures, got %s", got)
+	}
+	if !strings.Contains(logs.String(), "step=cache") || !strings.Contains(logs.String(), "step=queue") {
+		t.Fatalf("expected failed steps to be logged, got %s", logs.String())
+	}
+
+	if again := m.Shutdown(context.Background()); again != err {
+		t.Fatalf("expected later calls to return the first result, got %v", again)
+	}
+}
+
+func TestGo_StopsWorkerOnShutdown(t *testing.T) {
+	m := New(slog.New(slog.DiscardHandler))
+
+	started := make(chan struct{})
+	stopped := false
+	m.Go("worker", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		stopped = true
+	})
+	<-started
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if !stopped {
+		t.Fatal("expected Shutdown to wait for the worker")
+	}
+}
+
+func TestGo_TimesOutStuckWorkersAndStillRunsEarlierHooks(t *testing.T) {
+	m := New(slog.New(slog.DiscardHandler))
+
+	databaseClosed := false
+	m.OnStop("database", func(ctx context.Context) error {
+		if ctx.Err() == nil {
+			t.Error("expected the expired context to be passed on")
+		}
+		databaseClosed = true
+		return nil
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+	m.Go("stuck", func(context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err := m.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stop stuck: worker did not stop") {
+		t.Fatalf("expected the stuck worker to time out, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("expected Shutdown to give up at the deadline, took %s", elapsed)
+	}
+	if !databaseClosed {
+		t.Fatal("expected hooks registered before the worker to run after the timeout")
+	}
+}
+
+func TestShutdown_SlowHookDoesNotExpireLaterOnes(t *testing.T) {
+	m := New(slog.New(slog.DiscardHandler)).WithStepTimeout(time.Second)
+
+	var flushErr error
+	m.OnStop("flush", func(ctx context.Context) error {
+		flushErr = ctx.Err()
+		return nil
+	})
+
+	stopped := false
+	m.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		stopped = true
+	})
+
+	m.OnStopTimeout("drain", 20*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := m.Shutdown(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stop drain") {
+		t.Fatalf("expected only the drain to time out, got %v", err)
+	}
+	if strings.Contains(err.Error(), "stop worker") || !stopped {
+		t.Fatalf("expected the worker to be waited for after the drain used its budget, got %v", err)
+	}
+	if flushErr != nil {
+		t.Fatalf("expected the flush to get a live context, got %v", flushErr)
+	}
+}
@@ -0,0 +1,269 @@
+package metadata
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	marcFieldTerminator  = 0x1E
+	marcRecordTerminator = 0x1D
+	marcSubfieldMarker   = 0x1F
+	marcLeaderLength     = 24
+	marcDirectoryEntry   = 12
+)
+
+var ErrInvalidMARCRecord = errors.New("invalid MARC record")
+
+// MARCRecord is a format-neutral MARC21 bibliographic record shared by the
+// binary (ISO 2709) and MARCXML codecs.
+type MARCRecord struct {
+	Leader        string
+	ControlFields []MARCControlField
+	DataFields    []MARCDataField
+}
+
+type MARCControlField struct {
+	Tag   string
+	Value string
+}
+
+type MARCDataField struct {
+	Tag       string
+	Ind1      string
+	Ind2      string
+	Subfields []MARCSubfield
+}
+
+type MARCSubfield struct {
+	Code  string
+	Value string
+}
+
+func (r MARCRecord) ControlField(tag string) string {
+	for _, field := range r.ControlFields {
+		if field.Tag == tag {
+			return field.Value
+		}
+	}
+
+	return ""
+}
+
+func (r MARCRecord) Fields(tag string) []MARCDataField {
+	var fields []MARCDataField
+	for _, field := range r.DataFields {
+		if field.Tag == tag {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+func (f MARCDataField) Subfield(code string) string {
+	for _, subfield := range f.Subfields {
+		if subfield.Code == code {
+			return subfield.Value
+		}
+	}
+
+	return ""
+}
+
+// ParseMARC reads concatenated binary MARC21 records. Records are split on
+// the record terminator so one damaged record is skipped without losing the
+// rest of the file. MARC-8 encoded records are read as-is, which is only
+// lossless for their ASCII subset.
+func ParseMARC(r io.Reader, visit func(MARCRecord) error) (int, error) {
+	reader := bufio.NewReader(r)
+
+	skipped := 0
+	for {
+		raw, err := reader.ReadBytes(marcRecordTerminator)
+		if len(bytes.TrimSpace(raw)) > 0 {
+			record, parseErr := decodeMARC(raw)
+			if parseErr != nil {
+				skipped++
+			} else if visitErr := visit(record); visitErr != nil {
+				return skipped, visitErr
+			}
+		}
+
+		if err == io.EOF {
+			return skipped, nil
+		}
+		if err != nil {
+			return skipped, err
+		}
+	}
+}
+
+func decodeMARC(raw []byte) (MARCRecord, error) {
+	raw = bytes.TrimLeft(raw, "\r\n ")
+	if len(raw) < marcLeaderLength+1 {
+		return MARCRecord{}, ErrInvalidMARCRecord
+	}
+
+	// A record cut short, by a truncated file or a stray terminator byte,
+	// no longer matches the length its leader declares.
+	length, ok := marcNumber(raw[0:5])
+	if !ok || length != len(raw) || raw[len(raw)-1] != marcRecordTerminator {
+		return MARCRecord{}, ErrInvalidMARCRecord
+	}
+
+	leader := string(raw[:marcLeaderLength])
+	base, ok := marcNumber(raw[12:17])
+	if !ok || base <= marcLeaderLength || base > len(raw) || raw[base-1] != marcFieldTerminator {
+		return MARCRecord{}, ErrInvalidMARCRecord
+	}
+
+	directory := raw[marcLeaderLength : base-1]
+	if len(directory)%marcDirectoryEntry != 0 {
+		return MARCRecord{}, ErrInvalidMARCRecord
+	}
+
+	record := MARCRecord{Leader: leader}
+	for offset := 0; offset < len(directory); offset += marcDirectoryEntry {
+		entry := directory[offset : offset+marcDirectoryEntry]
+		tag := string(entry[:3])
+		length, lengthOK := marcNumber(entry[3:7])
+		start, startOK := marcNumber(entry[7:12])
+		if !lengthOK || !startOK || base+start+length > len(raw)-1 {
+			return MARCRecord{}, ErrInvalidMARCRecord
+		}
+
+		data := bytes.TrimSuffix(raw[base+start:base+start+length], []byte{marcFieldTerminator})
+		if isControlTag(tag) {
+			record.ControlFields = append(record.ControlFields, MARCControlField{Tag: tag, Value: string(data)})
+			continue
+		}
+
+		if len(data) < 2 {
+			return MARCRecord{}, ErrInvalidMARCRecord
+		}
+
+		field := MARCDataField{Tag: tag, Ind1: string(data[0]), Ind2: string(data[1])}
+		for _, part := range bytes.Split(data[2:], []byte{marcSubfieldMarker}) {
+			if len(part) == 0 {
+				continue
+			}
+
+			field.Subfields = append(field.Subfields, MARCSubfield{Code: string(part[0]), Value: string(part[1:])})
+		}
+		record.DataFields = append(record.DataFields, field)
+	}
+
+	return record, nil
+}
+
+// marcNumber reads a fixed-width leader or directory number. Unlike
+// strconv.Atoi it accepts digits only, so no sign can make it negative.
+func marcNumber(digits []byte) (int, bool) {
+	n := 0
+	for _, digit := range digits {
+		if digit < '0' || digit > '9' {
+			return 0, false
+		}
+		n = n*10 + int(digit-'0')
+	}
+
+	return n, true
+}
+
+// WriteMARC encodes records as binary MARC21 with a UTF-8 leader.
+func WriteMARC(w io.Writer, records ...MARCRecord) error {
+	for _, record := range records {
+		encoded, err := encodeMARC(record)
+		if err != nil {
+			return err
+		}
+
+		if _, err := w.Write(encoded); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encodeMARC(record MARCRecord) ([]byte, error) {
+	type field struct {
+		tag  string
+		data []byte
+	}
+
+	fields := make([]field, 0, len(record.ControlFields)+len(record.DataFields))
+	for _, control := range record.ControlFields {
+		fields = append(fields, field{tag: control.Tag, data: append([]byte(control.Value), marcFieldTerminator)})
+	}
+	for _, data := range record.DataFields {
+		buf := bytes.Buffer{}
+		buf.WriteString(indicator(data.Ind1))
+		buf.WriteString(indicator(data.Ind2))
+		for _, subfield := range data.Subfields {
+			buf.WriteByte(marcSubfieldMarker)
+			buf.WriteString(subfield.Code)
+			buf.WriteString(subfield.Value)
+		}
+		buf.WriteByte(marcFieldTerminator)
+		fields = append(fields, field{tag: data.Tag, data: buf.Bytes()})
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].tag < fields[j].tag })
+
+	directory := bytes.Buffer{}
+	body := bytes.Buffer{}
+	for _, f := range fields {
+		if len(f.tag) != 3 || len(f.data) > 9999 {
+			return nil, fmt.Errorf("%w: field %q cannot be encoded", ErrInvalidMARCRecord, f.tag)
+		}
+
+		fmt.Fprintf(&directory, "%s%04d%05d", f.tag, len(f.data), body.Len())
+		body.Write(f.data)
+	}
+	directory.WriteByte(marcFieldTerminator)
+
+	base := marcLeaderLength + directory.Len()
+	total := base + body.Len() + 1
+	if total > 99999 {
+		return nil, fmt.Errorf("%w: record exceeds 99999 bytes", ErrInvalidMARCRecord)
+	}
+
+	leader := []byte(normalizeLeader(record.Leader))
+	copy(leader[0:5], fmt.Sprintf("%05d", total))
+	leader[9] = 'a'
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+
+	encoded := make([]byte, 0, total)
+	encoded = append(encoded, leader...)
+	encoded = append(encoded, directory.Bytes()...)
+	encoded = append(encoded, body.Bytes()...)
+	return append(encoded, marcRecordTerminator), nil
+}
+
+// normalizeLeader pads or replaces a leader so it always describes a
+// language material monograph with the fixed MARC21 entry map.
+func normalizeLeader(leader string) string {
+	if len(leader) != marcLeaderLength {
+		leader = "00000nam a2200000 i 4500"
+	}
+
+	return leader[:10] + "22" + leader[12:20] + "4500"
+}
+
+func indicator(value string) string {
+	if value == "" {
+		return " "
+	}
+
+	return value[:1]
+}
+
+func isControlTag(tag string) bool {
+	return strings.HasPrefix(tag, "00")
+}
@@ -0,0 +1,222 @@
+package metadata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"desent-api/internal/models"
+	"desent-api/internal/utils"
+)
+
+// BookRequestFromMARC maps the MARC21 fields cataloguers rely on to a book:
+// 245 title, 100 (or 110/700) author, 264/260 year, 020 ISBN and 650 subjects.
+func BookRequestFromMARC(record MARCRecord) models.CreateBookRequest {
+	req := models.CreateBookRequest{
+		Title:    marcTitle(record),
+		Author:   marcAuthor(record),
+		Year:     marcYear(record),
+		Subjects: marcSubjects(record),
+	}
+
+	for _, field := range record.Fields("020") {
+		if isbn := firstToken(field.Subfield("a")); isbn != "" {
+			req.ISBN = isbn
+			break
+		}
+	}
+
+	return req
+}
+
+// EditionFromMARC maps a record into the metadata lookup table. MARC has no
+// author identifiers, so the heading itself becomes the author key.
+func EditionFromMARC(record MARCRecord) (models.MetadataEdition, []models.MetadataAuthor) {
+	edition := models.MetadataEdition{
+		Key:      strings.TrimSpace(record.ControlField("001")),
+		Title:    marcTitle(record),
+		Year:     marcYear(record),
+		Subjects: marcSubjects(record),
+		Source:   models.MetadataSourceMARC,
+	}
+	for _, field := range record.Fields("020") {
+		if isbn, ok := utils.NormalizeISBN(firstToken(field.Subfield("a"))); ok && !contains(edition.ISBNs, isbn) {
+			edition.ISBNs = append(edition.ISBNs, isbn)
+		}
+	}
+	if edition.Key == "" && len(edition.ISBNs) > 0 {
+		edition.Key = edition.ISBNs[0]
+	}
+
+	var authors []models.MetadataAuthor
+	if name := marcAuthor(record); name != "" {
+		author := models.MetadataAuthor{Key: "marc:" + name, Name: name}
+		edition.AuthorKeys = []string{author.Key}
+		authors = append(authors, author)
+	}
+
+	return edition, authors
+}
+
+// MARCFromBook builds a minimal MARC21 bibliographic record for a book.
+func MARCFromBook(book models.Book) MARCRecord {
+	record := MARCRecord{
+		Leader: "00000nam a2200000 i 4500",
+		ControlFields: []MARCControlField{
+			{Tag: "001", Value: strconv.FormatInt(book.ID, 10)},
+			{Tag: "008", Value: fmt.Sprintf("%6s%s%04d%4s%-3s%17s%s %s", "", "s", book.Year, "", "xx", "", "und", "d")},
+		},
+	}
+
+	if book.ISBN != "" {
+		record.DataFields = append(record.DataFields, MARCDataField{
+			Tag: "020", Ind1: " ", Ind2: " ",
+			Subfields: []MARCSubfield{{Code: "a", Value: book.ISBN}},
+		})
+	}
+
+	titleInd1 := "0"
+	if book.Author != "" {
+		titleInd1 = "1"
+		record.DataFields = append(record.DataFields, MARCDataField{
+			Tag: "100", Ind1: "1", Ind2: " ",
+			Subfields: []MARCSubfield{{Code: "a", Value: invertName(book.Author)}},
+		})
+	}
+
+	record.DataFields = append(record.DataFields, MARCDataField{
+		Tag: "245", Ind1: titleInd1, Ind2: "0",
+		Subfields: []MARCSubfield{{Code: "a", Value: book.Title}},
+	})
+
+	publication := MARCDataField{Tag: "264", Ind1: " ", Ind2: "1"}
+	if book.Publisher != nil && book.Publisher.Name != "" {
+		if book.Publisher.Location != "" {
+			publication.Subfields = append(publication.Subfields, MARCSubfield{Code: "a", Value: book.Publisher.Location})
+		}
+		publication.Subfields = append(publication.Subfields, MARCSubfield{Code: "b", Value: book.Publisher.Name})
+	}
+	publication.Subfields = append(publication.Subfields, MARCSubfield{Code: "c", Value: strconv.Itoa(book.Year)})
+	record.DataFields = append(record.DataFields, publication)
+
+	for _, subject := range book.Subjects {
+		record.DataFields = append(record.DataFields, MARCDataField{
+			Tag: "650", Ind1: " ", Ind2: "4",
+			Subfields: []MARCSubfield{{Code: "a", Value: subject}},
+		})
+	}
+
+	return record
+}
+
+func marcTitle(record MARCRecord) string {
+	fields := record.Fields("245")
+	if len(fields) == 0 {
+		return ""
+	}
+
+	title := cleanISBD(fields[0].Subfield("a"))
+	if subtitle := cleanISBD(fields[0].Subfield("b")); subtitle != "" {
+		title = joinTitle(title, subtitle)
+	}
+
+	return title
+}
+
+func marcAuthor(record MARCRecord) string {
+	for _, tag := range []string{"100", "110", "700"} {
+		for _, field := range record.Fields(tag) {
+			name := cleanISBD(field.Subfield("a"))
+			if name == "" {
+				continue
+			}
+
+			if tag != "110" && field.Ind1 == "1" {
+				return uninvertName(name)
+			}
+
+			return name
+		}
+	}
+
+	return ""
+}
+
+func marcYear(record MARCRecord) int {
+	for _, field := range record.Fields("264") {
+		if field.Ind2 == "1" {
+			if year := parseYear(field.Subfield("c")); year != 0 {
+				return year
+			}
+		}
+	}
+
+	for _, field := range record.Fields("260") {
+		if year := parseYear(field.Subfield("c")); year != 0 {
+			return year
+		}
+	}
+
+	if fixed := record.ControlField("008"); len(fixed) >= 11 {
+		if year, err := strconv.Atoi(fixed[7:11]); err == nil {
+			return year
+		}
+	}
+
+	return 0
+}
+
+func marcSubjects(record MARCRecord) []string {
+	var subjects []string
+	for _, field := range record.Fields("650") {
+		subjects = append(subjects, cleanISBD(field.Subfield("a")))
+	}
+
+	return cleanStrings(subjects)
+}
+
+// cleanISBD strips the trailing ISBD punctuation MARC uses between
+// subfields. A final period is kept after initials such as "Tolkien, J. R. R.".
+func cleanISBD(value string) string {
+	value = strings.TrimRight(strings.TrimSpace(value), " /:;,=")
+	if strings.HasSuffix(value, ".") && len(value) > 1 {
+		if previous := rune(value[len(value)-2]); unicode.IsLower(previous) || unicode.IsDigit(previous) {
+			value = value[:len(value)-1]
+		}
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// uninvertName turns a "Surname, Forename" heading into display order.
+func uninvertName(name string) string {
+	surname, forename, ok := strings.Cut(name, ", ")
+	if !ok || strings.Contains(forename, ",") {
+		return name
+	}
+
+	return forename + " " + surname
+}
+
+func invertName(name string) string {
+	if strings.Contains(name, ",") {
+		return name
+	}
+
+	index := strings.LastIndex(name, " ")
+	if index < 0 {
+		return name
+	}
+
+	return name[index+1:] + ", " + name[:index]
+}
+
+func firstToken(value string) string {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return fields[0]
+}
@@ -0,0 +1,119 @@
+package metadata
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func sampleMARCRecord() MARCRecord {
+	return MARCRecord{
+		Leader: "00000nam a2200000 i 4500",
+		ControlFields: []MARCControlField{
+			{Tag: "001", Value: "ocm00012345"},
+			{Tag: "008", Value: "      s1954    xx            000 0 und d"},
+		},
+		DataFields: []MARCDataField{
+			{Tag: "020", Ind1: " ", Ind2: " ", Subfields: []MARCSubfield{{Code: "a", Value: "9780261103573"}}},
+			{Tag: "100", Ind1: "1", Ind2: " ", Subfields: []MARCSubfield{{Code: "a", Value: "Tolkien, J. R. R."}}},
+			{Tag: "245", Ind1: "1", Ind2: "4", Subfields: []MARCSubfield{
+				{Code: "a", Value: "The fellowship of the ring /"},
+				{Code: "c", Value: "J.R.R. Tolkien."},
+			}},
+			{Tag: "650", Ind1: " ", Ind2: "0", Subfields: []MARCSubfield{{Code: "a", Value: "Middle Earth (Imaginary place)"}}},
+		},
+	}
+}
+
+func encodeSampleMARC(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := WriteMARC(&buf, sampleMARCRecord()); err != nil {
+		t.Fatalf("write marc: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func parseAllMARC(t *testing.T, data []byte) ([]MARCRecord, int) {
+	t.Helper()
+
+	var records []MARCRecord
+	skipped, err := ParseMARC(bytes.NewReader(data), func(record MARCRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parse marc: %v", err)
+	}
+	return records, skipped
+}
+
+func TestParseMARC_RoundTrip(t *testing.T) {
+	record := sampleMARCRecord()
+	data := encodeSampleMARC(t)
+
+	records, skipped := parseAllMARC(t, append(append(data, '\n'), data...))
+	if skipped != 0 || len(records) != 2 {
+		t.Fatalf("expected 2 records and none skipped, got %d and %d", len(records), skipped)
+	}
+	if got := records[0]; !reflect.DeepEqual(got.ControlFields, record.ControlFields) || !reflect.DeepEqual(got.DataFields, record.DataFields) {
+		t.Fatalf("expected the record to survive a round trip, got %+v", got)
+	}
+	if got := records[0].Leader; got[5:12] != "nam a22" || got[20:] != "4500" {
+		t.Fatalf("unexpected leader %q", got)
+	}
+}
+
+func TestParseMARC_SkipsDamagedRecords(t *testing.T) {
+	valid := encodeSampleMARC(t)
+	replace := func(at int, with string) []byte {
+		damaged := append([]byte(nil), valid...)
+		copy(damaged[at:], with)
+		return damaged
+	}
+	// The directory follows the leader; each entry is a tag, a four-digit
+	// length and a five-digit offset from the base address.
+	entry := marcLeaderLength
+
+	for name, damaged := range map[string][]byte{
+		"too short":              []byte("00012nam\x1d"),
+		"non-numeric length":     replace(0, "abcde"),
+		"length too long":        replace(0, "99999"),
+		"signed base address":    replace(12, "+0100"),
+		"base address in leader": replace(12, "00010"),
+		"base address past end":  replace(12, "99999"),
+		"base address mid-entry": replace(12, "00030"),
+		"signed field length":    replace(entry+3, "-001"),
+		"non-numeric offset":     replace(entry+7, "0x001"),
+		"offset past end":        replace(entry+7, "99990"),
+		"length past end":        replace(entry+3, "9999"),
+		"partial directory":      []byte("00031nam a2200030 i 450024500\x1e\x1d"),
+		"data field too short":   []byte("00040nam a2200037 i 4500245000200000\x1eA\x1e\x1d"),
+		"truncated":              valid[:len(valid)-20],
+		"missing terminator":     valid[:len(valid)-1],
+	} {
+		t.Run(name, func(t *testing.T) {
+			records, skipped := parseAllMARC(t, append(append([]byte(nil), valid...), damaged...))
+			if len(records) != 1 || skipped != 1 {
+				t.Fatalf("expected the damaged record to be skipped, got %d records and %d skipped", len(records), skipped)
+			}
+		})
+	}
+
+	records, skipped := parseAllMARC(t, bytes.Join([][]byte{valid, replace(entry+7, "99990"), valid}, nil))
+	if len(records) != 2 || skipped != 1 {
+		t.Fatalf("expected the records around a damaged one to be kept, got %d records and %d skipped", len(records), skipped)
+	}
+}
+
+func TestWriteMARC_RejectsUnencodableFields(t *testing.T) {
+	for name, record := range map[string]MARCRecord{
+		"bad tag":         {ControlFields: []MARCControlField{{Tag: "1", Value: "x"}}},
+		"oversized field": {ControlFields: []MARCControlField{{Tag: "001", Value: string(make([]byte, 10000))}}},
+	} {
+		if err := WriteMARC(&bytes.Buffer{}, record); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package metadata
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+const MARCXMLNamespace = "http://www.loc.gov/MARC21/slim"
+
+type marcXMLRecord struct {
+	XMLName       xml.Name              `xml:"record"`
+	Leader        string                `xml:"leader"`
+	ControlFields []marcXMLControlField `xml:"controlfield"`
+	DataFields    []marcXMLDataField    `xml:"datafield"`
+}
+
+type marcXMLControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type marcXMLDataField struct {
+	Tag       string            `xml:"tag,attr"`
+	Ind1      string            `xml:"ind1,attr"`
+	Ind2      string            `xml:"ind2,attr"`
+	Subfields []marcXMLSubfield `xml:"subfield"`
+}
+
+type marcXMLSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// ParseMARCXML streams <record> elements from a MARCXML document, whether
+// they are wrapped in a <collection> or not. Records that fail to decode
+// are counted and skipped; malformed XML outside a record stops the parse.
+func ParseMARCXML(r io.Reader, visit func(MARCRecord) error) (int, error) {
+	decoder := xml.NewDecoder(r)
+
+	skipped := 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return skipped, nil
+		}
+		if err != nil {
+			return skipped, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		var raw marcXMLRecord
+		if err := decoder.DecodeElement(&raw, &start); err != nil {
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return skipped, err
+			}
+
+			skipped++
+			continue
+		}
+
+		if err := visit(raw.toRecord()); err != nil {
+			return skipped, err
+		}
+	}
+}
+
+// WriteMARCXML writes records as a MARCXML <collection>.
+func WriteMARCXML(w io.Writer, records ...MARCRecord) error {
+	collection := struct {
+		XMLName xml.Name        `xml:"collection"`
+		XMLNS   string          `xml:"xmlns,attr"`
+		Records []marcXMLRecord `xml:"record"`
+	}{XMLNS: MARCXMLNamespace}
+	for _, record := range records {
+		collection.Records = append(collection.Records, toMARCXMLRecord(record))
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(collection); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+func (raw marcXMLRecord) toRecord() MARCRecord {
+	record := MARCRecord{Leader: raw.Leader}
+	for _, control := range raw.ControlFields {
+		record.ControlFields = append(record.ControlFields, MARCControlField(control))
+	}
+	for _, data := range raw.DataFields {
+		field := MARCDataField{Tag: data.Tag, Ind1: data.Ind1, Ind2: data.Ind2}
+		for _, subfield := range data.Subfields {
+			field.Subfields = append(field.Subfields, MARCSubfield(subfield))
+		}
+		record.DataFields = append(record.DataFields, field)
+	}
+
+	return record
+}
+
+func toMARCXMLRecord(record MARCRecord) marcXMLRecord {
+	raw := marcXMLRecord{Leader: normalizeLeader(record.Leader)}
+	for _, control := range record.ControlFields {
+		raw.ControlFields = append(raw.ControlFields, marcXMLControlField(control))
+	}
+	for _, data := range record.DataFields {
+		field := marcXMLDataField{Tag: data.Tag, Ind1: indicator(data.Ind1), Ind2: indicator(data.Ind2)}
+		for _, subfield := range data.Subfields {
+			field.Subfields = append(field.Subfields, marcXMLSubfield(subfield))
+		}
+		raw.DataFields = append(raw.DataFields, field)
+	}
+
+	return raw
+}
@@ -0,0 +1,84 @@
+package metadata
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseAllMARCXML(data string) ([]MARCRecord, int, error) {
+	var records []MARCRecord
+	skipped, err := ParseMARCXML(strings.NewReader(data), func(record MARCRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	return records, skipped, err
+}
+
+func TestMARCXML_RoundTrip(t *testing.T) {
+	record := sampleMARCRecord()
+	record.DataFields[3].Subfields[0].Value = `Dwarves & elves <"Ælfwine">`
+
+	var buf bytes.Buffer
+	if err := WriteMARCXML(&buf, record, record); err != nil {
+		t.Fatalf("write marcxml: %v", err)
+	}
+	if !strings.Contains(buf.String(), `<collection xmlns="`+MARCXMLNamespace+`">`) {
+		t.Fatalf("expected a namespaced collection, got %s", buf.String())
+	}
+
+	records, skipped, err := parseAllMARCXML(buf.String())
+	if err != nil || skipped != 0 || len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d (%d skipped): %v", len(records), skipped, err)
+	}
+	if got := records[1]; got.Leader != record.Leader || !reflect.DeepEqual(got.ControlFields, record.ControlFields) || !reflect.DeepEqual(got.DataFields, record.DataFields) {
+		t.Fatalf("expected the record to survive a round trip, got %+v", got)
+	}
+
+	var binary bytes.Buffer
+	if err := WriteMARC(&binary, records[0]); err != nil {
+		t.Fatalf("write marc: %v", err)
+	}
+	converted, skipped := parseAllMARC(t, binary.Bytes())
+	if skipped != 0 || len(converted) != 1 || !reflect.DeepEqual(converted[0].DataFields, record.DataFields) {
+		t.Fatalf("expected MARCXML to convert to binary MARC, got %+v", converted)
+	}
+}
+
+func TestParseMARCXML_BareRecordAndMissingParts(t *testing.T) {
+	records, skipped, err := parseAllMARCXML(`<record xmlns="` + MARCXMLNamespace + `">
+  <datafield tag="245"><subfield code="a">Untitled</subfield></datafield>
+</record>`)
+	if err != nil || skipped != 0 || len(records) != 1 {
+		t.Fatalf("expected one record, got %d (%d skipped): %v", len(records), skipped, err)
+	}
+	if got := records[0]; got.Leader != "" || got.Fields("245")[0].Subfield("a") != "Untitled" {
+		t.Fatalf("unexpected record %+v", got)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMARCXML(&buf, records[0]); err != nil {
+		t.Fatalf("write marcxml: %v", err)
+	}
+	if !strings.Contains(buf.String(), `<leader>00000nam a2200000 i 4500</leader>`) || !strings.Contains(buf.String(), `ind1=" " ind2=" "`) {
+		t.Fatalf("expected a default leader and blank indicators, got %s", buf.String())
+	}
+}
+
+func TestParseMARCXML_StopsOnMalformedXML(t *testing.T) {
+	for name, doc := range map[string]string{
+		"unclosed record":    `<collection><record><leader>x</leader></collection>`,
+		"unclosed subfield":  `<collection><record><datafield tag="245"><subfield code="a">x</datafield></record></collection>`,
+		"truncated document": `<collection><record><leader>00000nam a2200000 i 4500</leader><controlfield tag="001">1</control`,
+	} {
+		if _, _, err := parseAllMARCXML(doc); err == nil {
+			t.Fatalf("%s: expected a syntax error", name)
+		}
+	}
+
+	records, _, err := parseAllMARCXML(`<collection><record><controlfield tag="001">1</controlfield></record><record>`)
+	if err == nil || len(records) != 1 {
+		t.Fatalf("expected records before the damage to be visited, got %d: %v", len(records), err)
+	}
+}
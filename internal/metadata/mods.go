@@ -0,0 +1,119 @@
+package metadata
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"desent-api/internal/models"
+)
+
+const MODSNamespace = "http://www.loc.gov/mods/v3"
+
+type modsRecord struct {
+	XMLName     xml.Name         `xml:"mods"`
+	XMLNS       string           `xml:"xmlns,attr"`
+	Version     string           `xml:"version,attr"`
+	TitleInfo   modsTitleInfo    `xml:"titleInfo"`
+	Names       []modsName       `xml:"name,omitempty"`
+	TypeOf      string           `xml:"typeOfResource"`
+	OriginInfo  modsOriginInfo   `xml:"originInfo"`
+	Identifiers []modsIdentifier `xml:"identifier,omitempty"`
+	Subjects    []modsSubject    `xml:"subject,omitempty"`
+	RecordInfo  modsRecordInfo   `xml:"recordInfo"`
+}
+
+type modsTitleInfo struct {
+	Title string `xml:"title"`
+}
+
+type modsName struct {
+	Type     string   `xml:"type,attr"`
+	NamePart string   `xml:"namePart"`
+	Role     modsRole `xml:"role"`
+}
+
+type modsRole struct {
+	RoleTerm modsTerm `xml:"roleTerm"`
+}
+
+type modsTerm struct {
+	Type      string `xml:"type,attr"`
+	Authority string `xml:"authority,attr,omitempty"`
+	Value     string `xml:",chardata"`
+}
+
+type modsOriginInfo struct {
+	Place      *modsPlace `xml:"place,omitempty"`
+	Publisher  string     `xml:"publisher,omitempty"`
+	DateIssued modsDate   `xml:"dateIssued"`
+}
+
+type modsPlace struct {
+	PlaceTerm modsTerm `xml:"placeTerm"`
+}
+
+type modsDate struct {
+	Encoding string `xml:"encoding,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type modsIdentifier struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type modsSubject struct {
+	Topic string `xml:"topic"`
+}
+
+type modsRecordInfo struct {
+	RecordIdentifier string `xml:"recordIdentifier"`
+}
+
+// WriteMODS writes a book as a standalone MODS 3.8 record.
+func WriteMODS(w io.Writer, book models.Book) error {
+	record := modsRecord{
+		XMLNS:      MODSNamespace,
+		Version:    "3.8",
+		TitleInfo:  modsTitleInfo{Title: book.Title},
+		TypeOf:     "text",
+		OriginInfo: modsOriginInfo{DateIssued: modsDate{Encoding: "w3cdtf", Value: strconv.Itoa(book.Year)}},
+		RecordInfo: modsRecordInfo{RecordIdentifier: strconv.FormatInt(book.ID, 10)},
+	}
+
+	if book.Author != "" {
+		record.Names = append(record.Names, modsName{
+			Type:     "personal",
+			NamePart: book.Author,
+			Role:     modsRole{RoleTerm: modsTerm{Type: "text", Authority: "marcrelator", Value: "author"}},
+		})
+	}
+
+	if book.Publisher != nil {
+		record.OriginInfo.Publisher = book.Publisher.Name
+		if book.Publisher.Location != "" {
+			record.OriginInfo.Place = &modsPlace{PlaceTerm: modsTerm{Type: "text", Value: book.Publisher.Location}}
+		}
+	}
+
+	if book.ISBN != "" {
+		record.Identifiers = append(record.Identifiers, modsIdentifier{Type: "isbn", Value: book.ISBN})
+	}
+
+	for _, subject := range book.Subjects {
+		record.Subjects = append(record.Subjects, modsSubject{Topic: subject})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(record); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
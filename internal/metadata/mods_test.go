@@ -0,0 +1,59 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"desent-api/internal/models"
+)
+
+func TestWriteMODS(t *testing.T) {
+	book := models.Book{
+		ID:        42,
+		Title:     `Tom & Jerry <"annotated">`,
+		Author:    "Ann Author",
+		Year:      1999,
+		ISBN:      "9780261103573",
+		Subjects:  []string{"Cats", "Mice"},
+		Publisher: &models.Publisher{Name: "Acme Press", Location: "London"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMODS(&buf, book); err != nil {
+		t.Fatalf("write mods: %v", err)
+	}
+
+	var record modsRecord
+	if err := xml.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected well-formed XML, got %v:\n%s", err, buf.String())
+	}
+	if record.XMLName.Space != MODSNamespace || record.Version != "3.8" || record.TitleInfo.Title != book.Title {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if len(record.Names) != 1 || record.Names[0].NamePart != "Ann Author" || record.Names[0].Role.RoleTerm.Value != "author" {
+		t.Fatalf("unexpected names %+v", record.Names)
+	}
+	if origin := record.OriginInfo; origin.Publisher != "Acme Press" || origin.Place == nil || origin.Place.PlaceTerm.Value != "London" || origin.DateIssued.Value != "1999" {
+		t.Fatalf("unexpected origin %+v", origin)
+	}
+	if len(record.Identifiers) != 1 || record.Identifiers[0] != (modsIdentifier{Type: "isbn", Value: book.ISBN}) {
+		t.Fatalf("unexpected identifiers %+v", record.Identifiers)
+	}
+	if !reflect.DeepEqual(record.Subjects, []modsSubject{{Topic: "Cats"}, {Topic: "Mice"}}) || record.RecordInfo.RecordIdentifier != "42" {
+		t.Fatalf("unexpected subjects or record info %+v", record)
+	}
+
+	buf.Reset()
+	if err := WriteMODS(&buf, models.Book{ID: 7, Title: "Anonymous"}); err != nil {
+		t.Fatalf("write mods: %v", err)
+	}
+	record = modsRecord{}
+	if err := xml.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(record.Names) != 0 || len(record.Identifiers) != 0 || record.OriginInfo.Place != nil || record.OriginInfo.Publisher != "" {
+		t.Fatalf("expected optional elements to be left out, got %+v", record)
+	}
+}
@@ -0,0 +1,206 @@
+package metadata
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"desent-api/internal/models"
+	"desent-api/internal/utils"
+)
+
+const maxOpenLibraryLineBytes = 16 << 20
+
+var yearPattern = regexp.MustCompile(`\b(1[0-9]{3}|20[0-9]{2})\b`)
+
+// Visitor receives records as they are parsed; returning an error stops the parse.
+type Visitor struct {
+	Edition func(models.MetadataEdition) error
+	Work    func(models.MetadataWork) error
+	Author  func(models.MetadataAuthor) error
+}
+
+type openLibraryRef struct {
+	Key string `json:"key"`
+}
+
+type openLibraryRecord struct {
+	Type        openLibraryRef    `json:"type"`
+	Key         string            `json:"key"`
+	Title       string            `json:"title"`
+	Subtitle    string            `json:"subtitle"`
+	Name        string            `json:"name"`
+	ISBN10      []string          `json:"isbn_10"`
+	ISBN13      []string          `json:"isbn_13"`
+	PublishDate string            `json:"publish_date"`
+	Subjects    []string          `json:"subjects"`
+	Authors     []json.RawMessage `json:"authors"`
+	Works       []openLibraryRef  `json:"works"`
+}
+
+// ParseOpenLibraryDump reads the tab-separated Open Library dump format
+// (type, key, revision, last_modified, JSON) and also accepts one JSON
+// record per line. Lines that cannot be parsed are counted and skipped.
+func ParseOpenLibraryDump(r io.Reader, visitor Visitor) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxOpenLibraryLineBytes)
+
+	skipped := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		payload := line
+		if !strings.HasPrefix(line, "{") {
+			fields := strings.SplitN(line, "\t", 5)
+			if len(fields) != 5 {
+				skipped++
+				continue
+			}
+			payload = fields[4]
+		}
+
+		var record openLibraryRecord
+		if err := json.Unmarshal([]byte(payload), &record); err != nil {
+			skipped++
+			continue
+		}
+
+		handled, err := visitRecord(record, visitor)
+		if err != nil {
+			return skipped, err
+		}
+		if !handled {
+			skipped++
+		}
+	}
+
+	return skipped, scanner.Err()
+}
+
+func visitRecord(record openLibraryRecord, visitor Visitor) (bool, error) {
+	switch record.Type.Key {
+	case "/type/edition":
+		if visitor.Edition == nil {
+			return true, nil
+		}
+
+		edition := models.MetadataEdition{
+			Key:        record.Key,
+			Title:      joinTitle(record.Title, record.Subtitle),
+			Year:       parseYear(record.PublishDate),
+			Subjects:   cleanStrings(record.Subjects),
+			AuthorKeys: authorKeys(record.Authors),
+			Source:     models.MetadataSourceOpenLibrary,
+		}
+		if len(record.Works) > 0 {
+			edition.WorkKey = record.Works[0].Key
+		}
+		for _, raw := range append(record.ISBN13, record.ISBN10...) {
+			if isbn, ok := utils.NormalizeISBN(raw); ok && !contains(edition.ISBNs, isbn) {
+				edition.ISBNs = append(edition.ISBNs, isbn)
+			}
+		}
+		if len(edition.ISBNs) == 0 {
+			return false, nil
+		}
+
+		return true, visitor.Edition(edition)
+	case "/type/work":
+		if visitor.Work == nil {
+			return true, nil
+		}
+
+		return true, visitor.Work(models.MetadataWork{
+			Key:        record.Key,
+			Title:      joinTitle(record.Title, record.Subtitle),
+			Subjects:   cleanStrings(record.Subjects),
+			AuthorKeys: authorKeys(record.Authors),
+		})
+	case "/type/author":
+		if visitor.Author == nil {
+			return true, nil
+		}
+
+		name := strings.TrimSpace(record.Name)
+		if name == "" {
+			return false, nil
+		}
+
+		return true, visitor.Author(models.MetadataAuthor{Key: record.Key, Name: name})
+	default:
+		return false, nil
+	}
+}
+
+// authorKeys handles both edition style ({"key": ...}) and work style
+// ({"author": {"key": ...}}) author references.
+func authorKeys(raw []json.RawMessage) []string {
+	keys := make([]string, 0, len(raw))
+	for _, entry := range raw {
+		var ref struct {
+			Key    string         `json:"key"`
+			Author openLibraryRef `json:"author"`
+		}
+		if err := json.Unmarshal(entry, &ref); err != nil {
+			continue
+		}
+
+		key := ref.Key
+		if key == "" {
+			key = ref.Author.Key
+		}
+		if key != "" && !contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+func parseYear(publishDate string) int {
+	match := yearPattern.FindString(publishDate)
+	if match == "" {
+		return 0
+	}
+
+	year, _ := strconv.Atoi(match)
+	return year
+}
+
+func joinTitle(title, subtitle string) string {
+	title = strings.TrimSpace(title)
+	subtitle = strings.TrimSpace(subtitle)
+	if subtitle == "" {
+		return title
+	}
+
+	return title + ": " + subtitle
+}
+
+func cleanStrings(values []string) []string {
+	cleaned := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" && !contains(cleaned, value) {
+			cleaned = append(cleaned, value)
+		}
+	}
+
+	return cleaned
+}
+
+func contains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,23 @@
+package metrics
+
+// Reasons recorded by AuthFailures.
+const (
+	AuthFailureMissingToken       = "missing_token"
+	AuthFailureInvalidToken       = "invalid_token"
+	AuthFailureRevokedSession     = "revoked_session"
+	AuthFailureForbidden          = "forbidden"
+	AuthFailureMFARequired        = "mfa_required"
+	AuthFailureInsufficientScope  = "insufficient_scope"
+	AuthFailureInvalidCredentials = "invalid_credentials"
+	AuthFailureInvalidMFACode     = "invalid_mfa_code"
+	AuthFailureInvalidClient      = "invalid_client"
+	AuthFailureLocked             = "locked"
+)
+
+// AuthFailures counts rejected logins and requests rejected by the auth
+// middlewares.
+var AuthFailures = Default.NewCounterVec(
+	"auth_failures_total",
+	"Rejected logins and requests that failed authentication or authorization.",
+	"reason",
+)
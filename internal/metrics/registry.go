@@ -0,0 +1,346 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets suit HTTP and database latencies in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default is the registry served on /metrics. Packages register their
+// metrics on it when they are loaded.
+var Default = NewRegistry()
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families and renders them in the Prometheus text
+// exposition format.
+type Registry struct {
+	mu       sync.Mutex
+	names    map[string]bool
+	families []family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic("metrics: duplicate metric " + name)
+	}
+
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// NewCounterVec registers a counter partitioned by labels.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{labeled: newLabeled(name, help, "counter", labels, nil)}
+	r.register(name, c)
+	return c
+}
+
+// NewGaugeVec registers a gauge partitioned by labels.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{labeled: newLabeled(name, help, "gauge", labels, nil)}
+	r.register(name, g)
+	return g
+}
+
+// NewHistogramVec registers a histogram with the given upper bounds,
+// partitioned by labels.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	h := &HistogramVec{labeled: newLabeled(name, help, "histogram", labels, buckets)}
+	r.register(name, h)
+	return h
+}
+
+// NewGaugeFunc registers a gauge whose value is read on every scrape.
+func (r *Registry) NewGaugeFunc(name, help string, value func() float64) {
+	r.register(name, &funcMetric{name: name, help: help, kind: "gauge", value: value})
+}
+
+// NewCounterFunc registers a counter whose value is read on every scrape.
+// value must never decrease.
+func (r *Registry) NewCounterFunc(name, help string, value func() float64) {
+	r.register(name, &funcMetric{name: name, help: help, kind: "counter", value: value})
+}
+
+// WriteTo renders every family in registration order.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	counter := &countingWriter{w: w}
+	buffered := bufio.NewWriter(counter)
+	for _, f := range families {
+		f.write(buffered)
+	}
+
+	err := buffered.Flush()
+	return counter.n, err
+}
+
+// Handler serves the registry for Prometheus to scrape.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
+
+// labeled keeps one series per combination of label values.
+type labeled struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.RWMutex
+	series map[string]*series
+}
+
+// series is one time series. Counters and gauges use value; histograms
+// use counts (one per bucket plus +Inf), sum and value as the count.
+type series struct {
+	values []string
+
+	mu     sync.Mutex
+	value  float64
+	sum    float64
+	counts []uint64
+}
+
+func newLabeled(name, help, kind string, labels []string, buckets []float64) labeled {
+	return labeled{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: make(map[string]*series)}
+}
+
+func (l *labeled) with(values []string) *series {
+	if len(values) != len(l.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", l.name, len(l.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+	l.mu.RLock()
+	s, ok := l.series[key]
+	l.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.series[key]; ok {
+		return s
+	}
+
+	s = &series{values: slices.Clone(values)}
+	if l.buckets != nil {
+		s.counts = make([]uint64, len(l.buckets)+1)
+	}
+	l.series[key] = s
+	return s
+}
+
+// sorted returns the series ordered by label values so scrapes are stable.
+func (l *labeled) sorted() []*series {
+	l.mu.RLock()
+	keys := make([]string, 0, len(l.series))
+	for key := range l.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	all := make([]*series, 0, len(keys))
+	for _, key := range keys {
+		all = append(all, l.series[key])
+	}
+	l.mu.RUnlock()
+
+	return all
+}
+
+type CounterVec struct {
+	labeled
+}
+
+// Inc adds one to the series with the given label values.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds delta, which must not be negative.
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic("metrics: counter " + c.name + " cannot decrease")
+	}
+
+	s := c.with(values)
+	s.mu.Lock()
+	s.value += delta
+	s.mu.Unlock()
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	writeHeader(w, c.name, c.help, c.kind)
+	for _, s := range c.sorted() {
+		s.mu.Lock()
+		value := s.value
+		s.mu.Unlock()
+		writeSample(w, c.name, c.labels, s.values, "", "", value)
+	}
+}
+
+type GaugeVec struct {
+	labeled
+}
+
+func (g *GaugeVec) Set(value float64, values ...string) {
+	s := g.with(values)
+	s.mu.Lock()
+	s.value = value
+	s.mu.Unlock()
+}
+
+func (g *GaugeVec) Add(delta float64, values ...string) {
+	s := g.with(values)
+	s.mu.Lock()
+	s.value += delta
+	s.mu.Unlock()
+}
+
+func (g *GaugeVec) Inc(values ...string) {
+	g.Add(1, values...)
+}
+
+func (g *GaugeVec) Dec(values ...string) {
+	g.Add(-1, values...)
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	writeHeader(w, g.name, g.help, g.kind)
+	for _, s := range g.sorted() {
+		s.mu.Lock()
+		value := s.value
+		s.mu.Unlock()
+		writeSample(w, g.name, g.labels, s.values, "", "", value)
+	}
+}
+
+type HistogramVec struct {
+	labeled
+}
+
+// Observe records value in the series with the given label values.
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	s := h.with(values)
+	i, _ := slices.BinarySearch(h.buckets, value)
+
+	s.mu.Lock()
+	s.counts[i]++
+	s.sum += value
+	s.value++
+	s.mu.Unlock()
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	writeHeader(w, h.name, h.help, h.kind)
+	for _, s := range h.sorted() {
+		s.mu.Lock()
+		counts := slices.Clone(s.counts)
+		sum, count := s.sum, s.value
+		s.mu.Unlock()
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.values, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.values, "le", "+Inf", count)
+		writeSample(w, h.name+"_sum", h.labels, s.values, "", "", sum)
+		writeSample(w, h.name+"_count", h.labels, s.values, "", "", count)
+	}
+}
+
+type funcMetric struct {
+	name  string
+	help  string
+	kind  string
+	value func() float64
+}
+
+func (f *funcMetric) write(w *bufio.Writer) {
+	writeHeader(w, f.name, f.help, f.kind)
+	writeSample(w, f.name, nil, nil, "", "", f.value())
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		pairs := make([]string, 0, len(labels)+1)
+		for i, label := range labels {
+			pairs = append(pairs, label+`="`+escapeLabel(values[i])+`"`)
+		}
+		if extraLabel != "" {
+			pairs = append(pairs, extraLabel+`="`+extraValue+`"`)
+		}
+		w.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
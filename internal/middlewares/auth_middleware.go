@@ -1,10 +1,14 @@
 package middlewares
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
 	"strings"
 
+	"desent-api/internal/metrics"
+	"desent-api/internal/models"
 	"desent-api/internal/utils"
 )
 
@@ -13,23 +17,97 @@ type unauthorizedResponse struct {
 	Message   string `json:"message"`
 }
 
-func RequireBearerAuth(jwtSecret string) func(http.Handler) http.Handler {
+type principalContextKey struct{}
+
+type credentialsContextKey struct{}
+
+// resolvedCredentials remembers what a request's bearer token resolved to,
+// so the rate limiter and the auth middleware verify it and check its
+// session only once.
+type resolvedCredentials struct {
+	token     string
+	principal models.Principal
+	err       error
+
+	sessionChecked bool
+	sessionErr     error
+}
+
+// TokenVerifier accepts bearer tokens that were not issued by this API,
+// such as those from an external OIDC issuer.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (models.Principal, error)
+}
+
+// RequireBearerAuth accepts tokens signed with jwtSecret and, when given,
+// tokens any of verifiers accepts.
+func RequireBearerAuth(jwtSecret string, verifiers ...TokenVerifier) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authHeader := strings.TrimSpace(r.Header.Get("Authorization"))
-			if authHeader == "" {
-				writeUnauthorized(w)
+			token, ok := bearerToken(r)
+			if !ok {
+				writeUnauthorized(w, metrics.AuthFailureMissingToken)
 				return
 			}
 
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || strings.TrimSpace(parts[1]) == "" {
-				writeUnauthorized(w)
+			principal, err := authenticate(r.Context(), token, jwtSecret, verifiers)
+			if err != nil {
+				writeUnauthorized(w, metrics.AuthFailureInvalidToken)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// OptionalBearerAuth attaches the principal when a bearer token is sent but
+// lets anonymous requests through. Invalid tokens are still rejected.
+func OptionalBearerAuth(jwtSecret string, verifiers ...TokenVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.TrimSpace(r.Header.Get("Authorization")) == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := bearerToken(r)
+			if !ok {
+				writeUnauthorized(w, metrics.AuthFailureInvalidToken)
+				return
+			}
+
+			principal, err := authenticate(r.Context(), token, jwtSecret, verifiers)
+			if err != nil {
+				writeUnauthorized(w, metrics.AuthFailureInvalidToken)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// SessionValidator vets tokens issued by this API against their session.
+type SessionValidator interface {
+	Validate(ctx context.Context, principal models.Principal, ip string) error
+}
+
+// RequireActiveSession rejects tokens whose session was revoked and records
+// when and from where the rest were used. It runs after RequireBearerAuth or
+// OptionalBearerAuth and lets anonymous requests through. A session that
+// cannot be checked is treated as revoked.
+func RequireActiveSession(sessions SessionValidator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			if err := utils.ValidateToken(strings.TrimSpace(parts[1]), jwtSecret); err != nil {
-				writeUnauthorized(w)
+			if err := validateSession(r, sessions, principal); err != nil {
+				writeUnauthorized(w, metrics.AuthFailureRevokedSession)
 				return
 			}
 
@@ -38,7 +116,99 @@ func RequireBearerAuth(jwtSecret string) func(http.Handler) http.Handler {
 	}
 }
 
-func writeUnauthorized(w http.ResponseWriter) {
+// WithPrincipal also records the subject for the access log.
+func WithPrincipal(ctx context.Context, principal models.Principal) context.Context {
+	recordAccessLogUser(ctx, principal.Subject)
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (models.Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(models.Principal)
+	return principal, ok
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	authHeader := strings.TrimSpace(r.Header.Get("Authorization"))
+	if authHeader == "" {
+		return "", false
+	}
+
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || strings.TrimSpace(parts[1]) == "" {
+		return "", false
+	}
+
+	return strings.TrimSpace(parts[1]), true
+}
+
+// withCredentialCache lets authenticate and validateSession reuse their
+// result for the rest of the request.
+func withCredentialCache(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(credentialsContextKey{}).(*resolvedCredentials); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, credentialsContextKey{}, &resolvedCredentials{})
+}
+
+func authenticate(ctx context.Context, token, jwtSecret string, verifiers []TokenVerifier) (models.Principal, error) {
+	cached, _ := ctx.Value(credentialsContextKey{}).(*resolvedCredentials)
+	if cached != nil && cached.token == token {
+		return cached.principal, cached.err
+	}
+
+	principal, err := verifyToken(ctx, token, jwtSecret, verifiers)
+	if cached != nil {
+		*cached = resolvedCredentials{token: token, principal: principal, err: err}
+	}
+
+	return principal, err
+}
+
+func verifyToken(ctx context.Context, token, jwtSecret string, verifiers []TokenVerifier) (models.Principal, error) {
+	claims, err := utils.ParseToken(token, jwtSecret)
+	if err != nil {
+		for _, verifier := range verifiers {
+			if principal, verifyErr := verifier.Verify(ctx, token); verifyErr == nil {
+				return principal, nil
+			}
+		}
+
+		return models.Principal{}, err
+	}
+
+	principal := models.Principal{
+		Subject:     claims.Subject,
+		AuthMethods: claims.AuthMethods,
+		ClientID:    claims.ClientID,
+		SessionID:   claims.ID,
+	}
+	if claims.Scope != "" || claims.ClientID != "" {
+		principal.Scopes = strings.Fields(claims.Scope)
+	}
+
+	return principal, nil
+}
+
+// validateSession checks principal's session once per request when it is
+// the principal authenticate resolved.
+func validateSession(r *http.Request, sessions SessionValidator, principal models.Principal) error {
+	cached, _ := r.Context().Value(credentialsContextKey{}).(*resolvedCredentials)
+	reusable := cached != nil && cached.err == nil && cached.principal.Subject == principal.Subject && cached.principal.SessionID == principal.SessionID
+	if reusable && cached.sessionChecked {
+		return cached.sessionErr
+	}
+
+	err := sessions.Validate(r.Context(), principal, ClientIP(r))
+	if reusable {
+		cached.sessionChecked, cached.sessionErr = true, err
+	}
+
+	return err
+}
+
+func writeUnauthorized(w http.ResponseWriter, reason string) {
+	metrics.AuthFailures.Inc(reason)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusUnauthorized)
 	_ = json.NewEncoder(w).Encode(unauthorizedResponse{
@@ -46,3 +216,143 @@ func writeUnauthorized(w http.ResponseWriter) {
 		Message:   "unauthorized",
 	})
 }
+
+// RequireAdmin only lets through principals listed as administrators or
+// granted the admin role by an external issuer. It must run after
+// RequireBearerAuth.
+func RequireAdmin(adminSubjects []string) func(http.Handler) http.Handler {
+	admins := make(map[string]bool, len(adminSubjects))
+	for _, subject := range adminSubjects {
+		admins[subject] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				writeUnauthorized(w, metrics.AuthFailureMissingToken)
+				return
+			}
+
+			if !admins[principal.Subject] && !principal.HasRole(models.RoleAdmin) {
+				metrics.AuthFailures.Inc(metrics.AuthFailureForbidden)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(w).Encode(unauthorizedResponse{
+					ErrorCode: "FORBIDDEN",
+					Message:   "administrator access required",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireMFA only lets through principals whose token was issued after a
+// second factor. It must run after RequireBearerAuth.
+func RequireMFA() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				writeUnauthorized(w, metrics.AuthFailureMissingToken)
+				return
+			}
+
+			if !principal.HasAuthMethod(utils.AuthMethodOTP) && !principal.HasAuthMethod(utils.AuthMethodRecovery) {
+				metrics.AuthFailures.Inc(metrics.AuthFailureMFARequired)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(w).Encode(unauthorizedResponse{
+					ErrorCode: "MFA_REQUIRED",
+					Message:   "a token issued with multi-factor authentication is required",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope rejects OAuth tokens that were not granted scope. Tokens
+// from /auth/token carry no scope and pass. It must run after
+// RequireBearerAuth.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				writeUnauthorized(w, metrics.AuthFailureMissingToken)
+				return
+			}
+
+			if !principal.HasScope(scope) {
+				metrics.AuthFailures.Inc(metrics.AuthFailureInsufficientScope)
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+				w.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(w).Encode(unauthorizedResponse{
+					ErrorCode: "INSUFFICIENT_SCOPE",
+					Message:   "token is missing the " + scope + " scope",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireFirstPartyToken rejects tokens issued to an OAuth client, whatever
+// their scope, so delegated access never reaches account, MFA or admin
+// routes. It must run after RequireBearerAuth.
+func RequireFirstPartyToken() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				writeUnauthorized(w, metrics.AuthFailureMissingToken)
+				return
+			}
+
+			if principal.ClientID != "" || principal.Scopes != nil {
+				metrics.AuthFailures.Inc(metrics.AuthFailureInsufficientScope)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(w).Encode(unauthorizedResponse{
+					ErrorCode: "FIRST_PARTY_TOKEN_REQUIRED",
+					Message:   "tokens issued to OAuth clients cannot be used here",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AdminTokenSubject is the principal subject of requests authenticated
+// with the dedicated admin token.
+const AdminTokenSubject = "admin-token"
+
+// AllowAdminToken lets through requests whose bearer token is token and
+// hands every other request to otherwise, typically the administrator
+// chain. An empty token is never accepted.
+func AllowAdminToken(token string, otherwise func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fallback := otherwise(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sent, ok := bearerToken(r)
+			if token == "" || !ok || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+
+			principal := models.Principal{Subject: AdminTokenSubject, Roles: []string{models.RoleAdmin}}
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
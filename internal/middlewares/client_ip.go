@@ -0,0 +1,193 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type clientIPContextKey struct{}
+
+// Forwarding headers a trusted proxy can be configured to write.
+const (
+	ProxyHeaderXForwardedFor = "x-forwarded-for"
+	ProxyHeaderForwarded     = "forwarded"
+)
+
+// ClientIPResolver finds the address of the client behind a chain of
+// reverse proxies. Forwarding headers are only believed when they were
+// added by a trusted proxy, so a client cannot pick its own address.
+type ClientIPResolver struct {
+	trusted []netip.Prefix
+	header  string
+}
+
+// NewClientIPResolver accepts CIDRs or single addresses of trusted proxies
+// and the forwarding header they write, ProxyHeaderXForwardedFor by default.
+// Only that header is read: proxies pass the other one through from the
+// client untouched.
+func NewClientIPResolver(trustedProxies []string, header string) (*ClientIPResolver, error) {
+	header = strings.ToLower(strings.TrimSpace(header))
+	switch header {
+	case "":
+		header = ProxyHeaderXForwardedFor
+	case ProxyHeaderXForwardedFor, ProxyHeaderForwarded:
+	default:
+		return nil, fmt.Errorf("trusted proxy header %q: expected %s or %s", header, ProxyHeaderXForwardedFor, ProxyHeaderForwarded)
+	}
+
+	resolver := &ClientIPResolver{header: header}
+	for _, raw := range trustedProxies {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		if !strings.Contains(raw, "/") {
+			addr, err := netip.ParseAddr(raw)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", raw, err)
+			}
+			resolver.trusted = append(resolver.trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", raw, err)
+		}
+		resolver.trusted = append(resolver.trusted, prefix.Masked())
+	}
+
+	return resolver, nil
+}
+
+// Resolve walks the forwarding chain from the nearest hop outwards and
+// returns the first address that is not a trusted proxy. Only the
+// configured header is walked. If a hop cannot be parsed the last trusted address is returned, since
+// anything beyond it may have been written by the client.
+func (c *ClientIPResolver) Resolve(r *http.Request) string {
+	peer, ok := parseHop(r.RemoteAddr)
+	if !ok {
+		return remoteHost(r)
+	}
+	if !c.isTrusted(peer) {
+		return peer.String()
+	}
+
+	var hops []string
+	if c.header == ProxyHeaderForwarded {
+		hops = forwardedHops(r.Header)
+	} else {
+		hops = forwardedForHops(r.Header)
+	}
+
+	current := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseHop(hops[i])
+		if !ok {
+			return current.String()
+		}
+
+		current = hop
+		if !c.isTrusted(hop) {
+			return hop.String()
+		}
+	}
+
+	return current.String()
+}
+
+func (c *ClientIPResolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range c.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResolveClientIP stores the resolved client address on the request context
+// for the rate limiter and access log.
+func ResolveClientIP(resolver *ClientIPResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), clientIPContextKey{}, resolver.Resolve(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIP returns the address resolved by ResolveClientIP, or the peer
+// address when the middleware is not mounted.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPContextKey{}).(string); ok && ip != "" {
+		return ip
+	}
+
+	return remoteHost(r)
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err == nil && host != "" {
+		return host
+	}
+
+	if r.RemoteAddr == "" {
+		return "unknown"
+	}
+
+	return r.RemoteAddr
+}
+
+// forwardedHops returns the for= values of every Forwarded element in
+// order.
+func forwardedHops(header http.Header) []string {
+	var hops []string
+	for _, value := range header.Values("Forwarded") {
+		for _, element := range strings.Split(value, ",") {
+			hop := ""
+			for _, pair := range strings.Split(element, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hop = strings.Trim(val, `"`)
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+
+	return hops
+}
+
+func forwardedForHops(header http.Header) []string {
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	return hops
+}
+
+// parseHop accepts bare addresses and the host:port and [v6]:port forms
+// used by RemoteAddr and Forwarded.
+func parseHop(raw string) (netip.Addr, bool) {
+	raw = strings.TrimSpace(raw)
+	if addrPort, err := netip.ParseAddrPort(raw); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(raw, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
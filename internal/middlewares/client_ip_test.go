@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	xff, err := NewClientIPResolver([]string{"10.0.0.0/8", "2001:db8::1"}, ProxyHeaderXForwardedFor)
+	if err != nil {
+		t.Fatalf("new resolver: %v", err)
+	}
+	forwarded, err := NewClientIPResolver([]string{"10.0.0.0/8", "2001:db8::1"}, "Forwarded")
+	if err != nil {
+		t.Fatalf("new resolver: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		resolver   *ClientIPResolver
+		remoteAddr string
+		xff        []string
+		forwarded  []string
+		want       string
+	}{
+		{"untrusted peer ignores headers", xff, "203.0.113.9:4000", []string{"198.51.100.1"}, nil, "203.0.113.9"},
+		{"trusted peer uses forwarded for", xff, "10.0.0.5:4000", []string{"198.51.100.1"}, nil, "198.51.100.1"},
+		{"spoofed left hops are skipped", xff, "10.0.0.5:4000", []string{"1.2.3.4, 198.51.100.1"}, nil, "198.51.100.1"},
+		{"walks through trusted hops", xff, "10.0.0.5:4000", []string{"198.51.100.1, 10.1.1.1", "10.2.2.2"}, nil, "198.51.100.1"},
+		{"all trusted returns leftmost", xff, "10.0.0.5:4000", []string{"10.3.3.3, 10.1.1.1"}, nil, "10.3.3.3"},
+		{"garbage hop stops at last trusted", xff, "10.0.0.5:4000", []string{"198.51.100.1, nonsense, 10.1.1.1"}, nil, "10.1.1.1"},
+		{"no headers uses peer", xff, "10.0.0.5:4000", nil, nil, "10.0.0.5"},
+		{"spoofed forwarded beside proxy xff", xff, "10.0.0.5:4000", []string{"198.51.100.1"}, []string{"for=1.2.3.4"}, "198.51.100.1"},
+		{"forwarded alone is ignored", xff, "10.0.0.5:4000", nil, []string{"for=1.2.3.4"}, "10.0.0.5"},
+		{"rfc 7239 forwarded", forwarded, "10.0.0.5:4000", nil, []string{`for=192.0.2.60;proto=http;by=203.0.113.43, for="10.1.1.1:8080"`}, "192.0.2.60"},
+		{"forwarded ipv6 with port", forwarded, "[2001:db8::1]:4000", nil, []string{`for="[2001:db8:cafe::17]:4711"`}, "2001:db8:cafe::17"},
+		{"spoofed xff beside proxy forwarded", forwarded, "10.0.0.5:4000", []string{"1.2.3.4"}, []string{"for=192.0.2.60"}, "192.0.2.60"},
+		{"obfuscated forwarded identifier", forwarded, "10.0.0.5:4000", nil, []string{"for=_hidden"}, "10.0.0.5"},
+		{"ipv4 mapped peer", xff, "[::ffff:10.0.0.5]:4000", []string{"198.51.100.1"}, nil, "198.51.100.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for _, value := range tc.xff {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			for _, value := range tc.forwarded {
+				req.Header.Add("Forwarded", value)
+			}
+
+			if got := tc.resolver.Resolve(req); got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestClientIPResolver_InvalidConfig(t *testing.T) {
+	if _, err := NewClientIPResolver([]string{"10.0.0.0/33"}, ""); err == nil {
+		t.Fatal("expected invalid CIDR to be rejected")
+	}
+	if _, err := NewClientIPResolver([]string{"proxy.local"}, ""); err == nil {
+		t.Fatal("expected hostname to be rejected")
+	}
+	if _, err := NewClientIPResolver([]string{"10.0.0.0/8"}, "x-real-ip"); err == nil {
+		t.Fatal("expected an unsupported header to be rejected")
+	}
+}
+
+func TestRateLimiter_UsesResolvedClientIP(t *testing.T) {
+	resolver, err := NewClientIPResolver([]string{"10.0.0.0/8"}, "")
+	if err != nil {
+		t.Fatalf("new resolver: %v", err)
+	}
+
+	now := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	h := ResolveClientIP(resolver)(newRateLimitedHandler(1, 1, &now, nil))
+
+	send := func(remoteAddr, xff string) int {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", xff)
+		res := httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	if code := send("203.0.113.9:4000", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if code := send("203.0.113.9:4000", "198.51.100.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected a spoofed X-Forwarded-For not to grant a new bucket, got %d", code)
+	}
+	if code := send("10.0.0.5:4000", "198.51.100.3"); code != http.StatusOK {
+		t.Fatalf("expected clients behind a trusted proxy to get their own bucket, got %d", code)
+	}
+}
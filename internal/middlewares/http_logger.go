@@ -1,10 +1,16 @@
 package middlewares
 
 import (
+	"context"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"desent-api/internal/tracing"
+
 	"github.com/go-chi/chi/v5/middleware"
 )
 
@@ -29,7 +35,27 @@ func (rw *responseRecorder) Write(b []byte) (int, error) {
 	return n, err
 }
 
-func HTTPLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+// HTTPLogOptions tunes HTTPLogger. SuccessSampleRatio is the share of 2xx
+// responses that are logged, between 0 and 1; every other status is always
+// logged.
+type HTTPLogOptions struct {
+	SuccessSampleRatio float64
+}
+
+type accessLogKey struct{}
+
+// accessLogEntry collects fields that are only known deeper in the chain,
+// such as the authenticated user.
+type accessLogEntry struct {
+	userID string
+}
+
+// HTTPLogger writes one entry per request with the chi route pattern rather
+// than the raw path, the trace and user IDs, and the query string and
+// Authorization header with their secrets redacted. Server errors are
+// logged at Error and client errors at Warn. Mount it after Tracing so the
+// trace ID is known.
+func HTTPLogger(logger *slog.Logger, opts HTTPLogOptions) func(http.Handler) http.Handler {
 	if logger == nil {
 		logger = slog.Default()
 	}
@@ -38,19 +64,118 @@ func HTTPLogger(logger *slog.Logger) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			startedAt := time.Now()
 			recorder := &responseRecorder{ResponseWriter: w}
+			entry := &accessLogEntry{}
+
+			next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, entry)))
+
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status/100 == 2 && !sampled(opts.SuccessSampleRatio) {
+				return
+			}
 
-			next.ServeHTTP(recorder, r)
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
 
-			logger.Info("http request",
+			attrs := []slog.Attr{
 				slog.String("request_id", middleware.GetReqID(r.Context())),
 				slog.String("method", r.Method),
-				slog.String("path", r.URL.Path),
-				slog.Int("status", recorder.status),
+				slog.String("route", routePattern(r)),
+				slog.Int("status", status),
 				slog.Int("bytes", recorder.size),
 				slog.Int64("duration_ms", time.Since(startedAt).Milliseconds()),
-				slog.String("remote_ip", r.RemoteAddr),
+				slog.String("remote_ip", ClientIP(r)),
 				slog.String("user_agent", r.UserAgent()),
-			)
+			}
+			if r.URL.RawQuery != "" {
+				attrs = append(attrs, slog.String("query", redactQuery(r.URL.RawQuery)))
+			}
+			if auth := r.Header.Get("Authorization"); auth != "" {
+				attrs = append(attrs, slog.String("authorization", redactAuthorization(auth)))
+			}
+			if sc := tracing.SpanFromContext(r.Context()).SpanContext(); sc.IsValid() {
+				attrs = append(attrs, slog.String("trace_id", sc.TraceID.String()))
+			}
+			if entry.userID != "" {
+				attrs = append(attrs, slog.String("user_id", entry.userID))
+			}
+
+			logger.LogAttrs(r.Context(), level, "http request", attrs...)
 		})
 	}
 }
+
+func sampled(ratio float64) bool {
+	return ratio >= 1 || (ratio > 0 && rand.Float64() < ratio)
+}
+
+// recordAccessLogUser attaches the authenticated subject to the access log
+// entry of the request, if HTTPLogger is mounted.
+func recordAccessLogUser(ctx context.Context, subject string) {
+	if entry, ok := ctx.Value(accessLogKey{}).(*accessLogEntry); ok {
+		entry.userID = subject
+	}
+}
+
+const redacted = "[REDACTED]"
+
+// secretQueryParams are redacted wherever they appear in a query string;
+// parameters whose name contains one of secretQueryFragments are as well.
+var (
+	secretQueryParams    = map[string]bool{"code": true, "sig": true}
+	secretQueryFragments = []string{"token", "password", "secret", "key", "signature", "credential"}
+)
+
+// redactQuery keeps the order and encoding of rawQuery but replaces the
+// values of secret parameters.
+func redactQuery(rawQuery string) string {
+	parts := strings.Split(rawQuery, "&")
+	for i, part := range parts {
+		name, _, hasValue := strings.Cut(part, "=")
+		if !hasValue {
+			continue
+		}
+
+		decoded, err := url.QueryUnescape(name)
+		if err != nil {
+			decoded = name
+		}
+		if isSecretQueryParam(decoded) {
+			parts[i] = name + "=" + redacted
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+func isSecretQueryParam(name string) bool {
+	name = strings.ToLower(name)
+	if secretQueryParams[name] {
+		return true
+	}
+
+	for _, fragment := range secretQueryFragments {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactAuthorization keeps only the scheme, such as "Bearer".
+func redactAuthorization(value string) string {
+	scheme, _, hasCredentials := strings.Cut(strings.TrimSpace(value), " ")
+	if !hasCredentials {
+		return redacted
+	}
+
+	return scheme + " " + redacted
+}
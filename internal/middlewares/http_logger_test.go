@@ -0,0 +1,115 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"desent-api/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func decodeLogLines(t *testing.T, out *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("decode log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestHTTPLogger_RedactsAndUsesRoutePatterns(t *testing.T) {
+	spans := useTestTracer(t)
+
+	var out bytes.Buffer
+	r := chi.NewRouter()
+	r.Use(Tracing())
+	r.Use(HTTPLogger(slog.New(slog.NewJSONHandler(&out, nil)), HTTPLogOptions{SuccessSampleRatio: 1}))
+	r.With(RequireBearerAuth("test-secret")).Get("/logged/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	token, err := utils.GenerateToken("alice", "test-secret", time.Hour)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/logged/42?page=2&access_token=abc&Client_Secret=s3&code=xyz&q=go", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	traceID := spans()["GET /logged/{id}"].TraceID
+
+	entries := decodeLogLines(t, &out)
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, got %d: %s", len(entries), out.String())
+	}
+	entry := entries[0]
+	if entry["route"] != "/logged/{id}" || entry["status"] != float64(200) || entry["level"] != "INFO" {
+		t.Fatalf("unexpected entry %v", entry)
+	}
+	if entry["query"] != "page=2&access_token=[REDACTED]&Client_Secret=[REDACTED]&code=[REDACTED]&q=go" {
+		t.Fatalf("unexpected query %v", entry["query"])
+	}
+	if entry["authorization"] != "Bearer [REDACTED]" || entry["user_id"] != "alice" || entry["trace_id"] != traceID {
+		t.Fatalf("unexpected entry %v", entry)
+	}
+	if strings.Contains(out.String(), token) || strings.Contains(out.String(), "/logged/42") {
+		t.Fatalf("expected the token and raw path not to be logged: %s", out.String())
+	}
+}
+
+func TestHTTPLogger_SamplesOnlySuccessfulResponses(t *testing.T) {
+	var out bytes.Buffer
+	r := chi.NewRouter()
+	r.Use(HTTPLogger(slog.New(slog.NewJSONHandler(&out, nil)), HTTPLogOptions{SuccessSampleRatio: 0}))
+	r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/fail", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) })
+
+	for _, target := range []string{"/ok", "/ok", "/missing", "/fail"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	entries := decodeLogLines(t, &out)
+	if len(entries) != 2 {
+		t.Fatalf("expected only the error responses to be logged, got %s", out.String())
+	}
+	if entries[0]["route"] != "unmatched" || entries[0]["level"] != "WARN" {
+		t.Fatalf("unexpected entry for unknown path %v", entries[0])
+	}
+	if entries[1]["status"] != float64(502) || entries[1]["level"] != "ERROR" {
+		t.Fatalf("unexpected entry for server error %v", entries[1])
+	}
+	if _, ok := entries[0]["user_id"]; ok {
+		t.Fatalf("expected no user for anonymous requests, got %v", entries[0])
+	}
+}
+
+func TestAsyncWriter_FlushesOnCloseAndDropsWhenFull(t *testing.T) {
+	var out bytes.Buffer
+	writer := utils.NewAsyncWriter(&out, 100)
+	for i := 0; i < 50; i++ {
+		_, _ = writer.Write([]byte("entry\n"))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if got := strings.Count(out.String(), "entry\n"); got != 50 || writer.Dropped() != 0 {
+		t.Fatalf("expected 50 entries and none dropped, got %d and %d", got, writer.Dropped())
+	}
+
+	_, _ = writer.Write([]byte("late\n"))
+	if writer.Dropped() != 1 || strings.Contains(out.String(), "late") {
+		t.Fatal("expected writes after close to be dropped")
+	}
+}
@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"desent-api/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	httpRequests = metrics.Default.NewCounterVec(
+		"http_requests_total",
+		"HTTP requests by method, chi route pattern and status.",
+		"method", "route", "status",
+	)
+	httpRequestDuration = metrics.Default.NewHistogramVec(
+		"http_request_duration_seconds",
+		"HTTP request latency by method, chi route pattern and status.",
+		metrics.DefaultBuckets,
+		"method", "route", "status",
+	)
+	httpRequestsInFlight = metrics.Default.NewGaugeVec(
+		"http_requests_in_flight",
+		"HTTP requests currently being served.",
+	)
+)
+
+// unmatchedRoute labels requests no route matched, so unknown paths do not
+// each get their own series.
+const unmatchedRoute = "unmatched"
+
+// HTTPMetrics records request counts, latency and in-flight requests. The
+// route label is the chi pattern, such as /books/{id}, not the raw path.
+func HTTPMetrics() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			startedAt := time.Now()
+			recorder := &responseRecorder{ResponseWriter: w}
+
+			httpRequestsInFlight.Inc()
+			defer httpRequestsInFlight.Dec()
+
+			next.ServeHTTP(recorder, r)
+
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			labels := []string{r.Method, routePattern(r), strconv.Itoa(status)}
+			httpRequests.Inc(labels...)
+			httpRequestDuration.Observe(time.Since(startedAt).Seconds(), labels...)
+		})
+	}
+}
+
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+
+	return unmatchedRoute
+}
@@ -0,0 +1,87 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"desent-api/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func scrape(t *testing.T, registry *metrics.Registry) string {
+	t.Helper()
+
+	res := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(res.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", res.Header().Get("Content-Type"))
+	}
+	return res.Body.String()
+}
+
+func TestHTTPMetrics_LabelsByRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(HTTPMetrics())
+	r.Get("/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(scrape(t, metrics.Default), "http_requests_in_flight 1\n") {
+			t.Error("expected the request to be counted as in flight")
+		}
+		w.WriteHeader(http.StatusTeapot)
+	})
+	r.With(RequireBearerAuth("test-secret")).Get("/metrics-test-auth", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, target := range []string{"/metrics-test/1", "/metrics-test/2", "/metrics-test-auth", "/metrics-test-missing/1"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	body := scrape(t, metrics.Default)
+	for _, line := range []string{
+		`http_requests_total{method="GET",route="/metrics-test/{id}",status="418"} 2`,
+		`http_requests_total{method="GET",route="/metrics-test-auth",status="401"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/metrics-test/{id}",status="418"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/metrics-test/{id}",status="418",le="+Inf"} 2`,
+		`route="unmatched",status="404"}`,
+		`auth_failures_total{reason="missing_token"}`,
+		"http_requests_in_flight 0\n",
+	} {
+		if !strings.Contains(body, line) {
+			t.Fatalf("expected %s in\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "/metrics-test/1") {
+		t.Fatal("expected raw paths not to be used as labels")
+	}
+}
+
+func TestRegistry_TextFormat(t *testing.T) {
+	registry := metrics.NewRegistry()
+	latency := registry.NewHistogramVec("job_seconds", "Job latency.", []float64{1, 0.1}, "job")
+	jobs := registry.NewCounterVec("jobs_total", "Jobs run.\nBy name.", "job")
+	registry.NewGaugeFunc("queue_depth", "Queued jobs.", func() float64 { return 3 })
+
+	latency.Observe(0.05, "import")
+	latency.Observe(0.1, "import")
+	latency.Observe(5, "import")
+	jobs.Inc(`say "hi"`)
+
+	expected := `# HELP job_seconds Job latency.
+# TYPE job_seconds histogram
+job_seconds_bucket{job="import",le="0.1"} 2
+job_seconds_bucket{job="import",le="1"} 2
+job_seconds_bucket{job="import",le="+Inf"} 3
+job_seconds_sum{job="import"} 5.15
+job_seconds_count{job="import"} 3
+# HELP jobs_total Jobs run.\nBy name.
+# TYPE jobs_total counter
+jobs_total{job="say \"hi\""} 1
+# HELP queue_depth Queued jobs.
+# TYPE queue_depth gauge
+queue_depth 3
+`
+	if body := scrape(t, registry); body != expected {
+		t.Fatalf("unexpected exposition:\n%s", body)
+	}
+}
@@ -0,0 +1,331 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"desent-api/internal/oidc"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeIssuer serves a JWKS document and signs tokens like an identity
+// provider would.
+type fakeIssuer struct {
+	server  *httptest.Server
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fetches atomic.Int32
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+
+	issuer := &fakeIssuer{keys: map[string]*rsa.PrivateKey{}}
+	issuer.addKey(t, "key-1")
+	issuer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issuer.fetches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(issuer.jwks())
+	}))
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (f *fakeIssuer) addKey(t *testing.T, kid string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[kid] = key
+}
+
+func (f *fakeIssuer) jwks() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]map[string]string, 0, len(f.keys))
+	for kid, key := range f.keys {
+		keys = append(keys, map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	data, _ := json.Marshal(map[string]any{"keys": keys})
+	return data
+}
+
+func (f *fakeIssuer) token(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	f.mu.Lock()
+	key := f.keys[kid]
+	f.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func oidcClaims(issuer string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":    issuer,
+		"aud":    []string{"desent-api"},
+		"sub":    "alice",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"groups": []string{"staff", "library-admins"},
+	}
+}
+
+func serveWithToken(handler http.Handler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/admin/lockouts", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
+
+func TestRequireBearerAuth_OIDCIssuer(t *testing.T) {
+	fake := newFakeIssuer(t)
+	issuerURL := "https://id.example.test"
+	now := time.Now()
+	verifier, err := oidc.NewVerifier([]oidc.IssuerConfig{{
+		Name:         "corp",
+		Issuer:       issuerURL,
+		Audiences:    []string{"desent-api"},
+		JWKSURL:      fake.server.URL,
+		RolesClaim:   "groups",
+		RoleMappings: map[string]string{"library-admins": "admin"},
+	}}, fake.server.Client(), func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+
+	var subject string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := PrincipalFromContext(r.Context())
+		subject = principal.Subject
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := RequireBearerAuth("test-secret", verifier)(RequireAdmin([]string{"admin"})(next))
+
+	res := serveWithToken(handler, fake.token(t, "key-1", oidcClaims(issuerURL)))
+	if res.Code != http.StatusOK || subject != "corp:alice" {
+		t.Fatalf("expected the mapped admin role to pass as corp:alice, got %d (%q)", res.Code, subject)
+	}
+
+	serveWithToken(handler, fake.token(t, "key-1", oidcClaims(issuerURL)))
+	if fetches := fake.fetches.Load(); fetches != 1 {
+		t.Fatalf("expected the jwks to be cached, got %d fetches", fetches)
+	}
+
+	claims := oidcClaims(issuerURL)
+	claims["groups"] = []string{"staff"}
+	if res := serveWithToken(handler, fake.token(t, "key-1", claims)); res.Code != http.StatusForbidden {
+		t.Fatalf("expected unmapped groups to grant no role, got %d", res.Code)
+	}
+
+	fake.addKey(t, "key-2")
+	now = now.Add(11 * time.Second)
+	if res := serveWithToken(handler, fake.token(t, "key-2", oidcClaims(issuerURL))); res.Code != http.StatusOK {
+		t.Fatalf("expected a rotated key to be fetched, got %d", res.Code)
+	}
+
+	rejected := map[string]jwt.MapClaims{
+		"wrong audience": func() jwt.MapClaims { c := oidcClaims(issuerURL); c["aud"] = "other-api"; return c }(),
+		"wrong issuer":   oidcClaims("https://evil.example.test"),
+		"expired": func() jwt.MapClaims {
+			c := oidcClaims(issuerURL)
+			c["exp"] = time.Now().Add(-time.Hour).Unix()
+			return c
+		}(),
+		"no expiry": func() jwt.MapClaims { c := oidcClaims(issuerURL); delete(c, "exp"); return c }(),
+	}
+	for name, claims := range rejected {
+		t.Run(name, func(t *testing.T) {
+			if res := serveWithToken(handler, fake.token(t, "key-1", claims)); res.Code != http.StatusUnauthorized {
+				t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, res.Code)
+			}
+		})
+	}
+
+	t.Run("hmac token with issuer claims", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, oidcClaims(issuerURL))
+		token.Header["kid"] = "key-1"
+		signed, _ := token.SignedString([]byte("guessed"))
+		if res := serveWithToken(handler, signed); res.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, res.Code)
+		}
+	})
+}
+
+func TestRequireBearerAuth_OIDCJWKSFile(t *testing.T) {
+	fake := newFakeIssuer(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, fake.jwks(), 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+
+	prefix := "ext-"
+	verifier, err := oidc.NewVerifier([]oidc.IssuerConfig{{
+		Issuer:        "https://id.example.test",
+		Audiences:     []string{"desent-api"},
+		JWKSFile:      path,
+		SubjectClaim:  "email",
+		SubjectPrefix: &prefix,
+	}}, nil, nil)
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+
+	var subject string
+	handler := RequireBearerAuth("test-secret", verifier)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := PrincipalFromContext(r.Context())
+		subject = principal.Subject
+	}))
+
+	claims := oidcClaims("https://id.example.test")
+	claims["email"] = "alice@example.test"
+	if res := serveWithToken(handler, fake.token(t, "key-1", claims)); res.Code != http.StatusOK || subject != "ext-alice@example.test" {
+		t.Fatalf("expected the prefixed email claim as subject, got %d (%q)", res.Code, subject)
+	}
+}
+
+func TestNewVerifier_RejectsEmptySubjectPrefix(t *testing.T) {
+	prefix := ""
+	_, err := oidc.NewVerifier([]oidc.IssuerConfig{{
+		Issuer:        "https://id.example.test",
+		Audiences:     []string{"desent-api"},
+		JWKSFile:      "jwks.json",
+		SubjectPrefix: &prefix,
+	}}, nil, nil)
+	if !errors.Is(err, oidc.ErrInvalidIssuers) {
+		t.Fatalf("expected an empty subject_prefix to be rejected, got %v", err)
+	}
+}
+
+func TestRequireMFA_OIDCAMRNeedsTrustAMR(t *testing.T) {
+	fake := newFakeIssuer(t)
+	issuerURL := "https://id.example.test"
+	claims := oidcClaims(issuerURL)
+	claims["amr"] = []string{"pwd", "otp"}
+	token := fake.token(t, "key-1", claims)
+
+	for _, trust := range []bool{false, true} {
+		verifier, err := oidc.NewVerifier([]oidc.IssuerConfig{{
+			Name:      "corp",
+			Issuer:    issuerURL,
+			Audiences: []string{"desent-api"},
+			JWKSURL:   fake.server.URL,
+			TrustAMR:  trust,
+		}}, fake.server.Client(), nil)
+		if err != nil {
+			t.Fatalf("new verifier: %v", err)
+		}
+
+		handler := RequireBearerAuth("test-secret", verifier)(RequireMFA()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+		want := http.StatusForbidden
+		if trust {
+			want = http.StatusOK
+		}
+		if res := serveWithToken(handler, token); res.Code != want {
+			t.Fatalf("trust_amr=%v: expected status %d, got %d", trust, want, res.Code)
+		}
+	}
+}
+
+func TestRequireBearerAuth_OIDCFetchesJWKSOutsideTheLock(t *testing.T) {
+	fake := newFakeIssuer(t)
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(fake.jwks())
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	issuerURL := "https://id.example.test"
+	var nowMu sync.Mutex
+	now := time.Now()
+	verifier, err := oidc.NewVerifier([]oidc.IssuerConfig{{
+		Name:      "corp",
+		Issuer:    issuerURL,
+		Audiences: []string{"desent-api"},
+		JWKSURL:   server.URL,
+	}}, server.Client(), func() time.Time {
+		nowMu.Lock()
+		defer nowMu.Unlock()
+		return now
+	})
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+	handler := RequireBearerAuth("test-secret", verifier)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	known := fake.token(t, "key-1", oidcClaims(issuerURL))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveWithToken(handler, known)
+		}()
+	}
+	wg.Wait()
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("expected concurrent callers to share one fetch, got %d", got)
+	}
+
+	// A token with an unknown kid starts a refresh that hangs; tokens
+	// signed with a cached key must still verify meanwhile.
+	fake.addKey(t, "key-2")
+	nowMu.Lock()
+	now = now.Add(11 * time.Second)
+	nowMu.Unlock()
+	rotated := fake.token(t, "key-2", oidcClaims(issuerURL))
+	go serveWithToken(handler, rotated)
+	for fetches.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan int, 1)
+	go func() { done <- serveWithToken(handler, known).Code }()
+	select {
+	case code := <-done:
+		if code != http.StatusOK {
+			t.Fatalf("expected the cached key to verify, got %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("verification with a cached key waited for the jwks fetch")
+	}
+}
@@ -2,24 +2,36 @@ package middlewares
 
 import (
 	"encoding/json"
-	"net"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
-	"sync"
 	"time"
+
+	"desent-api/internal/metrics"
+	"desent-api/internal/ratelimit"
+)
+
+var rateLimitRejections = metrics.Default.NewCounterVec(
+	"rate_limit_rejections_total",
+	"Requests rejected by the rate limiter by policy and tier.",
+	"policy", "tier",
 )
 
-type rateLimitBucket struct {
-	windowStart time.Time
-	count       int
+// RateLimitSubject is who a request is counted against and which tier of
+// policies applies to them.
+type RateLimitSubject struct {
+	Key  string
+	Tier string
 }
 
-type RateLimiter struct {
-	limit   int
-	nowFunc func() time.Time
+// RateLimitSubjectFunc identifies the caller of a request.
+type RateLimitSubjectFunc func(r *http.Request) RateLimitSubject
 
-	mu      sync.Mutex
-	buckets map[string]rateLimitBucket
+// RateLimitPolicies resolves the policy for a request; false means the
+// request is not limited.
+type RateLimitPolicies interface {
+	Match(method, path, tier string) (ratelimit.Policy, bool)
 }
 
 type rateLimitResponse struct {
@@ -27,40 +39,36 @@ type rateLimitResponse struct {
 	Message   string `json:"message"`
 }
 
-func NewRateLimiter(limit int, nowFunc func() time.Time) *RateLimiter {
-	if limit <= 0 {
-		limit = 200
-	}
-	if nowFunc == nil {
-		nowFunc = time.Now
+// RateLimit applies the matching policy per subject, with a separate bucket
+// per policy, and reports the bucket state with the IETF RateLimit-*
+// headers. If the limiter itself fails the request is let through rather
+// than turning a storage outage into an API outage. A bearer token resolved
+// while identifying the subject is reused by the auth middleware.
+func RateLimit(limiter ratelimit.Limiter, policies RateLimitPolicies, subjectFunc RateLimitSubjectFunc) func(http.Handler) http.Handler {
+	if subjectFunc == nil {
+		subjectFunc = IPRateLimitSubject
 	}
 
-	return &RateLimiter{
-		limit:   limit,
-		nowFunc: nowFunc,
-		buckets: make(map[string]rateLimitBucket),
-	}
-}
-
-func RateLimitPerIP(limit int) func(http.Handler) http.Handler {
-	limiter := NewRateLimiter(limit, time.Now)
-	return limiter.Middleware()
-}
-
-func (l *RateLimiter) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ip := clientIP(r)
-			now := l.nowFunc().UTC()
-			window := now.Truncate(time.Minute)
-
-			allowed, remaining, retryAfter := l.allow(ip, window, now)
-			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(l.limit))
-			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
-			if !allowed {
-				if retryAfter > 0 {
-					w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
-				}
+			r = r.WithContext(withCredentialCache(r.Context()))
+			subject := subjectFunc(r)
+			policy, ok := policies.Match(r.Method, r.URL.Path, subject.Tier)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			decision, err := limiter.Allow(r.Context(), policy.Name+":"+subject.Key, policy)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			writeRateLimitHeaders(w, policy, decision)
+			if !decision.Allowed {
+				rateLimitRejections.Inc(policy.Name, subject.Tier)
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusTooManyRequests)
 				_ = json.NewEncoder(w).Encode(rateLimitResponse{
@@ -75,53 +83,42 @@ func (l *RateLimiter) Middleware() func(http.Handler) http.Handler {
 	}
 }
 
-func (l *RateLimiter) allow(ip string, window, now time.Time) (bool, int, int) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	l.cleanup(window)
-
-	bucket, ok := l.buckets[ip]
-	if !ok || !bucket.windowStart.Equal(window) {
-		bucket = rateLimitBucket{windowStart: window, count: 0}
-	}
+// PrincipalRateLimitSubject counts requests with a valid bearer token and
+// an active session against the token subject in the user tier and
+// everything else against the client IP in the anonymous tier. Invalid
+// tokens and revoked sessions fall back to the IP; rejecting them is left
+// to the auth middleware.
+func PrincipalRateLimitSubject(jwtSecret string, sessions SessionValidator, verifiers ...TokenVerifier) RateLimitSubjectFunc {
+	return func(r *http.Request) RateLimitSubject {
+		if principal, ok := PrincipalFromContext(r.Context()); ok {
+			return RateLimitSubject{Key: "user:" + principal.Subject, Tier: ratelimit.TierUser}
+		}
 
-	if bucket.count >= l.limit {
-		retryAfter := int(window.Add(time.Minute).Sub(now).Seconds())
-		if retryAfter < 1 {
-			retryAfter = 1
+		if token, ok := bearerToken(r); ok {
+			if principal, err := authenticate(r.Context(), token, jwtSecret, verifiers); err == nil && validateSession(r, sessions, principal) == nil {
+				return RateLimitSubject{Key: "user:" + principal.Subject, Tier: ratelimit.TierUser}
+			}
 		}
-		return false, 0, retryAfter
-	}
 
-	bucket.count++
-	l.buckets[ip] = bucket
-	remaining := l.limit - bucket.count
-	if remaining < 0 {
-		remaining = 0
+		return IPRateLimitSubject(r)
 	}
-
-	return true, remaining, 0
 }
 
-func (l *RateLimiter) cleanup(window time.Time) {
-	cutoff := window.Add(-2 * time.Minute)
-	for key, bucket := range l.buckets {
-		if bucket.windowStart.Before(cutoff) {
-			delete(l.buckets, key)
-		}
-	}
+func IPRateLimitSubject(r *http.Request) RateLimitSubject {
+	return RateLimitSubject{Key: "ip:" + ClientIP(r), Tier: ratelimit.TierAnonymous}
 }
 
-func clientIP(r *http.Request) string {
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err == nil && host != "" {
-		return host
-	}
+func writeRateLimitHeaders(w http.ResponseWriter, policy ratelimit.Policy, decision ratelimit.Decision) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.ResetAfter)))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Burst, ceilSeconds(policy.Window())))
+}
 
-	if r.RemoteAddr == "" {
-		return "unknown"
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
 	}
 
-	return r.RemoteAddr
+	return int(math.Ceil(d.Seconds()))
 }
@@ -1,99 +1,365 @@
 package middlewares
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"desent-api/internal/models"
+	"desent-api/internal/ratelimit"
+	"desent-api/internal/repositories"
+	"desent-api/internal/utils"
+
+	_ "modernc.org/sqlite"
 )
 
-func TestRateLimiter_AllowThenLimit(t *testing.T) {
-	now := time.Date(2026, 2, 28, 12, 0, 1, 0, time.UTC)
-	limiter := NewRateLimiter(2, func() time.Time { return now })
-	h := limiter.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+func newRateLimitedHandler(burst, perMinute int, now *time.Time, subjectFunc RateLimitSubjectFunc) http.Handler {
+	policies, _ := ratelimit.NewPolicyTable(ratelimit.Policy{Name: "default", Burst: burst, Rate: perMinute, Period: time.Minute})
+	return newPolicyLimitedHandler(policies, now, subjectFunc)
+}
+
+func newPolicyLimitedHandler(policies RateLimitPolicies, now *time.Time, subjectFunc RateLimitSubjectFunc) http.Handler {
+	limiter := ratelimit.NewMemoryLimiter(func() time.Time { return *now })
+
+	return RateLimit(limiter, policies, subjectFunc)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
+}
+
+// sessionValidatorFunc adapts a function to SessionValidator.
+type sessionValidatorFunc func(ctx context.Context, principal models.Principal, ip string) error
+
+func (f sessionValidatorFunc) Validate(ctx context.Context, principal models.Principal, ip string) error {
+	return f(ctx, principal, ip)
+}
+
+var acceptSessions = sessionValidatorFunc(func(context.Context, models.Principal, string) error { return nil })
+
+// countingVerifier accepts any token as subject and counts the calls.
+type countingVerifier struct {
+	subject string
+	calls   int
+}
+
+func (v *countingVerifier) Verify(context.Context, string) (models.Principal, error) {
+	v.calls++
+	return models.Principal{Subject: v.subject, Issuer: "https://idp.example.test"}, nil
+}
+
+func doRateLimited(h http.Handler, remoteAddr, token string) *httptest.ResponseRecorder {
+	return doRateLimitedRequest(h, http.MethodGet, "/ping", remoteAddr, token)
+}
+
+func doRateLimitedRequest(h http.Handler, method, target, remoteAddr, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.RemoteAddr = remoteAddr
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	return res
+}
+
+func TestRateLimiter_AllowThenLimit(t *testing.T) {
+	now := time.Date(2026, 2, 28, 12, 0, 1, 0, time.UTC)
+	h := newRateLimitedHandler(2, 2, &now, nil)
 
 	for i := 0; i < 2; i++ {
-		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
-		req.RemoteAddr = "10.0.0.1:1234"
-		res := httptest.NewRecorder()
-		h.ServeHTTP(res, req)
+		res := doRateLimited(h, "10.0.0.1:1234", "")
 		if res.Code != http.StatusOK {
 			t.Fatalf("expected status %d, got %d", http.StatusOK, res.Code)
 		}
 	}
 
-	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
-	req.RemoteAddr = "10.0.0.1:1234"
-	res := httptest.NewRecorder()
-	h.ServeHTTP(res, req)
+	res := doRateLimited(h, "10.0.0.1:1234", "")
 	if res.Code != http.StatusTooManyRequests {
 		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, res.Code)
 	}
+	if got := res.Header().Get("Retry-After"); got != "30" {
+		t.Fatalf("expected Retry-After 30, got %q", got)
+	}
 }
 
 func TestRateLimiter_PerIPIsolation(t *testing.T) {
 	now := time.Date(2026, 2, 28, 12, 0, 1, 0, time.UTC)
-	limiter := NewRateLimiter(1, func() time.Time { return now })
-	h := limiter.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	}))
+	h := newRateLimitedHandler(1, 1, &now, nil)
 
-	reqA1 := httptest.NewRequest(http.MethodGet, "/ping", nil)
-	reqA1.RemoteAddr = "10.0.0.1:1234"
-	resA1 := httptest.NewRecorder()
-	h.ServeHTTP(resA1, reqA1)
-	if resA1.Code != http.StatusOK {
-		t.Fatalf("expected status %d, got %d", http.StatusOK, resA1.Code)
+	if res := doRateLimited(h, "10.0.0.1:1234", ""); res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.Code)
 	}
 
-	reqA2 := httptest.NewRequest(http.MethodGet, "/ping", nil)
-	reqA2.RemoteAddr = "10.0.0.1:1234"
-	resA2 := httptest.NewRecorder()
-	h.ServeHTTP(resA2, reqA2)
-	if resA2.Code != http.StatusTooManyRequests {
-		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, resA2.Code)
+	if res := doRateLimited(h, "10.0.0.1:1234", ""); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, res.Code)
 	}
 
-	reqB := httptest.NewRequest(http.MethodGet, "/ping", nil)
-	reqB.RemoteAddr = "10.0.0.2:1234"
-	resB := httptest.NewRecorder()
-	h.ServeHTTP(resB, reqB)
-	if resB.Code != http.StatusOK {
-		t.Fatalf("expected status %d, got %d", http.StatusOK, resB.Code)
+	if res := doRateLimited(h, "10.0.0.2:1234", ""); res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.Code)
 	}
 }
 
-func TestRateLimiter_WindowReset(t *testing.T) {
+func TestRateLimiter_RefillsInsteadOfWindowReset(t *testing.T) {
 	current := time.Date(2026, 2, 28, 12, 0, 59, 0, time.UTC)
-	limiter := NewRateLimiter(1, func() time.Time { return current })
-	h := limiter.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	}))
+	h := newRateLimitedHandler(2, 2, &current, nil)
 
-	req1 := httptest.NewRequest(http.MethodGet, "/ping", nil)
-	req1.RemoteAddr = "10.0.0.1:1234"
-	res1 := httptest.NewRecorder()
-	h.ServeHTTP(res1, req1)
-	if res1.Code != http.StatusOK {
-		t.Fatalf("expected status %d, got %d", http.StatusOK, res1.Code)
+	for i := 0; i < 2; i++ {
+		if res := doRateLimited(h, "10.0.0.1:1234", ""); res.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, res.Code)
+		}
 	}
 
-	req2 := httptest.NewRequest(http.MethodGet, "/ping", nil)
-	req2.RemoteAddr = "10.0.0.1:1234"
-	res2 := httptest.NewRecorder()
-	h.ServeHTTP(res2, req2)
-	if res2.Code != http.StatusTooManyRequests {
-		t.Fatalf("expected status %d, got %d", http.StatusTooManyRequests, res2.Code)
+	// A fixed window would hand out a fresh quota at 12:01:00.
+	current = time.Date(2026, 2, 28, 12, 1, 0, 0, time.UTC)
+	if res := doRateLimited(h, "10.0.0.1:1234", ""); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status %d across the minute boundary, got %d", http.StatusTooManyRequests, res.Code)
 	}
 
-	current = time.Date(2026, 2, 28, 12, 1, 0, 0, time.UTC)
-	req3 := httptest.NewRequest(http.MethodGet, "/ping", nil)
-	req3.RemoteAddr = "10.0.0.1:1234"
-	res3 := httptest.NewRecorder()
-	h.ServeHTTP(res3, req3)
-	if res3.Code != http.StatusOK {
-		t.Fatalf("expected status %d, got %d", http.StatusOK, res3.Code)
+	current = time.Date(2026, 2, 28, 12, 1, 29, 0, time.UTC)
+	if res := doRateLimited(h, "10.0.0.1:1234", ""); res.Code != http.StatusOK {
+		t.Fatalf("expected one refilled token after 30s, got %d", res.Code)
+	}
+	if res := doRateLimited(h, "10.0.0.1:1234", ""); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected only one refilled token, got %d", res.Code)
+	}
+}
+
+func TestRateLimiter_Headers(t *testing.T) {
+	now := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	h := newRateLimitedHandler(10, 60, &now, nil)
+
+	res := doRateLimited(h, "10.0.0.1:1234", "")
+	want := map[string]string{
+		"RateLimit-Limit":     "10",
+		"RateLimit-Remaining": "9",
+		"RateLimit-Reset":     "1",
+		"RateLimit-Policy":    "10;w=10",
+	}
+	for header, value := range want {
+		if got := res.Header().Get(header); got != value {
+			t.Fatalf("expected %s %q, got %q", header, value, got)
+		}
+	}
+}
+
+func TestRateLimiter_KeyedByPrincipal(t *testing.T) {
+	secret := "test-secret"
+	alice, err := utils.GenerateToken("alice", secret, time.Hour)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	bob, err := utils.GenerateToken("bob", secret, time.Hour)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	now := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	h := newRateLimitedHandler(1, 1, &now, PrincipalRateLimitSubject(secret, acceptSessions))
+
+	if res := doRateLimited(h, "10.0.0.1:1234", alice); res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.Code)
+	}
+	if res := doRateLimited(h, "10.0.0.2:1234", alice); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the same user to share a bucket across IPs, got %d", res.Code)
+	}
+	if res := doRateLimited(h, "10.0.0.1:1234", bob); res.Code != http.StatusOK {
+		t.Fatalf("expected another user behind the same IP to have a bucket, got %d", res.Code)
+	}
+	if res := doRateLimited(h, "10.0.0.1:1234", ""); res.Code != http.StatusOK {
+		t.Fatalf("expected anonymous callers to be keyed by IP, got %d", res.Code)
+	}
+	if res := doRateLimited(h, "10.0.0.1:1234", "forged"); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected invalid tokens to fall back to the IP bucket, got %d", res.Code)
+	}
+}
+
+func TestRateLimiter_PrincipalNeedsActiveSessionAndIsResolvedOnce(t *testing.T) {
+	secret := "test-secret"
+	revoked, err := utils.GenerateSessionToken("alice", "session-1", secret, time.Hour)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	validations := 0
+	sessions := sessionValidatorFunc(func(_ context.Context, principal models.Principal, _ string) error {
+		validations++
+		if principal.SessionID == "session-1" {
+			return errors.New("session revoked")
+		}
+		return nil
+	})
+	verifier := &countingVerifier{subject: "oidc|bob"}
+
+	now := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	limiter := ratelimit.NewMemoryLimiter(func() time.Time { return now })
+	policies, _ := ratelimit.NewPolicyTable(ratelimit.Policy{Name: "default", Burst: 1, Rate: 1, Period: time.Minute})
+	var seen []string
+	h := RateLimit(limiter, policies, PrincipalRateLimitSubject(secret, sessions, verifier))(
+		OptionalBearerAuth(secret, verifier)(RequireActiveSession(sessions)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ := PrincipalFromContext(r.Context())
+			seen = append(seen, principal.Subject)
+			w.WriteHeader(http.StatusOK)
+		}))),
+	)
+
+	if res := doRateLimited(h, "10.0.0.1:1234", "external-token"); res.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.Code)
+	}
+	if verifier.calls != 1 || validations != 1 || len(seen) != 1 || seen[0] != "oidc|bob" {
+		t.Fatalf("expected the token to be verified and its session checked once, got %d verifications, %d validations and %v", verifier.calls, validations, seen)
+	}
+
+	if res := doRateLimited(h, "10.0.0.1:1234", ""); res.Code != http.StatusOK {
+		t.Fatalf("expected the anonymous bucket to be separate from bob's, got %d", res.Code)
+	}
+	if res := doRateLimited(h, "10.0.0.1:1234", revoked); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected a revoked session to be counted against its IP, got %d", res.Code)
+	}
+}
+
+func TestRateLimiter_PolicyTable(t *testing.T) {
+	secret := "test-secret"
+	token, err := utils.GenerateToken("alice", secret, time.Hour)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	rules, err := ratelimit.ParseRules(strings.NewReader(`{"policies": [
+		{"name": "auth", "pattern": "/auth/*", "methods": ["post"], "burst": 1, "rate": 1},
+		{"name": "book-writes", "pattern": "/books/{id}", "methods": ["PUT"], "tier": "user", "burst": 2, "rate": 2},
+		{"name": "anonymous", "pattern": "/*", "tier": "anonymous", "burst": 1, "rate": 1}
+	]}`))
+	if err != nil {
+		t.Fatalf("parse rules: %v", err)
+	}
+	policies, err := ratelimit.NewPolicyTable(ratelimit.Policy{Name: "default", Burst: 3, Rate: 3, Period: time.Minute}, rules...)
+	if err != nil {
+		t.Fatalf("build policy table: %v", err)
+	}
+
+	now := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	h := newPolicyLimitedHandler(policies, &now, PrincipalRateLimitSubject(secret, acceptSessions))
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		status int
+		limit  string
+	}{
+		{"auth policy", http.MethodPost, "/auth/token", token, http.StatusOK, "1"},
+		{"auth policy exhausted", http.MethodPost, "/auth/token", token, http.StatusTooManyRequests, "1"},
+		{"route param matches one segment", http.MethodPut, "/books/7", token, http.StatusOK, "2"},
+		{"user falls back to default", http.MethodPut, "/books/7/cover", token, http.StatusOK, "3"},
+		{"anonymous tier", http.MethodGet, "/books/7", "", http.StatusOK, "1"},
+		{"anonymous tier exhausted", http.MethodGet, "/ping", "", http.StatusTooManyRequests, "1"},
+		{"separate bucket per policy", http.MethodGet, "/ping", token, http.StatusOK, "3"},
+	}
+
+	for _, tc := range tests {
+		res := doRateLimitedRequest(h, tc.method, tc.target, "10.0.0.1:1234", tc.token)
+		if res.Code != tc.status {
+			t.Fatalf("%s: expected status %d, got %d", tc.name, tc.status, res.Code)
+		}
+		if got := res.Header().Get("RateLimit-Limit"); got != tc.limit {
+			t.Fatalf("%s: expected RateLimit-Limit %s, got %q", tc.name, tc.limit, got)
+		}
+	}
+}
+
+func TestRateLimiter_PolicyFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rate_limits.json")
+	write := func(body string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("write policies: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("touch policies: %v", err)
+		}
+	}
+
+	modTime := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	write(`{"policies": [{"name": "ping", "pattern": "/ping", "burst": 1, "rate": 1}]}`, modTime)
+
+	fallback := ratelimit.Policy{Name: "default", Burst: 10, Rate: 10, Period: time.Minute}
+	policies, err := ratelimit.LoadPolicyTable(path, fallback)
+	if err != nil {
+		t.Fatalf("load policies: %v", err)
+	}
+	if policy, _ := policies.Match(http.MethodGet, "/ping", ratelimit.TierAnonymous); policy.Name != "ping" || policy.Burst != 1 {
+		t.Fatalf("unexpected policy %+v", policy)
+	}
+
+	write(`{"policies": [{"name": "ping", "pattern": "/ping", "burst": 5, "rate": 5}]}`, modTime.Add(time.Second))
+	if err := policies.Reload(); err != nil {
+		t.Fatalf("reload policies: %v", err)
+	}
+	if policy, _ := policies.Match(http.MethodGet, "/ping", ratelimit.TierAnonymous); policy.Burst != 5 {
+		t.Fatalf("expected reloaded burst 5, got %+v", policy)
+	}
+
+	write(`{"policies": [{"name": "ping", "pattern": "ping", "burst": 0}]}`, modTime.Add(2*time.Second))
+	if err := policies.Reload(); !errors.Is(err, ratelimit.ErrInvalidPolicies) {
+		t.Fatalf("expected invalid policies error, got %v", err)
+	}
+	if policy, _ := policies.Match(http.MethodGet, "/ping", ratelimit.TierAnonymous); policy.Burst != 5 {
+		t.Fatalf("expected previous policies to stay active, got %+v", policy)
+	}
+}
+
+func TestRateLimiter_SQLiteStoreSharedAcrossProcesses(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "limits.db") + "?_pragma=busy_timeout(5000)"
+	now := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+
+	replicas := make([]http.Handler, 0, 2)
+	var limiter *ratelimit.SQLiteLimiter
+	for i := 0; i < 2; i++ {
+		db, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			t.Fatalf("open db: %v", err)
+		}
+		t.Cleanup(func() { _ = db.Close() })
+
+		if err := repositories.InitSchema(context.Background(), db); err != nil {
+			t.Fatalf("init schema: %v", err)
+		}
+		limiter = ratelimit.NewSQLiteLimiter(db, func() time.Time { return now })
+
+		policies, _ := ratelimit.NewPolicyTable(ratelimit.Policy{Name: "default", Burst: 2, Rate: 2, Period: time.Minute})
+		replicas = append(replicas, RateLimit(limiter, policies, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+	}
+
+	if res := doRateLimited(replicas[0], "10.0.0.1:1234", ""); res.Code != http.StatusOK || res.Header().Get("RateLimit-Remaining") != "1" {
+		t.Fatalf("expected first request allowed with 1 remaining, got %d %q", res.Code, res.Header().Get("RateLimit-Remaining"))
+	}
+	if res := doRateLimited(replicas[1], "10.0.0.1:1234", ""); res.Code != http.StatusOK || res.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("expected the second replica to see the shared bucket, got %d %q", res.Code, res.Header().Get("RateLimit-Remaining"))
+	}
+
+	res := doRateLimited(replicas[0], "10.0.0.1:1234", "")
+	if res.Code != http.StatusTooManyRequests || res.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expected shared quota to be exhausted, got %d (Retry-After %q)", res.Code, res.Header().Get("Retry-After"))
+	}
+
+	now = now.Add(30 * time.Second)
+	if res := doRateLimited(replicas[1], "10.0.0.1:1234", ""); res.Code != http.StatusOK {
+		t.Fatalf("expected a refilled token, got %d", res.Code)
+	}
+
+	now = now.Add(2 * time.Minute)
+	deleted, err := limiter.Cleanup(context.Background())
+	if err != nil || deleted != 1 {
+		t.Fatalf("expected one refilled bucket to be cleaned up, got %d (%v)", deleted, err)
 	}
 }
@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+
+	"desent-api/internal/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Tracing starts a server span for every request, continuing the trace
+// from an incoming traceparent header. The span is renamed to the chi
+// route pattern once routing has happened, and marked as failed for 5xx
+// responses.
+func Tracing() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := tracing.Extract(r.Context(), r.Header)
+			ctx, span := tracing.Start(ctx, "HTTP "+r.Method,
+				tracing.WithKind(tracing.SpanKindServer),
+				tracing.WithAttributes(
+					tracing.String("http.request.method", r.Method),
+					tracing.String("url.path", r.URL.Path),
+					tracing.String("client.address", ClientIP(r)),
+					tracing.String("user_agent.original", r.UserAgent()),
+					tracing.String("http.request_id", middleware.GetReqID(r.Context())),
+				),
+			)
+			defer span.End()
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			route := routePattern(r)
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(
+				tracing.String("http.route", route),
+				tracing.Int("http.response.status_code", status),
+			)
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(tracing.StatusError, http.StatusText(status))
+			}
+		})
+	}
+}
+
+type tracedMiddlewareKey struct{}
+
+// Traced wraps mw in a span named "middleware <name>". The span ends when
+// mw hands the request on, so it only covers mw's own work, and whatever
+// runs next sees the span that was current before mw.
+func Traced(name string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		inner := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if span, ok := ctx.Value(tracedMiddlewareKey{}).(*tracing.Span); ok && span != nil {
+				span.End()
+				ctx = tracing.ContextWithSpan(ctx, span.Parent())
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, tracedMiddlewareKey{}, nil)))
+		}))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := tracing.Start(r.Context(), "middleware "+name)
+			defer span.End()
+
+			inner.ServeHTTP(w, r.WithContext(context.WithValue(ctx, tracedMiddlewareKey{}, span)))
+		})
+	}
+}
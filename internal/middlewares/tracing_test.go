@@ -0,0 +1,163 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"desent-api/internal/tracing"
+	"desent-api/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type exportedSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	Attributes   []struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+			IntValue    string `json:"intValue"`
+		} `json:"value"`
+	} `json:"attributes"`
+	Status struct {
+		Code int `json:"code"`
+	} `json:"status"`
+}
+
+func (s exportedSpan) attribute(key string) string {
+	for _, attr := range s.Attributes {
+		if attr.Key == key {
+			return attr.Value.StringValue + attr.Value.IntValue
+		}
+	}
+	return ""
+}
+
+// useTestTracer installs a provider that samples everything and returns a
+// function that flushes it and decodes the exported spans by name.
+func useTestTracer(t *testing.T) func() map[string]exportedSpan {
+	t.Helper()
+
+	var out bytes.Buffer
+	provider := tracing.NewProvider(tracing.NewWriterExporter(&out, "test"), 1, func(err error) {
+		t.Errorf("export spans: %v", err)
+	}, nil)
+	tracing.SetProvider(provider)
+	t.Cleanup(func() { tracing.SetProvider(nil) })
+
+	return func() map[string]exportedSpan {
+		provider.Flush(context.Background())
+
+		spans := make(map[string]exportedSpan)
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var payload struct {
+				ResourceSpans []struct {
+					ScopeSpans []struct {
+						Spans []exportedSpan `json:"spans"`
+					} `json:"scopeSpans"`
+				} `json:"resourceSpans"`
+			}
+			if err := json.Unmarshal([]byte(line), &payload); err != nil {
+				t.Fatalf("decode exported spans %q: %v", line, err)
+			}
+			for _, resource := range payload.ResourceSpans {
+				for _, scope := range resource.ScopeSpans {
+					for _, span := range scope.Spans {
+						spans[span.Name] = span
+					}
+				}
+			}
+		}
+		out.Reset()
+		return spans
+	}
+}
+
+func TestTracing_ContinuesTraceparentAndScopesMiddlewareSpans(t *testing.T) {
+	spans := useTestTracer(t)
+
+	r := chi.NewRouter()
+	r.Use(Tracing())
+	r.With(Traced("auth", RequireBearerAuth("test-secret"))).Get("/traced/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "handler work")
+		span.End()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	token, err := utils.GenerateToken("alice", "test-secret", time.Hour)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/traced/42", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	exported := spans()
+	server, ok := exported["GET /traced/{id}"]
+	if !ok {
+		t.Fatalf("expected a server span named by route pattern, got %v", exported)
+	}
+	if server.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || server.ParentSpanID != "00f067aa0ba902b7" {
+		t.Fatalf("expected the server span to continue the incoming trace, got %+v", server)
+	}
+	if server.Kind != int(tracing.SpanKindServer) || server.attribute("http.route") != "/traced/{id}" || server.attribute("http.response.status_code") != "204" {
+		t.Fatalf("unexpected server span %+v", server)
+	}
+	for _, name := range []string{"middleware auth", "handler work"} {
+		span, ok := exported[name]
+		if !ok {
+			t.Fatalf("expected span %q, got %v", name, exported)
+		}
+		if span.TraceID != server.TraceID || span.ParentSpanID != server.SpanID {
+			t.Fatalf("expected %q to be a child of the server span, got %+v", name, span)
+		}
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/traced/42", nil))
+	exported = spans()
+	if _, ok := exported["handler work"]; ok {
+		t.Fatal("expected the handler not to run without a token")
+	}
+	server = exported["GET /traced/{id}"]
+	if server.TraceID == "4bf92f3577b34da6a3ce929d0e0e4736" || server.ParentSpanID != "" {
+		t.Fatalf("expected a request without traceparent to start a new trace, got %+v", server)
+	}
+	if server.attribute("http.response.status_code") != "401" || exported["middleware auth"].ParentSpanID != server.SpanID {
+		t.Fatalf("unexpected spans for a rejected request: %v", exported)
+	}
+}
+
+func TestParseTraceparent_RejectsMalformedHeaders(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, ok := tracing.ParseTraceparent(value); ok {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+
+	sc, ok := tracing.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	if !ok || sc.Sampled {
+		t.Fatalf("expected a newer version with extra fields to parse as unsampled, got %+v %v", sc, ok)
+	}
+	if got := tracing.FormatTraceparent(sc); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00" {
+		t.Fatalf("unexpected traceparent %q", got)
+	}
+}
@@ -5,6 +5,11 @@ type TokenRequest struct {
 	Password string `json:"password"`
 }
 
+// TokenResponse carries either a token or, for accounts with MFA enabled,
+// a challenge to complete at /auth/token/mfa.
 type TokenResponse struct {
-	Token string `json:"token"`
+	Token          string `json:"token,omitempty"`
+	MFARequired    bool   `json:"mfa_required,omitempty"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
+	ExpiresIn      int    `json:"expires_in,omitempty"`
 }
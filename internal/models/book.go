@@ -1,36 +1,75 @@
 package models
 
 type Book struct {
-	ID     int64
-	Title  string
-	Author string
-	Year   int
+	ID             int64
+	Title          string
+	Author         string
+	Year           int
+	ISBN           string
+	Subjects       []string
+	Work           *Work
+	Series         *Series
+	SeriesPosition int
+	Publisher      *Publisher
 }
 
 type BookListQuery struct {
-	Author string
-	Page   int
-	Limit  int
+	Author      string
+	WorkID      int64
+	SeriesID    int64
+	PublisherID int64
+	Page        int
+	Limit       int
 }
 
 type CreateBookRequest struct {
-	Title  string `json:"title"`
-	Author string `json:"author"`
-	Year   int    `json:"year"`
+	Title          string   `json:"title"`
+	Author         string   `json:"author"`
+	Year           int      `json:"year"`
+	ISBN           string   `json:"isbn"`
+	Subjects       []string `json:"subjects"`
+	WorkID         int64    `json:"work_id"`
+	SeriesID       int64    `json:"series_id"`
+	SeriesPosition int      `json:"series_position"`
+	PublisherID    int64    `json:"publisher_id"`
 }
 
 type BookResponse struct {
-	ID     int64  `json:"id"`
-	Title  string `json:"title"`
-	Author string `json:"author"`
-	Year   int    `json:"year"`
+	ID        int64                     `json:"id"`
+	Title     string                    `json:"title"`
+	Author    string                    `json:"author"`
+	Year      int                       `json:"year"`
+	ISBN      string                    `json:"isbn,omitempty"`
+	Subjects  []string                  `json:"subjects,omitempty"`
+	Work      *WorkSummaryResponse      `json:"work,omitempty"`
+	Series    *SeriesSummaryResponse    `json:"series,omitempty"`
+	Publisher *PublisherSummaryResponse `json:"publisher,omitempty"`
 }
 
 func ToBookResponse(book Book) BookResponse {
-	return BookResponse{
+	response := BookResponse{
 		ID:     book.ID,
 		Title:  book.Title,
 		Author: book.Author,
 		Year:   book.Year,
+		ISBN:   book.ISBN,
 	}
+
+	if len(book.Subjects) > 0 {
+		response.Subjects = book.Subjects
+	}
+
+	if book.Work != nil {
+		response.Work = &WorkSummaryResponse{ID: book.Work.ID, Title: book.Work.Title}
+	}
+
+	if book.Series != nil {
+		response.Series = &SeriesSummaryResponse{ID: book.Series.ID, Name: book.Series.Name, Position: book.SeriesPosition}
+	}
+
+	if book.Publisher != nil {
+		response.Publisher = &PublisherSummaryResponse{ID: book.Publisher.ID, Name: book.Publisher.Name}
+	}
+
+	return response
 }
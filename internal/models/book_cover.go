@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+const CoverSizeOriginal = "original"
+
+// CoverSizes maps thumbnail names to the bounding box edge in pixels.
+var CoverSizes = map[string]int{
+	"small":  96,
+	"medium": 240,
+	"large":  480,
+}
+
+type BookCover struct {
+	BookID      int64
+	Checksum    string
+	ContentType string
+	Width       int
+	Height      int
+	SizeBytes   int64
+	UpdatedAt   time.Time
+}
+
+type BookCoverImage struct {
+	Cover       BookCover
+	Size        string
+	ContentType string
+	ETag        string
+	Data        []byte
+}
+
+// CoverVersion identifies one cover image. It goes into the cover URLs as
+// v, so a new upload gets new URLs and the old ones can be cached for good.
+func CoverVersion(checksum string) string {
+	return checksum[:20]
+}
+
+type BookCoverResponse struct {
+	BookID      int64             `json:"book_id"`
+	ContentType string            `json:"content_type"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	SizeBytes   int64             `json:"size_bytes"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	URLs        map[string]string `json:"urls"`
+}
+
+func ToBookCoverResponse(cover BookCover, baseURL string) BookCoverResponse {
+	version := CoverVersion(cover.Checksum)
+	urls := map[string]string{CoverSizeOriginal: baseURL + "?size=" + CoverSizeOriginal + "&v=" + version}
+	for size := range CoverSizes {
+		urls[size] = baseURL + "?size=" + size + "&v=" + version
+	}
+
+	return BookCoverResponse{
+		BookID:      cover.BookID,
+		ContentType: cover.ContentType,
+		Width:       cover.Width,
+		Height:      cover.Height,
+		SizeBytes:   cover.SizeBytes,
+		UpdatedAt:   cover.UpdatedAt,
+		URLs:        urls,
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+const (
+	DuplicateReasonISBN    = "isbn"
+	DuplicateReasonExact   = "normalized_match"
+	DuplicateReasonSimilar = "similar"
+)
+
+type DuplicateBookGroup struct {
+	Reason     string
+	Similarity float64
+	Books      []Book
+}
+
+type DuplicateQuery struct {
+	Threshold float64
+}
+
+type MergeBooksRequest struct {
+	SourceID int64 `json:"source_id"`
+}
+
+type DuplicateBookGroupResponse struct {
+	Reason     string         `json:"reason"`
+	Similarity float64        `json:"similarity"`
+	Books      []BookResponse `json:"books"`
+}
+
+func ToDuplicateBookGroupResponse(group DuplicateBookGroup) DuplicateBookGroupResponse {
+	books := make([]BookResponse, 0, len(group.Books))
+	for _, book := range group.Books {
+		books = append(books, ToBookResponse(book))
+	}
+
+	return DuplicateBookGroupResponse{
+		Reason:     group.Reason,
+		Similarity: group.Similarity,
+		Books:      books,
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+type BookImportError struct {
+	Record  int
+	Message string
+}
+
+type BookImportResult struct {
+	Books   []Book
+	Errors  []BookImportError
+	Skipped int
+}
+
+type BookImportErrorResponse struct {
+	Record  int    `json:"record"`
+	Message string `json:"message"`
+}
+
+type BookImportResponse struct {
+	Imported int                       `json:"imported"`
+	Skipped  int                       `json:"skipped"`
+	Books    []BookResponse            `json:"books"`
+	Errors   []BookImportErrorResponse `json:"errors"`
+}
+
+func ToBookImportResponse(result BookImportResult) BookImportResponse {
+	response := BookImportResponse{
+		Imported: len(result.Books),
+		Skipped:  result.Skipped,
+		Books:    make([]BookResponse, 0, len(result.Books)),
+		Errors:   make([]BookImportErrorResponse, 0, len(result.Errors)),
+	}
+	for _, book := range result.Books {
+		response.Books = append(response.Books, ToBookResponse(book))
+	}
+	for _, importErr := range result.Errors {
+		response.Errors = append(response.Errors, BookImportErrorResponse{Record: importErr.Record, Message: importErr.Message})
+	}
+
+	return response
+}
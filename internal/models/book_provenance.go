@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+const (
+	BookFieldTitle    = "title"
+	BookFieldAuthor   = "author"
+	BookFieldYear     = "year"
+	BookFieldSubjects = "subjects"
+)
+
+type BookFieldProvenance struct {
+	BookID     int64
+	Field      string
+	Source     string
+	SourceRef  string
+	RecordedAt time.Time
+}
+
+// BookEnrichment is the outcome of filling a book from offline metadata.
+type BookEnrichment struct {
+	Book           Book
+	EnrichedFields []string
+	Provenance     []BookFieldProvenance
+}
+
+type BookFieldProvenanceResponse struct {
+	Field      string    `json:"field"`
+	Source     string    `json:"source"`
+	SourceRef  string    `json:"source_ref,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+type EnrichBookResponse struct {
+	Book           BookResponse                  `json:"book"`
+	EnrichedFields []string                      `json:"enriched_fields"`
+	Provenance     []BookFieldProvenanceResponse `json:"provenance"`
+}
+
+func ToBookFieldProvenanceResponses(provenance []BookFieldProvenance) []BookFieldProvenanceResponse {
+	response := make([]BookFieldProvenanceResponse, 0, len(provenance))
+	for _, entry := range provenance {
+		response = append(response, BookFieldProvenanceResponse{
+			Field:      entry.Field,
+			Source:     entry.Source,
+			SourceRef:  entry.SourceRef,
+			RecordedAt: entry.RecordedAt,
+		})
+	}
+
+	return response
+}
+
+func ToEnrichBookResponse(enrichment BookEnrichment) EnrichBookResponse {
+	fields := enrichment.EnrichedFields
+	if fields == nil {
+		fields = []string{}
+	}
+
+	return EnrichBookResponse{
+		Book:           ToBookResponse(enrichment.Book),
+		EnrichedFields: fields,
+		Provenance:     ToBookFieldProvenanceResponses(enrichment.Provenance),
+	}
+}
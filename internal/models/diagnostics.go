@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type RuntimeStatsResponse struct {
+	GoVersion     string      `json:"go_version"`
+	OS            string      `json:"os"`
+	Arch          string      `json:"arch"`
+	NumCPU        int         `json:"num_cpu"`
+	GOMAXPROCS    int         `json:"gomaxprocs"`
+	Goroutines    int         `json:"goroutines"`
+	StartedAt     time.Time   `json:"started_at"`
+	UptimeSeconds float64     `json:"uptime_seconds"`
+	Memory        MemoryStats `json:"memory"`
+	GC            GCStats     `json:"gc"`
+}
+
+// MemoryStats are in bytes, except for the object and allocation counts.
+type MemoryStats struct {
+	HeapAlloc   uint64 `json:"heap_alloc"`
+	HeapInuse   uint64 `json:"heap_inuse"`
+	HeapIdle    uint64 `json:"heap_idle"`
+	HeapObjects uint64 `json:"heap_objects"`
+	StackInuse  uint64 `json:"stack_inuse"`
+	Sys         uint64 `json:"sys"`
+	TotalAlloc  uint64 `json:"total_alloc"`
+	Mallocs     uint64 `json:"mallocs"`
+	Frees       uint64 `json:"frees"`
+}
+
+type GCStats struct {
+	NumGC        uint32     `json:"num_gc"`
+	PauseTotalMs float64    `json:"pause_total_ms"`
+	LastGC       *time.Time `json:"last_gc,omitempty"`
+	NextGC       uint64     `json:"next_gc"`
+}
+
+// BuildInfoResponse comes from the build information embedded in the
+// binary. The VCS fields are empty when it was built outside a checkout.
+type BuildInfoResponse struct {
+	GoVersion    string   `json:"go_version"`
+	Path         string   `json:"path"`
+	Version      string   `json:"version"`
+	VCSRevision  string   `json:"vcs_revision,omitempty"`
+	VCSTime      string   `json:"vcs_time,omitempty"`
+	VCSModified  bool     `json:"vcs_modified"`
+	Dependencies []string `json:"dependencies"`
+}
@@ -0,0 +1,11 @@
+package models
+
+type LogLevelRequest struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+}
+
+// LogLevelsResponse maps every log component to its current level name.
+type LogLevelsResponse struct {
+	Levels map[string]string `json:"levels"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	LoginScopeUsername = "username"
+	LoginScopeIP       = "ip"
+)
+
+// LoginAttempt tracks recent failed logins for one username or client IP.
+type LoginAttempt struct {
+	Scope        string
+	Subject      string
+	Failures     int
+	LastFailedAt time.Time
+	LockedUntil  time.Time
+}
+
+type LockoutResponse struct {
+	Scope       string    `json:"scope"`
+	Subject     string    `json:"subject"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func ToLockoutResponses(attempts []LoginAttempt) []LockoutResponse {
+	response := make([]LockoutResponse, 0, len(attempts))
+	for _, attempt := range attempts {
+		response = append(response, LockoutResponse{
+			Scope:       attempt.Scope,
+			Subject:     attempt.Subject,
+			Failures:    attempt.Failures,
+			LockedUntil: attempt.LockedUntil,
+		})
+	}
+
+	return response
+}
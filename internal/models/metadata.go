@@ -0,0 +1,51 @@
+package models
+
+const (
+	MetadataSourceOpenLibrary = "openlibrary"
+	MetadataSourceMARC        = "marc"
+
+	MetadataFormatOpenLibrary = "openlibrary"
+	MetadataFormatMARC        = "marc"
+	MetadataFormatMARCXML     = "marcxml"
+)
+
+// MetadataRecord is what the offline lookup table knows about one ISBN.
+type MetadataRecord struct {
+	ISBN      string
+	Title     string
+	Authors   []string
+	Year      int
+	Subjects  []string
+	Source    string
+	SourceRef string
+}
+
+type MetadataEdition struct {
+	Key        string
+	ISBNs      []string
+	Title      string
+	Year       int
+	Subjects   []string
+	AuthorKeys []string
+	WorkKey    string
+	Source     string
+}
+
+type MetadataWork struct {
+	Key        string
+	Title      string
+	Subjects   []string
+	AuthorKeys []string
+}
+
+type MetadataAuthor struct {
+	Key  string
+	Name string
+}
+
+type MetadataImportStats struct {
+	Editions int
+	Works    int
+	Authors  int
+	Skipped  int
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// MFAEnrollment holds a user's TOTP secret, sealed at rest. LastUsedStep is
+// the newest accepted time step so a code cannot be replayed.
+type MFAEnrollment struct {
+	Username     string
+	SealedSecret string
+	ConfirmedAt  time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+func (e MFAEnrollment) Confirmed() bool {
+	return !e.ConfirmedAt.IsZero()
+}
+
+// MFAChallenge is the pending second step of a login, stored by token hash.
+type MFAChallenge struct {
+	TokenHash string
+	Username  string
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+type MFASetup struct {
+	Secret     string
+	OTPAuthURI string
+}
+
+type MFAStatus struct {
+	Enabled                bool
+	Pending                bool
+	RecoveryCodesRemaining int
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type MFAChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type MFASetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFAStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	Pending                bool `json:"pending"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
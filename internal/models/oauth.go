@@ -0,0 +1,154 @@
+package models
+
+import "time"
+
+const (
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypePassword          = "password"
+)
+
+const (
+	ScopeBooksRead  = "books:read"
+	ScopeBooksWrite = "books:write"
+	ScopeListsWrite = "lists:write"
+)
+
+// OAuthScopes lists every scope a client can be registered for.
+var OAuthScopes = []string{ScopeBooksRead, ScopeBooksWrite, ScopeListsWrite}
+
+// OAuthClient is a registered confidential client. Only a hash of its
+// secret is stored.
+type OAuthClient struct {
+	ClientID   string
+	SecretHash string
+	Name       string
+	Scopes     []string
+	GrantTypes []string
+	CreatedAt  time.Time
+}
+
+func (c OAuthClient) AllowsGrant(grantType string) bool {
+	for _, grant := range c.GrantTypes {
+		if grant == grantType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OAuthTokenRequest is a /oauth/token request after client authentication
+// has been read from the Authorization header or the form.
+type OAuthTokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+	Username     string
+	Password     string
+	ClientIP     string
+	UserAgent    string
+}
+
+type OAuthToken struct {
+	AccessToken string
+	ExpiresIn   time.Duration
+	Scope       string
+}
+
+// OAuthIntrospection is the RFC 7662 view of a token.
+type OAuthIntrospection struct {
+	Active    bool
+	Scope     string
+	ClientID  string
+	Subject   string
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+}
+
+type CreateOAuthClientRequest struct {
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	GrantTypes []string `json:"grant_types"`
+}
+
+type OAuthClientResponse struct {
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+	Name         string    `json:"name"`
+	Scopes       []string  `json:"scopes"`
+	GrantTypes   []string  `json:"grant_types"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type OAuthIntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
+
+func ToOAuthClientResponse(client OAuthClient, secret string) OAuthClientResponse {
+	return OAuthClientResponse{
+		ClientID:     client.ClientID,
+		ClientSecret: secret,
+		Name:         client.Name,
+		Scopes:       client.Scopes,
+		GrantTypes:   client.GrantTypes,
+		CreatedAt:    client.CreatedAt,
+	}
+}
+
+func ToOAuthClientResponses(clients []OAuthClient) []OAuthClientResponse {
+	response := make([]OAuthClientResponse, 0, len(clients))
+	for _, client := range clients {
+		response = append(response, ToOAuthClientResponse(client, ""))
+	}
+
+	return response
+}
+
+// ToOAuthIntrospectionResponse reports the username only for tokens issued
+// to a user; client_credentials tokens have the client as their subject.
+func ToOAuthIntrospectionResponse(introspection OAuthIntrospection) OAuthIntrospectionResponse {
+	if !introspection.Active {
+		return OAuthIntrospectionResponse{}
+	}
+
+	response := OAuthIntrospectionResponse{
+		Active:    true,
+		Scope:     introspection.Scope,
+		ClientID:  introspection.ClientID,
+		Subject:   introspection.Subject,
+		TokenType: "Bearer",
+		ExpiresAt: introspection.ExpiresAt.Unix(),
+		IssuedAt:  introspection.IssuedAt.Unix(),
+	}
+	if introspection.Subject != OAuthClientSubject(introspection.ClientID) {
+		response.Username = introspection.Subject
+	}
+
+	return response
+}
+
+// OAuthClientSubject is the token subject for a client acting on its own
+// behalf, kept apart from usernames.
+func OAuthClientSubject(clientID string) string {
+	return "client:" + clientID
+}
@@ -0,0 +1,54 @@
+package models
+
+const RoleAdmin = "admin"
+
+// Principal is the caller a bearer token was issued to. Scopes is nil for
+// first-party tokens from /auth/token, which are not limited by scope.
+// Issuer and Roles are set for tokens from an external OIDC issuer.
+// SessionID is the jti of tokens issued by this API with session tracking.
+type Principal struct {
+	Subject     string
+	AuthMethods []string
+	ClientID    string
+	Scopes      []string
+	Issuer      string
+	Roles       []string
+	SessionID   string
+}
+
+// HasAuthMethod reports whether the principal's token records method in
+// its amr claim.
+func (p Principal) HasAuthMethod(method string) bool {
+	for _, m := range p.AuthMethods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasScope reports whether the principal may act within scope.
+func (p Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
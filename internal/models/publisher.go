@@ -0,0 +1,31 @@
+package models
+
+type Publisher struct {
+	ID       int64
+	Name     string
+	Location string
+}
+
+type CreatePublisherRequest struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+}
+
+type PublisherResponse struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+}
+
+type PublisherSummaryResponse struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func ToPublisherResponse(publisher Publisher) PublisherResponse {
+	return PublisherResponse{
+		ID:       publisher.ID,
+		Name:     publisher.Name,
+		Location: publisher.Location,
+	}
+}
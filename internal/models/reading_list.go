@@ -0,0 +1,107 @@
+package models
+
+import "time"
+
+const (
+	ReadingListVisibilityPrivate  = "private"
+	ReadingListVisibilityUnlisted = "unlisted"
+	ReadingListVisibilityPublic   = "public"
+)
+
+type ReadingList struct {
+	ID          int64
+	OwnerID     string
+	Name        string
+	Description string
+	Visibility  string
+	BookCount   int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Entries     []ReadingListEntry
+}
+
+type ReadingListEntry struct {
+	Position int
+	AddedAt  time.Time
+	Book     Book
+}
+
+type CreateReadingListRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+}
+
+type AddReadingListEntryRequest struct {
+	BookID   int64 `json:"book_id"`
+	Position int   `json:"position"`
+}
+
+type ReorderReadingListRequest struct {
+	BookIDs []int64 `json:"book_ids"`
+}
+
+type ReadingListEntryResponse struct {
+	Position int          `json:"position"`
+	AddedAt  time.Time    `json:"added_at"`
+	Book     BookResponse `json:"book"`
+}
+
+type ReadingListResponse struct {
+	ID          int64                      `json:"id"`
+	OwnerID     string                     `json:"owner_id"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Visibility  string                     `json:"visibility"`
+	BookCount   int                        `json:"book_count"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	UpdatedAt   time.Time                  `json:"updated_at"`
+	Entries     []ReadingListEntryResponse `json:"entries"`
+}
+
+type ReadingListSummaryResponse struct {
+	ID          int64     `json:"id"`
+	OwnerID     string    `json:"owner_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Visibility  string    `json:"visibility"`
+	BookCount   int       `json:"book_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func ToReadingListResponse(list ReadingList) ReadingListResponse {
+	entries := make([]ReadingListEntryResponse, 0, len(list.Entries))
+	for _, entry := range list.Entries {
+		entries = append(entries, ReadingListEntryResponse{
+			Position: entry.Position,
+			AddedAt:  entry.AddedAt,
+			Book:     ToBookResponse(entry.Book),
+		})
+	}
+
+	return ReadingListResponse{
+		ID:          list.ID,
+		OwnerID:     list.OwnerID,
+		Name:        list.Name,
+		Description: list.Description,
+		Visibility:  list.Visibility,
+		BookCount:   list.BookCount,
+		CreatedAt:   list.CreatedAt,
+		UpdatedAt:   list.UpdatedAt,
+		Entries:     entries,
+	}
+}
+
+func ToReadingListSummaryResponse(list ReadingList) ReadingListSummaryResponse {
+	return ReadingListSummaryResponse{
+		ID:          list.ID,
+		OwnerID:     list.OwnerID,
+		Name:        list.Name,
+		Description: list.Description,
+		Visibility:  list.Visibility,
+		BookCount:   list.BookCount,
+		CreatedAt:   list.CreatedAt,
+		UpdatedAt:   list.UpdatedAt,
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+type Series struct {
+	ID          int64
+	Name        string
+	Description string
+}
+
+type CreateSeriesRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type SeriesResponse struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type SeriesSummaryResponse struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Position int    `json:"position,omitempty"`
+}
+
+func ToSeriesResponse(series Series) SeriesResponse {
+	return SeriesResponse{
+		ID:          series.ID,
+		Name:        series.Name,
+		Description: series.Description,
+	}
+}
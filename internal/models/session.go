@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Session records a token issued by this API. Its ID is the token's jti
+// claim, so revoking the session rejects the token before it expires.
+// IP and UserAgent describe the login; LastIP is the address the token was
+// last used from.
+type Session struct {
+	ID          string
+	Subject     string
+	ClientID    string
+	AuthMethods []string
+	IP          string
+	UserAgent   string
+	LastIP      string
+	CreatedAt   time.Time
+	LastSeenAt  time.Time
+	ExpiresAt   time.Time
+	RevokedAt   time.Time
+}
+
+func (s Session) Revoked() bool {
+	return !s.RevokedAt.IsZero()
+}
+
+type SessionResponse struct {
+	ID          string    `json:"id"`
+	ClientID    string    `json:"client_id,omitempty"`
+	AuthMethods []string  `json:"auth_methods"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	LastIP      string    `json:"last_ip"`
+	IssuedAt    time.Time `json:"issued_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"`
+}
+
+// ToSessionResponses marks the session with currentID as the caller's own.
+func ToSessionResponses(sessions []Session, currentID string) []SessionResponse {
+	responses := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		authMethods := session.AuthMethods
+		if authMethods == nil {
+			authMethods = []string{}
+		}
+
+		responses = append(responses, SessionResponse{
+			ID:          session.ID,
+			ClientID:    session.ClientID,
+			AuthMethods: authMethods,
+			IP:          session.IP,
+			UserAgent:   session.UserAgent,
+			LastIP:      session.LastIP,
+			IssuedAt:    session.CreatedAt,
+			LastSeenAt:  session.LastSeenAt,
+			ExpiresAt:   session.ExpiresAt,
+			Current:     currentID != "" && session.ID == currentID,
+		})
+	}
+
+	return responses
+}
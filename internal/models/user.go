@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+const (
+	AccountTokenPasswordReset     = "password_reset"
+	AccountTokenEmailVerification = "email_verification"
+)
+
+// User holds the stored credentials and contact address of an account. An
+// empty PasswordHash means the account still uses its built-in password.
+type User struct {
+	Username        string
+	Email           string
+	PasswordHash    string
+	EmailVerifiedAt time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (u User) EmailVerified() bool {
+	return u.Email != "" && !u.EmailVerifiedAt.IsZero()
+}
+
+// AccountToken is a single-use token mailed to a user. Only its hash is
+// stored. Email is the address the token was sent to.
+type AccountToken struct {
+	TokenHash string
+	Username  string
+	Purpose   string
+	Email     string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// UpdateEmailRequest must carry the current password, and a TOTP or
+// recovery code when MFA is enabled.
+type UpdateEmailRequest struct {
+	Email           string `json:"email"`
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type AccountResponse struct {
+	Username      string `json:"username"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+func ToAccountResponse(user User) AccountResponse {
+	return AccountResponse{
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified(),
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+type Work struct {
+	ID           int64
+	Title        string
+	Author       string
+	OriginalYear int
+}
+
+type CreateWorkRequest struct {
+	Title        string `json:"title"`
+	Author       string `json:"author"`
+	OriginalYear int    `json:"original_year"`
+}
+
+type WorkResponse struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	Author       string `json:"author"`
+	OriginalYear int    `json:"original_year,omitempty"`
+}
+
+type WorkSummaryResponse struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+func ToWorkResponse(work Work) WorkResponse {
+	return WorkResponse{
+		ID:           work.ID,
+		Title:        work.Title,
+		Author:       work.Author,
+		OriginalYear: work.OriginalYear,
+	}
+}
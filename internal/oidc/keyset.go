@@ -0,0 +1,298 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrKeyNotFound = errors.New("signing key not found")
+
+const maxJWKSBytes = 1 << 20
+
+// KeySet is a JSON Web Key Set loaded from a file or an HTTP(S) URL. Keys
+// are cached for TTL; a token signed with an unknown kid triggers a refresh
+// at most once per MinRefreshInterval so key rotation is picked up without
+// letting bad tokens hammer the issuer. The set is fetched without holding
+// the lock, and callers that need it while a fetch runs wait for that one.
+type KeySet struct {
+	source             string
+	client             *http.Client
+	ttl                time.Duration
+	minRefreshInterval time.Duration
+	nowFunc            func() time.Time
+
+	mu         sync.Mutex
+	keys       map[string]crypto.PublicKey
+	fetchedAt  time.Time
+	refreshing *keyRefresh
+}
+
+// keyRefresh is a fetch in progress; err is set before done is closed.
+type keyRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+func NewKeySet(source string, ttl time.Duration, client *http.Client, nowFunc func() time.Time) *KeySet {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	return &KeySet{
+		source:             source,
+		client:             client,
+		ttl:                ttl,
+		minRefreshInterval: 10 * time.Second,
+		nowFunc:            nowFunc,
+	}
+}
+
+// Key returns the public key for kid. An empty kid matches the only key
+// of a single-key set.
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	keys, fetchedAt := s.snapshot()
+	if keys == nil || s.nowFunc().Sub(fetchedAt) >= s.ttl {
+		err := s.refresh(ctx)
+		keys, fetchedAt = s.snapshot()
+		if err != nil && keys == nil {
+			return nil, err
+		}
+	}
+
+	if key, ok := lookup(keys, kid); ok {
+		return key, nil
+	}
+
+	if s.nowFunc().Sub(fetchedAt) >= s.minRefreshInterval {
+		if err := s.refresh(ctx); err != nil {
+			return nil, err
+		}
+		keys, _ = s.snapshot()
+		if key, ok := lookup(keys, kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+}
+
+// snapshot returns the current keys. The map is replaced, never changed,
+// so it can be read without the lock.
+func (s *KeySet) snapshot() (map[string]crypto.PublicKey, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.keys, s.fetchedAt
+}
+
+func lookup(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+
+	key, ok := keys[kid]
+	return key, ok
+}
+
+// refresh starts a fetch, or joins the one in progress, and waits for it
+// until ctx is done. The fetch itself is not tied to any one caller, so a
+// caller going away does not fail it for the others.
+func (s *KeySet) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	call := s.refreshing
+	if call == nil {
+		call = &keyRefresh{done: make(chan struct{})}
+		s.refreshing = call
+		go s.runRefresh(context.WithoutCancel(ctx), call)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runRefresh replaces the cached keys. On failure the old keys stay in use
+// and are retried after minRefreshInterval.
+func (s *KeySet) runRefresh(ctx context.Context, call *keyRefresh) {
+	data, err := s.fetch(ctx)
+	var keys map[string]crypto.PublicKey
+	if err == nil {
+		keys, err = ParseJWKS(data)
+	}
+	now := s.nowFunc()
+
+	s.mu.Lock()
+	switch {
+	case err == nil:
+		s.keys = keys
+		s.fetchedAt = now
+	case s.keys != nil:
+		s.fetchedAt = now.Add(s.minRefreshInterval - s.ttl)
+	}
+	if err != nil {
+		call.err = fmt.Errorf("load jwks from %s: %w", s.source, err)
+	}
+	s.refreshing = nil
+	s.mu.Unlock()
+
+	close(call.done)
+}
+
+func (s *KeySet) fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.source, "http://") && !strings.HasPrefix(s.source, "https://") {
+		return os.ReadFile(s.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, maxJWKSBytes))
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS decodes the RSA and EC signing keys of a JWKS document. Keys
+// of other types or marked for encryption are skipped.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(document.Keys))
+	for i, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch jwk.Kty {
+		case "RSA":
+			key, err = jwk.rsaKey()
+		case "EC":
+			key, err = jwk.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %d (%q): %w", i, jwk.Kid, err)
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no usable signing keys")
+	}
+
+	return keys, nil
+}
+
+func (k jsonWebKey) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("modulus: %w", err)
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("exponent: %w", err)
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("exponent out of range")
+	}
+
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jsonWebKey) ecKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("x: %w", err)
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("y: %w", err)
+	}
+
+	key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on the curve")
+	}
+
+	return key, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, errors.New("missing value")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(raw), nil
+}
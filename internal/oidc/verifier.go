@@ -0,0 +1,234 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"desent-api/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownIssuer   = errors.New("token issuer is not trusted")
+	ErrInvalidToken    = errors.New("invalid external token")
+	ErrInvalidIssuers  = errors.New("invalid oidc issuer configuration")
+	signingMethods     = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+	defaultJWKSCache   = 5 * time.Minute
+	defaultClockLeeway = 30 * time.Second
+)
+
+// IssuerConfig describes one trusted identity provider, as read from the
+// OIDC_ISSUERS_FILE JSON array. TrustAMR makes the issuer's amr claim
+// count as proof of a second factor; without it the claim is ignored.
+type IssuerConfig struct {
+	Name             string            `json:"name"`
+	Issuer           string            `json:"issuer"`
+	Audiences        []string          `json:"audiences"`
+	JWKSURL          string            `json:"jwks_url"`
+	JWKSFile         string            `json:"jwks_file"`
+	JWKSCacheSeconds int               `json:"jwks_cache_seconds"`
+	SubjectClaim     string            `json:"subject_claim"`
+	SubjectPrefix    *string           `json:"subject_prefix"`
+	RolesClaim       string            `json:"roles_claim"`
+	RoleMappings     map[string]string `json:"role_mappings"`
+	TrustAMR         bool              `json:"trust_amr"`
+}
+
+type issuer struct {
+	config IssuerConfig
+	keys   *KeySet
+	prefix string
+}
+
+// Verifier accepts tokens signed by any configured issuer and maps them to
+// a local principal.
+type Verifier struct {
+	issuers map[string]*issuer
+	nowFunc func() time.Time
+}
+
+// LoadIssuers reads issuer configurations from a JSON file.
+func LoadIssuers(path string) ([]IssuerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []IssuerConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIssuers, err)
+	}
+
+	return configs, nil
+}
+
+func NewVerifier(configs []IssuerConfig, client *http.Client, nowFunc func() time.Time) (*Verifier, error) {
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	verifier := &Verifier{issuers: make(map[string]*issuer, len(configs)), nowFunc: nowFunc}
+	for i, config := range configs {
+		if config.Issuer == "" {
+			return nil, fmt.Errorf("%w: issuer %d has no issuer url", ErrInvalidIssuers, i)
+		}
+		if len(config.Audiences) == 0 {
+			return nil, fmt.Errorf("%w: issuer %s has no audiences", ErrInvalidIssuers, config.Issuer)
+		}
+		if (config.JWKSURL == "") == (config.JWKSFile == "") {
+			return nil, fmt.Errorf("%w: issuer %s needs exactly one of jwks_url and jwks_file", ErrInvalidIssuers, config.Issuer)
+		}
+		if _, ok := verifier.issuers[config.Issuer]; ok {
+			return nil, fmt.Errorf("%w: issuer %s is configured twice", ErrInvalidIssuers, config.Issuer)
+		}
+
+		if config.Name == "" {
+			config.Name = config.Issuer
+		}
+		if config.SubjectClaim == "" {
+			config.SubjectClaim = "sub"
+		}
+
+		// External subjects are namespaced so an IdP user called "admin"
+		// is not the local admin.
+		prefix := config.Name + ":"
+		if config.SubjectPrefix != nil {
+			if *config.SubjectPrefix == "" {
+				return nil, fmt.Errorf("%w: issuer %s has an empty subject_prefix", ErrInvalidIssuers, config.Issuer)
+			}
+			prefix = *config.SubjectPrefix
+		}
+
+		ttl := defaultJWKSCache
+		if config.JWKSCacheSeconds > 0 {
+			ttl = time.Duration(config.JWKSCacheSeconds) * time.Second
+		}
+
+		source := config.JWKSURL
+		if source == "" {
+			source = config.JWKSFile
+		}
+
+		verifier.issuers[config.Issuer] = &issuer{
+			config: config,
+			keys:   NewKeySet(source, ttl, client, nowFunc),
+			prefix: prefix,
+		}
+	}
+
+	return verifier, nil
+}
+
+// Verify checks the signature, iss, aud, exp and nbf of a token from a
+// configured issuer. Tokens from other issuers fail with ErrUnknownIssuer.
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (models.Principal, error) {
+	iss, err := unverifiedIssuer(tokenString)
+	if err != nil {
+		return models.Principal{}, err
+	}
+
+	issuer, ok := v.issuers[iss]
+	if !ok {
+		return models.Principal{}, ErrUnknownIssuer
+	}
+
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(issuer.config.Issuer),
+		jwt.WithAudience(issuer.config.Audiences...),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(defaultClockLeeway),
+		jwt.WithTimeFunc(v.nowFunc),
+	)
+	_, err = parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return issuer.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	subject, _ := claims[issuer.config.SubjectClaim].(string)
+	if subject == "" {
+		return models.Principal{}, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, issuer.config.SubjectClaim)
+	}
+
+	principal := models.Principal{
+		Subject: issuer.prefix + subject,
+		Roles:   issuer.mapRoles(claims),
+		Issuer:  issuer.config.Issuer,
+	}
+	if issuer.config.TrustAMR {
+		principal.AuthMethods = stringValues(claims["amr"])
+	}
+
+	return principal, nil
+}
+
+// mapRoles translates the values of the roles claim through role_mappings.
+// Values without a mapping grant nothing.
+func (i *issuer) mapRoles(claims jwt.MapClaims) []string {
+	if i.config.RolesClaim == "" {
+		return nil
+	}
+
+	var roles []string
+	for _, value := range stringValues(claimPath(claims, i.config.RolesClaim)) {
+		role, ok := i.config.RoleMappings[value]
+		if ok && !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
+// claimPath resolves dotted claim names such as "realm_access.roles".
+func claimPath(claims jwt.MapClaims, path string) any {
+	var current any = map[string]any(claims)
+	for _, part := range strings.Split(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		current = object[part]
+	}
+
+	return current
+}
+
+func stringValues(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func unverifiedIssuer(tokenString string) (string, error) {
+	claims := jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, &claims); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return claims.Issuer, nil
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Policy is a token bucket: Burst requests may be made at once and Rate
+// tokens are refilled every Period.
+type Policy struct {
+	Name   string
+	Burst  int
+	Rate   int
+	Period time.Duration
+}
+
+// Window is how long an empty bucket takes to refill completely.
+func (p Policy) Window() time.Duration {
+	return p.emissionInterval() * time.Duration(p.Burst)
+}
+
+func (p Policy) emissionInterval() time.Duration {
+	return p.Period / time.Duration(p.Rate)
+}
+
+func (p Policy) valid() bool {
+	return p.Burst > 0 && p.Rate > 0 && p.Period > 0
+}
+
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string, policy Policy) (Decision, error)
+}
+
+// gcra applies the generic cell rate algorithm to a key whose theoretical
+// arrival time is tat. It returns the tat to store when the request is
+// allowed; a zero tat means the key has never been seen.
+func gcra(now, tat time.Time, policy Policy) (time.Time, Decision) {
+	if tat.Before(now) {
+		tat = now
+	}
+
+	next := tat.Add(policy.emissionInterval())
+	if now.Before(next.Add(-policy.Window())) {
+		return time.Time{}, deniedDecision(now, tat, policy)
+	}
+
+	return next, allowedDecision(now, next, policy)
+}
+
+func allowedDecision(now, next time.Time, policy Policy) Decision {
+	allowAt := next.Add(-policy.Window())
+
+	return Decision{
+		Allowed:    true,
+		Limit:      policy.Burst,
+		Remaining:  int(now.Sub(allowAt) / policy.emissionInterval()),
+		ResetAfter: next.Sub(now),
+	}
+}
+
+func deniedDecision(now, tat time.Time, policy Policy) Decision {
+	if tat.Before(now) {
+		tat = now
+	}
+
+	allowAt := tat.Add(policy.emissionInterval()).Add(-policy.Window())
+	retryAfter := allowAt.Sub(now)
+	if retryAfter < 0 {
+		retryAfter = 0
+	}
+
+	return Decision{
+		Allowed:    false,
+		Limit:      policy.Burst,
+		Remaining:  0,
+		ResetAfter: tat.Sub(now),
+		RetryAfter: retryAfter,
+	}
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGCRA_BurstAndRefill(t *testing.T) {
+	now := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	// One token every 10s and room for 3, so an empty bucket refills in 30s.
+	policy := Policy{Name: "default", Burst: 3, Rate: 1, Period: 10 * time.Second}
+
+	cases := []struct {
+		name    string
+		tat     time.Time
+		wantTAT time.Time
+		want    Decision
+	}{
+		{
+			name:    "unseen key",
+			wantTAT: now.Add(10 * time.Second),
+			want:    Decision{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: 10 * time.Second},
+		},
+		{
+			name:    "stale tat is treated as a full bucket",
+			tat:     now.Add(-time.Hour),
+			wantTAT: now.Add(10 * time.Second),
+			want:    Decision{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: 10 * time.Second},
+		},
+		{
+			name:    "partly used",
+			tat:     now.Add(5 * time.Second),
+			wantTAT: now.Add(15 * time.Second),
+			want:    Decision{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 15 * time.Second},
+		},
+		{
+			name:    "last token of the burst",
+			tat:     now.Add(20 * time.Second),
+			wantTAT: now.Add(30 * time.Second),
+			want:    Decision{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 30 * time.Second},
+		},
+		{
+			name: "burst exhausted",
+			tat:  now.Add(30 * time.Second),
+			want: Decision{Allowed: false, Limit: 3, Remaining: 0, ResetAfter: 30 * time.Second, RetryAfter: 10 * time.Second},
+		},
+		{
+			name: "half a token refilled",
+			tat:  now.Add(25 * time.Second),
+			want: Decision{Allowed: false, Limit: 3, Remaining: 0, ResetAfter: 25 * time.Second, RetryAfter: 5 * time.Second},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tat, got := gcra(now, tc.tat, policy)
+			if got != tc.want {
+				t.Fatalf("expected %+v, got %+v", tc.want, got)
+			}
+			if !tat.Equal(tc.wantTAT) {
+				t.Fatalf("expected tat %v, got %v", tc.wantTAT, tat)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const memorySweepInterval = time.Minute
+
+// MemoryLimiter keeps bucket state in process memory. Keys whose bucket has
+// fully refilled carry no state and are swept periodically.
+type MemoryLimiter struct {
+	nowFunc func() time.Time
+
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryLimiter(nowFunc func() time.Time) *MemoryLimiter {
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	return &MemoryLimiter{
+		nowFunc: nowFunc,
+		tats:    make(map[string]time.Time),
+	}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, policy Policy) (Decision, error) {
+	if !policy.valid() {
+		return Decision{Allowed: true}, nil
+	}
+
+	now := l.nowFunc()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	tat, decision := gcra(now, l.tats[key], policy)
+	if decision.Allowed {
+		l.tats[key] = tat
+	}
+
+	return decision, nil
+}
+
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < memorySweepInterval {
+		return
+	}
+
+	for key, tat := range l.tats {
+		if !tat.After(now) {
+			delete(l.tats, key)
+		}
+	}
+	l.lastSweep = now
+}
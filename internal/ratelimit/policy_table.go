@@ -0,0 +1,263 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	TierAnonymous = "anonymous"
+	TierUser      = "user"
+)
+
+var ErrInvalidPolicies = errors.New("invalid rate limit policies")
+
+// Rule is one entry of a policy file. Pattern uses route syntax: {param}
+// matches a single path segment and a trailing /* matches the rest. Empty
+// Methods or Tier match everything.
+type Rule struct {
+	Name    string   `json:"name"`
+	Pattern string   `json:"pattern"`
+	Methods []string `json:"methods"`
+	Tier    string   `json:"tier"`
+	Burst   int      `json:"burst"`
+	Rate    int      `json:"rate"`
+	Period  string   `json:"period"`
+}
+
+type policyFile struct {
+	Policies []Rule `json:"policies"`
+}
+
+type compiledRule struct {
+	segments []string
+	prefix   bool
+	methods  map[string]bool
+	tier     string
+	policy   Policy
+}
+
+// PolicyTable resolves the policy for a request. Rules are checked in file
+// order and the first match wins; requests no rule matches use the
+// fallback policy. The rule set can be swapped at runtime by Reload.
+type PolicyTable struct {
+	fallback Policy
+	path     string
+	rules    atomic.Pointer[[]compiledRule]
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+func NewPolicyTable(fallback Policy, rules ...Rule) (*PolicyTable, error) {
+	table := &PolicyTable{fallback: fallback}
+	if err := table.Replace(rules); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// LoadPolicyTable reads rules from a JSON file of the form
+// {"policies": [...]}. An empty path yields a table with only the fallback.
+func LoadPolicyTable(path string, fallback Policy) (*PolicyTable, error) {
+	table, err := NewPolicyTable(fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	table.path = path
+	if path == "" {
+		return table, nil
+	}
+
+	if err := table.Reload(); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+func ParseRules(r io.Reader) ([]Rule, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var file policyFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicies, err)
+	}
+
+	return file.Policies, nil
+}
+
+// Replace validates rules and swaps them in atomically; on error the
+// current rules stay in place.
+func (t *PolicyTable) Replace(rules []Rule) error {
+	compiled := make([]compiledRule, 0, len(rules))
+	names := map[string]bool{t.fallback.Name: true}
+	for i, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			return fmt.Errorf("%w: policy %d: %v", ErrInvalidPolicies, i+1, err)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("%w: policy name %q is used twice", ErrInvalidPolicies, rule.Name)
+		}
+
+		names[rule.Name] = true
+		compiled = append(compiled, c)
+	}
+
+	t.rules.Store(&compiled)
+	return nil
+}
+
+// Reload re-reads the policy file if it changed since the last load.
+func (t *PolicyTable) Reload() error {
+	if t.path == "" {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPolicies, err)
+	}
+	if info.ModTime().Equal(t.modTime) {
+		return nil
+	}
+
+	f, err := os.Open(t.path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPolicies, err)
+	}
+	defer f.Close()
+
+	rules, err := ParseRules(f)
+	if err != nil {
+		return err
+	}
+
+	if err := t.Replace(rules); err != nil {
+		return err
+	}
+
+	t.modTime = info.ModTime()
+	return nil
+}
+
+// Watch polls the policy file until ctx is done, reporting failed reloads
+// to onError. The previous rules keep applying after a failed reload.
+func (t *PolicyTable) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	if t.path == "" || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Reload(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Match returns the policy for a request, or false when it is not limited.
+func (t *PolicyTable) Match(method, path, tier string) (Policy, bool) {
+	segments := splitPath(path)
+	for _, rule := range *t.rules.Load() {
+		if rule.matches(method, segments, tier) {
+			return rule.policy, true
+		}
+	}
+
+	return t.fallback, t.fallback.valid()
+}
+
+func compileRule(rule Rule) (compiledRule, error) {
+	if strings.TrimSpace(rule.Name) == "" {
+		return compiledRule{}, errors.New("name is required")
+	}
+	if !strings.HasPrefix(rule.Pattern, "/") {
+		return compiledRule{}, errors.New("pattern must start with /")
+	}
+
+	period := time.Minute
+	if rule.Period != "" {
+		parsed, err := time.ParseDuration(rule.Period)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("period: %v", err)
+		}
+		period = parsed
+	}
+
+	policy := Policy{Name: rule.Name, Burst: rule.Burst, Rate: rule.Rate, Period: period}
+	if !policy.valid() {
+		return compiledRule{}, errors.New("burst, rate and period must be positive")
+	}
+
+	c := compiledRule{segments: splitPath(rule.Pattern), tier: rule.Tier, policy: policy}
+	if n := len(c.segments); n > 0 && c.segments[n-1] == "*" {
+		c.segments = c.segments[:n-1]
+		c.prefix = true
+	}
+	if c.tier == "*" {
+		c.tier = ""
+	}
+	if len(rule.Methods) > 0 {
+		c.methods = make(map[string]bool, len(rule.Methods))
+		for _, method := range rule.Methods {
+			c.methods[strings.ToUpper(method)] = true
+		}
+	}
+
+	return c, nil
+}
+
+func (c compiledRule) matches(method string, segments []string, tier string) bool {
+	if c.methods != nil && !c.methods[method] {
+		return false
+	}
+	if c.tier != "" && c.tier != tier {
+		return false
+	}
+
+	if len(segments) < len(c.segments) || (!c.prefix && len(segments) != len(c.segments)) {
+		return false
+	}
+	for i, segment := range c.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			continue
+		}
+		if segment != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+const sqliteSweepInterval = time.Minute
+
+// SQLiteLimiter keeps bucket state in a shared SQLite database so every
+// process using the same file draws from the same quota. Each decision is a
+// single upsert, which SQLite serializes across connections and processes.
+type SQLiteLimiter struct {
+	db      *sql.DB
+	nowFunc func() time.Time
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewSQLiteLimiter expects the rate_limit_buckets table created by
+// repositories.InitSchema.
+func NewSQLiteLimiter(db *sql.DB, nowFunc func() time.Time) *SQLiteLimiter {
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	return &SQLiteLimiter{db: db, nowFunc: nowFunc}
+}
+
+func (l *SQLiteLimiter) Allow(ctx context.Context, key string, policy Policy) (Decision, error) {
+	if !policy.valid() {
+		return Decision{Allowed: true}, nil
+	}
+
+	now := l.nowFunc()
+	if err := l.sweep(ctx, now); err != nil {
+		return Decision{}, err
+	}
+
+	// The GCRA step from gcra, expressed as a conditional upsert: the row
+	// is only advanced when the request fits in the bucket.
+	nowNanos := now.UnixNano()
+	interval := int64(policy.emissionInterval())
+	var next int64
+	err := l.db.QueryRowContext(
+		ctx,
+		`INSERT INTO rate_limit_buckets (key, tat) VALUES (?1, ?2 + ?3)
+ON CONFLICT(key) DO UPDATE SET tat = max(tat, ?2) + ?3
+WHERE max(tat, ?2) + ?3 - ?4 <= ?2
+RETURNING tat`,
+		key,
+		nowNanos,
+		interval,
+		int64(policy.Window()),
+	).Scan(&next)
+	if err == nil {
+		return allowedDecision(now, time.Unix(0, next), policy), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Decision{}, err
+	}
+
+	var tat int64
+	if err := l.db.QueryRowContext(ctx, `SELECT tat FROM rate_limit_buckets WHERE key = ?`, key).Scan(&tat); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			tat = nowNanos
+		} else {
+			return Decision{}, err
+		}
+	}
+
+	return deniedDecision(now, time.Unix(0, tat), policy), nil
+}
+
+// Cleanup deletes buckets that have fully refilled; they carry no state.
+func (l *SQLiteLimiter) Cleanup(ctx context.Context) (int64, error) {
+	result, err := l.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE tat <= ?`, l.nowFunc().UnixNano())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (l *SQLiteLimiter) sweep(ctx context.Context, now time.Time) error {
+	l.mu.Lock()
+	due := now.Sub(l.lastSweep) >= sqliteSweepInterval
+	if due {
+		l.lastSweep = now
+	}
+	l.mu.Unlock()
+
+	if !due {
+		return nil
+	}
+
+	_, err := l.Cleanup(ctx)
+	return err
+}
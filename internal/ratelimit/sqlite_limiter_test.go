@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"desent-api/internal/repositories"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLiteLimiter_SharesBucketsAcrossLimiters(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "limits.db") + "?_pragma=busy_timeout(5000)"
+	now := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	policy := Policy{Name: "default", Burst: 2, Rate: 1, Period: 10 * time.Second}
+	ctx := context.Background()
+
+	limiters := make([]*SQLiteLimiter, 0, 2)
+	for i := 0; i < 2; i++ {
+		db, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			t.Fatalf("open db: %v", err)
+		}
+		t.Cleanup(func() { _ = db.Close() })
+
+		if err := repositories.InitSchema(ctx, db); err != nil {
+			t.Fatalf("init schema: %v", err)
+		}
+		limiters = append(limiters, NewSQLiteLimiter(db, func() time.Time { return now }))
+	}
+
+	steps := []struct {
+		limiter int
+		advance time.Duration
+		key     string
+		want    Decision
+	}{
+		{limiter: 0, key: "a", want: Decision{Allowed: true, Limit: 2, Remaining: 1, ResetAfter: 10 * time.Second}},
+		{limiter: 1, key: "a", want: Decision{Allowed: true, Limit: 2, Remaining: 0, ResetAfter: 20 * time.Second}},
+		{limiter: 0, key: "a", want: Decision{Allowed: false, Limit: 2, ResetAfter: 20 * time.Second, RetryAfter: 10 * time.Second}},
+		{limiter: 1, key: "b", want: Decision{Allowed: true, Limit: 2, Remaining: 1, ResetAfter: 10 * time.Second}},
+		{limiter: 1, advance: 4 * time.Second, key: "a", want: Decision{Allowed: false, Limit: 2, ResetAfter: 16 * time.Second, RetryAfter: 6 * time.Second}},
+		{limiter: 0, advance: 6 * time.Second, key: "a", want: Decision{Allowed: true, Limit: 2, Remaining: 0, ResetAfter: 20 * time.Second}},
+	}
+	for i, step := range steps {
+		now = now.Add(step.advance)
+		got, err := limiters[step.limiter].Allow(ctx, step.key, policy)
+		if err != nil {
+			t.Fatalf("step %d: allow: %v", i+1, err)
+		}
+		if got != step.want {
+			t.Fatalf("step %d: expected %+v, got %+v", i+1, step.want, got)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	deleted, err := limiters[1].Cleanup(ctx)
+	if err != nil || deleted != 2 {
+		t.Fatalf("expected both refilled buckets to be cleaned up, got %d (%v)", deleted, err)
+	}
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"desent-api/internal/models"
+)
+
+var ErrAccountTokenNotFound = errors.New("account token not found")
+
+type AccountTokenRepository interface {
+	Replace(ctx context.Context, token models.AccountToken) error
+	Consume(ctx context.Context, tokenHash, purpose string, now time.Time) (models.AccountToken, error)
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+type SQLiteAccountTokenRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteAccountTokenRepository(db *sql.DB) *SQLiteAccountTokenRepository {
+	return &SQLiteAccountTokenRepository{db: db}
+}
+
+func InitAccountTokensSchema(ctx context.Context, db *sql.DB) error {
+	query := `
+CREATE TABLE IF NOT EXISTS account_tokens (
+	token_hash TEXT PRIMARY KEY,
+	username TEXT NOT NULL,
+	purpose TEXT NOT NULL,
+	email TEXT NOT NULL,
+	expires_at INTEGER NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_account_tokens_user ON account_tokens(username, purpose);`
+
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+// Replace stores token and drops any earlier token with the same purpose
+// for the user, so only the newest mail works.
+func (r *SQLiteAccountTokenRepository) Replace(ctx context.Context, token models.AccountToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM account_tokens WHERE username = ? AND purpose = ?`, token.Username, token.Purpose); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO account_tokens (token_hash, username, purpose, email, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		token.TokenHash,
+		token.Username,
+		token.Purpose,
+		token.Email,
+		token.ExpiresAt.Unix(),
+		token.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Consume deletes and returns an unexpired token in one statement, so a
+// token can be used at most once even under concurrent requests.
+func (r *SQLiteAccountTokenRepository) Consume(ctx context.Context, tokenHash, purpose string, now time.Time) (models.AccountToken, error) {
+	var (
+		token     models.AccountToken
+		expiresAt int64
+		createdAt int64
+	)
+	err := r.db.QueryRowContext(
+		ctx,
+		`DELETE FROM account_tokens WHERE token_hash = ? AND purpose = ? AND expires_at > ?
+		RETURNING token_hash, username, purpose, email, expires_at, created_at`,
+		tokenHash,
+		purpose,
+		now.Unix(),
+	).Scan(&token.TokenHash, &token.Username, &token.Purpose, &token.Email, &expiresAt, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AccountToken{}, ErrAccountTokenNotFound
+		}
+
+		return models.AccountToken{}, err
+	}
+
+	token.ExpiresAt = time.Unix(expiresAt, 0).UTC()
+	token.CreatedAt = time.Unix(createdAt, 0).UTC()
+	return token, nil
+}
+
+func (r *SQLiteAccountTokenRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM account_tokens WHERE expires_at <= ?`, now.Unix())
+	return err
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"desent-api/internal/models"
+)
+
+var ErrBookCoverNotFound = errors.New("book cover not found")
+
+type BookCoverRepository interface {
+	Upsert(ctx context.Context, cover models.BookCover) error
+	FindByBookID(ctx context.Context, bookID int64) (models.BookCover, error)
+	DeleteByBookID(ctx context.Context, bookID int64) error
+	CountByChecksum(ctx context.Context, checksum string) (int, error)
+}
+
+type SQLiteBookCoverRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteBookCoverRepository(db *sql.DB) *SQLiteBookCoverRepository {
+	return &SQLiteBookCoverRepository{db: db}
+}
+
+func InitBookCoversSchema(ctx context.Context, db *sql.DB) error {
+	query := `
+CREATE TABLE IF NOT EXISTS book_covers (
+	book_id INTEGER PRIMARY KEY,
+	checksum TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	width INTEGER NOT NULL,
+	height INTEGER NOT NULL,
+	size_bytes INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_book_covers_checksum ON book_covers (checksum);
+CREATE TRIGGER IF NOT EXISTS books_delete_cover AFTER DELETE ON books
+BEGIN
+	DELETE FROM book_covers WHERE book_id = OLD.id;
+END;`
+
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+func (r *SQLiteBookCoverRepository) Upsert(ctx context.Context, cover models.BookCover) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO book_covers (book_id, checksum, content_type, width, height, size_bytes, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(book_id) DO UPDATE SET
+	checksum = excluded.checksum,
+	content_type = excluded.content_type,
+	width = excluded.width,
+	height = excluded.height,
+	size_bytes = excluded.size_bytes,
+	updated_at = excluded.updated_at`,
+		cover.BookID,
+		cover.Checksum,
+		cover.ContentType,
+		cover.Width,
+		cover.Height,
+		cover.SizeBytes,
+		cover.UpdatedAt.Unix(),
+	)
+	return err
+}
+
+func (r *SQLiteBookCoverRepository) FindByBookID(ctx context.Context, bookID int64) (models.BookCover, error) {
+	var cover models.BookCover
+	var updatedAt int64
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT book_id, checksum, content_type, width, height, size_bytes, updated_at FROM book_covers WHERE book_id = ?`,
+		bookID,
+	).Scan(
+		&cover.BookID,
+		&cover.Checksum,
+		&cover.ContentType,
+		&cover.Width,
+		&cover.Height,
+		&cover.SizeBytes,
+		&updatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.BookCover{}, ErrBookCoverNotFound
+		}
+
+		return models.BookCover{}, err
+	}
+
+	cover.UpdatedAt = time.Unix(updatedAt, 0).UTC()
+	return cover, nil
+}
+
+func (r *SQLiteBookCoverRepository) DeleteByBookID(ctx context.Context, bookID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM book_covers WHERE book_id = ?`, bookID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrBookCoverNotFound
+	}
+
+	return nil
+}
+
+func (r *SQLiteBookCoverRepository) CountByChecksum(ctx context.Context, checksum string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM book_covers WHERE checksum = ?`, checksum).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"desent-api/internal/models"
+)
+
+type BookProvenanceRepository interface {
+	Save(ctx context.Context, provenance []models.BookFieldProvenance) error
+	FindByBookID(ctx context.Context, bookID int64) ([]models.BookFieldProvenance, error)
+}
+
+type SQLiteBookProvenanceRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteBookProvenanceRepository(db *sql.DB) *SQLiteBookProvenanceRepository {
+	return &SQLiteBookProvenanceRepository{db: db}
+}
+
+func (r *SQLiteBookProvenanceRepository) Save(ctx context.Context, provenance []models.BookFieldProvenance) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range provenance {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT OR REPLACE INTO book_field_provenance (book_id, field, source, source_ref, recorded_at) VALUES (?, ?, ?, ?, ?)`,
+			entry.BookID,
+			entry.Field,
+			entry.Source,
+			entry.SourceRef,
+			entry.RecordedAt.Unix(),
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteBookProvenanceRepository) FindByBookID(ctx context.Context, bookID int64) ([]models.BookFieldProvenance, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT book_id, field, source, source_ref, recorded_at FROM book_field_provenance WHERE book_id = ? ORDER BY field ASC`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	provenance := make([]models.BookFieldProvenance, 0)
+	for rows.Next() {
+		var entry models.BookFieldProvenance
+		var recordedAt int64
+		if err := rows.Scan(&entry.BookID, &entry.Field, &entry.Source, &entry.SourceRef, &recordedAt); err != nil {
+			return nil, err
+		}
+
+		entry.RecordedAt = time.Unix(recordedAt, 0).UTC()
+		provenance = append(provenance, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return provenance, nil
+}
@@ -17,12 +17,17 @@ type BookRepository interface {
 	FindByID(ctx context.Context, id int64) (models.Book, error)
 	UpdateByID(ctx context.Context, id int64, book models.Book) (models.Book, error)
 	DeleteByID(ctx context.Context, id int64) error
+	Merge(ctx context.Context, targetID, sourceID int64) (models.Book, error)
 }
 
 type SQLiteBookRepository struct {
 	db *sql.DB
 }
 
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 func NewSQLiteBookRepository(db *sql.DB) *SQLiteBookRepository {
 	return &SQLiteBookRepository{db: db}
 }
@@ -33,15 +38,93 @@ CREATE TABLE IF NOT EXISTS books (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	title TEXT NOT NULL,
 	author TEXT NOT NULL,
-	year INTEGER NOT NULL
-);`
+	year INTEGER NOT NULL,
+	isbn TEXT NOT NULL DEFAULT '',
+	subjects TEXT NOT NULL DEFAULT '[]'
+);
+CREATE TABLE IF NOT EXISTS works (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL,
+	author TEXT NOT NULL,
+	original_year INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS series (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS publishers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	location TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS book_field_provenance (
+	book_id INTEGER NOT NULL,
+	field TEXT NOT NULL,
+	source TEXT NOT NULL,
+	source_ref TEXT NOT NULL DEFAULT '',
+	recorded_at INTEGER NOT NULL,
+	PRIMARY KEY (book_id, field)
+);
+CREATE TRIGGER IF NOT EXISTS books_delete_field_provenance AFTER DELETE ON books
+BEGIN
+	DELETE FROM book_field_provenance WHERE book_id = OLD.id;
+END;`
+
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return err
+	}
 
-	_, err := db.ExecContext(ctx, query)
+	columns := []struct {
+		name       string
+		definition string
+	}{
+		{"isbn", `TEXT NOT NULL DEFAULT ''`},
+		{"work_id", `INTEGER`},
+		{"series_id", `INTEGER`},
+		{"series_position", `INTEGER NOT NULL DEFAULT 0`},
+		{"publisher_id", `INTEGER`},
+		{"subjects", `TEXT NOT NULL DEFAULT '[]'`},
+	}
+	for _, column := range columns {
+		if err := ensureColumn(ctx, db, "books", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+
+	_, err := db.ExecContext(ctx, `
+CREATE INDEX IF NOT EXISTS idx_books_isbn ON books (isbn);
+CREATE INDEX IF NOT EXISTS idx_books_work_id ON books (work_id);
+CREATE INDEX IF NOT EXISTS idx_books_series_id ON books (series_id, series_position);
+CREATE INDEX IF NOT EXISTS idx_books_publisher_id ON books (publisher_id);`)
 	return err
 }
 
+const bookSelect = `SELECT b.id, b.title, b.author, b.year, b.isbn, b.subjects,
+	b.work_id, w.title, b.series_id, s.name, b.series_position, b.publisher_id, p.name
+FROM books b
+LEFT JOIN works w ON w.id = b.work_id
+LEFT JOIN series s ON s.id = b.series_id
+LEFT JOIN publishers p ON p.id = b.publisher_id`
+
 func (r *SQLiteBookRepository) Create(ctx context.Context, book models.Book) (models.Book, error) {
-	result, err := r.db.ExecContext(ctx, `INSERT INTO books (title, author, year) VALUES (?, ?, ?)`, book.Title, book.Author, book.Year)
+	if err := checkBookReferences(ctx, r.db, book); err != nil {
+		return models.Book{}, err
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO books (title, author, year, isbn, subjects, work_id, series_id, series_position, publisher_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		book.Title,
+		book.Author,
+		book.Year,
+		book.ISBN,
+		encodeSubjects(book.Subjects),
+		nullableID(workID(book)),
+		nullableID(seriesID(book)),
+		book.SeriesPosition,
+		nullableID(publisherID(book)),
+	)
 	if err != nil {
 		return models.Book{}, err
 	}
@@ -51,21 +134,41 @@ func (r *SQLiteBookRepository) Create(ctx context.Context, book models.Book) (mo
 		return models.Book{}, err
 	}
 
-	book.ID = id
-	return book, nil
+	return r.FindByID(ctx, id)
 }
 
 func (r *SQLiteBookRepository) FindAll(ctx context.Context, query models.BookListQuery) ([]models.Book, error) {
 	statement := strings.Builder{}
-	statement.WriteString(`SELECT id, title, author, year FROM books`)
+	statement.WriteString(bookSelect)
 
-	args := make([]any, 0, 3)
+	conditions := make([]string, 0, 4)
+	args := make([]any, 0, 6)
 	if query.Author != "" {
-		statement.WriteString(` WHERE author = ?`)
+		conditions = append(conditions, `b.author = ?`)
 		args = append(args, query.Author)
 	}
+	if query.WorkID > 0 {
+		conditions = append(conditions, `b.work_id = ?`)
+		args = append(args, query.WorkID)
+	}
+	if query.SeriesID > 0 {
+		conditions = append(conditions, `b.series_id = ?`)
+		args = append(args, query.SeriesID)
+	}
+	if query.PublisherID > 0 {
+		conditions = append(conditions, `b.publisher_id = ?`)
+		args = append(args, query.PublisherID)
+	}
+
+	if len(conditions) > 0 {
+		statement.WriteString(` WHERE ` + strings.Join(conditions, ` AND `))
+	}
 
-	statement.WriteString(` ORDER BY id ASC`)
+	if query.SeriesID > 0 {
+		statement.WriteString(` ORDER BY b.series_position ASC, b.id ASC`)
+	} else {
+		statement.WriteString(` ORDER BY b.id ASC`)
+	}
 
 	if query.Page > 0 && query.Limit > 0 {
 		offset := (query.Page - 1) * query.Limit
@@ -81,8 +184,8 @@ func (r *SQLiteBookRepository) FindAll(ctx context.Context, query models.BookLis
 
 	books := make([]models.Book, 0)
 	for rows.Next() {
-		var book models.Book
-		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Year); err != nil {
+		book, err := scanBook(rows)
+		if err != nil {
 			return nil, err
 		}
 
@@ -97,48 +200,60 @@ func (r *SQLiteBookRepository) FindAll(ctx context.Context, query models.BookLis
 }
 
 func (r *SQLiteBookRepository) FindByID(ctx context.Context, id int64) (models.Book, error) {
-	var book models.Book
-	err := r.db.QueryRowContext(ctx, `SELECT id, title, author, year FROM books WHERE id = ?`, id).Scan(
-		&book.ID,
-		&book.Title,
-		&book.Author,
-		&book.Year,
-	)
+	return findBook(ctx, r.db, id)
+}
+
+func (r *SQLiteBookRepository) UpdateByID(ctx context.Context, id int64, book models.Book) (models.Book, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return models.Book{}, ErrBookNotFound
-		}
+		return models.Book{}, err
+	}
+	defer tx.Rollback()
 
+	if err := checkBookReferences(ctx, tx, book); err != nil {
 		return models.Book{}, err
 	}
 
-	return book, nil
-}
+	previous, err := findBook(ctx, tx, id)
+	if err != nil {
+		return models.Book{}, err
+	}
 
-func (r *SQLiteBookRepository) UpdateByID(ctx context.Context, id int64, book models.Book) (models.Book, error) {
-	result, err := r.db.ExecContext(
+	if _, err := tx.ExecContext(
 		ctx,
-		`UPDATE books SET title = ?, author = ?, year = ? WHERE id = ?`,
+		`UPDATE books SET title = ?, author = ?, year = ?, isbn = ?, subjects = ?, work_id = ?, series_id = ?, series_position = ?, publisher_id = ? WHERE id = ?`,
 		book.Title,
 		book.Author,
 		book.Year,
+		book.ISBN,
+		encodeSubjects(book.Subjects),
+		nullableID(workID(book)),
+		nullableID(seriesID(book)),
+		book.SeriesPosition,
+		nullableID(publisherID(book)),
 		id,
-	)
-	if err != nil {
+	); err != nil {
 		return models.Book{}, err
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	// Provenance describes where a value came from, so it no longer applies
+	// once someone edits that field by hand.
+	for _, field := range changedProvenanceFields(previous, book) {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM book_field_provenance WHERE book_id = ? AND field = ?`, id, field); err != nil {
+			return models.Book{}, err
+		}
+	}
+
+	updated, err := findBook(ctx, tx, id)
 	if err != nil {
 		return models.Book{}, err
 	}
 
-	if rowsAffected == 0 {
-		return models.Book{}, ErrBookNotFound
+	if err := tx.Commit(); err != nil {
+		return models.Book{}, err
 	}
 
-	book.ID = id
-	return book, nil
+	return updated, nil
 }
 
 func (r *SQLiteBookRepository) DeleteByID(ctx context.Context, id int64) error {
@@ -158,3 +273,229 @@ func (r *SQLiteBookRepository) DeleteByID(ctx context.Context, id int64) error {
 
 	return nil
 }
+
+// Merge folds the source book into the target: missing target fields are
+// filled from the source, dependent rows are repointed and the source is
+// deleted, all in one transaction.
+func (r *SQLiteBookRepository) Merge(ctx context.Context, targetID, sourceID int64) (models.Book, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Book{}, err
+	}
+	defer tx.Rollback()
+
+	target, err := findBook(ctx, tx, targetID)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	source, err := findBook(ctx, tx, sourceID)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	// Fields copied from the source keep their provenance.
+	var copiedFields []string
+	if target.ISBN == "" {
+		target.ISBN = source.ISBN
+	}
+	if len(target.Subjects) == 0 {
+		target.Subjects = source.Subjects
+		copiedFields = append(copiedFields, models.BookFieldSubjects)
+	}
+	if target.Work == nil {
+		target.Work = source.Work
+	}
+	if target.Series == nil {
+		target.Series = source.Series
+		target.SeriesPosition = source.SeriesPosition
+	}
+	if target.Publisher == nil {
+		target.Publisher = source.Publisher
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE books SET isbn = ?, subjects = ?, work_id = ?, series_id = ?, series_position = ?, publisher_id = ? WHERE id = ?`,
+		target.ISBN,
+		encodeSubjects(target.Subjects),
+		nullableID(workID(target)),
+		nullableID(seriesID(target)),
+		target.SeriesPosition,
+		nullableID(publisherID(target)),
+		targetID,
+	); err != nil {
+		return models.Book{}, err
+	}
+
+	type repoint struct {
+		query string
+		args  []any
+	}
+	repoints := []repoint{
+		{`DELETE FROM reading_list_entries WHERE book_id = ? AND list_id IN (SELECT list_id FROM reading_list_entries WHERE book_id = ?)`, []any{sourceID, targetID}},
+		{`UPDATE reading_list_entries SET book_id = ? WHERE book_id = ?`, []any{targetID, sourceID}},
+		{`UPDATE book_covers SET book_id = ? WHERE book_id = ? AND NOT EXISTS (SELECT 1 FROM book_covers WHERE book_id = ?)`, []any{targetID, sourceID, targetID}},
+	}
+	for _, field := range copiedFields {
+		repoints = append(repoints,
+			repoint{`DELETE FROM book_field_provenance WHERE book_id = ? AND field = ?`, []any{targetID, field}},
+			repoint{`UPDATE book_field_provenance SET book_id = ? WHERE book_id = ? AND field = ?`, []any{targetID, sourceID, field}},
+		)
+	}
+	for _, repoint := range repoints {
+		if _, err := tx.ExecContext(ctx, repoint.query, repoint.args...); err != nil {
+			return models.Book{}, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM books WHERE id = ?`, sourceID); err != nil {
+		return models.Book{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Book{}, err
+	}
+
+	return target, nil
+}
+
+func findBook(ctx context.Context, q queryRower, id int64) (models.Book, error) {
+	book, err := scanBook(q.QueryRowContext(ctx, bookSelect+` WHERE b.id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Book{}, ErrBookNotFound
+		}
+
+		return models.Book{}, err
+	}
+
+	return book, nil
+}
+
+func scanBook(row rowScanner) (models.Book, error) {
+	var (
+		book          models.Book
+		subjects      string
+		workID        sql.NullInt64
+		workTitle     sql.NullString
+		seriesID      sql.NullInt64
+		seriesName    sql.NullString
+		publisherID   sql.NullInt64
+		publisherName sql.NullString
+	)
+	if err := row.Scan(
+		&book.ID,
+		&book.Title,
+		&book.Author,
+		&book.Year,
+		&book.ISBN,
+		&subjects,
+		&workID,
+		&workTitle,
+		&seriesID,
+		&seriesName,
+		&book.SeriesPosition,
+		&publisherID,
+		&publisherName,
+	); err != nil {
+		return models.Book{}, err
+	}
+
+	book.Subjects = decodeStrings(subjects)
+
+	if workID.Valid && workTitle.Valid {
+		book.Work = &models.Work{ID: workID.Int64, Title: workTitle.String}
+	}
+	if seriesID.Valid && seriesName.Valid {
+		book.Series = &models.Series{ID: seriesID.Int64, Name: seriesName.String}
+	} else {
+		book.SeriesPosition = 0
+	}
+	if publisherID.Valid && publisherName.Valid {
+		book.Publisher = &models.Publisher{ID: publisherID.Int64, Name: publisherName.String}
+	}
+
+	return book, nil
+}
+
+func checkBookReferences(ctx context.Context, q queryRower, book models.Book) error {
+	references := []struct {
+		table string
+		id    int64
+		err   error
+	}{
+		{"works", workID(book), ErrWorkNotFound},
+		{"series", seriesID(book), ErrSeriesNotFound},
+		{"publishers", publisherID(book), ErrPublisherNotFound},
+	}
+	for _, reference := range references {
+		if reference.id == 0 {
+			continue
+		}
+
+		var exists int
+		if err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+reference.table+` WHERE id = ?`, reference.id).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return reference.err
+		}
+	}
+
+	return nil
+}
+
+func workID(book models.Book) int64 {
+	if book.Work == nil {
+		return 0
+	}
+
+	return book.Work.ID
+}
+
+func seriesID(book models.Book) int64 {
+	if book.Series == nil {
+		return 0
+	}
+
+	return book.Series.ID
+}
+
+func publisherID(book models.Book) int64 {
+	if book.Publisher == nil {
+		return 0
+	}
+
+	return book.Publisher.ID
+}
+
+func nullableID(id int64) any {
+	if id <= 0 {
+		return nil
+	}
+
+	return id
+}
+
+func encodeSubjects(subjects []string) string {
+	return encodeStrings(subjects)
+}
+
+func changedProvenanceFields(previous, updated models.Book) []string {
+	fields := make([]string, 0, 4)
+	if previous.Title != updated.Title {
+		fields = append(fields, models.BookFieldTitle)
+	}
+	if previous.Author != updated.Author {
+		fields = append(fields, models.BookFieldAuthor)
+	}
+	if previous.Year != updated.Year {
+		fields = append(fields, models.BookFieldYear)
+	}
+	if encodeSubjects(previous.Subjects) != encodeSubjects(updated.Subjects) {
+		fields = append(fields, models.BookFieldSubjects)
+	}
+
+	return fields
+}
@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"desent-api/internal/models"
+)
+
+var ErrLoginAttemptNotFound = errors.New("login attempt not found")
+
+type LoginAttemptRepository interface {
+	Find(ctx context.Context, scope, subject string) (models.LoginAttempt, error)
+	RecordFailure(ctx context.Context, scope, subject string, now time.Time, window time.Duration) (int, error)
+	Lock(ctx context.Context, scope, subject string, until time.Time) error
+	Delete(ctx context.Context, scope, subject string) error
+	FindLocked(ctx context.Context, now time.Time) ([]models.LoginAttempt, error)
+	DeleteExpired(ctx context.Context, now time.Time, window time.Duration) error
+}
+
+type SQLiteLoginAttemptRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteLoginAttemptRepository(db *sql.DB) *SQLiteLoginAttemptRepository {
+	return &SQLiteLoginAttemptRepository{db: db}
+}
+
+func InitLoginAttemptsSchema(ctx context.Context, db *sql.DB) error {
+	query := `
+CREATE TABLE IF NOT EXISTS login_attempts (
+	scope TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	failures INTEGER NOT NULL DEFAULT 0,
+	last_failed_at INTEGER NOT NULL DEFAULT 0,
+	locked_until INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (scope, subject)
+);`
+
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+func (r *SQLiteLoginAttemptRepository) Find(ctx context.Context, scope, subject string) (models.LoginAttempt, error) {
+	attempt, err := scanLoginAttempt(r.db.QueryRowContext(
+		ctx,
+		`SELECT scope, subject, failures, last_failed_at, locked_until FROM login_attempts WHERE scope = ? AND subject = ?`,
+		scope,
+		subject,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LoginAttempt{}, ErrLoginAttemptNotFound
+		}
+
+		return models.LoginAttempt{}, err
+	}
+
+	return attempt, nil
+}
+
+// RecordFailure counts a failed login and returns the new failure count.
+// The count starts over once window has passed since both the last failure
+// and the end of the last lockout, so lockouts keep growing for an attacker
+// who simply waits them out.
+func (r *SQLiteLoginAttemptRepository) RecordFailure(ctx context.Context, scope, subject string, now time.Time, window time.Duration) (int, error) {
+	var failures int
+	err := r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO login_attempts (scope, subject, failures, last_failed_at) VALUES (?1, ?2, 1, ?3)
+ON CONFLICT(scope, subject) DO UPDATE SET
+	failures = CASE WHEN max(last_failed_at, locked_until) < ?4 THEN 1 ELSE failures + 1 END,
+	last_failed_at = ?3
+RETURNING failures`,
+		scope,
+		subject,
+		now.Unix(),
+		now.Add(-window).Unix(),
+	).Scan(&failures)
+	return failures, err
+}
+
+func (r *SQLiteLoginAttemptRepository) Lock(ctx context.Context, scope, subject string, until time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE login_attempts SET locked_until = ? WHERE scope = ? AND subject = ?`, until.Unix(), scope, subject)
+	return err
+}
+
+func (r *SQLiteLoginAttemptRepository) Delete(ctx context.Context, scope, subject string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE scope = ? AND subject = ?`, scope, subject)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLoginAttemptNotFound
+	}
+
+	return nil
+}
+
+func (r *SQLiteLoginAttemptRepository) FindLocked(ctx context.Context, now time.Time) ([]models.LoginAttempt, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT scope, subject, failures, last_failed_at, locked_until FROM login_attempts WHERE locked_until > ? ORDER BY locked_until DESC`,
+		now.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := make([]models.LoginAttempt, 0)
+	for rows.Next() {
+		attempt, err := scanLoginAttempt(rows)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// DeleteExpired drops attempts that no longer lock anything and whose
+// failures RecordFailure would start over, because window has passed since
+// both the last failure and the end of the last lockout.
+func (r *SQLiteLoginAttemptRepository) DeleteExpired(ctx context.Context, now time.Time, window time.Duration) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM login_attempts WHERE max(last_failed_at, locked_until) < ?`,
+		now.Add(-window).Unix(),
+	)
+	return err
+}
+
+func scanLoginAttempt(row rowScanner) (models.LoginAttempt, error) {
+	var (
+		attempt      models.LoginAttempt
+		lastFailedAt int64
+		lockedUntil  int64
+	)
+	if err := row.Scan(&attempt.Scope, &attempt.Subject, &attempt.Failures, &lastFailedAt, &lockedUntil); err != nil {
+		return models.LoginAttempt{}, err
+	}
+
+	attempt.LastFailedAt = time.Unix(lastFailedAt, 0).UTC()
+	if lockedUntil > 0 {
+		attempt.LockedUntil = time.Unix(lockedUntil, 0).UTC()
+	}
+
+	return attempt, nil
+}
@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"desent-api/internal/models"
+)
+
+var ErrMetadataNotFound = errors.New("metadata not found")
+
+type MetadataRepository interface {
+	SaveBatch(ctx context.Context, editions []models.MetadataEdition, works []models.MetadataWork, authors []models.MetadataAuthor) error
+	FindByISBN(ctx context.Context, isbn string) (models.MetadataRecord, error)
+}
+
+type SQLiteMetadataRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteMetadataRepository(db *sql.DB) *SQLiteMetadataRepository {
+	return &SQLiteMetadataRepository{db: db}
+}
+
+func InitMetadataSchema(ctx context.Context, db *sql.DB) error {
+	query := `
+CREATE TABLE IF NOT EXISTS metadata_editions (
+	isbn TEXT PRIMARY KEY,
+	edition_key TEXT NOT NULL,
+	title TEXT NOT NULL,
+	year INTEGER NOT NULL DEFAULT 0,
+	subjects TEXT NOT NULL DEFAULT '[]',
+	author_keys TEXT NOT NULL DEFAULT '[]',
+	work_key TEXT NOT NULL DEFAULT '',
+	source TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS metadata_works (
+	key TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	subjects TEXT NOT NULL DEFAULT '[]',
+	author_keys TEXT NOT NULL DEFAULT '[]'
+);
+CREATE TABLE IF NOT EXISTS metadata_authors (
+	key TEXT PRIMARY KEY,
+	name TEXT NOT NULL
+);`
+
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+func (r *SQLiteMetadataRepository) SaveBatch(ctx context.Context, editions []models.MetadataEdition, works []models.MetadataWork, authors []models.MetadataAuthor) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, edition := range editions {
+		for _, isbn := range edition.ISBNs {
+			if _, err := tx.ExecContext(
+				ctx,
+				`INSERT OR REPLACE INTO metadata_editions (isbn, edition_key, title, year, subjects, author_keys, work_key, source) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				isbn,
+				edition.Key,
+				edition.Title,
+				edition.Year,
+				encodeStrings(edition.Subjects),
+				encodeStrings(edition.AuthorKeys),
+				edition.WorkKey,
+				edition.Source,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, work := range works {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT OR REPLACE INTO metadata_works (key, title, subjects, author_keys) VALUES (?, ?, ?, ?)`,
+			work.Key,
+			work.Title,
+			encodeStrings(work.Subjects),
+			encodeStrings(work.AuthorKeys),
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, author := range authors {
+		if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO metadata_authors (key, name) VALUES (?, ?)`, author.Key, author.Name); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindByISBN resolves an edition and falls back to its work for title,
+// subjects and authors, which Open Library often only records on the work.
+func (r *SQLiteMetadataRepository) FindByISBN(ctx context.Context, isbn string) (models.MetadataRecord, error) {
+	var (
+		record        models.MetadataRecord
+		subjects      string
+		authorKeys    string
+		workKey       string
+		workTitle     sql.NullString
+		workSubjects  sql.NullString
+		workAuthorKey sql.NullString
+	)
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT e.isbn, e.edition_key, e.title, e.year, e.subjects, e.author_keys, e.work_key, e.source, w.title, w.subjects, w.author_keys
+FROM metadata_editions e
+LEFT JOIN metadata_works w ON w.key = e.work_key
+WHERE e.isbn = ?`,
+		isbn,
+	).Scan(
+		&record.ISBN,
+		&record.SourceRef,
+		&record.Title,
+		&record.Year,
+		&subjects,
+		&authorKeys,
+		&workKey,
+		&record.Source,
+		&workTitle,
+		&workSubjects,
+		&workAuthorKey,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.MetadataRecord{}, ErrMetadataNotFound
+		}
+
+		return models.MetadataRecord{}, err
+	}
+
+	record.Subjects = decodeStrings(subjects)
+	keys := decodeStrings(authorKeys)
+
+	if record.Title == "" && workTitle.Valid {
+		record.Title = workTitle.String
+	}
+	if len(record.Subjects) == 0 && workSubjects.Valid {
+		record.Subjects = decodeStrings(workSubjects.String)
+	}
+	if len(keys) == 0 && workAuthorKey.Valid {
+		keys = decodeStrings(workAuthorKey.String)
+	}
+
+	for _, key := range keys {
+		var name string
+		err := r.db.QueryRowContext(ctx, `SELECT name FROM metadata_authors WHERE key = ?`, key).Scan(&name)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+
+			return models.MetadataRecord{}, err
+		}
+
+		record.Authors = append(record.Authors, name)
+	}
+
+	return record, nil
+}
+
+func encodeStrings(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "[]"
+	}
+
+	return string(encoded)
+}
+
+func decodeStrings(encoded string) []string {
+	var values []string
+	if err := json.Unmarshal([]byte(encoded), &values); err != nil {
+		return nil
+	}
+
+	return values
+}
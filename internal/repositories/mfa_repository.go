@@ -0,0 +1,260 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"desent-api/internal/models"
+)
+
+var ErrMFAEnrollmentNotFound = errors.New("mfa enrollment not found")
+var ErrMFAChallengeNotFound = errors.New("mfa challenge not found")
+
+type MFARepository interface {
+	SaveEnrollment(ctx context.Context, enrollment models.MFAEnrollment) error
+	FindEnrollment(ctx context.Context, username string) (models.MFAEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, username string, confirmedAt time.Time, step int64) error
+	UseStep(ctx context.Context, username string, step int64) (bool, error)
+	DeleteEnrollment(ctx context.Context, username string) error
+	ReplaceRecoveryCodes(ctx context.Context, username string, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, username, codeHash string, usedAt time.Time) (bool, error)
+	CountRecoveryCodes(ctx context.Context, username string) (int, error)
+	CreateChallenge(ctx context.Context, challenge models.MFAChallenge) error
+	FindChallenge(ctx context.Context, tokenHash string) (models.MFAChallenge, error)
+	IncrementChallengeAttempts(ctx context.Context, tokenHash string) (int, error)
+	DeleteChallenge(ctx context.Context, tokenHash string) error
+	DeleteExpiredChallenges(ctx context.Context, now time.Time) error
+}
+
+type SQLiteMFARepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteMFARepository(db *sql.DB) *SQLiteMFARepository {
+	return &SQLiteMFARepository{db: db}
+}
+
+func InitMFASchema(ctx context.Context, db *sql.DB) error {
+	query := `
+CREATE TABLE IF NOT EXISTS mfa_enrollments (
+	username TEXT PRIMARY KEY,
+	sealed_secret TEXT NOT NULL,
+	confirmed_at INTEGER NOT NULL DEFAULT 0,
+	last_used_step INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+	username TEXT NOT NULL,
+	code_hash TEXT NOT NULL,
+	used_at INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (username, code_hash)
+);
+CREATE TABLE IF NOT EXISTS mfa_challenges (
+	token_hash TEXT PRIMARY KEY,
+	username TEXT NOT NULL,
+	expires_at INTEGER NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0
+);
+CREATE TRIGGER IF NOT EXISTS mfa_enrollments_delete_recovery_codes
+AFTER DELETE ON mfa_enrollments
+BEGIN
+	DELETE FROM mfa_recovery_codes WHERE username = OLD.username;
+END;`
+
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+// SaveEnrollment starts a new, unconfirmed enrollment, replacing any
+// earlier one for the user.
+func (r *SQLiteMFARepository) SaveEnrollment(ctx context.Context, enrollment models.MFAEnrollment) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO mfa_enrollments (username, sealed_secret, confirmed_at, last_used_step, created_at) VALUES (?, ?, 0, 0, ?)`,
+		enrollment.Username,
+		enrollment.SealedSecret,
+		enrollment.CreatedAt.Unix(),
+	)
+	return err
+}
+
+func (r *SQLiteMFARepository) FindEnrollment(ctx context.Context, username string) (models.MFAEnrollment, error) {
+	var (
+		enrollment  models.MFAEnrollment
+		confirmedAt int64
+		createdAt   int64
+	)
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT username, sealed_secret, confirmed_at, last_used_step, created_at FROM mfa_enrollments WHERE username = ?`,
+		username,
+	).Scan(&enrollment.Username, &enrollment.SealedSecret, &confirmedAt, &enrollment.LastUsedStep, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.MFAEnrollment{}, ErrMFAEnrollmentNotFound
+		}
+
+		return models.MFAEnrollment{}, err
+	}
+
+	if confirmedAt > 0 {
+		enrollment.ConfirmedAt = time.Unix(confirmedAt, 0).UTC()
+	}
+	enrollment.CreatedAt = time.Unix(createdAt, 0).UTC()
+
+	return enrollment, nil
+}
+
+func (r *SQLiteMFARepository) ConfirmEnrollment(ctx context.Context, username string, confirmedAt time.Time, step int64) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE mfa_enrollments SET confirmed_at = ?, last_used_step = ? WHERE username = ?`,
+		confirmedAt.Unix(),
+		step,
+		username,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, ErrMFAEnrollmentNotFound)
+}
+
+// UseStep records step as used unless it, or a later step, already was.
+func (r *SQLiteMFARepository) UseStep(ctx context.Context, username string, step int64) (bool, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE mfa_enrollments SET last_used_step = ? WHERE username = ? AND last_used_step < ?`,
+		step,
+		username,
+		step,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *SQLiteMFARepository) DeleteEnrollment(ctx context.Context, username string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM mfa_enrollments WHERE username = ?`, username)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, ErrMFAEnrollmentNotFound)
+}
+
+func (r *SQLiteMFARepository) ReplaceRecoveryCodes(ctx context.Context, username string, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE username = ?`, username); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO mfa_recovery_codes (username, code_hash) VALUES (?, ?)`, username, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteMFARepository) UseRecoveryCode(ctx context.Context, username, codeHash string, usedAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE mfa_recovery_codes SET used_at = ? WHERE username = ? AND code_hash = ? AND used_at = 0`,
+		usedAt.Unix(),
+		username,
+		codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *SQLiteMFARepository) CountRecoveryCodes(ctx context.Context, username string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM mfa_recovery_codes WHERE username = ? AND used_at = 0`, username).Scan(&count)
+	return count, err
+}
+
+func (r *SQLiteMFARepository) CreateChallenge(ctx context.Context, challenge models.MFAChallenge) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO mfa_challenges (token_hash, username, expires_at) VALUES (?, ?, ?)`,
+		challenge.TokenHash,
+		challenge.Username,
+		challenge.ExpiresAt.Unix(),
+	)
+	return err
+}
+
+func (r *SQLiteMFARepository) FindChallenge(ctx context.Context, tokenHash string) (models.MFAChallenge, error) {
+	var (
+		challenge models.MFAChallenge
+		expiresAt int64
+	)
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT token_hash, username, expires_at, attempts FROM mfa_challenges WHERE token_hash = ?`,
+		tokenHash,
+	).Scan(&challenge.TokenHash, &challenge.Username, &expiresAt, &challenge.Attempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.MFAChallenge{}, ErrMFAChallengeNotFound
+		}
+
+		return models.MFAChallenge{}, err
+	}
+
+	challenge.ExpiresAt = time.Unix(expiresAt, 0).UTC()
+	return challenge, nil
+}
+
+func (r *SQLiteMFARepository) IncrementChallengeAttempts(ctx context.Context, tokenHash string) (int, error) {
+	var attempts int
+	err := r.db.QueryRowContext(
+		ctx,
+		`UPDATE mfa_challenges SET attempts = attempts + 1 WHERE token_hash = ? RETURNING attempts`,
+		tokenHash,
+	).Scan(&attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrMFAChallengeNotFound
+	}
+
+	return attempts, err
+}
+
+func (r *SQLiteMFARepository) DeleteChallenge(ctx context.Context, tokenHash string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM mfa_challenges WHERE token_hash = ?`, tokenHash)
+	return err
+}
+
+func (r *SQLiteMFARepository) DeleteExpiredChallenges(ctx context.Context, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM mfa_challenges WHERE expires_at <= ?`, now.Unix())
+	return err
+}
+
+func requireAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"desent-api/internal/models"
+)
+
+var ErrOAuthClientNotFound = errors.New("oauth client not found")
+
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client models.OAuthClient) error
+	FindByClientID(ctx context.Context, clientID string) (models.OAuthClient, error)
+	List(ctx context.Context) ([]models.OAuthClient, error)
+	Delete(ctx context.Context, clientID string) error
+}
+
+type SQLiteOAuthClientRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteOAuthClientRepository(db *sql.DB) *SQLiteOAuthClientRepository {
+	return &SQLiteOAuthClientRepository{db: db}
+}
+
+func InitOAuthClientsSchema(ctx context.Context, db *sql.DB) error {
+	query := `
+CREATE TABLE IF NOT EXISTS oauth_clients (
+	client_id TEXT PRIMARY KEY,
+	secret_hash TEXT NOT NULL,
+	name TEXT NOT NULL,
+	scopes TEXT NOT NULL DEFAULT '[]',
+	grant_types TEXT NOT NULL DEFAULT '[]',
+	created_at INTEGER NOT NULL
+);`
+
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+func (r *SQLiteOAuthClientRepository) Create(ctx context.Context, client models.OAuthClient) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO oauth_clients (client_id, secret_hash, name, scopes, grant_types, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		client.ClientID,
+		client.SecretHash,
+		client.Name,
+		encodeStrings(client.Scopes),
+		encodeStrings(client.GrantTypes),
+		client.CreatedAt.Unix(),
+	)
+	return err
+}
+
+func (r *SQLiteOAuthClientRepository) FindByClientID(ctx context.Context, clientID string) (models.OAuthClient, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT client_id, secret_hash, name, scopes, grant_types, created_at FROM oauth_clients WHERE client_id = ?`,
+		clientID,
+	)
+
+	client, err := scanOAuthClient(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.OAuthClient{}, ErrOAuthClientNotFound
+	}
+
+	return client, err
+}
+
+func (r *SQLiteOAuthClientRepository) List(ctx context.Context) ([]models.OAuthClient, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT client_id, secret_hash, name, scopes, grant_types, created_at FROM oauth_clients ORDER BY created_at, client_id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := make([]models.OAuthClient, 0)
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		clients = append(clients, client)
+	}
+
+	return clients, rows.Err()
+}
+
+func (r *SQLiteOAuthClientRepository) Delete(ctx context.Context, clientID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM oauth_clients WHERE client_id = ?`, clientID)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, ErrOAuthClientNotFound)
+}
+
+func scanOAuthClient(row rowScanner) (models.OAuthClient, error) {
+	var (
+		client     models.OAuthClient
+		scopes     string
+		grantTypes string
+		createdAt  int64
+	)
+	if err := row.Scan(&client.ClientID, &client.SecretHash, &client.Name, &scopes, &grantTypes, &createdAt); err != nil {
+		return models.OAuthClient{}, err
+	}
+
+	client.Scopes = decodeStrings(scopes)
+	client.GrantTypes = decodeStrings(grantTypes)
+	client.CreatedAt = time.Unix(createdAt, 0).UTC()
+	return client, nil
+}
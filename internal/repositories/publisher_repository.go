@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"desent-api/internal/models"
+)
+
+var ErrPublisherNotFound = errors.New("publisher not found")
+
+type PublisherRepository interface {
+	Create(ctx context.Context, publisher models.Publisher) (models.Publisher, error)
+	FindAll(ctx context.Context) ([]models.Publisher, error)
+	FindByID(ctx context.Context, id int64) (models.Publisher, error)
+}
+
+type SQLitePublisherRepository struct {
+	db *sql.DB
+}
+
+func NewSQLitePublisherRepository(db *sql.DB) *SQLitePublisherRepository {
+	return &SQLitePublisherRepository{db: db}
+}
+
+func (r *SQLitePublisherRepository) Create(ctx context.Context, publisher models.Publisher) (models.Publisher, error) {
+	result, err := r.db.ExecContext(ctx, `INSERT INTO publishers (name, location) VALUES (?, ?)`, publisher.Name, publisher.Location)
+	if err != nil {
+		return models.Publisher{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Publisher{}, err
+	}
+
+	publisher.ID = id
+	return publisher, nil
+}
+
+func (r *SQLitePublisherRepository) FindAll(ctx context.Context) ([]models.Publisher, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, location FROM publishers ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	publishers := make([]models.Publisher, 0)
+	for rows.Next() {
+		var publisher models.Publisher
+		if err := rows.Scan(&publisher.ID, &publisher.Name, &publisher.Location); err != nil {
+			return nil, err
+		}
+
+		publishers = append(publishers, publisher)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return publishers, nil
+}
+
+func (r *SQLitePublisherRepository) FindByID(ctx context.Context, id int64) (models.Publisher, error) {
+	var publisher models.Publisher
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, location FROM publishers WHERE id = ?`, id).Scan(
+		&publisher.ID,
+		&publisher.Name,
+		&publisher.Location,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Publisher{}, ErrPublisherNotFound
+		}
+
+		return models.Publisher{}, err
+	}
+
+	return publisher, nil
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+)
+
+// InitRateLimitSchema creates the buckets ratelimit.SQLiteLimiter shares
+// between processes. tat is a theoretical arrival time in Unix nanoseconds.
+func InitRateLimitSchema(ctx context.Context, db *sql.DB) error {
+	query := `
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+	key TEXT PRIMARY KEY,
+	tat INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_tat ON rate_limit_buckets(tat);`
+
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
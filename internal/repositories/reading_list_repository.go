@@ -0,0 +1,379 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"desent-api/internal/models"
+)
+
+var ErrReadingListNotFound = errors.New("reading list not found")
+var ErrReadingListEntryNotFound = errors.New("reading list entry not found")
+var ErrReadingListEntryExists = errors.New("reading list entry already exists")
+
+type ReadingListRepository interface {
+	Create(ctx context.Context, list models.ReadingList) (models.ReadingList, error)
+	FindByID(ctx context.Context, id int64) (models.ReadingList, error)
+	FindByOwner(ctx context.Context, ownerID string, visibilities []string) ([]models.ReadingList, error)
+	UpdateByID(ctx context.Context, id int64, list models.ReadingList) (models.ReadingList, error)
+	DeleteByID(ctx context.Context, id int64) error
+	AddEntry(ctx context.Context, listID int64, entry models.ReadingListEntry) error
+	RemoveEntry(ctx context.Context, listID, bookID int64, updatedAt time.Time) error
+	ReorderEntries(ctx context.Context, listID int64, bookIDs []int64, updatedAt time.Time) error
+}
+
+type SQLiteReadingListRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteReadingListRepository(db *sql.DB) *SQLiteReadingListRepository {
+	return &SQLiteReadingListRepository{db: db}
+}
+
+func InitReadingListsSchema(ctx context.Context, db *sql.DB) error {
+	query := `
+CREATE TABLE IF NOT EXISTS reading_lists (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	owner_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	visibility TEXT NOT NULL DEFAULT 'private',
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_reading_lists_owner_id ON reading_lists (owner_id);
+CREATE TABLE IF NOT EXISTS reading_list_entries (
+	list_id INTEGER NOT NULL,
+	book_id INTEGER NOT NULL,
+	position INTEGER NOT NULL,
+	added_at INTEGER NOT NULL,
+	PRIMARY KEY (list_id, book_id)
+);
+CREATE INDEX IF NOT EXISTS idx_reading_list_entries_book_id ON reading_list_entries (book_id);
+CREATE TRIGGER IF NOT EXISTS reading_lists_delete_entries AFTER DELETE ON reading_lists
+BEGIN
+	DELETE FROM reading_list_entries WHERE list_id = OLD.id;
+END;
+CREATE TRIGGER IF NOT EXISTS books_delete_reading_list_entries AFTER DELETE ON books
+BEGIN
+	DELETE FROM reading_list_entries WHERE book_id = OLD.id;
+END;`
+
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+const readingListColumns = `l.id, l.owner_id, l.name, l.description, l.visibility, l.created_at, l.updated_at,
+	(SELECT COUNT(*) FROM reading_list_entries e WHERE e.list_id = l.id)`
+
+func (r *SQLiteReadingListRepository) Create(ctx context.Context, list models.ReadingList) (models.ReadingList, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO reading_lists (owner_id, name, description, visibility, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		list.OwnerID,
+		list.Name,
+		list.Description,
+		list.Visibility,
+		list.CreatedAt.Unix(),
+		list.UpdatedAt.Unix(),
+	)
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+
+	list.ID = id
+	list.Entries = []models.ReadingListEntry{}
+	return list, nil
+}
+
+func (r *SQLiteReadingListRepository) FindByID(ctx context.Context, id int64) (models.ReadingList, error) {
+	list, err := scanReadingList(r.db.QueryRowContext(ctx, `SELECT `+readingListColumns+` FROM reading_lists l WHERE l.id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ReadingList{}, ErrReadingListNotFound
+		}
+
+		return models.ReadingList{}, err
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT e.position, e.added_at, b.id, b.title, b.author, b.year
+FROM reading_list_entries e
+JOIN books b ON b.id = e.book_id
+WHERE e.list_id = ?
+ORDER BY e.position ASC, e.added_at ASC`,
+		id,
+	)
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+	defer rows.Close()
+
+	list.Entries = make([]models.ReadingListEntry, 0)
+	for rows.Next() {
+		var entry models.ReadingListEntry
+		var addedAt int64
+		if err := rows.Scan(&entry.Position, &addedAt, &entry.Book.ID, &entry.Book.Title, &entry.Book.Author, &entry.Book.Year); err != nil {
+			return models.ReadingList{}, err
+		}
+
+		entry.AddedAt = time.Unix(addedAt, 0).UTC()
+		list.Entries = append(list.Entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.ReadingList{}, err
+	}
+
+	return list, nil
+}
+
+func (r *SQLiteReadingListRepository) FindByOwner(ctx context.Context, ownerID string, visibilities []string) ([]models.ReadingList, error) {
+	statement := strings.Builder{}
+	statement.WriteString(`SELECT ` + readingListColumns + ` FROM reading_lists l WHERE l.owner_id = ?`)
+
+	args := make([]any, 0, len(visibilities)+1)
+	args = append(args, ownerID)
+	if len(visibilities) > 0 {
+		statement.WriteString(` AND l.visibility IN (?` + strings.Repeat(`, ?`, len(visibilities)-1) + `)`)
+		for _, visibility := range visibilities {
+			args = append(args, visibility)
+		}
+	}
+
+	statement.WriteString(` ORDER BY l.id ASC`)
+
+	rows, err := r.db.QueryContext(ctx, statement.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := make([]models.ReadingList, 0)
+	for rows.Next() {
+		list, err := scanReadingList(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, list)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+func (r *SQLiteReadingListRepository) UpdateByID(ctx context.Context, id int64, list models.ReadingList) (models.ReadingList, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE reading_lists SET name = ?, description = ?, visibility = ?, updated_at = ? WHERE id = ?`,
+		list.Name,
+		list.Description,
+		list.Visibility,
+		list.UpdatedAt.Unix(),
+		id,
+	)
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+
+	if rowsAffected == 0 {
+		return models.ReadingList{}, ErrReadingListNotFound
+	}
+
+	return r.FindByID(ctx, id)
+}
+
+func (r *SQLiteReadingListRepository) DeleteByID(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM reading_lists WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrReadingListNotFound
+	}
+
+	return nil
+}
+
+func (r *SQLiteReadingListRepository) AddEntry(ctx context.Context, listID int64, entry models.ReadingListEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM books WHERE id = ?`, entry.Book.ID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrBookNotFound
+	}
+
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM reading_list_entries WHERE list_id = ? AND book_id = ?`, listID, entry.Book.ID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return ErrReadingListEntryExists
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM reading_list_entries WHERE list_id = ?`, listID).Scan(&count); err != nil {
+		return err
+	}
+
+	position := entry.Position
+	if position <= 0 || position > count+1 {
+		position = count + 1
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE reading_list_entries SET position = position + 1 WHERE list_id = ? AND position >= ?`, listID, position); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO reading_list_entries (list_id, book_id, position, added_at) VALUES (?, ?, ?, ?)`,
+		listID,
+		entry.Book.ID,
+		position,
+		entry.AddedAt.Unix(),
+	); err != nil {
+		return err
+	}
+
+	if err := touchReadingList(ctx, tx, listID, entry.AddedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteReadingListRepository) RemoveEntry(ctx context.Context, listID, bookID int64, updatedAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var position int
+	err = tx.QueryRowContext(ctx, `SELECT position FROM reading_list_entries WHERE list_id = ? AND book_id = ?`, listID, bookID).Scan(&position)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReadingListEntryNotFound
+		}
+
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reading_list_entries WHERE list_id = ? AND book_id = ?`, listID, bookID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE reading_list_entries SET position = position - 1 WHERE list_id = ? AND position > ?`, listID, position); err != nil {
+		return err
+	}
+
+	if err := touchReadingList(ctx, tx, listID, updatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteReadingListRepository) ReorderEntries(ctx context.Context, listID int64, bookIDs []int64, updatedAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, bookID := range bookIDs {
+		result, err := tx.ExecContext(ctx, `UPDATE reading_list_entries SET position = ? WHERE list_id = ? AND book_id = ?`, i+1, listID, bookID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrReadingListEntryNotFound
+		}
+	}
+
+	if err := touchReadingList(ctx, tx, listID, updatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReadingList(row rowScanner) (models.ReadingList, error) {
+	var list models.ReadingList
+	var createdAt, updatedAt int64
+	if err := row.Scan(
+		&list.ID,
+		&list.OwnerID,
+		&list.Name,
+		&list.Description,
+		&list.Visibility,
+		&createdAt,
+		&updatedAt,
+		&list.BookCount,
+	); err != nil {
+		return models.ReadingList{}, err
+	}
+
+	list.CreatedAt = time.Unix(createdAt, 0).UTC()
+	list.UpdatedAt = time.Unix(updatedAt, 0).UTC()
+	return list, nil
+}
+
+func touchReadingList(ctx context.Context, tx *sql.Tx, listID int64, updatedAt time.Time) error {
+	result, err := tx.ExecContext(ctx, `UPDATE reading_lists SET updated_at = ? WHERE id = ?`, updatedAt.Unix(), listID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrReadingListNotFound
+	}
+
+	return nil
+}
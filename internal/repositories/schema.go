@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ensureColumn adds a column to a table created by an older release, since
+// CREATE TABLE IF NOT EXISTS leaves existing tables untouched.
+func ensureColumn(ctx context.Context, db *sql.DB, table, column, definition string) error {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return err
+		}
+
+		if name == column {
+			return nil
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
+
+// schemaInitializers create the API's tables in order. Tables that
+// reference books come after it so their triggers can attach to it. tables
+// lists what each one creates, for CheckSchema.
+var schemaInitializers = []struct {
+	name   string
+	init   func(context.Context, *sql.DB) error
+	tables []string
+}{
+	{"books", InitBooksSchema, []string{"books", "works", "series", "publishers", "book_field_provenance"}},
+	{"reading lists", InitReadingListsSchema, []string{"reading_lists", "reading_list_entries"}},
+	{"book covers", InitBookCoversSchema, []string{"book_covers"}},
+	{"metadata", InitMetadataSchema, []string{"metadata_editions", "metadata_works", "metadata_authors"}},
+	{"login attempts", InitLoginAttemptsSchema, []string{"login_attempts"}},
+	{"mfa", InitMFASchema, []string{"mfa_enrollments", "mfa_recovery_codes", "mfa_challenges"}},
+	{"oauth clients", InitOAuthClientsSchema, []string{"oauth_clients"}},
+	{"users", InitUsersSchema, []string{"users"}},
+	{"account tokens", InitAccountTokensSchema, []string{"account_tokens"}},
+	{"sessions", InitSessionsSchema, []string{"sessions"}},
+	{"rate limits", InitRateLimitSchema, []string{"rate_limit_buckets"}},
+}
+
+// InitSchema creates every table the API needs.
+func InitSchema(ctx context.Context, db *sql.DB) error {
+	for _, initializer := range schemaInitializers {
+		if err := initializer.init(ctx, db); err != nil {
+			return fmt.Errorf("init %s schema: %w", initializer.name, err)
+		}
+	}
+
+	return nil
+}
+
+// CheckSchema reports the first table InitSchema would create that is
+// missing from db.
+func CheckSchema(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table'`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+
+		existing[name] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, initializer := range schemaInitializers {
+		for _, table := range initializer.tables {
+			if !existing[table] {
+				return fmt.Errorf("%s schema is not migrated: table %s is missing", initializer.name, table)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"desent-api/internal/models"
+)
+
+var ErrSeriesNotFound = errors.New("series not found")
+
+type SeriesRepository interface {
+	Create(ctx context.Context, series models.Series) (models.Series, error)
+	FindAll(ctx context.Context) ([]models.Series, error)
+	FindByID(ctx context.Context, id int64) (models.Series, error)
+}
+
+type SQLiteSeriesRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteSeriesRepository(db *sql.DB) *SQLiteSeriesRepository {
+	return &SQLiteSeriesRepository{db: db}
+}
+
+func (r *SQLiteSeriesRepository) Create(ctx context.Context, series models.Series) (models.Series, error) {
+	result, err := r.db.ExecContext(ctx, `INSERT INTO series (name, description) VALUES (?, ?)`, series.Name, series.Description)
+	if err != nil {
+		return models.Series{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Series{}, err
+	}
+
+	series.ID = id
+	return series, nil
+}
+
+func (r *SQLiteSeriesRepository) FindAll(ctx context.Context) ([]models.Series, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, description FROM series ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seriesList := make([]models.Series, 0)
+	for rows.Next() {
+		var series models.Series
+		if err := rows.Scan(&series.ID, &series.Name, &series.Description); err != nil {
+			return nil, err
+		}
+
+		seriesList = append(seriesList, series)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return seriesList, nil
+}
+
+func (r *SQLiteSeriesRepository) FindByID(ctx context.Context, id int64) (models.Series, error) {
+	var series models.Series
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, description FROM series WHERE id = ?`, id).Scan(
+		&series.ID,
+		&series.Name,
+		&series.Description,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Series{}, ErrSeriesNotFound
+		}
+
+		return models.Series{}, err
+	}
+
+	return series, nil
+}
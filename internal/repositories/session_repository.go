@@ -0,0 +1,195 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"desent-api/internal/models"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionRepository interface {
+	Create(ctx context.Context, session models.Session) error
+	FindByID(ctx context.Context, id string) (models.Session, error)
+	ListActive(ctx context.Context, subject string, now time.Time) ([]models.Session, error)
+	Touch(ctx context.Context, id, ip string, seenAt time.Time) error
+	Revoke(ctx context.Context, id, subject string, revokedAt time.Time) error
+	RevokeAll(ctx context.Context, subject string, revokedAt time.Time) (int64, error)
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+type SQLiteSessionRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteSessionRepository(db *sql.DB) *SQLiteSessionRepository {
+	return &SQLiteSessionRepository{db: db}
+}
+
+func InitSessionsSchema(ctx context.Context, db *sql.DB) error {
+	query := `
+CREATE TABLE IF NOT EXISTS sessions (
+	id TEXT PRIMARY KEY,
+	subject TEXT NOT NULL,
+	client_id TEXT NOT NULL DEFAULT '',
+	auth_methods TEXT NOT NULL DEFAULT '[]',
+	ip TEXT NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	last_ip TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL,
+	last_seen_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL,
+	revoked_at INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_subject ON sessions(subject, expires_at);`
+
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+const sessionColumns = `id, subject, client_id, auth_methods, ip, user_agent, last_ip, created_at, last_seen_at, expires_at, revoked_at`
+
+func (r *SQLiteSessionRepository) Create(ctx context.Context, session models.Session) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO sessions (`+sessionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0)`,
+		session.ID,
+		session.Subject,
+		session.ClientID,
+		encodeStrings(session.AuthMethods),
+		session.IP,
+		session.UserAgent,
+		session.LastIP,
+		session.CreatedAt.Unix(),
+		session.LastSeenAt.Unix(),
+		session.ExpiresAt.Unix(),
+	)
+	return err
+}
+
+func (r *SQLiteSessionRepository) FindByID(ctx context.Context, id string) (models.Session, error) {
+	session, err := scanSession(r.db.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Session{}, ErrSessionNotFound
+	}
+
+	return session, err
+}
+
+// ListActive returns the subject's unrevoked, unexpired sessions, most
+// recently used first.
+func (r *SQLiteSessionRepository) ListActive(ctx context.Context, subject string, now time.Time) ([]models.Session, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT `+sessionColumns+` FROM sessions
+		WHERE subject = ? AND revoked_at = 0 AND expires_at > ?
+		ORDER BY last_seen_at DESC, created_at DESC, id`,
+		subject,
+		now.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]models.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *SQLiteSessionRepository) Touch(ctx context.Context, id, ip string, seenAt time.Time) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE sessions SET last_ip = ?, last_seen_at = ? WHERE id = ?`, ip, seenAt.Unix(), id)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, ErrSessionNotFound)
+}
+
+// Revoke only matches an active session of subject, so users cannot revoke
+// each other's sessions or learn which IDs exist.
+func (r *SQLiteSessionRepository) Revoke(ctx context.Context, id, subject string, revokedAt time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE sessions SET revoked_at = ? WHERE id = ? AND subject = ? AND revoked_at = 0 AND expires_at > ?`,
+		revokedAt.Unix(),
+		id,
+		subject,
+		revokedAt.Unix(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, ErrSessionNotFound)
+}
+
+// RevokeAll revokes every active session of subject and returns how many
+// there were.
+func (r *SQLiteSessionRepository) RevokeAll(ctx context.Context, subject string, revokedAt time.Time) (int64, error) {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE sessions SET revoked_at = ? WHERE subject = ? AND revoked_at = 0 AND expires_at > ?`,
+		revokedAt.Unix(),
+		subject,
+		revokedAt.Unix(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteExpired drops sessions whose token can no longer be used anyway,
+// revoked or not.
+func (r *SQLiteSessionRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= ?`, now.Unix())
+	return err
+}
+
+func scanSession(row rowScanner) (models.Session, error) {
+	var (
+		session     models.Session
+		authMethods string
+		createdAt   int64
+		lastSeenAt  int64
+		expiresAt   int64
+		revokedAt   int64
+	)
+	if err := row.Scan(
+		&session.ID,
+		&session.Subject,
+		&session.ClientID,
+		&authMethods,
+		&session.IP,
+		&session.UserAgent,
+		&session.LastIP,
+		&createdAt,
+		&lastSeenAt,
+		&expiresAt,
+		&revokedAt,
+	); err != nil {
+		return models.Session{}, err
+	}
+
+	session.AuthMethods = decodeStrings(authMethods)
+	session.CreatedAt = time.Unix(createdAt, 0).UTC()
+	session.LastSeenAt = time.Unix(lastSeenAt, 0).UTC()
+	session.ExpiresAt = time.Unix(expiresAt, 0).UTC()
+	if revokedAt > 0 {
+		session.RevokedAt = time.Unix(revokedAt, 0).UTC()
+	}
+	return session, nil
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"desent-api/internal/models"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+var ErrEmailTaken = errors.New("email belongs to another user")
+
+type UserRepository interface {
+	FindByUsername(ctx context.Context, username string) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	SetEmail(ctx context.Context, username, email string, now time.Time) error
+	SetPassword(ctx context.Context, username, passwordHash string, now time.Time) error
+	MarkEmailVerified(ctx context.Context, username, email string, now time.Time) error
+}
+
+type SQLiteUserRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
+	return &SQLiteUserRepository{db: db}
+}
+
+func InitUsersSchema(ctx context.Context, db *sql.DB) error {
+	query := `
+CREATE TABLE IF NOT EXISTS users (
+	username TEXT PRIMARY KEY,
+	email TEXT,
+	password_hash TEXT NOT NULL DEFAULT '',
+	email_verified_at INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email COLLATE NOCASE);`
+
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+func (r *SQLiteUserRepository) FindByUsername(ctx context.Context, username string) (models.User, error) {
+	return r.findOne(ctx, `WHERE username = ?`, username)
+}
+
+func (r *SQLiteUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.findOne(ctx, `WHERE email = ? COLLATE NOCASE`, email)
+}
+
+// SetEmail stores a new, unverified address, creating the user row for a
+// built-in account on first use.
+func (r *SQLiteUserRepository) SetEmail(ctx context.Context, username, email string, now time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var taken int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE email = ? COLLATE NOCASE AND username <> ?`, email, username).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrEmailTaken
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO users (username, email, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(username) DO UPDATE SET
+			email = excluded.email,
+			email_verified_at = CASE WHEN users.email = excluded.email COLLATE NOCASE THEN users.email_verified_at ELSE 0 END,
+			updated_at = excluded.updated_at`,
+		username,
+		email,
+		now.Unix(),
+		now.Unix(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteUserRepository) SetPassword(ctx context.Context, username, passwordHash string, now time.Time) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO users (username, password_hash, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(username) DO UPDATE SET password_hash = excluded.password_hash, updated_at = excluded.updated_at`,
+		username,
+		passwordHash,
+		now.Unix(),
+		now.Unix(),
+	)
+	return err
+}
+
+// MarkEmailVerified only verifies email if it is still the user's address,
+// so a token for an address the user has since replaced does nothing.
+func (r *SQLiteUserRepository) MarkEmailVerified(ctx context.Context, username, email string, now time.Time) error {
+	result, err := r.db.ExecContext(
+		ctx,
+		`UPDATE users SET email_verified_at = ?, updated_at = ? WHERE username = ? AND email = ? COLLATE NOCASE`,
+		now.Unix(),
+		now.Unix(),
+		username,
+		email,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(result, ErrUserNotFound)
+}
+
+func (r *SQLiteUserRepository) findOne(ctx context.Context, where string, arg any) (models.User, error) {
+	var (
+		user            models.User
+		email           sql.NullString
+		emailVerifiedAt int64
+		createdAt       int64
+		updatedAt       int64
+	)
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT username, email, password_hash, email_verified_at, created_at, updated_at FROM users `+where,
+		arg,
+	).Scan(&user.Username, &email, &user.PasswordHash, &emailVerifiedAt, &createdAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, ErrUserNotFound
+		}
+
+		return models.User{}, err
+	}
+
+	user.Email = email.String
+	if emailVerifiedAt > 0 {
+		user.EmailVerifiedAt = time.Unix(emailVerifiedAt, 0).UTC()
+	}
+	user.CreatedAt = time.Unix(createdAt, 0).UTC()
+	user.UpdatedAt = time.Unix(updatedAt, 0).UTC()
+	return user, nil
+}
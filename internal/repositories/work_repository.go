@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"desent-api/internal/models"
+)
+
+var ErrWorkNotFound = errors.New("work not found")
+
+type WorkRepository interface {
+	Create(ctx context.Context, work models.Work) (models.Work, error)
+	FindAll(ctx context.Context) ([]models.Work, error)
+	FindByID(ctx context.Context, id int64) (models.Work, error)
+}
+
+type SQLiteWorkRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteWorkRepository(db *sql.DB) *SQLiteWorkRepository {
+	return &SQLiteWorkRepository{db: db}
+}
+
+func (r *SQLiteWorkRepository) Create(ctx context.Context, work models.Work) (models.Work, error) {
+	result, err := r.db.ExecContext(ctx, `INSERT INTO works (title, author, original_year) VALUES (?, ?, ?)`, work.Title, work.Author, work.OriginalYear)
+	if err != nil {
+		return models.Work{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Work{}, err
+	}
+
+	work.ID = id
+	return work, nil
+}
+
+func (r *SQLiteWorkRepository) FindAll(ctx context.Context) ([]models.Work, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, title, author, original_year FROM works ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	works := make([]models.Work, 0)
+	for rows.Next() {
+		var work models.Work
+		if err := rows.Scan(&work.ID, &work.Title, &work.Author, &work.OriginalYear); err != nil {
+			return nil, err
+		}
+
+		works = append(works, work)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return works, nil
+}
+
+func (r *SQLiteWorkRepository) FindByID(ctx context.Context, id int64) (models.Work, error) {
+	var work models.Work
+	err := r.db.QueryRowContext(ctx, `SELECT id, title, author, original_year FROM works WHERE id = ?`, id).Scan(
+		&work.ID,
+		&work.Title,
+		&work.Author,
+		&work.OriginalYear,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Work{}, ErrWorkNotFound
+		}
+
+		return models.Work{}, err
+	}
+
+	return work, nil
+}
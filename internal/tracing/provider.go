@@ -0,0 +1,167 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultQueueSize     = 2048
+	defaultBatchSize     = 256
+	defaultFlushInterval = 5 * time.Second
+)
+
+// Exporter sends finished spans to a backend.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// Provider samples new traces and batches finished spans for its exporter.
+// Spans are queued without blocking; when the queue is full they are
+// dropped and counted.
+type Provider struct {
+	exporter  Exporter
+	threshold uint64
+	nowFunc   func() time.Time
+	onError   func(error)
+
+	queue   chan SpanData
+	full    chan struct{}
+	flushMu sync.Mutex
+	dropped atomic.Uint64
+}
+
+var global atomic.Pointer[Provider]
+
+// SetProvider installs p for Start. A nil p disables tracing.
+func SetProvider(p *Provider) {
+	global.Store(p)
+}
+
+func currentProvider() *Provider {
+	return global.Load()
+}
+
+// NewProvider samples sampleRatio of new traces, between 0 and 1. Spans
+// whose parent came from another process follow the parent's decision.
+// onError is called when an export fails and may be nil.
+func NewProvider(exporter Exporter, sampleRatio float64, onError func(error), nowFunc func() time.Time) *Provider {
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	var threshold uint64
+	switch {
+	case sampleRatio >= 1:
+		threshold = math.MaxUint64
+	case sampleRatio > 0:
+		threshold = uint64(sampleRatio * math.MaxUint64)
+	}
+
+	return &Provider{
+		exporter:  exporter,
+		threshold: threshold,
+		nowFunc:   nowFunc,
+		onError:   onError,
+		queue:     make(chan SpanData, defaultQueueSize),
+		full:      make(chan struct{}, 1),
+	}
+}
+
+func (p *Provider) now() time.Time {
+	return p.nowFunc()
+}
+
+// sample decides on the random half of the trace ID so every process that
+// sees the trace reaches the same decision for the same ratio.
+func (p *Provider) sample(traceID TraceID) bool {
+	if p.threshold == math.MaxUint64 {
+		return true
+	}
+
+	return binary.BigEndian.Uint64(traceID[8:]) < p.threshold
+}
+
+func (p *Provider) enqueue(span SpanData) {
+	select {
+	case p.queue <- span:
+	default:
+		p.dropped.Add(1)
+		return
+	}
+
+	if len(p.queue) >= defaultBatchSize {
+		select {
+		case p.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Dropped reports how many spans were discarded because the queue was full.
+func (p *Provider) Dropped() uint64 {
+	return p.dropped.Load()
+}
+
+// Run exports batches every few seconds, or as soon as a batch fills up,
+// until ctx is cancelled. It flushes the queue before returning.
+func (p *Provider) Run(ctx context.Context) {
+	ticker := time.NewTicker(defaultFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), defaultFlushInterval)
+			defer cancel()
+			p.Flush(flushCtx)
+			return
+		case <-ticker.C:
+			p.Flush(ctx)
+		case <-p.full:
+			p.Flush(ctx)
+		}
+	}
+}
+
+// Flush exports every queued span.
+func (p *Provider) Flush(ctx context.Context) {
+	for {
+		batch := p.drain()
+		if len(batch) == 0 {
+			return
+		}
+
+		p.export(ctx, batch)
+	}
+}
+
+func (p *Provider) drain() []SpanData {
+	var batch []SpanData
+	for len(batch) < defaultBatchSize {
+		select {
+		case span := <-p.queue:
+			batch = append(batch, span)
+		default:
+			return batch
+		}
+	}
+
+	return batch
+}
+
+func (p *Provider) export(ctx context.Context, batch []SpanData) {
+	p.flushMu.Lock()
+	defer p.flushMu.Unlock()
+
+	if err := p.exporter.Export(ctx, batch); err != nil {
+		p.onError(err)
+	}
+}
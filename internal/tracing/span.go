@@ -0,0 +1,292 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// SpanContext is the part of a span that crosses process boundaries in the
+// traceparent header.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	Remote  bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = iota + 1
+	SpanKindServer
+	SpanKindClient
+)
+
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+// Attribute is a span attribute. Value holds a string, int64, bool or
+// float64.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+func Int64(key string, value int64) Attribute { return Attribute{Key: key, Value: value} }
+
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+func Float64(key string, value float64) Attribute { return Attribute{Key: key, Value: value} }
+
+// Event is a timestamped annotation on a span, such as a recorded error.
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// SpanData is a finished span as handed to exporters.
+type SpanData struct {
+	Name          string
+	SpanContext   SpanContext
+	Parent        SpanContext
+	Kind          SpanKind
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Events        []Event
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Span is an operation being timed. All methods are safe on a nil Span,
+// which is what Start returns while tracing is disabled.
+type Span struct {
+	provider *Provider
+	parent   *Span
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+type SpanOption func(*SpanData)
+
+func WithKind(kind SpanKind) SpanOption {
+	return func(data *SpanData) { data.Kind = kind }
+}
+
+func WithAttributes(attrs ...Attribute) SpanOption {
+	return func(data *SpanData) { data.Attributes = append(data.Attributes, attrs...) }
+}
+
+// Start begins a span as a child of the span in ctx and returns a context
+// carrying it. Without a provider it returns ctx unchanged and a nil Span.
+func Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	provider := currentProvider()
+	if provider == nil {
+		return ctx, nil
+	}
+
+	parent := SpanFromContext(ctx)
+	span := &Span{
+		provider: provider,
+		parent:   parent,
+		data: SpanData{
+			Name:  name,
+			Kind:  SpanKindInternal,
+			Start: provider.now(),
+		},
+	}
+	for _, opt := range opts {
+		opt(&span.data)
+	}
+
+	if parent != nil && parent.SpanContext().IsValid() {
+		span.data.Parent = parent.SpanContext()
+		span.data.SpanContext.TraceID = span.data.Parent.TraceID
+		span.data.SpanContext.Sampled = span.data.Parent.Sampled
+	} else {
+		span.data.SpanContext.TraceID = newTraceID()
+		span.data.SpanContext.Sampled = provider.sample(span.data.SpanContext.TraceID)
+	}
+	span.data.SpanContext.SpanID = newSpanID()
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// SpanContext returns the identifiers of s.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data.SpanContext
+}
+
+// Parent returns the span s was started under, if it is known locally.
+func (s *Span) Parent() *Span {
+	if s == nil {
+		return nil
+	}
+
+	return s.parent
+}
+
+func (s *Span) IsRecording() bool {
+	if s == nil || s.provider == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data.SpanContext.Sampled && !s.ended
+}
+
+func (s *Span) SetName(name string) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Name = name
+}
+
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// RecordError adds an exception event and marks the span as failed.
+func (s *Span) RecordError(err error) {
+	if err == nil || !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Events = append(s.data.Events, Event{
+		Name:       "exception",
+		Time:       s.provider.now(),
+		Attributes: []Attribute{String("exception.message", err.Error())},
+	})
+	s.data.Status = StatusError
+	s.data.StatusMessage = err.Error()
+}
+
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Status = code
+	s.data.StatusMessage = message
+}
+
+// End finishes s and queues it for export if it was sampled. Only the
+// first call has an effect.
+func (s *Span) End() {
+	if s == nil || s.provider == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = s.provider.now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.SpanContext.Sampled {
+		s.provider.enqueue(data)
+	}
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan returns ctx with span as the current span. A nil span
+// clears it.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext makes sc, received from another process, the
+// parent of spans started from the returned context.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+
+	sc.Remote = true
+	return ContextWithSpan(ctx, &Span{data: SpanData{SpanContext: sc}, ended: true})
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+
+	return id
+}
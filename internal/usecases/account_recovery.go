@@ -0,0 +1,398 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"desent-api/internal/mail"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/utils"
+)
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 128
+)
+
+// AccountRecoveryPolicy configures mailed tokens. BaseURL, when set, is
+// used to build links; otherwise mails carry the bare token.
+type AccountRecoveryPolicy struct {
+	BaseURL         string
+	ResetTTL        time.Duration
+	VerificationTTL time.Duration
+}
+
+// AccountRecovery runs the password reset and email verification flows.
+// Tokens are single use, expire, and are only stored as hashes.
+type AccountRecovery struct {
+	users    repositories.UserRepository
+	tokens   repositories.AccountTokenRepository
+	creds    *Credentials
+	mailer   mail.Mailer
+	policy   AccountRecoveryPolicy
+	throttle *LoginThrottle
+	mfa      *MFA
+	sessions *Sessions
+	events   *slog.Logger
+	nowFunc  func() time.Time
+}
+
+// NewAccountRecovery checks the current password against creds before an
+// email change.
+func NewAccountRecovery(
+	users repositories.UserRepository,
+	tokens repositories.AccountTokenRepository,
+	creds *Credentials,
+	mailer mail.Mailer,
+	policy AccountRecoveryPolicy,
+	events *slog.Logger,
+	nowFunc func() time.Time,
+) *AccountRecovery {
+	if events == nil {
+		events = slog.Default()
+	}
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	return &AccountRecovery{users: users, tokens: tokens, creds: creds, mailer: mailer, policy: policy, events: events, nowFunc: nowFunc}
+}
+
+// WithLoginThrottle clears a username's lockout once its password is reset
+// and counts wrong passwords given for an email change as failed logins.
+func (a *AccountRecovery) WithLoginThrottle(throttle *LoginThrottle) *AccountRecovery {
+	a.throttle = throttle
+	return a
+}
+
+// WithMFA requires a second factor for email changes by enrolled users.
+func (a *AccountRecovery) WithMFA(mfa *MFA) *AccountRecovery {
+	a.mfa = mfa
+	return a
+}
+
+// WithSessions revokes every session of a user whose password is reset.
+func (a *AccountRecovery) WithSessions(sessions *Sessions) *AccountRecovery {
+	a.sessions = sessions
+	return a
+}
+
+func (a *AccountRecovery) Account(ctx context.Context, username string) (models.User, error) {
+	user, err := a.users.FindByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return models.User{Username: username}, nil
+		}
+
+		return models.User{}, fmt.Errorf("find user: %w", err)
+	}
+
+	return user, nil
+}
+
+// ForgotPassword mails a reset token to a verified address. Unknown and
+// unverified addresses succeed silently so the endpoint does not reveal
+// which addresses have accounts.
+func (a *AccountRecovery) ForgotPassword(ctx context.Context, email string) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.users.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			a.event(ctx, slog.LevelInfo, "password_reset_unknown_email")
+			return nil
+		}
+
+		return fmt.Errorf("find user: %w", err)
+	}
+	if !user.EmailVerified() {
+		a.event(ctx, slog.LevelInfo, "password_reset_unverified_email", slog.String("username", user.Username))
+		return nil
+	}
+
+	token, err := a.issueToken(ctx, user.Username, user.Email, models.AccountTokenPasswordReset, a.policy.ResetTTL)
+	if err != nil {
+		return err
+	}
+
+	err = a.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Text: fmt.Sprintf(
+			"Someone asked to reset the password for %s.\n\n%s\n\nThe token expires in %s and works once. If you did not ask for this, ignore this message.\n",
+			user.Username,
+			a.instructions("reset-password", "POST /auth/password/reset", token),
+			a.policy.ResetTTL,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("send password reset mail: %w", err)
+	}
+
+	a.event(ctx, slog.LevelInfo, "password_reset_requested", slog.String("username", user.Username))
+	return nil
+}
+
+func (a *AccountRecovery) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	issued, err := a.consumeToken(ctx, token, models.AccountTokenPasswordReset)
+	if err != nil {
+		return err
+	}
+
+	// A token mailed to an address the user has since replaced is void.
+	user, err := a.users.FindByUsername(ctx, issued.Username)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return ErrInvalidAccountToken
+		}
+
+		return fmt.Errorf("find user: %w", err)
+	}
+	if !strings.EqualFold(user.Email, issued.Email) {
+		return ErrInvalidAccountToken
+	}
+
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	if err := a.users.SetPassword(ctx, user.Username, hash, a.nowFunc()); err != nil {
+		return fmt.Errorf("save password: %w", err)
+	}
+
+	if a.throttle != nil {
+		if err := a.throttle.RecordSuccess(ctx, user.Username); err != nil {
+			return err
+		}
+	}
+
+	// Whoever knew the old password may still hold a token.
+	if a.sessions != nil {
+		if err := a.sessions.RevokeAll(ctx, user.Username); err != nil {
+			return err
+		}
+	}
+
+	a.event(ctx, slog.LevelInfo, "password_reset", slog.String("username", user.Username))
+	return nil
+}
+
+// ChangeEmail stores a new unverified address and mails it a verification
+// token after checking the current password, and a second factor when one
+// is enrolled. A previously verified address is told about the change.
+// Password resets only go to verified addresses.
+func (a *AccountRecovery) ChangeEmail(ctx context.Context, username, ip string, req models.UpdateEmailRequest) error {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return err
+	}
+
+	previous, err := a.users.FindByUsername(ctx, username)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrUserNotFound) {
+			return fmt.Errorf("find user: %w", err)
+		}
+		// External and client subjects have no row and must not get one.
+		if username != builtInUsername {
+			return ErrNoLocalAccount
+		}
+	}
+
+	if err := a.reauthenticate(ctx, username, ip, req.CurrentPassword, req.Code); err != nil {
+		return err
+	}
+
+	if err := a.users.SetEmail(ctx, username, email, a.nowFunc()); err != nil {
+		if errors.Is(err, repositories.ErrEmailTaken) {
+			return ErrEmailTaken
+		}
+
+		return fmt.Errorf("save email: %w", err)
+	}
+
+	user, err := a.users.FindByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("find user: %w", err)
+	}
+	if user.EmailVerified() {
+		return nil
+	}
+
+	a.event(ctx, slog.LevelInfo, "email_changed", slog.String("username", username))
+	if previous.EmailVerified() {
+		err = a.mailer.Send(ctx, mail.Message{
+			To:      previous.Email,
+			Subject: "Your email address was changed",
+			Text: fmt.Sprintf(
+				"The address for %s was changed from %s to %s. If you did not do this, reset your password and contact an administrator.\n",
+				username,
+				previous.Email,
+				email,
+			),
+		})
+		if err != nil {
+			return fmt.Errorf("send email change notice: %w", err)
+		}
+	}
+
+	token, err := a.issueToken(ctx, username, email, models.AccountTokenEmailVerification, a.policy.VerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	err = a.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Text: fmt.Sprintf(
+			"Confirm that %s is the address for %s.\n\n%s\n\nThe token expires in %s.\n",
+			email,
+			username,
+			a.instructions("verify-email", "POST /auth/email/verify", token),
+			a.policy.VerificationTTL,
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("send verification mail: %w", err)
+	}
+
+	return nil
+}
+
+// reauthenticate checks the current password, counting a wrong one as a
+// failed login, and then the second factor.
+func (a *AccountRecovery) reauthenticate(ctx context.Context, username, ip, password, code string) error {
+	if a.throttle != nil {
+		if err := a.throttle.Check(ctx, username, ip); err != nil {
+			return err
+		}
+	}
+
+	valid, err := a.creds.Check(ctx, username, password)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		if a.throttle != nil {
+			if err := a.throttle.RecordFailure(ctx, username, ip); err != nil {
+				return err
+			}
+		}
+
+		return ErrInvalidCredentials
+	}
+
+	if a.mfa != nil {
+		return a.mfa.Reauthenticate(ctx, username, code)
+	}
+
+	return nil
+}
+
+func (a *AccountRecovery) VerifyEmail(ctx context.Context, token string) error {
+	issued, err := a.consumeToken(ctx, token, models.AccountTokenEmailVerification)
+	if err != nil {
+		return err
+	}
+
+	if err := a.users.MarkEmailVerified(ctx, issued.Username, issued.Email, a.nowFunc()); err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return ErrInvalidAccountToken
+		}
+
+		return fmt.Errorf("verify email: %w", err)
+	}
+
+	a.event(ctx, slog.LevelInfo, "email_verified", slog.String("username", issued.Username))
+	return nil
+}
+
+func (a *AccountRecovery) issueToken(ctx context.Context, username, email, purpose string, ttl time.Duration) (string, error) {
+	now := a.nowFunc()
+	if err := a.tokens.DeleteExpired(ctx, now); err != nil {
+		return "", fmt.Errorf("delete expired tokens: %w", err)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+
+	err = a.tokens.Replace(ctx, models.AccountToken{
+		TokenHash: hashToken(token),
+		Username:  username,
+		Purpose:   purpose,
+		Email:     email,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", fmt.Errorf("save token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (a *AccountRecovery) consumeToken(ctx context.Context, token, purpose string) (models.AccountToken, error) {
+	if strings.TrimSpace(token) == "" {
+		return models.AccountToken{}, fmt.Errorf("%w: token is required", ErrValidation)
+	}
+
+	issued, err := a.tokens.Consume(ctx, hashToken(strings.TrimSpace(token)), purpose, a.nowFunc())
+	if err != nil {
+		if errors.Is(err, repositories.ErrAccountTokenNotFound) {
+			return models.AccountToken{}, ErrInvalidAccountToken
+		}
+
+		return models.AccountToken{}, fmt.Errorf("consume token: %w", err)
+	}
+
+	return issued, nil
+}
+
+func (a *AccountRecovery) instructions(path, endpoint, token string) string {
+	if a.policy.BaseURL == "" {
+		return fmt.Sprintf("Send this token to %s:\n\n%s", endpoint, token)
+	}
+
+	link := strings.TrimRight(a.policy.BaseURL, "/") + "/" + path + "?token=" + url.QueryEscape(token)
+	return fmt.Sprintf("Open this link:\n\n%s", link)
+}
+
+func (a *AccountRecovery) event(ctx context.Context, level slog.Level, name string, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{slog.String("event", "auth."+name)}, attrs...)
+	a.events.LogAttrs(ctx, level, "security event", attrs...)
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := netmail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > 254 {
+		return "", fmt.Errorf("%w: email must be a valid address", ErrValidation)
+	}
+
+	return email, nil
+}
+
+func validatePassword(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < minPasswordLength || length > maxPasswordLength {
+		return fmt.Errorf("%w: password must be %d to %d characters", ErrValidation, minPasswordLength, maxPasswordLength)
+	}
+
+	return nil
+}
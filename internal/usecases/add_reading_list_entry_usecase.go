@@ -0,0 +1,62 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type AddReadingListEntryUsecase struct {
+	repo repositories.ReadingListRepository
+}
+
+func NewAddReadingListEntryUsecase(repo repositories.ReadingListRepository) *AddReadingListEntryUsecase {
+	return &AddReadingListEntryUsecase{repo: repo}
+}
+
+func (u *AddReadingListEntryUsecase) Execute(ctx context.Context, ownerID, rawID string, req models.AddReadingListEntryRequest) (models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "AddReadingListEntryUsecase.Execute")
+	defer span.End()
+
+	id, err := parseReadingListID(rawID)
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+
+	if req.BookID <= 0 {
+		return models.ReadingList{}, fmt.Errorf("%w: book_id is required", ErrValidation)
+	}
+
+	if req.Position < 0 {
+		return models.ReadingList{}, fmt.Errorf("%w: position must be a positive integer", ErrValidation)
+	}
+
+	if _, err := findOwnedReadingList(ctx, u.repo, ownerID, id); err != nil {
+		return models.ReadingList{}, err
+	}
+
+	entry := models.ReadingListEntry{
+		Position: req.Position,
+		AddedAt:  time.Now().UTC(),
+		Book:     models.Book{ID: req.BookID},
+	}
+	if err := u.repo.AddEntry(ctx, id, entry); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrBookNotFound):
+			return models.ReadingList{}, ErrBookNotFound
+		case errors.Is(err, repositories.ErrReadingListEntryExists):
+			return models.ReadingList{}, ErrReadingListEntryExists
+		case errors.Is(err, repositories.ErrReadingListNotFound):
+			return models.ReadingList{}, ErrReadingListNotFound
+		default:
+			return models.ReadingList{}, fmt.Errorf("add reading list entry: %w", err)
+		}
+	}
+
+	return findOwnedReadingList(ctx, u.repo, ownerID, id)
+}
@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/utils"
+)
+
+// BookEnricher fills empty book fields from the offline metadata table and
+// records where each filled value came from.
+type BookEnricher struct {
+	metadataRepo   repositories.MetadataRepository
+	provenanceRepo repositories.BookProvenanceRepository
+}
+
+func NewBookEnricher(metadataRepo repositories.MetadataRepository, provenanceRepo repositories.BookProvenanceRepository) *BookEnricher {
+	return &BookEnricher{metadataRepo: metadataRepo, provenanceRepo: provenanceRepo}
+}
+
+func (e *BookEnricher) lookup(ctx context.Context, rawISBN string) (models.MetadataRecord, error) {
+	isbn, ok := utils.NormalizeISBN(rawISBN)
+	if !ok {
+		return models.MetadataRecord{}, fmt.Errorf("%w: isbn must be a valid ISBN-10 or ISBN-13", ErrValidation)
+	}
+
+	record, err := e.metadataRepo.FindByISBN(ctx, isbn)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMetadataNotFound) {
+			return models.MetadataRecord{}, ErrMetadataNotFound
+		}
+
+		return models.MetadataRecord{}, fmt.Errorf("lookup metadata: %w", err)
+	}
+
+	return record, nil
+}
+
+func (e *BookEnricher) recordProvenance(ctx context.Context, bookID int64, fields []string, record models.MetadataRecord) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	provenance := make([]models.BookFieldProvenance, 0, len(fields))
+	for _, field := range fields {
+		provenance = append(provenance, models.BookFieldProvenance{
+			BookID:     bookID,
+			Field:      field,
+			Source:     record.Source,
+			SourceRef:  record.SourceRef,
+			RecordedAt: now,
+		})
+	}
+
+	if err := e.provenanceRepo.Save(ctx, provenance); err != nil {
+		return fmt.Errorf("save provenance: %w", err)
+	}
+
+	return nil
+}
+
+// fillMissingBookFields only touches fields the caller left empty and
+// returns the names of the fields it filled.
+func fillMissingBookFields(req *models.CreateBookRequest, record models.MetadataRecord) []string {
+	var filled []string
+
+	if strings.TrimSpace(req.Title) == "" && record.Title != "" {
+		req.Title = record.Title
+		filled = append(filled, models.BookFieldTitle)
+	}
+
+	if strings.TrimSpace(req.Author) == "" && len(record.Authors) > 0 {
+		req.Author = strings.Join(record.Authors, ", ")
+		filled = append(filled, models.BookFieldAuthor)
+	}
+
+	if req.Year == 0 && record.Year >= 1450 && record.Year <= 2100 {
+		req.Year = record.Year
+		filled = append(filled, models.BookFieldYear)
+	}
+
+	if len(req.Subjects) == 0 && len(record.Subjects) > 0 {
+		req.Subjects = record.Subjects
+		if len(req.Subjects) > maxBookSubjects {
+			req.Subjects = req.Subjects[:maxBookSubjects]
+		}
+		filled = append(filled, models.BookFieldSubjects)
+	}
+
+	return filled
+}
@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var leadingArticles = []string{"the ", "a ", "an "}
+
+func normalizeTitle(title string) string {
+	normalized := normalizeText(title)
+	for _, article := range leadingArticles {
+		if strings.HasPrefix(normalized, article) {
+			return strings.TrimPrefix(normalized, article)
+		}
+	}
+
+	return normalized
+}
+
+// normalizeAuthor sorts name tokens so "Herbert, Frank" matches "Frank Herbert".
+func normalizeAuthor(author string) string {
+	tokens := strings.Fields(normalizeText(author))
+	sort.Strings(tokens)
+	return strings.Join(tokens, " ")
+}
+
+func normalizeText(value string) string {
+	builder := strings.Builder{}
+	for _, r := range strings.ToLower(value) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+			continue
+		}
+		builder.WriteRune(' ')
+	}
+
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -1,11 +1,19 @@
 package usecases
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/utils"
+)
+
+const (
+	maxBookSubjects      = 50
+	maxBookSubjectLength = 200
 )
 
 func parseBookID(rawID string) (int64, error) {
@@ -33,9 +41,110 @@ func validateCreateBookRequest(req models.CreateBookRequest) (models.Book, error
 		return models.Book{}, fmt.Errorf("%w: year must be between 1450 and 2100", ErrValidation)
 	}
 
-	return models.Book{
-		Title:  title,
-		Author: author,
-		Year:   req.Year,
-	}, nil
+	isbn := ""
+	if strings.TrimSpace(req.ISBN) != "" {
+		normalized, ok := utils.NormalizeISBN(req.ISBN)
+		if !ok {
+			return models.Book{}, fmt.Errorf("%w: isbn must be a valid ISBN-10 or ISBN-13", ErrValidation)
+		}
+		isbn = normalized
+	}
+
+	subjects, err := normalizeSubjects(req.Subjects)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	if req.WorkID < 0 || req.SeriesID < 0 || req.PublisherID < 0 {
+		return models.Book{}, fmt.Errorf("%w: work_id, series_id and publisher_id must be positive integers", ErrValidation)
+	}
+
+	if req.SeriesPosition < 0 {
+		return models.Book{}, fmt.Errorf("%w: series_position must be a positive integer", ErrValidation)
+	}
+
+	if req.SeriesPosition > 0 && req.SeriesID == 0 {
+		return models.Book{}, fmt.Errorf("%w: series_position requires series_id", ErrValidation)
+	}
+
+	book := models.Book{
+		Title:          title,
+		Author:         author,
+		Year:           req.Year,
+		ISBN:           isbn,
+		Subjects:       subjects,
+		SeriesPosition: req.SeriesPosition,
+	}
+	if req.WorkID > 0 {
+		book.Work = &models.Work{ID: req.WorkID}
+	}
+	if req.SeriesID > 0 {
+		book.Series = &models.Series{ID: req.SeriesID}
+	}
+	if req.PublisherID > 0 {
+		book.Publisher = &models.Publisher{ID: req.PublisherID}
+	}
+
+	return book, nil
+}
+
+func normalizeSubjects(raw []string) ([]string, error) {
+	subjects := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, subject := range raw {
+		subject = strings.TrimSpace(subject)
+		if subject == "" || seen[strings.ToLower(subject)] {
+			continue
+		}
+
+		if len(subject) > maxBookSubjectLength {
+			return nil, fmt.Errorf("%w: subjects must be at most %d characters", ErrValidation, maxBookSubjectLength)
+		}
+
+		seen[strings.ToLower(subject)] = true
+		subjects = append(subjects, subject)
+	}
+
+	if len(subjects) > maxBookSubjects {
+		return nil, fmt.Errorf("%w: at most %d subjects are allowed", ErrValidation, maxBookSubjects)
+	}
+
+	return subjects, nil
+}
+
+// bookToRequest rebuilds the request a stored book would have been created
+// from, so enrichment can reuse the regular validation and update path.
+func bookToRequest(book models.Book) models.CreateBookRequest {
+	req := models.CreateBookRequest{
+		Title:          book.Title,
+		Author:         book.Author,
+		Year:           book.Year,
+		ISBN:           book.ISBN,
+		Subjects:       book.Subjects,
+		SeriesPosition: book.SeriesPosition,
+	}
+	if book.Work != nil {
+		req.WorkID = book.Work.ID
+	}
+	if book.Series != nil {
+		req.SeriesID = book.Series.ID
+	}
+	if book.Publisher != nil {
+		req.PublisherID = book.Publisher.ID
+	}
+
+	return req
+}
+
+func mapBookReferenceError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrWorkNotFound):
+		return fmt.Errorf("%w: work_id does not exist", ErrValidation)
+	case errors.Is(err, repositories.ErrSeriesNotFound):
+		return fmt.Errorf("%w: series_id does not exist", ErrValidation)
+	case errors.Is(err, repositories.ErrPublisherNotFound):
+		return fmt.Errorf("%w: publisher_id does not exist", ErrValidation)
+	default:
+		return nil
+	}
 }
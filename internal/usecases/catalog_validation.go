@@ -0,0 +1,65 @@
+package usecases
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"desent-api/internal/models"
+)
+
+func parseCatalogID(rawID string, notFound error) (int64, error) {
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, notFound
+	}
+
+	return id, nil
+}
+
+func validateCreateWorkRequest(req models.CreateWorkRequest) (models.Work, error) {
+	title := strings.TrimSpace(req.Title)
+	author := strings.TrimSpace(req.Author)
+
+	if title == "" {
+		return models.Work{}, fmt.Errorf("%w: title is required", ErrValidation)
+	}
+
+	if author == "" {
+		return models.Work{}, fmt.Errorf("%w: author is required", ErrValidation)
+	}
+
+	if req.OriginalYear != 0 && (req.OriginalYear < -3000 || req.OriginalYear > 2100) {
+		return models.Work{}, fmt.Errorf("%w: original_year must be between -3000 and 2100", ErrValidation)
+	}
+
+	return models.Work{
+		Title:        title,
+		Author:       author,
+		OriginalYear: req.OriginalYear,
+	}, nil
+}
+
+func validateCreateSeriesRequest(req models.CreateSeriesRequest) (models.Series, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return models.Series{}, fmt.Errorf("%w: name is required", ErrValidation)
+	}
+
+	return models.Series{
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+	}, nil
+}
+
+func validateCreatePublisherRequest(req models.CreatePublisherRequest) (models.Publisher, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return models.Publisher{}, fmt.Errorf("%w: name is required", ErrValidation)
+	}
+
+	return models.Publisher{
+		Name:     name,
+		Location: strings.TrimSpace(req.Location),
+	}, nil
+}
@@ -0,0 +1,99 @@
+package usecases
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"desent-api/internal/models"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	maxCoverDimension = 8000
+	// maxCoverPixels bounds the decoded image to about 64 MiB of RGBA.
+	maxCoverPixels = 16_000_000
+)
+
+var coverDecoders = map[string]func([]byte) (image.Image, error){
+	"image/jpeg": func(data []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(data)) },
+	"image/png":  func(data []byte) (image.Image, error) { return png.Decode(bytes.NewReader(data)) },
+	"image/gif":  func(data []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(data)) },
+}
+
+// decodeCover sniffs the content type instead of trusting the request header
+// and checks dimensions and the pixel count before decoding so huge images
+// are rejected cheaply.
+func decodeCover(data []byte) (image.Image, string, error) {
+	contentType := http.DetectContentType(data)
+	decode, ok := coverDecoders[contentType]
+	if !ok {
+		return nil, "", ErrUnsupportedCoverType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: cover image is corrupt", ErrValidation)
+	}
+
+	if config.Width > maxCoverDimension || config.Height > maxCoverDimension {
+		return nil, "", fmt.Errorf("%w: cover must be at most %dx%d pixels", ErrValidation, maxCoverDimension, maxCoverDimension)
+	}
+	if config.Width*config.Height > maxCoverPixels {
+		return nil, "", fmt.Errorf("%w: cover must be at most %d megapixels", ErrValidation, maxCoverPixels/1_000_000)
+	}
+
+	img, err := decode(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: cover image is corrupt", ErrValidation)
+	}
+
+	return img, contentType, nil
+}
+
+// thumbnail scales img to fit inside a box x box square, never upscaling,
+// and encodes it as JPEG.
+func thumbnail(img image.Image, box int) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > box || height > box {
+		if width >= height {
+			height = max(1, height*box/width)
+			width = box
+		} else {
+			width = max(1, width*box/height)
+			height = box
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func coverChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func coverBlobKey(checksum, size string) string {
+	return "covers/" + checksum + "/" + size
+}
+
+func coverETag(checksum, size string) string {
+	return `"` + models.CoverVersion(checksum) + "-" + size + `"`
+}
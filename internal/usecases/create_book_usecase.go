@@ -2,21 +2,50 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"desent-api/internal/models"
 	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
 )
 
 type CreateBookUsecase struct {
-	repo repositories.BookRepository
+	repo     repositories.BookRepository
+	enricher *BookEnricher
 }
 
 func NewCreateBookUsecase(repo repositories.BookRepository) *CreateBookUsecase {
 	return &CreateBookUsecase{repo: repo}
 }
 
+// WithEnricher turns on filling missing fields from offline metadata when
+// a book is created with an ISBN.
+func (u *CreateBookUsecase) WithEnricher(enricher *BookEnricher) *CreateBookUsecase {
+	u.enricher = enricher
+	return u
+}
+
 func (u *CreateBookUsecase) Execute(ctx context.Context, req models.CreateBookRequest) (models.Book, error) {
+	ctx, span := tracing.Start(ctx, "CreateBookUsecase.Execute")
+	defer span.End()
+
+	var (
+		record models.MetadataRecord
+		filled []string
+	)
+	if u.enricher != nil && strings.TrimSpace(req.ISBN) != "" {
+		found, err := u.enricher.lookup(ctx, req.ISBN)
+		switch {
+		case err == nil:
+			record = found
+			filled = fillMissingBookFields(&req, record)
+		case !errors.Is(err, ErrMetadataNotFound) && !errors.Is(err, ErrValidation):
+			return models.Book{}, err
+		}
+	}
+
 	book, err := validateCreateBookRequest(req)
 	if err != nil {
 		return models.Book{}, err
@@ -24,8 +53,18 @@ func (u *CreateBookUsecase) Execute(ctx context.Context, req models.CreateBookRe
 
 	created, err := u.repo.Create(ctx, book)
 	if err != nil {
+		if referenceErr := mapBookReferenceError(err); referenceErr != nil {
+			return models.Book{}, referenceErr
+		}
+
 		return models.Book{}, fmt.Errorf("create book: %w", err)
 	}
 
+	if len(filled) > 0 {
+		if err := u.enricher.recordProvenance(ctx, created.ID, filled, record); err != nil {
+			return models.Book{}, err
+		}
+	}
+
 	return created, nil
 }
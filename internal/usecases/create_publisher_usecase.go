@@ -0,0 +1,35 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type CreatePublisherUsecase struct {
+	repo repositories.PublisherRepository
+}
+
+func NewCreatePublisherUsecase(repo repositories.PublisherRepository) *CreatePublisherUsecase {
+	return &CreatePublisherUsecase{repo: repo}
+}
+
+func (u *CreatePublisherUsecase) Execute(ctx context.Context, req models.CreatePublisherRequest) (models.Publisher, error) {
+	ctx, span := tracing.Start(ctx, "CreatePublisherUsecase.Execute")
+	defer span.End()
+
+	publisher, err := validateCreatePublisherRequest(req)
+	if err != nil {
+		return models.Publisher{}, err
+	}
+
+	created, err := u.repo.Create(ctx, publisher)
+	if err != nil {
+		return models.Publisher{}, fmt.Errorf("create publisher: %w", err)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type CreateReadingListUsecase struct {
+	repo repositories.ReadingListRepository
+}
+
+func NewCreateReadingListUsecase(repo repositories.ReadingListRepository) *CreateReadingListUsecase {
+	return &CreateReadingListUsecase{repo: repo}
+}
+
+func (u *CreateReadingListUsecase) Execute(ctx context.Context, ownerID string, req models.CreateReadingListRequest) (models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "CreateReadingListUsecase.Execute")
+	defer span.End()
+
+	list, err := validateReadingListRequest(req)
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+
+	now := time.Now().UTC()
+	list.OwnerID = ownerID
+	list.CreatedAt = now
+	list.UpdatedAt = now
+
+	created, err := u.repo.Create(ctx, list)
+	if err != nil {
+		return models.ReadingList{}, fmt.Errorf("create reading list: %w", err)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,35 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type CreateSeriesUsecase struct {
+	repo repositories.SeriesRepository
+}
+
+func NewCreateSeriesUsecase(repo repositories.SeriesRepository) *CreateSeriesUsecase {
+	return &CreateSeriesUsecase{repo: repo}
+}
+
+func (u *CreateSeriesUsecase) Execute(ctx context.Context, req models.CreateSeriesRequest) (models.Series, error) {
+	ctx, span := tracing.Start(ctx, "CreateSeriesUsecase.Execute")
+	defer span.End()
+
+	series, err := validateCreateSeriesRequest(req)
+	if err != nil {
+		return models.Series{}, err
+	}
+
+	created, err := u.repo.Create(ctx, series)
+	if err != nil {
+		return models.Series{}, fmt.Errorf("create series: %w", err)
+	}
+
+	return created, nil
+}
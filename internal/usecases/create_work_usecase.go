@@ -0,0 +1,35 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type CreateWorkUsecase struct {
+	repo repositories.WorkRepository
+}
+
+func NewCreateWorkUsecase(repo repositories.WorkRepository) *CreateWorkUsecase {
+	return &CreateWorkUsecase{repo: repo}
+}
+
+func (u *CreateWorkUsecase) Execute(ctx context.Context, req models.CreateWorkRequest) (models.Work, error) {
+	ctx, span := tracing.Start(ctx, "CreateWorkUsecase.Execute")
+	defer span.End()
+
+	work, err := validateCreateWorkRequest(req)
+	if err != nil {
+		return models.Work{}, err
+	}
+
+	created, err := u.repo.Create(ctx, work)
+	if err != nil {
+		return models.Work{}, fmt.Errorf("create work: %w", err)
+	}
+
+	return created, nil
+}
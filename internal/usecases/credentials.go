@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"desent-api/internal/repositories"
+	"desent-api/internal/utils"
+)
+
+// builtInUsername is the account that exists before any user row does.
+const builtInUsername = "admin"
+
+// CheckPassword verifies the built-in account, which is used until a
+// password has been stored for it.
+func CheckPassword(username, password string) bool {
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(builtInUsername)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte("password")) == 1
+	return userOK && passwordOK
+}
+
+// Credentials verifies passwords against stored hashes, falling back to
+// CheckPassword for accounts that have never set one.
+type Credentials struct {
+	users repositories.UserRepository
+}
+
+func NewCredentials(users repositories.UserRepository) *Credentials {
+	return &Credentials{users: users}
+}
+
+func (c *Credentials) Check(ctx context.Context, username, password string) (bool, error) {
+	user, err := c.users.FindByUsername(ctx, username)
+	if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
+		return false, fmt.Errorf("find user: %w", err)
+	}
+
+	if err != nil || user.PasswordHash == "" {
+		return CheckPassword(username, password), nil
+	}
+
+	ok, err := utils.VerifyPassword(password, user.PasswordHash)
+	if err != nil {
+		return false, fmt.Errorf("verify password: %w", err)
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"desent-api/internal/repositories"
+	"desent-api/internal/storage"
+	"desent-api/internal/tracing"
+)
+
+type DeleteBookCoverUsecase struct {
+	coverRepo repositories.BookCoverRepository
+	store     storage.BlobStore
+}
+
+func NewDeleteBookCoverUsecase(coverRepo repositories.BookCoverRepository, store storage.BlobStore) *DeleteBookCoverUsecase {
+	return &DeleteBookCoverUsecase{coverRepo: coverRepo, store: store}
+}
+
+func (u *DeleteBookCoverUsecase) Execute(ctx context.Context, rawID string) error {
+	ctx, span := tracing.Start(ctx, "DeleteBookCoverUsecase.Execute")
+	defer span.End()
+
+	id, err := parseBookID(rawID)
+	if err != nil {
+		return err
+	}
+
+	cover, err := u.coverRepo.FindByBookID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookCoverNotFound) {
+			return ErrBookCoverNotFound
+		}
+
+		return fmt.Errorf("get cover: %w", err)
+	}
+
+	if err := u.coverRepo.DeleteByBookID(ctx, id); err != nil {
+		if errors.Is(err, repositories.ErrBookCoverNotFound) {
+			return ErrBookCoverNotFound
+		}
+
+		return fmt.Errorf("delete cover: %w", err)
+	}
+
+	return deleteUnreferencedCoverBlobs(ctx, u.coverRepo, u.store, cover.Checksum)
+}
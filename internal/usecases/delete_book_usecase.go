@@ -5,23 +5,48 @@ import (
 	"errors"
 	"fmt"
 
+	"desent-api/internal/models"
+
 	"desent-api/internal/repositories"
+	"desent-api/internal/storage"
+	"desent-api/internal/tracing"
 )
 
 type DeleteBookUsecase struct {
-	repo repositories.BookRepository
+	repo      repositories.BookRepository
+	coverRepo repositories.BookCoverRepository
+	store     storage.BlobStore
 }
 
 func NewDeleteBookUsecase(repo repositories.BookRepository) *DeleteBookUsecase {
 	return &DeleteBookUsecase{repo: repo}
 }
 
+// WithCovers removes the cover images of deleted books from store. The
+// cover row itself goes with the book.
+func (u *DeleteBookUsecase) WithCovers(coverRepo repositories.BookCoverRepository, store storage.BlobStore) *DeleteBookUsecase {
+	u.coverRepo = coverRepo
+	u.store = store
+	return u
+}
+
 func (u *DeleteBookUsecase) Execute(ctx context.Context, rawID string) error {
+	ctx, span := tracing.Start(ctx, "DeleteBookUsecase.Execute")
+	defer span.End()
+
 	id, err := parseBookID(rawID)
 	if err != nil {
 		return err
 	}
 
+	var cover models.BookCover
+	if u.coverRepo != nil {
+		cover, err = u.coverRepo.FindByBookID(ctx, id)
+		if err != nil && !errors.Is(err, repositories.ErrBookCoverNotFound) {
+			return fmt.Errorf("get cover: %w", err)
+		}
+	}
+
 	err = u.repo.DeleteByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, repositories.ErrBookNotFound) {
@@ -31,5 +56,9 @@ func (u *DeleteBookUsecase) Execute(ctx context.Context, rawID string) error {
 		return fmt.Errorf("delete book: %w", err)
 	}
 
-	return nil
+	if cover.Checksum == "" {
+		return nil
+	}
+
+	return deleteUnreferencedCoverBlobs(ctx, u.coverRepo, u.store, cover.Checksum)
 }
@@ -0,0 +1,42 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type DeleteReadingListUsecase struct {
+	repo repositories.ReadingListRepository
+}
+
+func NewDeleteReadingListUsecase(repo repositories.ReadingListRepository) *DeleteReadingListUsecase {
+	return &DeleteReadingListUsecase{repo: repo}
+}
+
+func (u *DeleteReadingListUsecase) Execute(ctx context.Context, ownerID, rawID string) error {
+	ctx, span := tracing.Start(ctx, "DeleteReadingListUsecase.Execute")
+	defer span.End()
+
+	id, err := parseReadingListID(rawID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := findOwnedReadingList(ctx, u.repo, ownerID, id); err != nil {
+		return err
+	}
+
+	if err := u.repo.DeleteByID(ctx, id); err != nil {
+		if errors.Is(err, repositories.ErrReadingListNotFound) {
+			return ErrReadingListNotFound
+		}
+
+		return fmt.Errorf("delete reading list: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type EnrichBookUsecase struct {
+	bookRepo repositories.BookRepository
+	enricher *BookEnricher
+}
+
+func NewEnrichBookUsecase(bookRepo repositories.BookRepository, enricher *BookEnricher) *EnrichBookUsecase {
+	return &EnrichBookUsecase{bookRepo: bookRepo, enricher: enricher}
+}
+
+func (u *EnrichBookUsecase) Execute(ctx context.Context, rawID string) (models.BookEnrichment, error) {
+	ctx, span := tracing.Start(ctx, "EnrichBookUsecase.Execute")
+	defer span.End()
+
+	id, err := parseBookID(rawID)
+	if err != nil {
+		return models.BookEnrichment{}, err
+	}
+
+	book, err := u.bookRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookNotFound) {
+			return models.BookEnrichment{}, ErrBookNotFound
+		}
+
+		return models.BookEnrichment{}, fmt.Errorf("get book: %w", err)
+	}
+
+	if book.ISBN == "" {
+		return models.BookEnrichment{}, fmt.Errorf("%w: book has no isbn to enrich from", ErrValidation)
+	}
+
+	record, err := u.enricher.lookup(ctx, book.ISBN)
+	if err != nil {
+		return models.BookEnrichment{}, err
+	}
+
+	req := bookToRequest(book)
+	filled := fillMissingBookFields(&req, record)
+	if len(filled) > 0 {
+		enriched, err := validateCreateBookRequest(req)
+		if err != nil {
+			return models.BookEnrichment{}, err
+		}
+
+		book, err = u.bookRepo.UpdateByID(ctx, id, enriched)
+		if err != nil {
+			return models.BookEnrichment{}, fmt.Errorf("update book: %w", err)
+		}
+
+		if err := u.enricher.recordProvenance(ctx, id, filled, record); err != nil {
+			return models.BookEnrichment{}, err
+		}
+	}
+
+	provenance, err := u.enricher.provenanceRepo.FindByBookID(ctx, id)
+	if err != nil {
+		return models.BookEnrichment{}, fmt.Errorf("get provenance: %w", err)
+	}
+
+	return models.BookEnrichment{Book: book, EnrichedFields: filled, Provenance: provenance}, nil
+}
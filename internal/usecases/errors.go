@@ -5,3 +5,28 @@ import "errors"
 var ErrValidation = errors.New("validation error")
 var ErrInvalidBookID = errors.New("invalid book id")
 var ErrBookNotFound = errors.New("book not found")
+var ErrReadingListNotFound = errors.New("reading list not found")
+var ErrReadingListForbidden = errors.New("reading list belongs to another user")
+var ErrReadingListEntryNotFound = errors.New("book is not in reading list")
+var ErrReadingListEntryExists = errors.New("book is already in reading list")
+var ErrWorkNotFound = errors.New("work not found")
+var ErrSeriesNotFound = errors.New("series not found")
+var ErrPublisherNotFound = errors.New("publisher not found")
+var ErrBookCoverNotFound = errors.New("book cover not found")
+var ErrUnsupportedCoverType = errors.New("cover must be a JPEG, PNG or GIF image")
+var ErrMetadataNotFound = errors.New("no metadata found for isbn")
+var ErrInvalidRecords = errors.New("records could not be parsed")
+var ErrLoginLocked = errors.New("too many failed logins")
+var ErrLockoutNotFound = errors.New("lockout not found")
+var ErrMFAAlreadyEnabled = errors.New("multi-factor authentication is already enabled")
+var ErrMFANotEnrolled = errors.New("multi-factor authentication is not enrolled")
+var ErrInvalidMFACode = errors.New("invalid verification code")
+var ErrInvalidMFAChallenge = errors.New("mfa challenge is invalid or expired")
+var ErrOAuthClientNotFound = errors.New("oauth client not found")
+var ErrInvalidAccountToken = errors.New("token is invalid or expired")
+var ErrEmailTaken = errors.New("email belongs to another user")
+var ErrNoLocalAccount = errors.New("account is not managed by this API")
+var ErrInvalidCredentials = errors.New("invalid username or password")
+var ErrSessionNotFound = errors.New("session not found")
+var ErrSessionRevoked = errors.New("session was revoked or has expired")
+var ErrSessionRequired = errors.New("token has no session")
@@ -0,0 +1,234 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+const DefaultDuplicateThreshold = 0.88
+
+type FindDuplicateBooksUsecase struct {
+	repo repositories.BookRepository
+}
+
+func NewFindDuplicateBooksUsecase(repo repositories.BookRepository) *FindDuplicateBooksUsecase {
+	return &FindDuplicateBooksUsecase{repo: repo}
+}
+
+type duplicateLink struct {
+	reason     string
+	similarity float64
+}
+
+func (u *FindDuplicateBooksUsecase) Execute(ctx context.Context, query models.DuplicateQuery) ([]models.DuplicateBookGroup, error) {
+	ctx, span := tracing.Start(ctx, "FindDuplicateBooksUsecase.Execute")
+	defer span.End()
+
+	threshold := query.Threshold
+	if threshold == 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("%w: threshold must be between 0 and 1", ErrValidation)
+	}
+
+	books, err := u.repo.FindAll(ctx, models.BookListQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("list books: %w", err)
+	}
+
+	titles := make([]string, len(books))
+	authors := make([]string, len(books))
+	for i, book := range books {
+		titles[i] = normalizeTitle(book.Title)
+		authors[i] = normalizeAuthor(book.Author)
+	}
+
+	parent := make([]int, len(books))
+	groupISBN := make([]string, len(books))
+	for i := range parent {
+		parent[i] = i
+		groupISBN[i] = books[i].ISBN
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	links := make(map[int]duplicateLink)
+	for _, pair := range candidatePairs(books, titles) {
+		i, j := pair[0], pair[1]
+		link, ok := compareBooks(books[i], books[j], titles[i], titles[j], authors[i], authors[j], threshold)
+		if !ok {
+			continue
+		}
+
+		ri, rj := find(i), find(j)
+		if ri != rj && groupISBN[ri] != "" && groupISBN[rj] != "" && groupISBN[ri] != groupISBN[rj] {
+			// Joining would put two different editions in one group.
+			continue
+		}
+
+		merged := mergeLinks(links[ri], links[rj], link)
+		if ri != rj {
+			parent[rj] = ri
+			if groupISBN[ri] == "" {
+				groupISBN[ri] = groupISBN[rj]
+			}
+			delete(links, rj)
+		}
+		links[ri] = merged
+	}
+
+	members := make(map[int][]models.Book)
+	for i, book := range books {
+		root := find(i)
+		members[root] = append(members[root], book)
+	}
+
+	groups := make([]models.DuplicateBookGroup, 0)
+	for root, groupBooks := range members {
+		if len(groupBooks) < 2 {
+			continue
+		}
+
+		link := links[root]
+		groups = append(groups, models.DuplicateBookGroup{
+			Reason:     link.reason,
+			Similarity: math.Round(link.similarity*1000) / 1000,
+			Books:      groupBooks,
+		})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Books[0].ID < groups[j].Books[0].ID
+	})
+
+	return groups, nil
+}
+
+// duplicateTitleAnchor is how many runes from each end of a normalized
+// title go into a blocking key.
+const duplicateTitleAnchor = 3
+
+// candidatePairs returns the pairs of books worth comparing, in index
+// order. Comparing every pair is quadratic in the catalogue with a
+// Levenshtein distance each, so books are first put into blocks: one per
+// ISBN, and one per title start or end combined with the author's surname.
+// Only books that share a block are compared, which misses matches whose
+// titles differ at both ends or whose surnames differ.
+func candidatePairs(books []models.Book, titles []string) [][2]int {
+	blocks := make(map[string][]int)
+	for i, book := range books {
+		if book.ISBN != "" {
+			blocks["isbn:"+book.ISBN] = append(blocks["isbn:"+book.ISBN], i)
+		}
+
+		title := []rune(titles[i])
+		anchor := min(len(title), duplicateTitleAnchor)
+		surname := authorSurname(book.Author)
+		for _, key := range []string{
+			"start:" + string(title[:anchor]) + "|" + surname,
+			"end:" + string(title[len(title)-anchor:]) + "|" + surname,
+		} {
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	seen := make(map[[2]int]struct{})
+	var pairs [][2]int
+	for _, members := range blocks {
+		for a := 0; a < len(members); a++ {
+			for b := a + 1; b < len(members); b++ {
+				pair := [2]int{members[a], members[b]}
+				if pair[0] == pair[1] {
+					continue
+				}
+				if _, ok := seen[pair]; ok {
+					continue
+				}
+				seen[pair] = struct{}{}
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(a, b int) bool {
+		if pairs[a][0] != pairs[b][0] {
+			return pairs[a][0] < pairs[b][0]
+		}
+		return pairs[a][1] < pairs[b][1]
+	})
+
+	return pairs
+}
+
+// authorSurname is the last name token before a comma in "Surname,
+// Forename" headings, or the last token otherwise.
+func authorSurname(author string) string {
+	name, _, _ := strings.Cut(author, ",")
+	tokens := strings.Fields(normalizeText(name))
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	return tokens[len(tokens)-1]
+}
+
+func compareBooks(a, b models.Book, titleA, titleB, authorA, authorB string, threshold float64) (duplicateLink, bool) {
+	if a.ISBN != "" && b.ISBN != "" {
+		if a.ISBN == b.ISBN {
+			return duplicateLink{reason: models.DuplicateReasonISBN, similarity: 1}, true
+		}
+
+		// Distinct ISBNs are distinct editions, however alike the titles are.
+		return duplicateLink{}, false
+	}
+
+	if titleA == titleB && authorA == authorB {
+		return duplicateLink{reason: models.DuplicateReasonExact, similarity: 1}, true
+	}
+
+	score := math.Min(similarity(titleA, titleB), similarity(authorA, authorB))
+	if score >= threshold {
+		return duplicateLink{reason: models.DuplicateReasonSimilar, similarity: score}, true
+	}
+
+	return duplicateLink{}, false
+}
+
+// mergeLinks keeps the weakest evidence that joined a group so the report
+// never overstates how confident a match is.
+func mergeLinks(links ...duplicateLink) duplicateLink {
+	rank := map[string]int{
+		models.DuplicateReasonSimilar: 1,
+		models.DuplicateReasonExact:   2,
+		models.DuplicateReasonISBN:    3,
+	}
+
+	result := duplicateLink{}
+	for _, link := range links {
+		if link.reason == "" {
+			continue
+		}
+
+		if result.reason == "" || rank[link.reason] < rank[result.reason] {
+			result.reason = link.reason
+		}
+		if result.similarity == 0 || link.similarity < result.similarity {
+			result.similarity = link.similarity
+		}
+	}
+
+	return result
+}
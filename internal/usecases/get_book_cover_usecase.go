@@ -0,0 +1,78 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/storage"
+	"desent-api/internal/tracing"
+)
+
+type GetBookCoverUsecase struct {
+	coverRepo repositories.BookCoverRepository
+	store     storage.BlobStore
+}
+
+func NewGetBookCoverUsecase(coverRepo repositories.BookCoverRepository, store storage.BlobStore) *GetBookCoverUsecase {
+	return &GetBookCoverUsecase{coverRepo: coverRepo, store: store}
+}
+
+func (u *GetBookCoverUsecase) Execute(ctx context.Context, rawID, size string) (models.BookCoverImage, error) {
+	ctx, span := tracing.Start(ctx, "GetBookCoverUsecase.Execute")
+	defer span.End()
+
+	id, err := parseBookID(rawID)
+	if err != nil {
+		return models.BookCoverImage{}, err
+	}
+
+	size = strings.ToLower(strings.TrimSpace(size))
+	if size == "" {
+		size = models.CoverSizeOriginal
+	}
+	if _, ok := models.CoverSizes[size]; !ok && size != models.CoverSizeOriginal {
+		return models.BookCoverImage{}, fmt.Errorf("%w: size must be one of original, small, medium, large", ErrValidation)
+	}
+
+	cover, err := u.coverRepo.FindByBookID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookCoverNotFound) {
+			return models.BookCoverImage{}, ErrBookCoverNotFound
+		}
+
+		return models.BookCoverImage{}, fmt.Errorf("get cover: %w", err)
+	}
+
+	blob, err := u.store.Get(ctx, coverBlobKey(cover.Checksum, size))
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return models.BookCoverImage{}, ErrBookCoverNotFound
+		}
+
+		return models.BookCoverImage{}, fmt.Errorf("read cover: %w", err)
+	}
+	defer blob.Close()
+
+	data, err := io.ReadAll(blob)
+	if err != nil {
+		return models.BookCoverImage{}, fmt.Errorf("read cover: %w", err)
+	}
+
+	contentType := "image/jpeg"
+	if size == models.CoverSizeOriginal {
+		contentType = cover.ContentType
+	}
+
+	return models.BookCoverImage{
+		Cover:       cover,
+		Size:        size,
+		ContentType: contentType,
+		ETag:        coverETag(cover.Checksum, size),
+		Data:        data,
+	}, nil
+}
@@ -0,0 +1,45 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type GetBookProvenanceUsecase struct {
+	bookRepo       repositories.BookRepository
+	provenanceRepo repositories.BookProvenanceRepository
+}
+
+func NewGetBookProvenanceUsecase(bookRepo repositories.BookRepository, provenanceRepo repositories.BookProvenanceRepository) *GetBookProvenanceUsecase {
+	return &GetBookProvenanceUsecase{bookRepo: bookRepo, provenanceRepo: provenanceRepo}
+}
+
+func (u *GetBookProvenanceUsecase) Execute(ctx context.Context, rawID string) ([]models.BookFieldProvenance, error) {
+	ctx, span := tracing.Start(ctx, "GetBookProvenanceUsecase.Execute")
+	defer span.End()
+
+	id, err := parseBookID(rawID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := u.bookRepo.FindByID(ctx, id); err != nil {
+		if errors.Is(err, repositories.ErrBookNotFound) {
+			return nil, ErrBookNotFound
+		}
+
+		return nil, fmt.Errorf("get book: %w", err)
+	}
+
+	provenance, err := u.provenanceRepo.FindByBookID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get provenance: %w", err)
+	}
+
+	return provenance, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"desent-api/internal/models"
 	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
 )
 
 type GetBookUsecase struct {
@@ -18,6 +19,9 @@ func NewGetBookUsecase(repo repositories.BookRepository) *GetBookUsecase {
 }
 
 func (u *GetBookUsecase) Execute(ctx context.Context, rawID string) (models.Book, error) {
+	ctx, span := tracing.Start(ctx, "GetBookUsecase.Execute")
+	defer span.End()
+
 	id, err := parseBookID(rawID)
 	if err != nil {
 		return models.Book{}, err
@@ -0,0 +1,40 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type GetPublisherUsecase struct {
+	repo repositories.PublisherRepository
+}
+
+func NewGetPublisherUsecase(repo repositories.PublisherRepository) *GetPublisherUsecase {
+	return &GetPublisherUsecase{repo: repo}
+}
+
+func (u *GetPublisherUsecase) Execute(ctx context.Context, rawID string) (models.Publisher, error) {
+	ctx, span := tracing.Start(ctx, "GetPublisherUsecase.Execute")
+	defer span.End()
+
+	id, err := parseCatalogID(rawID, ErrPublisherNotFound)
+	if err != nil {
+		return models.Publisher{}, err
+	}
+
+	publisher, err := u.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPublisherNotFound) {
+			return models.Publisher{}, ErrPublisherNotFound
+		}
+
+		return models.Publisher{}, fmt.Errorf("get publisher: %w", err)
+	}
+
+	return publisher, nil
+}
@@ -0,0 +1,44 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type GetReadingListUsecase struct {
+	repo repositories.ReadingListRepository
+}
+
+func NewGetReadingListUsecase(repo repositories.ReadingListRepository) *GetReadingListUsecase {
+	return &GetReadingListUsecase{repo: repo}
+}
+
+func (u *GetReadingListUsecase) Execute(ctx context.Context, viewerID, rawID string) (models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "GetReadingListUsecase.Execute")
+	defer span.End()
+
+	id, err := parseReadingListID(rawID)
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+
+	list, err := u.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrReadingListNotFound) {
+			return models.ReadingList{}, ErrReadingListNotFound
+		}
+
+		return models.ReadingList{}, fmt.Errorf("get reading list: %w", err)
+	}
+
+	if !canViewReadingList(list, viewerID) {
+		return models.ReadingList{}, ErrReadingListNotFound
+	}
+
+	return list, nil
+}
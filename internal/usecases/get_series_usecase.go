@@ -0,0 +1,40 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type GetSeriesUsecase struct {
+	repo repositories.SeriesRepository
+}
+
+func NewGetSeriesUsecase(repo repositories.SeriesRepository) *GetSeriesUsecase {
+	return &GetSeriesUsecase{repo: repo}
+}
+
+func (u *GetSeriesUsecase) Execute(ctx context.Context, rawID string) (models.Series, error) {
+	ctx, span := tracing.Start(ctx, "GetSeriesUsecase.Execute")
+	defer span.End()
+
+	id, err := parseCatalogID(rawID, ErrSeriesNotFound)
+	if err != nil {
+		return models.Series{}, err
+	}
+
+	series, err := u.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrSeriesNotFound) {
+			return models.Series{}, ErrSeriesNotFound
+		}
+
+		return models.Series{}, fmt.Errorf("get series: %w", err)
+	}
+
+	return series, nil
+}
@@ -0,0 +1,40 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type GetWorkUsecase struct {
+	repo repositories.WorkRepository
+}
+
+func NewGetWorkUsecase(repo repositories.WorkRepository) *GetWorkUsecase {
+	return &GetWorkUsecase{repo: repo}
+}
+
+func (u *GetWorkUsecase) Execute(ctx context.Context, rawID string) (models.Work, error) {
+	ctx, span := tracing.Start(ctx, "GetWorkUsecase.Execute")
+	defer span.End()
+
+	id, err := parseCatalogID(rawID, ErrWorkNotFound)
+	if err != nil {
+		return models.Work{}, err
+	}
+
+	work, err := u.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrWorkNotFound) {
+			return models.Work{}, ErrWorkNotFound
+		}
+
+		return models.Work{}, fmt.Errorf("get work: %w", err)
+	}
+
+	return work, nil
+}
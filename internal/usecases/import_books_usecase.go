@@ -0,0 +1,71 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"desent-api/internal/metadata"
+	"desent-api/internal/models"
+	"desent-api/internal/tracing"
+)
+
+type ImportBooksUsecase struct {
+	createUsecase *CreateBookUsecase
+}
+
+func NewImportBooksUsecase(createUsecase *CreateBookUsecase) *ImportBooksUsecase {
+	return &ImportBooksUsecase{createUsecase: createUsecase}
+}
+
+// Execute creates one book per MARC record. Records that fail validation
+// are reported by their 1-based position and do not stop the import.
+func (u *ImportBooksUsecase) Execute(ctx context.Context, format string, r io.Reader) (models.BookImportResult, error) {
+	ctx, span := tracing.Start(ctx, "ImportBooksUsecase.Execute")
+	defer span.End()
+
+	result := models.BookImportResult{Books: make([]models.Book, 0)}
+
+	var createErr error
+	position := 0
+	visit := func(record metadata.MARCRecord) error {
+		position++
+
+		book, err := u.createUsecase.Execute(ctx, metadata.BookRequestFromMARC(record))
+		if err != nil {
+			if errors.Is(err, ErrValidation) {
+				result.Errors = append(result.Errors, models.BookImportError{Record: position, Message: err.Error()})
+				return nil
+			}
+
+			createErr = err
+			return err
+		}
+
+		result.Books = append(result.Books, book)
+		return nil
+	}
+
+	var (
+		skipped int
+		err     error
+	)
+	switch format {
+	case models.MetadataFormatMARC:
+		skipped, err = metadata.ParseMARC(r, visit)
+	case models.MetadataFormatMARCXML:
+		skipped, err = metadata.ParseMARCXML(r, visit)
+	default:
+		return models.BookImportResult{}, fmt.Errorf("%w: unsupported record format %q", ErrValidation, format)
+	}
+	result.Skipped = skipped
+	if createErr != nil {
+		return result, fmt.Errorf("import books: %w", createErr)
+	}
+	if err != nil {
+		return result, fmt.Errorf("%w: %w", ErrInvalidRecords, err)
+	}
+
+	return result, nil
+}
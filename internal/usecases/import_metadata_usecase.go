@@ -0,0 +1,115 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"desent-api/internal/metadata"
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+const metadataImportBatchSize = 1000
+
+type ImportMetadataUsecase struct {
+	repo repositories.MetadataRepository
+}
+
+func NewImportMetadataUsecase(repo repositories.MetadataRepository) *ImportMetadataUsecase {
+	return &ImportMetadataUsecase{repo: repo}
+}
+
+// Execute streams a dump into the lookup table, committing every
+// metadataImportBatchSize records so large dumps never sit in memory.
+func (u *ImportMetadataUsecase) Execute(ctx context.Context, format string, r io.Reader) (models.MetadataImportStats, error) {
+	ctx, span := tracing.Start(ctx, "ImportMetadataUsecase.Execute")
+	defer span.End()
+
+	var (
+		stats    models.MetadataImportStats
+		editions []models.MetadataEdition
+		works    []models.MetadataWork
+		authors  []models.MetadataAuthor
+	)
+
+	flush := func() error {
+		if len(editions)+len(works)+len(authors) == 0 {
+			return nil
+		}
+
+		if err := u.repo.SaveBatch(ctx, editions, works, authors); err != nil {
+			return fmt.Errorf("save metadata: %w", err)
+		}
+
+		stats.Editions += len(editions)
+		stats.Works += len(works)
+		stats.Authors += len(authors)
+		editions, works, authors = editions[:0], works[:0], authors[:0]
+		return nil
+	}
+	flushIfFull := func() error {
+		if len(editions)+len(works)+len(authors) < metadataImportBatchSize {
+			return nil
+		}
+
+		return flush()
+	}
+
+	visitor := metadata.Visitor{
+		Edition: func(edition models.MetadataEdition) error {
+			editions = append(editions, edition)
+			return flushIfFull()
+		},
+		Work: func(work models.MetadataWork) error {
+			works = append(works, work)
+			return flushIfFull()
+		},
+		Author: func(author models.MetadataAuthor) error {
+			authors = append(authors, author)
+			return flushIfFull()
+		},
+	}
+
+	var (
+		skipped int
+		err     error
+	)
+	visitMARC := func(record metadata.MARCRecord) error {
+		edition, marcAuthors := metadata.EditionFromMARC(record)
+		if len(edition.ISBNs) == 0 {
+			stats.Skipped++
+			return nil
+		}
+
+		for _, author := range marcAuthors {
+			if err := visitor.Author(author); err != nil {
+				return err
+			}
+		}
+
+		return visitor.Edition(edition)
+	}
+
+	switch format {
+	case models.MetadataFormatOpenLibrary:
+		skipped, err = metadata.ParseOpenLibraryDump(r, visitor)
+	case models.MetadataFormatMARC:
+		skipped, err = metadata.ParseMARC(r, visitMARC)
+	case models.MetadataFormatMARCXML:
+		skipped, err = metadata.ParseMARCXML(r, visitMARC)
+	default:
+		return models.MetadataImportStats{}, fmt.Errorf("%w: unsupported metadata format %q", ErrValidation, format)
+	}
+	stats.Skipped += skipped
+	if err != nil {
+		return stats, fmt.Errorf("parse metadata: %w", err)
+	}
+
+	if err := flush(); err != nil {
+		return stats, err
+	}
+
+	return stats, nil
+}
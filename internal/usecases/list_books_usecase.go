@@ -5,6 +5,7 @@ import (
 
 	"desent-api/internal/models"
 	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
 )
 
 type ListBooksUsecase struct {
@@ -16,5 +17,8 @@ func NewListBooksUsecase(repo repositories.BookRepository) *ListBooksUsecase {
 }
 
 func (u *ListBooksUsecase) Execute(ctx context.Context, query models.BookListQuery) ([]models.Book, error) {
+	ctx, span := tracing.Start(ctx, "ListBooksUsecase.Execute")
+	defer span.End()
+
 	return u.repo.FindAll(ctx, query)
 }
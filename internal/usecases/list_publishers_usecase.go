@@ -0,0 +1,24 @@
+package usecases
+
+import (
+	"context"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type ListPublishersUsecase struct {
+	repo repositories.PublisherRepository
+}
+
+func NewListPublishersUsecase(repo repositories.PublisherRepository) *ListPublishersUsecase {
+	return &ListPublishersUsecase{repo: repo}
+}
+
+func (u *ListPublishersUsecase) Execute(ctx context.Context) ([]models.Publisher, error) {
+	ctx, span := tracing.Start(ctx, "ListPublishersUsecase.Execute")
+	defer span.End()
+
+	return u.repo.FindAll(ctx)
+}
@@ -0,0 +1,24 @@
+package usecases
+
+import (
+	"context"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type ListSeriesUsecase struct {
+	repo repositories.SeriesRepository
+}
+
+func NewListSeriesUsecase(repo repositories.SeriesRepository) *ListSeriesUsecase {
+	return &ListSeriesUsecase{repo: repo}
+}
+
+func (u *ListSeriesUsecase) Execute(ctx context.Context) ([]models.Series, error) {
+	ctx, span := tracing.Start(ctx, "ListSeriesUsecase.Execute")
+	defer span.End()
+
+	return u.repo.FindAll(ctx)
+}
@@ -0,0 +1,39 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type ListUserReadingListsUsecase struct {
+	repo repositories.ReadingListRepository
+}
+
+func NewListUserReadingListsUsecase(repo repositories.ReadingListRepository) *ListUserReadingListsUsecase {
+	return &ListUserReadingListsUsecase{repo: repo}
+}
+
+func (u *ListUserReadingListsUsecase) Execute(ctx context.Context, viewerID, ownerID string) ([]models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "ListUserReadingListsUsecase.Execute")
+	defer span.End()
+
+	ownerID = strings.TrimSpace(ownerID)
+
+	// Unlisted lists are only reachable by link, so other users only see public ones.
+	var visibilities []string
+	if viewerID == "" || viewerID != ownerID {
+		visibilities = []string{models.ReadingListVisibilityPublic}
+	}
+
+	lists, err := u.repo.FindByOwner(ctx, ownerID, visibilities)
+	if err != nil {
+		return nil, fmt.Errorf("list reading lists: %w", err)
+	}
+
+	return lists, nil
+}
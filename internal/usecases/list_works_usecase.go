@@ -0,0 +1,24 @@
+package usecases
+
+import (
+	"context"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type ListWorksUsecase struct {
+	repo repositories.WorkRepository
+}
+
+func NewListWorksUsecase(repo repositories.WorkRepository) *ListWorksUsecase {
+	return &ListWorksUsecase{repo: repo}
+}
+
+func (u *ListWorksUsecase) Execute(ctx context.Context) ([]models.Work, error) {
+	ctx, span := tracing.Start(ctx, "ListWorksUsecase.Execute")
+	defer span.End()
+
+	return u.repo.FindAll(ctx)
+}
@@ -0,0 +1,227 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+)
+
+// LoginThrottlePolicy configures lockouts. A subject is locked once it
+// reaches its threshold of failures, for BaseLockout doubled with every
+// further failure and capped at MaxLockout.
+type LoginThrottlePolicy struct {
+	UsernameThreshold int
+	IPThreshold       int
+	BaseLockout       time.Duration
+	MaxLockout        time.Duration
+	FailureWindow     time.Duration
+}
+
+// LoginLockedError reports how long the caller must wait before trying again.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed logins, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginLockedError) Is(target error) bool {
+	return target == ErrLoginLocked
+}
+
+// LoginThrottle tracks failed logins per username and per client IP and
+// writes security events for failures, lockouts and unlocks.
+type LoginThrottle struct {
+	repo    repositories.LoginAttemptRepository
+	policy  LoginThrottlePolicy
+	events  *slog.Logger
+	nowFunc func() time.Time
+}
+
+func NewLoginThrottle(repo repositories.LoginAttemptRepository, policy LoginThrottlePolicy, events *slog.Logger, nowFunc func() time.Time) *LoginThrottle {
+	if events == nil {
+		events = slog.Default()
+	}
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	return &LoginThrottle{repo: repo, policy: policy, events: events, nowFunc: nowFunc}
+}
+
+// Check returns a *LoginLockedError while either the username or the IP is
+// locked out, before any password is compared.
+func (t *LoginThrottle) Check(ctx context.Context, username, ip string) error {
+	now := t.nowFunc()
+
+	var retryAfter time.Duration
+	for _, key := range loginKeys(username, ip) {
+		attempt, err := t.repo.Find(ctx, key.scope, key.subject)
+		if err != nil {
+			if errors.Is(err, repositories.ErrLoginAttemptNotFound) {
+				continue
+			}
+
+			return fmt.Errorf("check login attempts: %w", err)
+		}
+
+		if wait := attempt.LockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter <= 0 {
+		return nil
+	}
+
+	t.event(ctx, slog.LevelWarn, "login_blocked",
+		slog.String("username", username),
+		slog.String("ip", ip),
+		slog.Int64("retry_after_seconds", int64(retryAfter.Round(time.Second)/time.Second)),
+	)
+	return &LoginLockedError{RetryAfter: retryAfter}
+}
+
+func (t *LoginThrottle) RecordFailure(ctx context.Context, username, ip string) error {
+	now := t.nowFunc()
+
+	for _, key := range loginKeys(username, ip) {
+		failures, err := t.repo.RecordFailure(ctx, key.scope, key.subject, now, t.policy.FailureWindow)
+		if err != nil {
+			return fmt.Errorf("record login failure: %w", err)
+		}
+
+		lockout := t.lockoutFor(key.scope, failures)
+		if lockout <= 0 {
+			continue
+		}
+
+		until := now.Add(lockout)
+		if err := t.repo.Lock(ctx, key.scope, key.subject, until); err != nil {
+			return fmt.Errorf("lock %s: %w", key.scope, err)
+		}
+
+		t.event(ctx, slog.LevelWarn, "lockout",
+			slog.String("scope", key.scope),
+			slog.String("subject", key.subject),
+			slog.Int("failures", failures),
+			slog.Time("locked_until", until.UTC()),
+		)
+	}
+
+	t.event(ctx, slog.LevelInfo, "login_failed", slog.String("username", username), slog.String("ip", ip))
+	return nil
+}
+
+// RecordSuccess clears the username's failures. The IP counter is left
+// alone so one valid account cannot be used to reset guessing from an IP.
+func (t *LoginThrottle) RecordSuccess(ctx context.Context, username string) error {
+	err := t.repo.Delete(ctx, models.LoginScopeUsername, username)
+	if err != nil && !errors.Is(err, repositories.ErrLoginAttemptNotFound) {
+		return fmt.Errorf("clear login failures: %w", err)
+	}
+
+	return nil
+}
+
+func (t *LoginThrottle) ListLockouts(ctx context.Context) ([]models.LoginAttempt, error) {
+	attempts, err := t.repo.FindLocked(ctx, t.nowFunc())
+	if err != nil {
+		return nil, fmt.Errorf("list lockouts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+func (t *LoginThrottle) Unlock(ctx context.Context, scope, subject, actor string) error {
+	if scope != models.LoginScopeUsername && scope != models.LoginScopeIP {
+		return fmt.Errorf("%w: scope must be username or ip", ErrValidation)
+	}
+
+	if err := t.repo.Delete(ctx, scope, subject); err != nil {
+		if errors.Is(err, repositories.ErrLoginAttemptNotFound) {
+			return ErrLockoutNotFound
+		}
+
+		return fmt.Errorf("unlock %s: %w", scope, err)
+	}
+
+	t.event(ctx, slog.LevelInfo, "unlock", slog.String("scope", scope), slog.String("subject", subject), slog.String("actor", actor))
+	return nil
+}
+
+// DeleteExpired removes the attempts that can no longer affect a login.
+func (t *LoginThrottle) DeleteExpired(ctx context.Context) error {
+	if err := t.repo.DeleteExpired(ctx, t.nowFunc(), t.policy.FailureWindow); err != nil {
+		return fmt.Errorf("delete expired login attempts: %w", err)
+	}
+
+	return nil
+}
+
+// RunCleanup calls DeleteExpired every interval until ctx is done,
+// reporting failures to onError. A non-positive interval disables it.
+func (t *LoginThrottle) RunCleanup(ctx context.Context, interval time.Duration, onError func(error)) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.DeleteExpired(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (t *LoginThrottle) lockoutFor(scope string, failures int) time.Duration {
+	threshold := t.policy.UsernameThreshold
+	if scope == models.LoginScopeIP {
+		threshold = t.policy.IPThreshold
+	}
+	if threshold <= 0 || failures < threshold {
+		return 0
+	}
+
+	lockout := t.policy.BaseLockout
+	for i := threshold; i < failures && lockout < t.policy.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > t.policy.MaxLockout {
+		lockout = t.policy.MaxLockout
+	}
+
+	return lockout
+}
+
+// event writes a security event. Failures and unlocks are routine and go
+// out at Info; lockouts and blocked attempts at Warn.
+func (t *LoginThrottle) event(ctx context.Context, level slog.Level, name string, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{slog.String("event", "auth."+name)}, attrs...)
+	t.events.LogAttrs(ctx, level, "security event", attrs...)
+}
+
+type loginKey struct {
+	scope   string
+	subject string
+}
+
+func loginKeys(username, ip string) []loginKey {
+	return []loginKey{
+		{scope: models.LoginScopeUsername, subject: username},
+		{scope: models.LoginScopeIP, subject: ip},
+	}
+}
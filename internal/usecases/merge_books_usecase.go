@@ -0,0 +1,74 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/storage"
+	"desent-api/internal/tracing"
+)
+
+type MergeBooksUsecase struct {
+	repo      repositories.BookRepository
+	coverRepo repositories.BookCoverRepository
+	store     storage.BlobStore
+}
+
+func NewMergeBooksUsecase(repo repositories.BookRepository) *MergeBooksUsecase {
+	return &MergeBooksUsecase{repo: repo}
+}
+
+// WithCovers removes the source's cover images from store when the target
+// keeps its own cover.
+func (u *MergeBooksUsecase) WithCovers(coverRepo repositories.BookCoverRepository, store storage.BlobStore) *MergeBooksUsecase {
+	u.coverRepo = coverRepo
+	u.store = store
+	return u
+}
+
+func (u *MergeBooksUsecase) Execute(ctx context.Context, rawTargetID string, req models.MergeBooksRequest) (models.Book, error) {
+	ctx, span := tracing.Start(ctx, "MergeBooksUsecase.Execute")
+	defer span.End()
+
+	targetID, err := parseBookID(rawTargetID)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	if req.SourceID <= 0 {
+		return models.Book{}, fmt.Errorf("%w: source_id is required", ErrValidation)
+	}
+
+	if req.SourceID == targetID {
+		return models.Book{}, fmt.Errorf("%w: cannot merge a book into itself", ErrValidation)
+	}
+
+	var sourceCover models.BookCover
+	if u.coverRepo != nil {
+		sourceCover, err = u.coverRepo.FindByBookID(ctx, req.SourceID)
+		if err != nil && !errors.Is(err, repositories.ErrBookCoverNotFound) {
+			return models.Book{}, fmt.Errorf("get cover: %w", err)
+		}
+	}
+
+	merged, err := u.repo.Merge(ctx, targetID, req.SourceID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrBookNotFound) {
+			return models.Book{}, ErrBookNotFound
+		}
+
+		return models.Book{}, fmt.Errorf("merge books: %w", err)
+	}
+
+	// A cover moved to the target is still referenced and stays.
+	if sourceCover.Checksum != "" {
+		if err := deleteUnreferencedCoverBlobs(ctx, u.coverRepo, u.store, sourceCover.Checksum); err != nil {
+			return models.Book{}, err
+		}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,444 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/utils"
+)
+
+const (
+	recoveryCodeCount = 10
+	totpSkewSteps     = 1
+)
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijkmnpqrstuvwxyz23456789").WithPadding(base32.NoPadding)
+
+// MFAPolicy configures TOTP enrollment and the login challenge.
+type MFAPolicy struct {
+	Issuer               string
+	ChallengeTTL         time.Duration
+	MaxChallengeAttempts int
+}
+
+// MFA manages TOTP enrollment, recovery codes and the second step of a
+// login. Secrets are sealed at rest; recovery codes and challenge tokens
+// are only stored as hashes.
+type MFA struct {
+	repo     repositories.MFARepository
+	box      *utils.SecretBox
+	policy   MFAPolicy
+	throttle *LoginThrottle
+	events   *slog.Logger
+	nowFunc  func() time.Time
+}
+
+func NewMFA(repo repositories.MFARepository, box *utils.SecretBox, policy MFAPolicy, events *slog.Logger, nowFunc func() time.Time) *MFA {
+	if policy.MaxChallengeAttempts <= 0 {
+		policy.MaxChallengeAttempts = 5
+	}
+	if events == nil {
+		events = slog.Default()
+	}
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	return &MFA{repo: repo, box: box, policy: policy, events: events, nowFunc: nowFunc}
+}
+
+// WithLoginThrottle counts wrong second-factor codes as failed logins.
+func (m *MFA) WithLoginThrottle(throttle *LoginThrottle) *MFA {
+	m.throttle = throttle
+	return m
+}
+
+// Enroll starts (or restarts) enrollment with a fresh secret. The secret is
+// only used for logins once Confirm succeeds.
+func (m *MFA) Enroll(ctx context.Context, username string) (models.MFASetup, error) {
+	enrollment, err := m.repo.FindEnrollment(ctx, username)
+	if err == nil && enrollment.Confirmed() {
+		return models.MFASetup{}, ErrMFAAlreadyEnabled
+	}
+	if err != nil && !errors.Is(err, repositories.ErrMFAEnrollmentNotFound) {
+		return models.MFASetup{}, fmt.Errorf("find mfa enrollment: %w", err)
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return models.MFASetup{}, fmt.Errorf("generate totp secret: %w", err)
+	}
+
+	sealed, err := m.box.Seal(secret)
+	if err != nil {
+		return models.MFASetup{}, fmt.Errorf("seal totp secret: %w", err)
+	}
+
+	err = m.repo.SaveEnrollment(ctx, models.MFAEnrollment{
+		Username:     username,
+		SealedSecret: sealed,
+		CreatedAt:    m.nowFunc(),
+	})
+	if err != nil {
+		return models.MFASetup{}, fmt.Errorf("save mfa enrollment: %w", err)
+	}
+
+	return models.MFASetup{Secret: secret, OTPAuthURI: utils.TOTPURI(m.policy.Issuer, username, secret)}, nil
+}
+
+// Confirm enables MFA once the user proves their authenticator works and
+// returns the one-time recovery codes.
+func (m *MFA) Confirm(ctx context.Context, username, code string) ([]string, error) {
+	enrollment, err := m.findEnrollment(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.Confirmed() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := m.box.Open(enrollment.SealedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("open totp secret: %w", err)
+	}
+
+	now := m.nowFunc()
+	step, ok := utils.ValidateTOTP(secret, code, now, totpSkewSteps)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	if err := m.repo.ConfirmEnrollment(ctx, username, now, step); err != nil {
+		return nil, fmt.Errorf("confirm mfa enrollment: %w", err)
+	}
+
+	codes, err := m.replaceRecoveryCodes(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	m.event(ctx, slog.LevelInfo, "mfa_enabled", slog.String("username", username))
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a
+// current second factor.
+func (m *MFA) RegenerateRecoveryCodes(ctx context.Context, username, code string) ([]string, error) {
+	enrollment, err := m.findConfirmedEnrollment(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.verify(ctx, enrollment, code, code); err != nil {
+		return nil, err
+	}
+
+	return m.replaceRecoveryCodes(ctx, username)
+}
+
+// Disable removes the enrollment after checking a TOTP or recovery code.
+// An unconfirmed enrollment can be dropped without one.
+func (m *MFA) Disable(ctx context.Context, username, code string) error {
+	enrollment, err := m.findEnrollment(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	if enrollment.Confirmed() {
+		if _, err := m.verify(ctx, enrollment, code, code); err != nil {
+			return err
+		}
+	}
+
+	if err := m.repo.DeleteEnrollment(ctx, username); err != nil {
+		if errors.Is(err, repositories.ErrMFAEnrollmentNotFound) {
+			return ErrMFANotEnrolled
+		}
+
+		return fmt.Errorf("delete mfa enrollment: %w", err)
+	}
+
+	m.event(ctx, slog.LevelInfo, "mfa_disabled", slog.String("username", username))
+	return nil
+}
+
+// Reauthenticate checks a TOTP or recovery code before a sensitive account
+// change. Users without a confirmed enrollment need no code.
+func (m *MFA) Reauthenticate(ctx context.Context, username, code string) error {
+	enrollment, err := m.findConfirmedEnrollment(ctx, username)
+	if err != nil {
+		if errors.Is(err, ErrMFANotEnrolled) {
+			return nil
+		}
+
+		return err
+	}
+
+	_, err = m.verify(ctx, enrollment, code, code)
+	return err
+}
+
+func (m *MFA) Status(ctx context.Context, username string) (models.MFAStatus, error) {
+	enrollment, err := m.repo.FindEnrollment(ctx, username)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMFAEnrollmentNotFound) {
+			return models.MFAStatus{}, nil
+		}
+
+		return models.MFAStatus{}, fmt.Errorf("find mfa enrollment: %w", err)
+	}
+
+	if !enrollment.Confirmed() {
+		return models.MFAStatus{Pending: true}, nil
+	}
+
+	remaining, err := m.repo.CountRecoveryCodes(ctx, username)
+	if err != nil {
+		return models.MFAStatus{}, fmt.Errorf("count recovery codes: %w", err)
+	}
+
+	return models.MFAStatus{Enabled: true, RecoveryCodesRemaining: remaining}, nil
+}
+
+// StartChallenge is called after a correct password for a user with MFA
+// enabled. ok is false when the user has no confirmed enrollment.
+func (m *MFA) StartChallenge(ctx context.Context, username string) (token string, ok bool, err error) {
+	enrollment, err := m.repo.FindEnrollment(ctx, username)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMFAEnrollmentNotFound) {
+			return "", false, nil
+		}
+
+		return "", false, fmt.Errorf("find mfa enrollment: %w", err)
+	}
+	if !enrollment.Confirmed() {
+		return "", false, nil
+	}
+
+	now := m.nowFunc()
+	if err := m.repo.DeleteExpiredChallenges(ctx, now); err != nil {
+		return "", false, fmt.Errorf("delete expired mfa challenges: %w", err)
+	}
+
+	token, err = randomToken()
+	if err != nil {
+		return "", false, fmt.Errorf("generate mfa challenge: %w", err)
+	}
+
+	err = m.repo.CreateChallenge(ctx, models.MFAChallenge{
+		TokenHash: hashToken(token),
+		Username:  username,
+		ExpiresAt: now.Add(m.policy.ChallengeTTL),
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("create mfa challenge: %w", err)
+	}
+
+	return token, true, nil
+}
+
+func (m *MFA) ChallengeTTL() time.Duration {
+	return m.policy.ChallengeTTL
+}
+
+// CompleteChallenge checks the second factor for a pending login and
+// returns the username and the amr method of the factor used. A challenge
+// is consumed on success and after MaxChallengeAttempts wrong codes.
+func (m *MFA) CompleteChallenge(ctx context.Context, token, code, recoveryCode, ip string) (string, string, error) {
+	if strings.TrimSpace(token) == "" {
+		return "", "", fmt.Errorf("%w: challenge_token is required", ErrValidation)
+	}
+	if strings.TrimSpace(code) == "" && strings.TrimSpace(recoveryCode) == "" {
+		return "", "", fmt.Errorf("%w: code or recovery_code is required", ErrValidation)
+	}
+
+	tokenHash := hashToken(token)
+	challenge, err := m.repo.FindChallenge(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMFAChallengeNotFound) {
+			return "", "", ErrInvalidMFAChallenge
+		}
+
+		return "", "", fmt.Errorf("find mfa challenge: %w", err)
+	}
+	if !m.nowFunc().Before(challenge.ExpiresAt) {
+		_ = m.repo.DeleteChallenge(ctx, tokenHash)
+		return "", "", ErrInvalidMFAChallenge
+	}
+
+	if m.throttle != nil {
+		if err := m.throttle.Check(ctx, challenge.Username, ip); err != nil {
+			return "", "", err
+		}
+	}
+
+	enrollment, err := m.findConfirmedEnrollment(ctx, challenge.Username)
+	if err != nil {
+		if errors.Is(err, ErrMFANotEnrolled) {
+			_ = m.repo.DeleteChallenge(ctx, tokenHash)
+			return "", "", ErrInvalidMFAChallenge
+		}
+
+		return "", "", err
+	}
+
+	method, err := m.verify(ctx, enrollment, code, recoveryCode)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidMFACode) {
+			return "", "", err
+		}
+
+		return "", "", m.failChallenge(ctx, challenge, ip)
+	}
+
+	if err := m.repo.DeleteChallenge(ctx, tokenHash); err != nil {
+		return "", "", fmt.Errorf("delete mfa challenge: %w", err)
+	}
+
+	if m.throttle != nil {
+		if err := m.throttle.RecordSuccess(ctx, challenge.Username); err != nil {
+			return "", "", err
+		}
+	}
+
+	if method == utils.AuthMethodRecovery {
+		m.event(ctx, slog.LevelWarn, "recovery_code_used", slog.String("username", challenge.Username), slog.String("ip", ip))
+	}
+
+	return challenge.Username, method, nil
+}
+
+func (m *MFA) failChallenge(ctx context.Context, challenge models.MFAChallenge, ip string) error {
+	attempts, err := m.repo.IncrementChallengeAttempts(ctx, challenge.TokenHash)
+	if err != nil && !errors.Is(err, repositories.ErrMFAChallengeNotFound) {
+		return fmt.Errorf("count mfa challenge attempt: %w", err)
+	}
+	if errors.Is(err, repositories.ErrMFAChallengeNotFound) || attempts >= m.policy.MaxChallengeAttempts {
+		if err := m.repo.DeleteChallenge(ctx, challenge.TokenHash); err != nil {
+			return fmt.Errorf("delete mfa challenge: %w", err)
+		}
+	}
+
+	if m.throttle != nil {
+		if err := m.throttle.RecordFailure(ctx, challenge.Username, ip); err != nil {
+			return err
+		}
+	}
+
+	m.event(ctx, slog.LevelWarn, "mfa_failed", slog.String("username", challenge.Username), slog.String("ip", ip))
+	return ErrInvalidMFACode
+}
+
+// verify accepts a TOTP code, whose time step may only be used once, or an
+// unused recovery code, and returns the matching amr method.
+func (m *MFA) verify(ctx context.Context, enrollment models.MFAEnrollment, code, recoveryCode string) (string, error) {
+	if code = strings.TrimSpace(code); code != "" {
+		secret, err := m.box.Open(enrollment.SealedSecret)
+		if err != nil {
+			return "", fmt.Errorf("open totp secret: %w", err)
+		}
+
+		if step, ok := utils.ValidateTOTP(secret, code, m.nowFunc(), totpSkewSteps); ok {
+			fresh, err := m.repo.UseStep(ctx, enrollment.Username, step)
+			if err != nil {
+				return "", fmt.Errorf("record totp step: %w", err)
+			}
+			if fresh {
+				return utils.AuthMethodOTP, nil
+			}
+		}
+	}
+
+	if recoveryCode = normalizeRecoveryCode(recoveryCode); recoveryCode != "" {
+		used, err := m.repo.UseRecoveryCode(ctx, enrollment.Username, hashToken(recoveryCode), m.nowFunc())
+		if err != nil {
+			return "", fmt.Errorf("use recovery code: %w", err)
+		}
+		if used {
+			return utils.AuthMethodRecovery, nil
+		}
+	}
+
+	return "", ErrInvalidMFACode
+}
+
+func (m *MFA) findEnrollment(ctx context.Context, username string) (models.MFAEnrollment, error) {
+	enrollment, err := m.repo.FindEnrollment(ctx, username)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMFAEnrollmentNotFound) {
+			return models.MFAEnrollment{}, ErrMFANotEnrolled
+		}
+
+		return models.MFAEnrollment{}, fmt.Errorf("find mfa enrollment: %w", err)
+	}
+
+	return enrollment, nil
+}
+
+func (m *MFA) findConfirmedEnrollment(ctx context.Context, username string) (models.MFAEnrollment, error) {
+	enrollment, err := m.findEnrollment(ctx, username)
+	if err != nil {
+		return models.MFAEnrollment{}, err
+	}
+	if !enrollment.Confirmed() {
+		return models.MFAEnrollment{}, ErrMFANotEnrolled
+	}
+
+	return enrollment, nil
+}
+
+func (m *MFA) replaceRecoveryCodes(ctx context.Context, username string) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+
+		encoded := recoveryCodeEncoding.EncodeToString(raw)
+		codes[i] = encoded[:4] + "-" + encoded[4:]
+		hashes[i] = hashToken(encoded)
+	}
+
+	if err := m.repo.ReplaceRecoveryCodes(ctx, username, hashes); err != nil {
+		return nil, fmt.Errorf("save recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (m *MFA) event(ctx context.Context, level slog.Level, name string, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{slog.String("event", "auth."+name)}, attrs...)
+	m.events.LogAttrs(ctx, level, "security event", attrs...)
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func randomToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,373 @@
+package usecases
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RFC 6749 section 5.2 error codes.
+const (
+	OAuthInvalidRequest       = "invalid_request"
+	OAuthInvalidClient        = "invalid_client"
+	OAuthInvalidGrant         = "invalid_grant"
+	OAuthUnauthorizedClient   = "unauthorized_client"
+	OAuthUnsupportedGrantType = "unsupported_grant_type"
+	OAuthInvalidScope         = "invalid_scope"
+)
+
+// OAuthError is an error returned to OAuth clients as-is.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+
+	return e.Code + ": " + e.Description
+}
+
+// OAuthServer registers clients and issues and introspects access tokens.
+// Tokens are the same JWTs /auth/token issues, with scope and client_id
+// claims added.
+type OAuthServer struct {
+	repo      repositories.OAuthClientRepository
+	jwtSecret string
+	tokenTTL  time.Duration
+	throttle  *LoginThrottle
+	mfa       *MFA
+	creds     *Credentials
+	sessions  *Sessions
+	nowFunc   func() time.Time
+}
+
+func NewOAuthServer(repo repositories.OAuthClientRepository, jwtSecret string, tokenTTL time.Duration, nowFunc func() time.Time) *OAuthServer {
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	return &OAuthServer{repo: repo, jwtSecret: jwtSecret, tokenTTL: tokenTTL, nowFunc: nowFunc}
+}
+
+// WithLoginThrottle applies login lockouts to the password grant.
+func (s *OAuthServer) WithLoginThrottle(throttle *LoginThrottle) *OAuthServer {
+	s.throttle = throttle
+	return s
+}
+
+// WithCredentials checks the password grant against stored hashes.
+func (s *OAuthServer) WithCredentials(creds *Credentials) *OAuthServer {
+	s.creds = creds
+	return s
+}
+
+// WithMFA refuses the password grant for users with MFA enabled, since the
+// grant has no step for a second factor.
+func (s *OAuthServer) WithMFA(mfa *MFA) *OAuthServer {
+	s.mfa = mfa
+	return s
+}
+
+// WithSessions records a session for every access token and reports
+// revoked ones as inactive on introspection.
+func (s *OAuthServer) WithSessions(sessions *Sessions) *OAuthServer {
+	s.sessions = sessions
+	return s
+}
+
+// RegisterClient creates a client and returns it with its secret, which is
+// not stored and cannot be shown again.
+func (s *OAuthServer) RegisterClient(ctx context.Context, req models.CreateOAuthClientRequest) (models.OAuthClient, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return models.OAuthClient{}, "", fmt.Errorf("%w: name is required", ErrValidation)
+	}
+	if len(req.Scopes) == 0 {
+		return models.OAuthClient{}, "", fmt.Errorf("%w: scopes is required", ErrValidation)
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(models.OAuthScopes, scope) {
+			return models.OAuthClient{}, "", fmt.Errorf("%w: unknown scope %q", ErrValidation, scope)
+		}
+	}
+
+	grantTypes := req.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = []string{models.GrantTypeClientCredentials}
+	}
+	for _, grant := range grantTypes {
+		if grant != models.GrantTypeClientCredentials && grant != models.GrantTypePassword {
+			return models.OAuthClient{}, "", fmt.Errorf("%w: unsupported grant type %q", ErrValidation, grant)
+		}
+	}
+
+	clientID, err := randomToken()
+	if err != nil {
+		return models.OAuthClient{}, "", fmt.Errorf("generate client id: %w", err)
+	}
+	secret, err := randomToken()
+	if err != nil {
+		return models.OAuthClient{}, "", fmt.Errorf("generate client secret: %w", err)
+	}
+
+	client := models.OAuthClient{
+		ClientID:   clientID[:22],
+		SecretHash: hashToken(secret),
+		Name:       name,
+		Scopes:     compactScopes(req.Scopes),
+		GrantTypes: compactScopes(grantTypes),
+		CreatedAt:  s.nowFunc().UTC(),
+	}
+	if err := s.repo.Create(ctx, client); err != nil {
+		return models.OAuthClient{}, "", fmt.Errorf("create oauth client: %w", err)
+	}
+
+	return client, secret, nil
+}
+
+func (s *OAuthServer) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	clients, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list oauth clients: %w", err)
+	}
+
+	return clients, nil
+}
+
+func (s *OAuthServer) DeleteClient(ctx context.Context, clientID string) error {
+	if err := s.repo.Delete(ctx, clientID); err != nil {
+		if errors.Is(err, repositories.ErrOAuthClientNotFound) {
+			return ErrOAuthClientNotFound
+		}
+
+		return fmt.Errorf("delete oauth client: %w", err)
+	}
+
+	return nil
+}
+
+// AuthenticateClient checks a client's credentials and returns an
+// invalid_client *OAuthError when they do not match.
+func (s *OAuthServer) AuthenticateClient(ctx context.Context, clientID, secret string) (models.OAuthClient, error) {
+	if clientID == "" || secret == "" {
+		return models.OAuthClient{}, &OAuthError{Code: OAuthInvalidClient, Description: "client authentication is required"}
+	}
+
+	client, err := s.repo.FindByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrOAuthClientNotFound) {
+			return models.OAuthClient{}, &OAuthError{Code: OAuthInvalidClient, Description: "client authentication failed"}
+		}
+
+		return models.OAuthClient{}, fmt.Errorf("find oauth client: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return models.OAuthClient{}, &OAuthError{Code: OAuthInvalidClient, Description: "client authentication failed"}
+	}
+
+	return client, nil
+}
+
+func (s *OAuthServer) Token(ctx context.Context, req models.OAuthTokenRequest) (models.OAuthToken, error) {
+	if req.GrantType == "" {
+		return models.OAuthToken{}, &OAuthError{Code: OAuthInvalidRequest, Description: "grant_type is required"}
+	}
+	if req.GrantType != models.GrantTypeClientCredentials && req.GrantType != models.GrantTypePassword {
+		return models.OAuthToken{}, &OAuthError{Code: OAuthUnsupportedGrantType}
+	}
+
+	client, err := s.AuthenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return models.OAuthToken{}, err
+	}
+	if !client.AllowsGrant(req.GrantType) {
+		return models.OAuthToken{}, &OAuthError{Code: OAuthUnauthorizedClient, Description: "client may not use the " + req.GrantType + " grant"}
+	}
+
+	scopes, err := grantedScopes(client, req.Scope)
+	if err != nil {
+		return models.OAuthToken{}, err
+	}
+
+	subject := models.OAuthClientSubject(client.ClientID)
+	var authMethods []string
+	if req.GrantType == models.GrantTypePassword {
+		if err := s.checkPassword(ctx, req); err != nil {
+			return models.OAuthToken{}, err
+		}
+
+		subject = req.Username
+		authMethods = []string{utils.AuthMethodPassword}
+	}
+
+	var sessionID string
+	if s.sessions != nil {
+		session, err := s.sessions.Start(ctx, models.Session{
+			Subject:     subject,
+			ClientID:    client.ClientID,
+			AuthMethods: authMethods,
+			IP:          req.ClientIP,
+			UserAgent:   req.UserAgent,
+		}, s.tokenTTL)
+		if err != nil {
+			return models.OAuthToken{}, err
+		}
+
+		sessionID = session.ID
+	}
+
+	now := s.nowFunc()
+	scope := strings.Join(scopes, " ")
+	token, err := utils.SignToken(utils.TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.tokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		AuthMethods: authMethods,
+		Scope:       scope,
+		ClientID:    client.ClientID,
+	}, s.jwtSecret)
+	if err != nil {
+		return models.OAuthToken{}, fmt.Errorf("sign access token: %w", err)
+	}
+
+	return models.OAuthToken{AccessToken: token, ExpiresIn: s.tokenTTL, Scope: scope}, nil
+}
+
+// Introspect reports whether token is an active token issued by this API.
+// The caller must be an authenticated client.
+func (s *OAuthServer) Introspect(ctx context.Context, clientID, secret, token string) (models.OAuthIntrospection, error) {
+	if _, err := s.AuthenticateClient(ctx, clientID, secret); err != nil {
+		return models.OAuthIntrospection{}, err
+	}
+	if strings.TrimSpace(token) == "" {
+		return models.OAuthIntrospection{}, &OAuthError{Code: OAuthInvalidRequest, Description: "token is required"}
+	}
+
+	claims, err := utils.ParseToken(token, s.jwtSecret)
+	if err != nil {
+		return models.OAuthIntrospection{Active: false}, nil
+	}
+	if s.sessions != nil {
+		if claims.ID == "" {
+			return models.OAuthIntrospection{Active: false}, nil
+		}
+
+		active, err := s.sessions.Active(ctx, claims.ID)
+		if err != nil {
+			return models.OAuthIntrospection{}, err
+		}
+		if !active {
+			return models.OAuthIntrospection{Active: false}, nil
+		}
+	}
+
+	introspection := models.OAuthIntrospection{
+		Active:   true,
+		Scope:    claims.Scope,
+		ClientID: claims.ClientID,
+		Subject:  claims.Subject,
+	}
+	if claims.ExpiresAt != nil {
+		introspection.ExpiresAt = claims.ExpiresAt.Time
+	}
+	if claims.IssuedAt != nil {
+		introspection.IssuedAt = claims.IssuedAt.Time
+	}
+
+	return introspection, nil
+}
+
+func (s *OAuthServer) checkPassword(ctx context.Context, req models.OAuthTokenRequest) error {
+	if req.Username == "" || req.Password == "" {
+		return &OAuthError{Code: OAuthInvalidRequest, Description: "username and password are required"}
+	}
+
+	if s.throttle != nil {
+		if err := s.throttle.Check(ctx, req.Username, req.ClientIP); err != nil {
+			return err
+		}
+	}
+
+	valid := CheckPassword(req.Username, req.Password)
+	if s.creds != nil {
+		var err error
+		if valid, err = s.creds.Check(ctx, req.Username, req.Password); err != nil {
+			return err
+		}
+	}
+
+	if !valid {
+		if s.throttle != nil {
+			if err := s.throttle.RecordFailure(ctx, req.Username, req.ClientIP); err != nil {
+				return err
+			}
+		}
+
+		return &OAuthError{Code: OAuthInvalidGrant, Description: "invalid username or password"}
+	}
+
+	if s.mfa != nil {
+		status, err := s.mfa.Status(ctx, req.Username)
+		if err != nil {
+			return err
+		}
+		if status.Enabled {
+			return &OAuthError{Code: OAuthInvalidGrant, Description: "multi-factor authentication is required; use /auth/token"}
+		}
+	}
+
+	if s.throttle != nil {
+		return s.throttle.RecordSuccess(ctx, req.Username)
+	}
+
+	return nil
+}
+
+// grantedScopes resolves the space-separated scope parameter. An empty
+// request is granted every scope the client is registered for.
+func grantedScopes(client models.OAuthClient, requested string) ([]string, error) {
+	scopes := strings.Fields(requested)
+	if len(scopes) == 0 {
+		return client.Scopes, nil
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(client.Scopes, scope) {
+			return nil, &OAuthError{Code: OAuthInvalidScope, Description: "scope " + scope + " is not allowed for this client"}
+		}
+	}
+
+	return compactScopes(scopes), nil
+}
+
+func compactScopes(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if _, ok := seen[value]; ok {
+			continue
+		}
+
+		seen[value] = struct{}{}
+		result = append(result, value)
+	}
+
+	return result
+}
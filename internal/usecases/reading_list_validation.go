@@ -0,0 +1,82 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+)
+
+func parseReadingListID(rawID string) (int64, error) {
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrReadingListNotFound
+	}
+
+	return id, nil
+}
+
+func validateReadingListRequest(req models.CreateReadingListRequest) (models.ReadingList, error) {
+	name := strings.TrimSpace(req.Name)
+	description := strings.TrimSpace(req.Description)
+	visibility := strings.ToLower(strings.TrimSpace(req.Visibility))
+
+	if name == "" {
+		return models.ReadingList{}, fmt.Errorf("%w: name is required", ErrValidation)
+	}
+
+	if len(name) > 100 {
+		return models.ReadingList{}, fmt.Errorf("%w: name must be at most 100 characters", ErrValidation)
+	}
+
+	if len(description) > 1000 {
+		return models.ReadingList{}, fmt.Errorf("%w: description must be at most 1000 characters", ErrValidation)
+	}
+
+	switch visibility {
+	case "":
+		visibility = models.ReadingListVisibilityPrivate
+	case models.ReadingListVisibilityPrivate, models.ReadingListVisibilityUnlisted, models.ReadingListVisibilityPublic:
+	default:
+		return models.ReadingList{}, fmt.Errorf("%w: visibility must be one of private, unlisted, public", ErrValidation)
+	}
+
+	return models.ReadingList{
+		Name:        name,
+		Description: description,
+		Visibility:  visibility,
+	}, nil
+}
+
+func canViewReadingList(list models.ReadingList, viewerID string) bool {
+	if viewerID != "" && list.OwnerID == viewerID {
+		return true
+	}
+
+	return list.Visibility != models.ReadingListVisibilityPrivate
+}
+
+func findOwnedReadingList(ctx context.Context, repo repositories.ReadingListRepository, ownerID string, id int64) (models.ReadingList, error) {
+	list, err := repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrReadingListNotFound) {
+			return models.ReadingList{}, ErrReadingListNotFound
+		}
+
+		return models.ReadingList{}, fmt.Errorf("get reading list: %w", err)
+	}
+
+	if list.OwnerID != ownerID {
+		if canViewReadingList(list, ownerID) {
+			return models.ReadingList{}, ErrReadingListForbidden
+		}
+
+		return models.ReadingList{}, ErrReadingListNotFound
+	}
+
+	return list, nil
+}
@@ -0,0 +1,52 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type RemoveReadingListEntryUsecase struct {
+	repo repositories.ReadingListRepository
+}
+
+func NewRemoveReadingListEntryUsecase(repo repositories.ReadingListRepository) *RemoveReadingListEntryUsecase {
+	return &RemoveReadingListEntryUsecase{repo: repo}
+}
+
+func (u *RemoveReadingListEntryUsecase) Execute(ctx context.Context, ownerID, rawID, rawBookID string) error {
+	ctx, span := tracing.Start(ctx, "RemoveReadingListEntryUsecase.Execute")
+	defer span.End()
+
+	id, err := parseReadingListID(rawID)
+	if err != nil {
+		return err
+	}
+
+	bookID, err := strconv.ParseInt(rawBookID, 10, 64)
+	if err != nil || bookID <= 0 {
+		return ErrReadingListEntryNotFound
+	}
+
+	if _, err := findOwnedReadingList(ctx, u.repo, ownerID, id); err != nil {
+		return err
+	}
+
+	if err := u.repo.RemoveEntry(ctx, id, bookID, time.Now().UTC()); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrReadingListEntryNotFound):
+			return ErrReadingListEntryNotFound
+		case errors.Is(err, repositories.ErrReadingListNotFound):
+			return ErrReadingListNotFound
+		default:
+			return fmt.Errorf("remove reading list entry: %w", err)
+		}
+	}
+
+	return nil
+}
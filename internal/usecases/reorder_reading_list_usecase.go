@@ -0,0 +1,62 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type ReorderReadingListUsecase struct {
+	repo repositories.ReadingListRepository
+}
+
+func NewReorderReadingListUsecase(repo repositories.ReadingListRepository) *ReorderReadingListUsecase {
+	return &ReorderReadingListUsecase{repo: repo}
+}
+
+func (u *ReorderReadingListUsecase) Execute(ctx context.Context, ownerID, rawID string, req models.ReorderReadingListRequest) (models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "ReorderReadingListUsecase.Execute")
+	defer span.End()
+
+	id, err := parseReadingListID(rawID)
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+
+	list, err := findOwnedReadingList(ctx, u.repo, ownerID, id)
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+
+	if len(req.BookIDs) != len(list.Entries) {
+		return models.ReadingList{}, fmt.Errorf("%w: book_ids must list every book in the reading list exactly once", ErrValidation)
+	}
+
+	current := make(map[int64]bool, len(list.Entries))
+	for _, entry := range list.Entries {
+		current[entry.Book.ID] = true
+	}
+
+	seen := make(map[int64]bool, len(req.BookIDs))
+	for _, bookID := range req.BookIDs {
+		if !current[bookID] || seen[bookID] {
+			return models.ReadingList{}, fmt.Errorf("%w: book_ids must list every book in the reading list exactly once", ErrValidation)
+		}
+		seen[bookID] = true
+	}
+
+	if err := u.repo.ReorderEntries(ctx, id, req.BookIDs, time.Now().UTC()); err != nil {
+		if errors.Is(err, repositories.ErrReadingListNotFound) {
+			return models.ReadingList{}, ErrReadingListNotFound
+		}
+
+		return models.ReadingList{}, fmt.Errorf("reorder reading list: %w", err)
+	}
+
+	return findOwnedReadingList(ctx, u.repo, ownerID, id)
+}
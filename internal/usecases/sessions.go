@@ -0,0 +1,175 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+)
+
+// sessionTouchInterval limits how often a session's last-seen time is
+// written, so busy clients do not cost a write per request.
+const sessionTouchInterval = time.Minute
+
+const maxUserAgentLength = 512
+
+// Sessions tracks the tokens this API issues so users can see where they
+// are logged in and revoke a token before it expires.
+type Sessions struct {
+	repo    repositories.SessionRepository
+	events  *slog.Logger
+	nowFunc func() time.Time
+}
+
+func NewSessions(repo repositories.SessionRepository, events *slog.Logger, nowFunc func() time.Time) *Sessions {
+	if events == nil {
+		events = slog.Default()
+	}
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	return &Sessions{repo: repo, events: events, nowFunc: nowFunc}
+}
+
+// Start records a session for a token valid for ttl and returns it with the
+// ID to put in the token's jti claim. Subject, ClientID, AuthMethods, IP and
+// UserAgent are taken from session.
+func (s *Sessions) Start(ctx context.Context, session models.Session, ttl time.Duration) (models.Session, error) {
+	id, err := randomToken()
+	if err != nil {
+		return models.Session{}, fmt.Errorf("generate session id: %w", err)
+	}
+
+	now := s.nowFunc()
+	if err := s.repo.DeleteExpired(ctx, now); err != nil {
+		return models.Session{}, fmt.Errorf("delete expired sessions: %w", err)
+	}
+
+	session.ID = id[:22]
+	session.LastIP = session.IP
+	if len(session.UserAgent) > maxUserAgentLength {
+		session.UserAgent = session.UserAgent[:maxUserAgentLength]
+	}
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(ttl)
+	session.RevokedAt = time.Time{}
+
+	if err := s.repo.Create(ctx, session); err != nil {
+		return models.Session{}, fmt.Errorf("create session: %w", err)
+	}
+
+	return session, nil
+}
+
+// Validate rejects the principal's token if its session was revoked and
+// otherwise records that it was used from ip. Tokens from external issuers
+// have no session and are accepted as they are; a token of this API
+// without one cannot be revoked and is rejected.
+func (s *Sessions) Validate(ctx context.Context, principal models.Principal, ip string) error {
+	if principal.SessionID == "" {
+		if principal.Issuer != "" {
+			return nil
+		}
+
+		return ErrSessionRequired
+	}
+
+	session, err := s.active(ctx, principal.SessionID)
+	if err != nil {
+		return err
+	}
+	if session.Subject != principal.Subject {
+		return ErrSessionRevoked
+	}
+
+	now := s.nowFunc()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval && ip == session.LastIP {
+		return nil
+	}
+
+	if err := s.repo.Touch(ctx, session.ID, ip, now); err != nil && !errors.Is(err, repositories.ErrSessionNotFound) {
+		return fmt.Errorf("touch session: %w", err)
+	}
+
+	return nil
+}
+
+// Active reports whether the session with id can still be used.
+func (s *Sessions) Active(ctx context.Context, id string) (bool, error) {
+	if _, err := s.active(ctx, id); err != nil {
+		if errors.Is(err, ErrSessionRevoked) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *Sessions) List(ctx context.Context, subject string) ([]models.Session, error) {
+	sessions, err := s.repo.ListActive(ctx, subject, s.nowFunc())
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// Revoke ends one of subject's sessions. Requests with its token are
+// rejected from then on.
+func (s *Sessions) Revoke(ctx context.Context, subject, id string) error {
+	if err := s.repo.Revoke(ctx, id, subject, s.nowFunc()); err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+
+		return fmt.Errorf("revoke session: %w", err)
+	}
+
+	s.events.LogAttrs(ctx, slog.LevelInfo, "security event",
+		slog.String("event", "auth.session_revoked"),
+		slog.String("subject", subject),
+		slog.String("session_id", id),
+	)
+	return nil
+}
+
+// RevokeAll ends every session of subject, for example after its password
+// was reset.
+func (s *Sessions) RevokeAll(ctx context.Context, subject string) error {
+	revoked, err := s.repo.RevokeAll(ctx, subject, s.nowFunc())
+	if err != nil {
+		return fmt.Errorf("revoke sessions: %w", err)
+	}
+
+	s.events.LogAttrs(ctx, slog.LevelInfo, "security event",
+		slog.String("event", "auth.sessions_revoked"),
+		slog.String("subject", subject),
+		slog.Int64("sessions", revoked),
+	)
+	return nil
+}
+
+func (s *Sessions) active(ctx context.Context, id string) (models.Session, error) {
+	session, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			return models.Session{}, ErrSessionRevoked
+		}
+
+		return models.Session{}, fmt.Errorf("find session: %w", err)
+	}
+
+	if session.Revoked() || !s.nowFunc().Before(session.ExpiresAt) {
+		return models.Session{}, ErrSessionRevoked
+	}
+
+	return session, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"desent-api/internal/models"
 	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
 )
 
 type UpdateBookUsecase struct {
@@ -18,6 +19,9 @@ func NewUpdateBookUsecase(repo repositories.BookRepository) *UpdateBookUsecase {
 }
 
 func (u *UpdateBookUsecase) Execute(ctx context.Context, rawID string, req models.CreateBookRequest) (models.Book, error) {
+	ctx, span := tracing.Start(ctx, "UpdateBookUsecase.Execute")
+	defer span.End()
+
 	id, err := parseBookID(rawID)
 	if err != nil {
 		return models.Book{}, err
@@ -34,6 +38,10 @@ func (u *UpdateBookUsecase) Execute(ctx context.Context, rawID string, req model
 			return models.Book{}, ErrBookNotFound
 		}
 
+		if referenceErr := mapBookReferenceError(err); referenceErr != nil {
+			return models.Book{}, referenceErr
+		}
+
 		return models.Book{}, fmt.Errorf("update book: %w", err)
 	}
 
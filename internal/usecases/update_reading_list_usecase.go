@@ -0,0 +1,51 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/tracing"
+)
+
+type UpdateReadingListUsecase struct {
+	repo repositories.ReadingListRepository
+}
+
+func NewUpdateReadingListUsecase(repo repositories.ReadingListRepository) *UpdateReadingListUsecase {
+	return &UpdateReadingListUsecase{repo: repo}
+}
+
+func (u *UpdateReadingListUsecase) Execute(ctx context.Context, ownerID, rawID string, req models.CreateReadingListRequest) (models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "UpdateReadingListUsecase.Execute")
+	defer span.End()
+
+	id, err := parseReadingListID(rawID)
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+
+	list, err := validateReadingListRequest(req)
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+
+	if _, err := findOwnedReadingList(ctx, u.repo, ownerID, id); err != nil {
+		return models.ReadingList{}, err
+	}
+
+	list.UpdatedAt = time.Now().UTC()
+	updated, err := u.repo.UpdateByID(ctx, id, list)
+	if err != nil {
+		if errors.Is(err, repositories.ErrReadingListNotFound) {
+			return models.ReadingList{}, ErrReadingListNotFound
+		}
+
+		return models.ReadingList{}, fmt.Errorf("update reading list: %w", err)
+	}
+
+	return updated, nil
+}
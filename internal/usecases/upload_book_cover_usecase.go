@@ -0,0 +1,131 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"desent-api/internal/models"
+	"desent-api/internal/repositories"
+	"desent-api/internal/storage"
+	"desent-api/internal/tracing"
+)
+
+type UploadBookCoverUsecase struct {
+	bookRepo  repositories.BookRepository
+	coverRepo repositories.BookCoverRepository
+	store     storage.BlobStore
+}
+
+func NewUploadBookCoverUsecase(bookRepo repositories.BookRepository, coverRepo repositories.BookCoverRepository, store storage.BlobStore) *UploadBookCoverUsecase {
+	return &UploadBookCoverUsecase{bookRepo: bookRepo, coverRepo: coverRepo, store: store}
+}
+
+func (u *UploadBookCoverUsecase) Execute(ctx context.Context, rawID string, data []byte) (models.BookCover, error) {
+	ctx, span := tracing.Start(ctx, "UploadBookCoverUsecase.Execute")
+	defer span.End()
+
+	id, err := parseBookID(rawID)
+	if err != nil {
+		return models.BookCover{}, err
+	}
+
+	if _, err := u.bookRepo.FindByID(ctx, id); err != nil {
+		if errors.Is(err, repositories.ErrBookNotFound) {
+			return models.BookCover{}, ErrBookNotFound
+		}
+
+		return models.BookCover{}, fmt.Errorf("get book: %w", err)
+	}
+
+	if len(data) == 0 {
+		return models.BookCover{}, fmt.Errorf("%w: cover image is required", ErrValidation)
+	}
+
+	img, contentType, err := decodeCover(data)
+	if err != nil {
+		return models.BookCover{}, err
+	}
+
+	checksum := coverChecksum(data)
+	// The blobs are written before the row that references them. If the
+	// row is never saved they are removed again, unless another book
+	// already shares the image.
+	discard := func(err error) (models.BookCover, error) {
+		if cleanupErr := deleteUnreferencedCoverBlobs(context.WithoutCancel(ctx), u.coverRepo, u.store, checksum); cleanupErr != nil {
+			return models.BookCover{}, errors.Join(err, cleanupErr)
+		}
+
+		return models.BookCover{}, err
+	}
+
+	if err := u.store.Put(ctx, coverBlobKey(checksum, models.CoverSizeOriginal), bytes.NewReader(data)); err != nil {
+		return discard(fmt.Errorf("store cover: %w", err))
+	}
+
+	for size, box := range models.CoverSizes {
+		thumb, err := thumbnail(img, box)
+		if err != nil {
+			return discard(fmt.Errorf("generate %s thumbnail: %w", size, err))
+		}
+
+		if err := u.store.Put(ctx, coverBlobKey(checksum, size), bytes.NewReader(thumb)); err != nil {
+			return discard(fmt.Errorf("store %s thumbnail: %w", size, err))
+		}
+	}
+
+	previous, err := u.coverRepo.FindByBookID(ctx, id)
+	if err != nil && !errors.Is(err, repositories.ErrBookCoverNotFound) {
+		return discard(fmt.Errorf("get cover: %w", err))
+	}
+
+	bounds := img.Bounds()
+	cover := models.BookCover{
+		BookID:      id,
+		Checksum:    checksum,
+		ContentType: contentType,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		SizeBytes:   int64(len(data)),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if err := u.coverRepo.Upsert(ctx, cover); err != nil {
+		return discard(fmt.Errorf("save cover: %w", err))
+	}
+
+	if previous.Checksum != "" && previous.Checksum != checksum {
+		if err := deleteUnreferencedCoverBlobs(ctx, u.coverRepo, u.store, previous.Checksum); err != nil {
+			return models.BookCover{}, err
+		}
+	}
+
+	return cover, nil
+}
+
+// deleteUnreferencedCoverBlobs removes stored images once no book points at
+// them; blobs are keyed by checksum so identical uploads share storage.
+func deleteUnreferencedCoverBlobs(ctx context.Context, coverRepo repositories.BookCoverRepository, store storage.BlobStore, checksum string) error {
+	references, err := coverRepo.CountByChecksum(ctx, checksum)
+	if err != nil {
+		return fmt.Errorf("count cover references: %w", err)
+	}
+
+	if references > 0 {
+		return nil
+	}
+
+	sizes := []string{models.CoverSizeOriginal}
+	for size := range models.CoverSizes {
+		sizes = append(sizes, size)
+	}
+
+	for _, size := range sizes {
+		if err := store.Delete(ctx, coverBlobKey(checksum, size)); err != nil {
+			return fmt.Errorf("delete cover blob: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const asyncWriterBufferBytes = 64 << 10
+
+// AsyncWriter moves writes off the caller's goroutine. Each Write is queued
+// as one entry and a background goroutine writes entries through a buffer
+// that is flushed whenever the queue runs empty, so bursts turn into few
+// large writes. When the queue is full entries are dropped rather than
+// blocking the caller.
+type AsyncWriter struct {
+	entries chan []byte
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex
+	closed bool
+	err    error
+}
+
+// NewAsyncWriter queues up to queueSize entries for w.
+func NewAsyncWriter(w io.Writer, queueSize int) *AsyncWriter {
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+
+	a := &AsyncWriter{
+		entries: make(chan []byte, queueSize),
+		done:    make(chan struct{}),
+	}
+	go a.run(bufio.NewWriterSize(w, asyncWriterBufferBytes))
+
+	return a
+}
+
+// Write queues a copy of p and never fails; a full queue or a closed
+// writer drops the entry.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		a.dropped.Add(1)
+		return len(p), nil
+	}
+
+	select {
+	case a.entries <- append([]byte(nil), p...):
+	default:
+		a.dropped.Add(1)
+	}
+
+	return len(p), nil
+}
+
+// Dropped reports how many entries were discarded.
+func (a *AsyncWriter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Close writes the queued entries and stops the background goroutine. It
+// returns the first write error seen. It does not close the wrapped writer.
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.entries)
+	}
+	a.mu.Unlock()
+
+	<-a.done
+	return a.err
+}
+
+func (a *AsyncWriter) run(w *bufio.Writer) {
+	defer close(a.done)
+
+	for entry := range a.entries {
+		if _, err := w.Write(entry); err != nil && a.err == nil {
+			a.err = err
+		}
+		if len(a.entries) == 0 {
+			if err := w.Flush(); err != nil && a.err == nil {
+				a.err = err
+			}
+		}
+	}
+
+	if err := w.Flush(); err != nil && a.err == nil {
+		a.err = err
+	}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"desent-api/configs"
+	"desent-api/internal/dbinstrument"
+)
+
+// OpenDatabase opens and pings the configured database, creating the parent
+// directory of file-backed SQLite databases first. Every statement is
+// reported to observers.
+func OpenDatabase(cfg configs.DatabaseConfig, observers ...dbinstrument.Observer) (*sql.DB, error) {
+	if cfg.Driver == "sqlite" {
+		if err := ensureSQLiteDir(cfg.DSN); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := dbinstrument.Open(cfg.Driver, cfg.DSN, observers...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func ensureSQLiteDir(dsn string) error {
+	if !strings.HasPrefix(dsn, "file:") {
+		return nil
+	}
+
+	filePart := strings.TrimPrefix(dsn, "file:")
+	filePath := strings.SplitN(filePart, "?", 2)[0]
+	if filePath == "" || filePath == ":memory:" {
+		return nil
+	}
+
+	dir := filepath.Dir(filePath)
+	if dir == "." || dir == "" {
+		return nil
+	}
+
+	return os.MkdirAll(dir, 0o755)
+}
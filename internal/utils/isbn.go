@@ -0,0 +1,84 @@
+package utils
+
+import "strings"
+
+// NormalizeISBN strips separators and returns the ISBN-13 form of a valid
+// ISBN-10 or ISBN-13, so the same edition always compares equal.
+func NormalizeISBN(raw string) (string, bool) {
+	cleaned := strings.Builder{}
+	for _, r := range strings.ToUpper(raw) {
+		switch {
+		case r >= '0' && r <= '9', r == 'X':
+			cleaned.WriteRune(r)
+		case r == '-' || r == ' ':
+		default:
+			return "", false
+		}
+	}
+
+	isbn := cleaned.String()
+	switch len(isbn) {
+	case 10:
+		if !validISBN10(isbn) {
+			return "", false
+		}
+		return isbn10To13(isbn), true
+	case 13:
+		if !validISBN13(isbn) {
+			return "", false
+		}
+		return isbn, true
+	default:
+		return "", false
+	}
+}
+
+func validISBN10(isbn string) bool {
+	sum := 0
+	for i := 0; i < 10; i++ {
+		var digit int
+		switch {
+		case isbn[i] == 'X' && i == 9:
+			digit = 10
+		case isbn[i] >= '0' && isbn[i] <= '9':
+			digit = int(isbn[i] - '0')
+		default:
+			return false
+		}
+		sum += digit * (10 - i)
+	}
+
+	return sum%11 == 0
+}
+
+func validISBN13(isbn string) bool {
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if isbn[i] < '0' || isbn[i] > '9' {
+			return false
+		}
+
+		digit := int(isbn[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	return sum%10 == 0
+}
+
+func isbn10To13(isbn10 string) string {
+	body := "978" + isbn10[:9]
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(body[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	check := (10 - sum%10) % 10
+	return body + string(rune('0'+check))
+}
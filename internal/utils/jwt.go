@@ -9,19 +9,55 @@ import (
 
 var ErrInvalidToken = errors.New("invalid token")
 
-func GenerateToken(username, secret string, ttl time.Duration) (string, error) {
-	claims := jwt.RegisteredClaims{
-		Subject:   username,
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+// Authentication method references (RFC 8176) recorded in the amr claim.
+const (
+	AuthMethodPassword = "pwd"
+	AuthMethodOTP      = "otp"
+	AuthMethodRecovery = "mfa"
+)
+
+type TokenClaims struct {
+	jwt.RegisteredClaims
+	AuthMethods []string `json:"amr,omitempty"`
+	// Scope and ClientID are set on tokens issued through /oauth/token
+	// (RFC 9068). A token without a scope is a first-party token.
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+}
+
+func GenerateToken(username, secret string, ttl time.Duration, authMethods ...string) (string, error) {
+	return GenerateSessionToken(username, "", secret, ttl, authMethods...)
+}
+
+// GenerateSessionToken is GenerateToken for a tracked session; sessionID
+// becomes the jti claim.
+func GenerateSessionToken(username, sessionID, secret string, ttl time.Duration, authMethods ...string) (string, error) {
+	claims := TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
+			Subject:   username,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		AuthMethods: authMethods,
 	}
 
+	return SignToken(claims, secret)
+}
+
+func SignToken(claims TokenClaims, secret string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secret))
 }
 
 func ValidateToken(tokenString, secret string) error {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
+	_, err := ParseToken(tokenString, secret)
+	return err
+}
+
+func ParseToken(tokenString, secret string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
@@ -29,12 +65,12 @@ func ValidateToken(tokenString, secret string) error {
 		return []byte(secret), nil
 	})
 	if err != nil {
-		return ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
-	if !token.Valid {
-		return ErrInvalidToken
+	if !token.Valid || claims.Subject == "" {
+		return nil, ErrInvalidToken
 	}
 
-	return nil
+	return claims, nil
 }
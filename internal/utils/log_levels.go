@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Components with their own adjustable log level.
+const (
+	LogComponentApp    = "app"
+	LogComponentHTTP   = "http"
+	LogComponentDB     = "db"
+	LogComponentAuth   = "auth"
+	LogComponentWorker = "worker"
+)
+
+var (
+	ErrUnknownLogComponent = errors.New("unknown log component")
+	ErrInvalidLogLevel     = errors.New("invalid log level")
+)
+
+// defaultLogLevels keeps the access log, the application's own entries and
+// security events at Info and the rest quiet except for slow queries and
+// failures.
+var defaultLogLevels = map[string]slog.Level{
+	LogComponentApp:    slog.LevelInfo,
+	LogComponentHTTP:   slog.LevelInfo,
+	LogComponentDB:     slog.LevelWarn,
+	LogComponentAuth:   slog.LevelInfo,
+	LogComponentWorker: slog.LevelError,
+}
+
+// LogLevels holds one slog.LevelVar per component, so loggers built on them
+// pick up changes immediately. Changes are serialised so a reload and an
+// admin change cannot interleave.
+type LogLevels struct {
+	mu       sync.Mutex
+	vars     map[string]*slog.LevelVar
+	defaults map[string]slog.Level
+}
+
+// NewLogLevels starts every component at its built-in level, overridden by
+// overrides.
+func NewLogLevels(overrides map[string]slog.Level) *LogLevels {
+	levels := &LogLevels{
+		vars:     make(map[string]*slog.LevelVar, len(defaultLogLevels)),
+		defaults: make(map[string]slog.Level, len(defaultLogLevels)),
+	}
+	for component, level := range defaultLogLevels {
+		if override, ok := overrides[component]; ok {
+			level = override
+		}
+
+		levels.defaults[component] = level
+		levels.vars[component] = &slog.LevelVar{}
+		levels.vars[component].Set(level)
+	}
+
+	return levels
+}
+
+// Var returns the level of component, or nil for an unknown component.
+func (l *LogLevels) Var(component string) *slog.LevelVar {
+	return l.vars[component]
+}
+
+// Set changes the level of one component. level is a slog level name such
+// as "debug" or "WARN".
+func (l *LogLevels) Set(component, level string) error {
+	parsed, err := parseLogLevel(level)
+	if err != nil {
+		return err
+	}
+
+	return l.Apply(map[string]slog.Level{component: parsed})
+}
+
+// Apply changes several components at once; nothing changes if one of
+// them is unknown.
+func (l *LogLevels) Apply(levels map[string]slog.Level) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for component := range levels {
+		if l.vars[component] == nil {
+			return fmt.Errorf("%w: %q", ErrUnknownLogComponent, component)
+		}
+	}
+
+	for component, level := range levels {
+		l.vars[component].Set(level)
+	}
+
+	return nil
+}
+
+// Reset returns every component to the level it started with.
+func (l *LogLevels) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for component, level := range l.defaults {
+		l.vars[component].Set(level)
+	}
+}
+
+// Replace sets every component to its level in levels, or to the level it
+// started with if levels leaves it out. Nothing changes if one of them is
+// unknown.
+func (l *LogLevels) Replace(levels map[string]slog.Level) error {
+	next := make(map[string]slog.Level, len(l.defaults))
+	for component, level := range l.defaults {
+		next[component] = level
+	}
+	for component, level := range levels {
+		if _, ok := next[component]; !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownLogComponent, component)
+		}
+		next[component] = level
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for component, level := range next {
+		l.vars[component].Set(level)
+	}
+
+	return nil
+}
+
+// Snapshot returns the current level name of every component.
+func (l *LogLevels) Snapshot() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	snapshot := make(map[string]string, len(l.vars))
+	for component, level := range l.vars {
+		snapshot[component] = level.Level().String()
+	}
+
+	return snapshot
+}
+
+// Components lists the known components in name order.
+func (l *LogLevels) Components() []string {
+	components := make([]string, 0, len(l.vars))
+	for component := range l.vars {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+
+	return components
+}
+
+// ParseLogLevels reads "component=level" pairs separated by commas or new
+// lines, such as "http=info,db=debug". Blank lines and lines starting with
+// # are skipped.
+func ParseLogLevels(spec string) (map[string]slog.Level, error) {
+	levels := make(map[string]slog.Level)
+	for _, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		for _, pair := range strings.Split(line, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+
+			component, level, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("%w: expected component=level, got %q", ErrInvalidLogLevel, pair)
+			}
+
+			component = strings.ToLower(strings.TrimSpace(component))
+			if _, known := defaultLogLevels[component]; !known {
+				return nil, fmt.Errorf("%w: %q", ErrUnknownLogComponent, component)
+			}
+
+			parsed, err := parseLogLevel(level)
+			if err != nil {
+				return nil, err
+			}
+			levels[component] = parsed
+		}
+	}
+
+	return levels, nil
+}
+
+func parseLogLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidLogLevel, value)
+	}
+
+	return level, nil
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -13,81 +14,209 @@ import (
 	"desent-api/configs"
 )
 
+// Loggers holds the access log (HTTP), the error log and one logger per
+// component in LogLevels. The error log is the app component; the other
+// component loggers write to its output with a component attribute and
+// their own adjustable level.
 type Loggers struct {
-	HTTP    *slog.Logger
-	Error   *slog.Logger
-	closers []io.Closer
+	HTTP       *slog.Logger
+	Error      *slog.Logger
+	Levels     *LogLevels
+	components map[string]*slog.Logger
+	cfg        configs.LoggingConfig
+	httpLog    *AsyncWriter
+	files      []*RotatingWriter
+	closers    []io.Closer
 }
 
 func NewLoggers(cfg configs.LoggingConfig) (*Loggers, error) {
+	overrides, err := ParseLogLevels(cfg.Levels)
+	if err != nil {
+		return nil, fmt.Errorf("parse LOG_LEVELS: %w", err)
+	}
+	levels := NewLogLevels(overrides)
+
 	if err := os.MkdirAll(cfg.LogsDir, 0o755); err != nil {
 		return nil, fmt.Errorf("create logs dir: %w", err)
 	}
 
-	if err := cleanupOldLogs(cfg.LogsDir, cfg.RetentionDays, []string{cfg.HTTPLogFilePrefix, cfg.ErrorLogFilePrefix}); err != nil {
+	if err := cleanupOldLogs(cfg.LogsDir, cfg.RetentionDays, []string{cfg.HTTPLogFilePrefix, cfg.ErrorLogFilePrefix}, time.Now(), nil); err != nil {
 		return nil, fmt.Errorf("cleanup old logs: %w", err)
 	}
 
-	httpWriter, httpClosers, err := buildWriters(cfg, cfg.HTTPLogFilePrefix, os.Stdout)
+	httpWriter, httpFile, err := buildWriters(cfg, cfg.HTTPLogFilePrefix, os.Stdout)
 	if err != nil {
 		return nil, err
 	}
 
-	errorWriter, errorClosers, err := buildWriters(cfg, cfg.ErrorLogFilePrefix, os.Stderr)
+	errorWriter, errorFile, err := buildWriters(cfg, cfg.ErrorLogFilePrefix, os.Stderr)
 	if err != nil {
-		closeAll(httpClosers)
+		if httpFile != nil {
+			_ = httpFile.Close()
+		}
 		return nil, err
 	}
 
+	// Access log entries are written in the background so requests do not
+	// wait on the console or disk; error entries stay synchronous.
+	httpLog := NewAsyncWriter(httpWriter, cfg.HTTPLogQueueSize)
 	loggers := &Loggers{
-		HTTP: slog.New(slog.NewJSONHandler(httpWriter, &slog.HandlerOptions{Level: slog.LevelInfo})),
-		Error: slog.New(slog.NewJSONHandler(errorWriter, &slog.HandlerOptions{
-			Level: slog.LevelError,
-		})),
-		closers: append(httpClosers, errorClosers...),
+		HTTP:       slog.New(slog.NewJSONHandler(httpLog, &slog.HandlerOptions{Level: levels.Var(LogComponentHTTP)})),
+		Error:      slog.New(slog.NewJSONHandler(errorWriter, &slog.HandlerOptions{Level: levels.Var(LogComponentApp)})),
+		Levels:     levels,
+		components: make(map[string]*slog.Logger),
+		cfg:        cfg,
+		httpLog:    httpLog,
+	}
+	for _, component := range levels.Components() {
+		switch component {
+		case LogComponentHTTP:
+			loggers.components[component] = loggers.HTTP
+		case LogComponentApp:
+			loggers.components[component] = loggers.Error
+		default:
+			handler := slog.NewJSONHandler(errorWriter, &slog.HandlerOptions{Level: levels.Var(component)})
+			loggers.components[component] = slog.New(handler).With(slog.String("component", component))
+		}
+	}
+	for _, file := range []*RotatingWriter{httpFile, errorFile} {
+		if file != nil {
+			loggers.files = append(loggers.files, file)
+			loggers.closers = append(loggers.closers, file)
+		}
+	}
+
+	if err := loggers.ReloadLevels(); err != nil {
+		_ = loggers.Close()
+		return nil, err
 	}
 
 	return loggers, nil
 }
 
+// Component returns the logger of one of the LogLevels components. Unknown
+// components get the error logger.
+func (l *Loggers) Component(name string) *slog.Logger {
+	if logger, ok := l.components[name]; ok {
+		return logger
+	}
+
+	return l.Error
+}
+
+// ReloadLevels returns every component to its LOG_LEVELS level and then
+// applies the levels file, if one is configured. On error nothing changes.
+func (l *Loggers) ReloadLevels() error {
+	var fileLevels map[string]slog.Level
+	if l.cfg.LevelsFile != "" {
+		data, err := os.ReadFile(l.cfg.LevelsFile)
+		if err != nil {
+			return fmt.Errorf("read log levels file: %w", err)
+		}
+
+		fileLevels, err = ParseLogLevels(string(data))
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", l.cfg.LevelsFile, err)
+		}
+	}
+
+	return l.Levels.Replace(fileLevels)
+}
+
+// RunRetention rotates idle log files once their day is over and deletes
+// files older than the retention period, every interval until ctx is
+// cancelled. A non-positive interval disables it.
+func (l *Loggers) RunRetention(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, file := range l.files {
+				if err := file.RotateIfDue(); err != nil {
+					l.Component(LogComponentWorker).Error("rotate log file", "error", err.Error())
+				}
+			}
+
+			prefixes := []string{l.cfg.HTTPLogFilePrefix, l.cfg.ErrorLogFilePrefix}
+			if err := cleanupOldLogs(l.cfg.LogsDir, l.cfg.RetentionDays, prefixes, time.Now(), l.isCompressing); err != nil {
+				l.Component(LogComponentWorker).Error("cleanup old logs", "error", err.Error())
+			}
+		}
+	}
+}
+
+// isCompressing reports whether one of the log files is still compressing
+// path, so retention leaves it alone until the next run.
+func (l *Loggers) isCompressing(path string) bool {
+	for _, file := range l.files {
+		if file.isCompressing(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DroppedHTTPEntries reports how many access log entries were discarded
+// because the write queue was full.
+func (l *Loggers) DroppedHTTPEntries() uint64 {
+	return l.httpLog.Dropped()
+}
+
+// Close writes the queued access log entries and closes the log files.
 func (l *Loggers) Close() error {
 	if l == nil {
 		return nil
 	}
 
+	err := l.httpLog.Close()
 	closeAll(l.closers)
-	return nil
+	return err
 }
 
-func buildWriters(cfg configs.LoggingConfig, prefix string, console io.Writer) (io.Writer, []io.Closer, error) {
+func buildWriters(cfg configs.LoggingConfig, prefix string, console io.Writer) (io.Writer, *RotatingWriter, error) {
 	writers := make([]io.Writer, 0, 2)
-	closers := make([]io.Closer, 0, 1)
 
 	if cfg.ConsoleEnabled {
 		writers = append(writers, console)
 	}
 
+	var file *RotatingWriter
 	if cfg.FileEnabled {
-		filename := fmt.Sprintf("%s.%s.log", prefix, time.Now().Format("2006-01-02"))
-		path := filepath.Join(cfg.LogsDir, filename)
-
-		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		var err error
+		file, err = NewRotatingWriter(cfg.LogsDir, prefix, RotationPolicy{
+			MaxBytes: cfg.RotateMaxBytes,
+			Compress: cfg.CompressRotated,
+		}, func(err error) {
+			// The error logger may not exist yet; the default logger writes
+			// to stderr.
+			slog.Error("log rotation", "error", err.Error())
+		}, time.Now)
 		if err != nil {
-			return nil, nil, fmt.Errorf("open log file %s: %w", path, err)
+			return nil, nil, err
 		}
 
 		writers = append(writers, file)
-		closers = append(closers, file)
 	}
 
 	if len(writers) == 0 {
 		writers = append(writers, console)
 	}
 
-	return io.MultiWriter(writers...), closers, nil
+	return io.MultiWriter(writers...), file, nil
 }
 
-func cleanupOldLogs(logDir string, retentionDays int, prefixes []string) error {
+// cleanupOldLogs removes log files dated more than retentionDays before
+// now. Files for which busy reports true are skipped; busy may be nil.
+func cleanupOldLogs(logDir string, retentionDays int, prefixes []string, now time.Time, busy func(path string) bool) error {
 	if retentionDays <= 0 {
 		return nil
 	}
@@ -97,7 +226,7 @@ func cleanupOldLogs(logDir string, retentionDays int, prefixes []string) error {
 		return err
 	}
 
-	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	cutoff := now.AddDate(0, 0, -retentionDays)
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
@@ -108,23 +237,27 @@ func cleanupOldLogs(logDir string, retentionDays int, prefixes []string) error {
 			continue
 		}
 
-		logDate, err := time.Parse("2006-01-02", datePart)
+		logDate, err := time.Parse(logDateLayout, datePart)
 		if err != nil {
 			continue
 		}
 
-		if logDate.Before(cutoff) {
-			if err := os.Remove(filepath.Join(logDir, entry.Name())); err != nil {
-				return err
-			}
+		path := filepath.Join(logDir, entry.Name())
+		if !logDate.Before(cutoff) || (busy != nil && busy(path)) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// splitLogFilename accepts <prefix>.<date>.log and the rotated forms
+// <prefix>.<date>.<n>.log, either optionally gzipped.
 func splitLogFilename(name string) (prefix, datePart string, ok bool) {
-	trimmed := strings.TrimSuffix(name, ".log")
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".log")
 	if trimmed == name {
 		return "", "", false
 	}
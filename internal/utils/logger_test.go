@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"desent-api/configs"
+)
+
+func TestLoggers_ErrorLogLevelIsAdjustable(t *testing.T) {
+	dir := t.TempDir()
+	levelsFile := filepath.Join(dir, "levels")
+	if err := os.WriteFile(levelsFile, []byte("app=warn\n"), 0o600); err != nil {
+		t.Fatalf("write levels file: %v", err)
+	}
+
+	loggers, err := NewLoggers(configs.LoggingConfig{
+		LogsDir:            dir,
+		FileEnabled:        true,
+		HTTPLogFilePrefix:  "http",
+		ErrorLogFilePrefix: "error",
+		HTTPLogQueueSize:   16,
+		LevelsFile:         levelsFile,
+	})
+	if err != nil {
+		t.Fatalf("new loggers: %v", err)
+	}
+	t.Cleanup(func() { _ = loggers.Close() })
+
+	ctx := context.Background()
+	if loggers.Error.Enabled(ctx, slog.LevelInfo) || !loggers.Error.Enabled(ctx, slog.LevelWarn) {
+		t.Fatalf("expected the levels file to set the error log to WARN, got %v", loggers.Levels.Snapshot())
+	}
+	if loggers.Component(LogComponentApp) != loggers.Error {
+		t.Fatal("expected the app component to be the error log")
+	}
+
+	if err := loggers.Levels.Set(LogComponentApp, "debug"); err != nil {
+		t.Fatalf("set level: %v", err)
+	}
+	if !loggers.Error.Enabled(ctx, slog.LevelDebug) {
+		t.Fatal("expected the error log to pick up the new level")
+	}
+
+	if err := loggers.ReloadLevels(); err != nil {
+		t.Fatalf("reload levels: %v", err)
+	}
+	if loggers.Error.Enabled(ctx, slog.LevelInfo) {
+		t.Fatalf("expected a reload to restore WARN, got %v", loggers.Levels.Snapshot())
+	}
+}
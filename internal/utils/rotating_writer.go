@@ -0,0 +1,300 @@
+package utils
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const logDateLayout = "2006-01-02"
+
+// RotationPolicy controls when a RotatingWriter starts a new file besides
+// the change of day. MaxBytes of zero disables size-based rotation.
+type RotationPolicy struct {
+	MaxBytes int64
+	Compress bool
+}
+
+// RotatingWriter writes to <dir>/<prefix>.<date>.log and switches to a new
+// file at local midnight. A file that grows past MaxBytes is renamed to
+// <prefix>.<date>.<n>.log and a fresh one is started. Rotated files are
+// gzipped in the background when Compress is set.
+type RotatingWriter struct {
+	dir     string
+	prefix  string
+	policy  RotationPolicy
+	nowFunc func() time.Time
+	onError func(error)
+
+	mu     sync.Mutex
+	file   *os.File
+	day    string
+	size   int64
+	closed bool
+
+	compressing sync.WaitGroup
+	pendingMu   sync.Mutex
+	pending     map[string]struct{}
+}
+
+// NewRotatingWriter opens today's file. onError receives failures from
+// background compression and may be nil. Files left uncompressed by an
+// earlier run are compressed in the background.
+func NewRotatingWriter(dir, prefix string, policy RotationPolicy, onError func(error), nowFunc func() time.Time) (*RotatingWriter, error) {
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	w := &RotatingWriter{dir: dir, prefix: prefix, policy: policy, nowFunc: nowFunc, onError: onError, pending: make(map[string]struct{})}
+	if err := w.open(nowFunc().Format(logDateLayout)); err != nil {
+		return nil, err
+	}
+
+	if policy.Compress {
+		stale, err := w.staleFiles()
+		if err != nil {
+			onError(err)
+		}
+		for _, path := range stale {
+			w.compressLater(path)
+		}
+	}
+
+	return w, nil
+}
+
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.rotateIfDue(int64(len(p))); err != nil {
+		return 0, err
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// RotateIfDue switches to a new file if the day has changed, so quiet logs
+// are still rotated and compressed shortly after midnight.
+func (w *RotatingWriter) RotateIfDue() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotateIfDue(0)
+}
+
+// Close closes the current file and waits for background compression.
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	w.closed = true
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.compressing.Wait()
+	return err
+}
+
+func (w *RotatingWriter) rotateIfDue(incoming int64) error {
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file == nil {
+		// An earlier size rotation could not reopen the file.
+		if err := w.open(w.day); err != nil {
+			return err
+		}
+	}
+
+	day := w.nowFunc().Format(logDateLayout)
+	switch {
+	case day != w.day:
+		previous := w.path(w.day, 0)
+		if err := w.open(day); err != nil {
+			return err
+		}
+		w.compressLater(previous)
+	case w.policy.MaxBytes > 0 && w.size > 0 && w.size+incoming > w.policy.MaxBytes:
+		current := w.path(w.day, 0)
+		rotated, err := w.nextRotatedPath()
+		if err != nil {
+			return err
+		}
+		// The file is closed before the rename for platforms that cannot
+		// rename open files. If it cannot be reopened, the next write
+		// retries instead of using the closed file.
+		_ = w.file.Close()
+		w.file = nil
+		renameErr := os.Rename(current, rotated)
+		if err := w.open(w.day); err != nil {
+			return err
+		}
+		if renameErr != nil {
+			return fmt.Errorf("rotate log file %s: %w", current, renameErr)
+		}
+		w.compressLater(rotated)
+	}
+
+	return nil
+}
+
+// open makes the file for day current, closing the previous one.
+func (w *RotatingWriter) open(day string) error {
+	path := w.path(day, 0)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file %s: %w", path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat log file %s: %w", path, err)
+	}
+
+	if w.file != nil {
+		_ = w.file.Close()
+	}
+	w.file, w.day, w.size = file, day, info.Size()
+	return nil
+}
+
+func (w *RotatingWriter) path(day string, index int) string {
+	if index == 0 {
+		return filepath.Join(w.dir, fmt.Sprintf("%s.%s.log", w.prefix, day))
+	}
+
+	return filepath.Join(w.dir, fmt.Sprintf("%s.%s.%d.log", w.prefix, day, index))
+}
+
+func (w *RotatingWriter) nextRotatedPath() (string, error) {
+	for index := 1; ; index++ {
+		path := w.path(w.day, index)
+		free := true
+		for _, candidate := range []string{path, path + ".gz"} {
+			_, err := os.Stat(candidate)
+			switch {
+			case err == nil:
+				free = false
+			case !errors.Is(err, os.ErrNotExist):
+				return "", fmt.Errorf("stat log file %s: %w", candidate, err)
+			}
+		}
+		if free {
+			return path, nil
+		}
+	}
+}
+
+// staleFiles lists this writer's uncompressed files other than the current
+// one.
+func (w *RotatingWriter) staleFiles() ([]string, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for _, entry := range entries {
+		prefix, day, ok := splitLogFilename(entry.Name())
+		path := filepath.Join(w.dir, entry.Name())
+		if entry.IsDir() || !ok || prefix != w.prefix || path == w.path(w.day, 0) || strings.HasSuffix(path, ".gz") {
+			continue
+		}
+		if _, err := time.Parse(logDateLayout, day); err != nil {
+			continue
+		}
+
+		stale = append(stale, path)
+	}
+
+	return stale, nil
+}
+
+func (w *RotatingWriter) compressLater(path string) {
+	if !w.policy.Compress {
+		return
+	}
+
+	w.pendingMu.Lock()
+	w.pending[path] = struct{}{}
+	w.pendingMu.Unlock()
+
+	w.compressing.Add(1)
+	go func() {
+		defer w.compressing.Done()
+		err := gzipFile(path)
+
+		w.pendingMu.Lock()
+		delete(w.pending, path)
+		w.pendingMu.Unlock()
+
+		// Retention may remove a file before its turn to be compressed.
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			w.onError(fmt.Errorf("compress log file %s: %w", path, err))
+		}
+	}()
+}
+
+// isCompressing reports whether path, or the path.gz it is being compressed
+// into, is still being written by a background compression.
+func (w *RotatingWriter) isCompressing(path string) bool {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+
+	_, ok := w.pending[strings.TrimSuffix(path, ".gz")]
+	return ok
+}
+
+// gzipFile replaces path with path.gz. The archive is written to
+// path.gz.tmp and renamed into place, so one left half-written by a crash
+// is replaced rather than appended to.
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,304 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRotatingWriter(t *testing.T, dir string, policy RotationPolicy, now *time.Time) (*RotatingWriter, *[]error) {
+	t.Helper()
+
+	var errs []error
+	w, err := NewRotatingWriter(dir, "app", policy, func(err error) { errs = append(errs, err) }, func() time.Time { return *now })
+	if err != nil {
+		t.Fatalf("new rotating writer: %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	return w, &errs
+}
+
+func writeLine(t *testing.T, w *RotatingWriter, line string) {
+	t.Helper()
+
+	if _, err := w.Write([]byte(line)); err != nil {
+		t.Fatalf("write %q: %v", line, err)
+	}
+}
+
+func logFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("gzip reader %s: %v", path, err)
+		}
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestRotatingWriter_RotatesAtMidnightAndCompresses(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 23, 59, 0, 0, time.Local)
+	w, errs := newTestRotatingWriter(t, dir, RotationPolicy{Compress: true}, &now)
+
+	writeLine(t, w, "before midnight\n")
+	now = now.Add(2 * time.Minute)
+	writeLine(t, w, "after midnight\n")
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	want := []string{"app.2026-03-01.log.gz", "app.2026-03-02.log"}
+	if got := logFiles(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected files %v, got %v", want, got)
+	}
+	if got := readLog(t, filepath.Join(dir, want[0])); got != "before midnight\n" {
+		t.Fatalf("unexpected compressed content %q", got)
+	}
+	if got := readLog(t, filepath.Join(dir, want[1])); got != "after midnight\n" {
+		t.Fatalf("unexpected current content %q", got)
+	}
+	if len(*errs) != 0 {
+		t.Fatalf("unexpected background errors %v", *errs)
+	}
+}
+
+func TestRotatingWriter_RotateIfDueRotatesIdleFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	w, _ := newTestRotatingWriter(t, dir, RotationPolicy{}, &now)
+
+	writeLine(t, w, "noon\n")
+	now = now.Add(24 * time.Hour)
+	if err := w.RotateIfDue(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+
+	want := []string{"app.2026-03-01.log", "app.2026-03-02.log"}
+	if got := logFiles(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected files %v, got %v", want, got)
+	}
+}
+
+func TestRotatingWriter_RotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	w, _ := newTestRotatingWriter(t, dir, RotationPolicy{MaxBytes: 10}, &now)
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		writeLine(t, w, line)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"app.2026-03-01.1.log": "first\n",
+		"app.2026-03-01.2.log": "second\n",
+		"app.2026-03-01.log":   "third\n",
+	} {
+		if got := readLog(t, filepath.Join(dir, name)); got != want {
+			t.Fatalf("expected %s to hold %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestRotatingWriter_SizeRotationSkipsCompressedIndexes(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	if err := os.WriteFile(filepath.Join(dir, "app.2026-03-01.1.log.gz"), nil, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	w, _ := newTestRotatingWriter(t, dir, RotationPolicy{MaxBytes: 10}, &now)
+
+	writeLine(t, w, "first\n")
+	writeLine(t, w, "second\n")
+
+	if got := readLog(t, filepath.Join(dir, "app.2026-03-01.2.log")); got != "first\n" {
+		t.Fatalf("expected the next free index to be used, got %q", got)
+	}
+}
+
+func TestRotatingWriter_RecoversWhenReopenFails(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	w, _ := newTestRotatingWriter(t, dir, RotationPolicy{MaxBytes: 10}, &now)
+
+	writeLine(t, w, "first\n")
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("remove dir: %v", err)
+	}
+	if _, err := w.Write([]byte("lost\n")); err == nil {
+		t.Fatal("expected the write to fail while the file cannot be reopened")
+	}
+	if w.file != nil {
+		t.Fatal("expected no current file after a failed reopen")
+	}
+
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeLine(t, w, "second\n")
+	if got := readLog(t, filepath.Join(dir, "app.2026-03-01.log")); got != "second\n" {
+		t.Fatalf("expected writes to resume in a new file, got %q", got)
+	}
+}
+
+func TestRotatingWriter_NextRotatedPathReturnsStatErrors(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	w, _ := newTestRotatingWriter(t, dir, RotationPolicy{MaxBytes: 10}, &now)
+
+	// A regular file in place of the directory makes stat fail with
+	// ENOTDIR rather than ENOENT.
+	notDir := filepath.Join(dir, "not-a-dir")
+	if err := os.WriteFile(notDir, nil, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	w.dir = notDir
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.nextRotatedPath()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected the stat error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nextRotatedPath did not return")
+	}
+}
+
+func TestRotatingWriter_CompressesStaleFilesOnStart(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.Local)
+	// A crash mid-compression leaves a half-written archive or temp file
+	// next to the log it came from.
+	var partial bytes.Buffer
+	zw := gzip.NewWriter(&partial)
+	_, _ = zw.Write([]byte("yesterday\n"))
+	_ = zw.Close()
+	for name, content := range map[string]string{
+		"app.2026-03-01.log":          "yesterday\n",
+		"app.2026-03-01.log.gz":       partial.String()[:partial.Len()/2],
+		"app.2026-03-01.1.log":        "yesterday rotated\n",
+		"app.2026-03-01.1.log.gz.tmp": "junk",
+		"other.2026-03-01.log":        "not ours\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	w, errs := newTestRotatingWriter(t, dir, RotationPolicy{Compress: true}, &now)
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	want := []string{"app.2026-03-01.1.log.gz", "app.2026-03-01.log.gz", "app.2026-03-02.log", "other.2026-03-01.log"}
+	if got := logFiles(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected files %v, got %v", want, got)
+	}
+	if got := readLog(t, filepath.Join(dir, "app.2026-03-01.log.gz")); got != "yesterday\n" {
+		t.Fatalf("unexpected compressed content %q", got)
+	}
+	if got := readLog(t, filepath.Join(dir, "app.2026-03-01.1.log.gz")); got != "yesterday rotated\n" {
+		t.Fatalf("unexpected compressed content %q", got)
+	}
+	if len(*errs) != 0 {
+		t.Fatalf("unexpected background errors %v", *errs)
+	}
+}
+
+func TestCleanupOldLogs_RemovesExpiredFilesOnly(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	for _, name := range []string{
+		"app.2026-03-01.log",
+		"app.2026-03-01.1.log.gz",
+		"app.2026-03-02.log.gz",
+		"app.2026-03-09.log",
+		"other.2026-03-01.log",
+		"app.not-a-date.log",
+		"notes.txt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	busy := func(path string) bool { return filepath.Base(path) == "app.2026-03-02.log.gz" }
+	if err := cleanupOldLogs(dir, 7, []string{"app"}, now, busy); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+
+	want := []string{"app.2026-03-02.log.gz", "app.2026-03-09.log", "app.not-a-date.log", "notes.txt", "other.2026-03-01.log"}
+	if got := logFiles(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected files %v, got %v", want, got)
+	}
+
+	if err := cleanupOldLogs(dir, 0, []string{"app"}, now.AddDate(1, 0, 0), nil); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if got := logFiles(t, dir); len(got) != len(want) {
+		t.Fatalf("expected a retention of zero to keep everything, got %v", got)
+	}
+}
+
+func TestRotatingWriter_ReportsFilesBeingCompressed(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	w, _ := newTestRotatingWriter(t, dir, RotationPolicy{Compress: true}, &now)
+
+	path := filepath.Join(dir, "app.2026-02-28.log")
+	w.pending[path] = struct{}{}
+	if !w.isCompressing(path) || !w.isCompressing(path+".gz") {
+		t.Fatal("expected both the source and the archive to be reported")
+	}
+	delete(w.pending, path)
+	if w.isCompressing(path) {
+		t.Fatal("expected finished files not to be reported")
+	}
+}
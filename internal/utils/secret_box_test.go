@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestDeriveKey_SeparatesLabels(t *testing.T) {
+	mfa, err := DeriveKey("jwt-secret", KeyLabelMFAEncryption)
+	if err != nil {
+		t.Fatalf("derive: %v", err)
+	}
+	if raw, err := hex.DecodeString(mfa); err != nil || len(raw) != 32 {
+		t.Fatalf("expected a hex-encoded 32-byte key, got %q", mfa)
+	}
+
+	again, _ := DeriveKey("jwt-secret", KeyLabelMFAEncryption)
+	other, _ := DeriveKey("jwt-secret", "desent-api other purpose")
+	if again != mfa || other == mfa || mfa == "jwt-secret" {
+		t.Fatalf("expected a stable key per label, got %q, %q and %q", mfa, again, other)
+	}
+
+	derived, _ := NewSecretBox(mfa)
+	direct, _ := NewSecretBox("jwt-secret")
+	sealed, err := derived.Seal("totp secret")
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if _, err := direct.Open(sealed); !errors.Is(err, ErrSecretBoxOpen) {
+		t.Fatalf("expected the raw secret not to open the derived box, got %v", err)
+	}
+	if opened, err := derived.Open(sealed); err != nil || opened != "totp secret" {
+		t.Fatalf("expected a round trip, got %q, %v", opened, err)
+	}
+}